@@ -0,0 +1,554 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: api/admin/v1/admin.proto
+
+package adminv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BackendService_ListBackends_FullMethodName  = "/helios.admin.v1.BackendService/ListBackends"
+	BackendService_AddBackend_FullMethodName    = "/helios.admin.v1.BackendService/AddBackend"
+	BackendService_RemoveBackend_FullMethodName = "/helios.admin.v1.BackendService/RemoveBackend"
+	BackendService_SetWeight_FullMethodName     = "/helios.admin.v1.BackendService/SetWeight"
+)
+
+// BackendServiceClient is the client API for BackendService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BackendServiceClient interface {
+	ListBackends(ctx context.Context, in *ListBackendsRequest, opts ...grpc.CallOption) (*ListBackendsResponse, error)
+	AddBackend(ctx context.Context, in *AddBackendRequest, opts ...grpc.CallOption) (*AddBackendResponse, error)
+	RemoveBackend(ctx context.Context, in *RemoveBackendRequest, opts ...grpc.CallOption) (*RemoveBackendResponse, error)
+	SetWeight(ctx context.Context, in *SetWeightRequest, opts ...grpc.CallOption) (*SetWeightResponse, error)
+}
+
+type backendServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBackendServiceClient(cc grpc.ClientConnInterface) BackendServiceClient {
+	return &backendServiceClient{cc}
+}
+
+func (c *backendServiceClient) ListBackends(ctx context.Context, in *ListBackendsRequest, opts ...grpc.CallOption) (*ListBackendsResponse, error) {
+	out := new(ListBackendsResponse)
+	err := c.cc.Invoke(ctx, BackendService_ListBackends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) AddBackend(ctx context.Context, in *AddBackendRequest, opts ...grpc.CallOption) (*AddBackendResponse, error) {
+	out := new(AddBackendResponse)
+	err := c.cc.Invoke(ctx, BackendService_AddBackend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) RemoveBackend(ctx context.Context, in *RemoveBackendRequest, opts ...grpc.CallOption) (*RemoveBackendResponse, error) {
+	out := new(RemoveBackendResponse)
+	err := c.cc.Invoke(ctx, BackendService_RemoveBackend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) SetWeight(ctx context.Context, in *SetWeightRequest, opts ...grpc.CallOption) (*SetWeightResponse, error) {
+	out := new(SetWeightResponse)
+	err := c.cc.Invoke(ctx, BackendService_SetWeight_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BackendServiceServer is the server API for BackendService service.
+// All implementations must embed UnimplementedBackendServiceServer
+// for forward compatibility
+type BackendServiceServer interface {
+	ListBackends(context.Context, *ListBackendsRequest) (*ListBackendsResponse, error)
+	AddBackend(context.Context, *AddBackendRequest) (*AddBackendResponse, error)
+	RemoveBackend(context.Context, *RemoveBackendRequest) (*RemoveBackendResponse, error)
+	SetWeight(context.Context, *SetWeightRequest) (*SetWeightResponse, error)
+	mustEmbedUnimplementedBackendServiceServer()
+}
+
+// UnimplementedBackendServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBackendServiceServer struct {
+}
+
+func (UnimplementedBackendServiceServer) ListBackends(context.Context, *ListBackendsRequest) (*ListBackendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBackends not implemented")
+}
+func (UnimplementedBackendServiceServer) AddBackend(context.Context, *AddBackendRequest) (*AddBackendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBackend not implemented")
+}
+func (UnimplementedBackendServiceServer) RemoveBackend(context.Context, *RemoveBackendRequest) (*RemoveBackendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBackend not implemented")
+}
+func (UnimplementedBackendServiceServer) SetWeight(context.Context, *SetWeightRequest) (*SetWeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWeight not implemented")
+}
+func (UnimplementedBackendServiceServer) mustEmbedUnimplementedBackendServiceServer() {}
+
+// UnsafeBackendServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BackendServiceServer will
+// result in compilation errors.
+type UnsafeBackendServiceServer interface {
+	mustEmbedUnimplementedBackendServiceServer()
+}
+
+func RegisterBackendServiceServer(s grpc.ServiceRegistrar, srv BackendServiceServer) {
+	s.RegisterService(&BackendService_ServiceDesc, srv)
+}
+
+func _BackendService_ListBackends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBackendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).ListBackends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_ListBackends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).ListBackends(ctx, req.(*ListBackendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_AddBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).AddBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_AddBackend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).AddBackend(ctx, req.(*AddBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_RemoveBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).RemoveBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_RemoveBackend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).RemoveBackend(ctx, req.(*RemoveBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_SetWeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).SetWeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_SetWeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).SetWeight(ctx, req.(*SetWeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BackendService_ServiceDesc is the grpc.ServiceDesc for BackendService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BackendService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helios.admin.v1.BackendService",
+	HandlerType: (*BackendServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBackends",
+			Handler:    _BackendService_ListBackends_Handler,
+		},
+		{
+			MethodName: "AddBackend",
+			Handler:    _BackendService_AddBackend_Handler,
+		},
+		{
+			MethodName: "RemoveBackend",
+			Handler:    _BackendService_RemoveBackend_Handler,
+		},
+		{
+			MethodName: "SetWeight",
+			Handler:    _BackendService_SetWeight_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/admin/v1/admin.proto",
+}
+
+const (
+	StrategyService_GetStrategy_FullMethodName = "/helios.admin.v1.StrategyService/GetStrategy"
+	StrategyService_SetStrategy_FullMethodName = "/helios.admin.v1.StrategyService/SetStrategy"
+)
+
+// StrategyServiceClient is the client API for StrategyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StrategyServiceClient interface {
+	GetStrategy(ctx context.Context, in *GetStrategyRequest, opts ...grpc.CallOption) (*GetStrategyResponse, error)
+	SetStrategy(ctx context.Context, in *SetStrategyRequest, opts ...grpc.CallOption) (*SetStrategyResponse, error)
+}
+
+type strategyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStrategyServiceClient(cc grpc.ClientConnInterface) StrategyServiceClient {
+	return &strategyServiceClient{cc}
+}
+
+func (c *strategyServiceClient) GetStrategy(ctx context.Context, in *GetStrategyRequest, opts ...grpc.CallOption) (*GetStrategyResponse, error) {
+	out := new(GetStrategyResponse)
+	err := c.cc.Invoke(ctx, StrategyService_GetStrategy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *strategyServiceClient) SetStrategy(ctx context.Context, in *SetStrategyRequest, opts ...grpc.CallOption) (*SetStrategyResponse, error) {
+	out := new(SetStrategyResponse)
+	err := c.cc.Invoke(ctx, StrategyService_SetStrategy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StrategyServiceServer is the server API for StrategyService service.
+// All implementations must embed UnimplementedStrategyServiceServer
+// for forward compatibility
+type StrategyServiceServer interface {
+	GetStrategy(context.Context, *GetStrategyRequest) (*GetStrategyResponse, error)
+	SetStrategy(context.Context, *SetStrategyRequest) (*SetStrategyResponse, error)
+	mustEmbedUnimplementedStrategyServiceServer()
+}
+
+// UnimplementedStrategyServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedStrategyServiceServer struct {
+}
+
+func (UnimplementedStrategyServiceServer) GetStrategy(context.Context, *GetStrategyRequest) (*GetStrategyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStrategy not implemented")
+}
+func (UnimplementedStrategyServiceServer) SetStrategy(context.Context, *SetStrategyRequest) (*SetStrategyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetStrategy not implemented")
+}
+func (UnimplementedStrategyServiceServer) mustEmbedUnimplementedStrategyServiceServer() {}
+
+// UnsafeStrategyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StrategyServiceServer will
+// result in compilation errors.
+type UnsafeStrategyServiceServer interface {
+	mustEmbedUnimplementedStrategyServiceServer()
+}
+
+func RegisterStrategyServiceServer(s grpc.ServiceRegistrar, srv StrategyServiceServer) {
+	s.RegisterService(&StrategyService_ServiceDesc, srv)
+}
+
+func _StrategyService_GetStrategy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStrategyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyServiceServer).GetStrategy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StrategyService_GetStrategy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyServiceServer).GetStrategy(ctx, req.(*GetStrategyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StrategyService_SetStrategy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetStrategyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyServiceServer).SetStrategy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StrategyService_SetStrategy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyServiceServer).SetStrategy(ctx, req.(*SetStrategyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StrategyService_ServiceDesc is the grpc.ServiceDesc for StrategyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StrategyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helios.admin.v1.StrategyService",
+	HandlerType: (*StrategyServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStrategy",
+			Handler:    _StrategyService_GetStrategy_Handler,
+		},
+		{
+			MethodName: "SetStrategy",
+			Handler:    _StrategyService_SetStrategy_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/admin/v1/admin.proto",
+}
+
+const (
+	MetricsService_GetSnapshot_FullMethodName = "/helios.admin.v1.MetricsService/GetSnapshot"
+)
+
+// MetricsServiceClient is the client API for MetricsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MetricsServiceClient interface {
+	GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*GetSnapshotResponse, error)
+}
+
+type metricsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMetricsServiceClient(cc grpc.ClientConnInterface) MetricsServiceClient {
+	return &metricsServiceClient{cc}
+}
+
+func (c *metricsServiceClient) GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*GetSnapshotResponse, error) {
+	out := new(GetSnapshotResponse)
+	err := c.cc.Invoke(ctx, MetricsService_GetSnapshot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetricsServiceServer is the server API for MetricsService service.
+// All implementations must embed UnimplementedMetricsServiceServer
+// for forward compatibility
+type MetricsServiceServer interface {
+	GetSnapshot(context.Context, *GetSnapshotRequest) (*GetSnapshotResponse, error)
+	mustEmbedUnimplementedMetricsServiceServer()
+}
+
+// UnimplementedMetricsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMetricsServiceServer struct {
+}
+
+func (UnimplementedMetricsServiceServer) GetSnapshot(context.Context, *GetSnapshotRequest) (*GetSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (UnimplementedMetricsServiceServer) mustEmbedUnimplementedMetricsServiceServer() {}
+
+// UnsafeMetricsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MetricsServiceServer will
+// result in compilation errors.
+type UnsafeMetricsServiceServer interface {
+	mustEmbedUnimplementedMetricsServiceServer()
+}
+
+func RegisterMetricsServiceServer(s grpc.ServiceRegistrar, srv MetricsServiceServer) {
+	s.RegisterService(&MetricsService_ServiceDesc, srv)
+}
+
+func _MetricsService_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetSnapshot(ctx, req.(*GetSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MetricsService_ServiceDesc is the grpc.ServiceDesc for MetricsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MetricsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helios.admin.v1.MetricsService",
+	HandlerType: (*MetricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _MetricsService_GetSnapshot_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/admin/v1/admin.proto",
+}
+
+const (
+	EventService_Subscribe_FullMethodName = "/helios.admin.v1.EventService/Subscribe"
+)
+
+// EventServiceClient is the client API for EventService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventServiceClient interface {
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventService_SubscribeClient, error)
+}
+
+type eventServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventServiceClient(cc grpc.ClientConnInterface) EventServiceClient {
+	return &eventServiceClient{cc}
+}
+
+func (c *eventServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventService_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type eventServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServiceSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventServiceServer is the server API for EventService service.
+// All implementations must embed UnimplementedEventServiceServer
+// for forward compatibility
+type EventServiceServer interface {
+	Subscribe(*SubscribeRequest, EventService_SubscribeServer) error
+	mustEmbedUnimplementedEventServiceServer()
+}
+
+// UnimplementedEventServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedEventServiceServer struct {
+}
+
+func (UnimplementedEventServiceServer) Subscribe(*SubscribeRequest, EventService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
+
+// UnsafeEventServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventServiceServer will
+// result in compilation errors.
+type UnsafeEventServiceServer interface {
+	mustEmbedUnimplementedEventServiceServer()
+}
+
+func RegisterEventServiceServer(s grpc.ServiceRegistrar, srv EventServiceServer) {
+	s.RegisterService(&EventService_ServiceDesc, srv)
+}
+
+func _EventService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServiceServer).Subscribe(m, &eventServiceSubscribeServer{stream})
+}
+
+type EventService_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type eventServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServiceSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helios.admin.v1.EventService",
+	HandlerType: (*EventServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _EventService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/admin/v1/admin.proto",
+}
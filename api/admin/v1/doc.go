@@ -0,0 +1,4 @@
+// Package adminv1 contains the generated gRPC bindings for the Helios Admin API.
+package adminv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/admin/v1/admin.proto
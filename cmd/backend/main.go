@@ -59,7 +59,9 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
-	logging.Init(config.LoggingConfig{Format: "text"})
+	if err := logging.Init(config.LoggingConfig{Format: "text"}); err != nil {
+		logging.L().Fatal().Err(err).Msg("failed to initialize logging")
+	}
 	logger := logging.L()
 
 	// No need to initialize random seed in Go 1.20+
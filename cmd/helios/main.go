@@ -7,6 +7,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/0xReLogic/Helios/internal/adminapi"
 	"github.com/0xReLogic/Helios/internal/config"
 	"github.com/0xReLogic/Helios/internal/loadbalancer"
 	"github.com/0xReLogic/Helios/internal/logging"
@@ -15,39 +16,76 @@ import (
 func main() {
 	// Load configuration
 	configPath := flag.String("config", "helios.yaml", "Path to config file")
+	printOpenAPI := flag.Bool("print-openapi", false, "Print the Admin API OpenAPI document and exit")
+	validateOnly := flag.Bool("validate", false, "Validate the config file, including the plugin chain, and exit")
 	flag.Parse()
 
+	if *printOpenAPI {
+		spec, err := adminapi.OpenAPISpec()
+		if err != nil {
+			logging.L().Fatal().Err(err).Msg("failed to generate openapi document")
+		}
+		_, _ = os.Stdout.Write(spec)
+		return
+	}
+
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		logging.L().Fatal().Err(err).Msg("failed to load configuration")
 	}
 
-	logging.Init(cfg.Logging)
+	// LoadConfig has validated the config and the plugin chain; strategy
+	// options belong to the loadbalancer package's registry
+	if err := loadbalancer.ValidateStrategies(cfg.LoadBalancer); err != nil {
+		logging.L().Fatal().Err(err).Msg("invalid configuration")
+	}
+
+	if *validateOnly {
+		// TLS files are checked here because they are only read at startup
+		if err := validateTLSFiles(cfg); err != nil {
+			logging.L().Fatal().Err(err).Msg("invalid configuration")
+		}
+		logging.L().Info().Str("config", *configPath).Msg("configuration is valid")
+		return
+	}
+
+	if err := logging.Init(cfg.Logging); err != nil {
+		logging.L().Fatal().Err(err).Msg("failed to initialize logging outputs")
+	}
 	logger := logging.L()
 
+	warnUntrustedClientIPs(cfg)
+
 	// Create load balancer
 	lb, err := loadbalancer.NewLoadBalancer(cfg)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to create load balancer")
 	}
 
+	// Join the cluster before serving the Admin API peers take leases through
+	elector := setupCluster(cfg)
+
 	// Setup ancillary servers
 	setupMetricsServer(cfg, lb)
-	setupAdminAPIServer(cfg, lb)
+	setupAdminAPIServer(cfg, lb, elector)
+	grpcServer := setupAdminGRPCServer(cfg, lb)
 
 	// Build HTTP handler with plugins
-	handler, err := buildHandler(cfg, lb)
+	handler, chain, err := buildHandler(cfg, lb)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to build handler")
 	}
 
+	// Start synthetic monitoring against the fully built handler
+	syntheticRunner := setupSyntheticMonitoring(cfg, handler, lb, elector)
+
 	// Validate TLS configuration
 	if err := validateTLSFiles(cfg); err != nil {
 		logger.Fatal().Err(err).Msg("tls validation failed")
 	}
 
 	// Create and configure HTTP server
-	server := createHTTPServer(cfg, handler)
+	server := createHTTPServer(cfg, handler, lb)
 
 	// Determine shutdown timeout
 	shutdownTimeout := time.Duration(cfg.Server.Timeouts.Shutdown) * time.Second
@@ -72,6 +110,12 @@ func main() {
 		logger.Fatal().Err(err).Msg("server failed to start")
 	case sig := <-sigChan:
 		logger.Info().Str("signal", sig.String()).Msg("shutdown signal received")
-		shutdownGracefully(server, lb, shutdownTimeout)
+		if syntheticRunner != nil {
+			syntheticRunner.Stop()
+		}
+		if elector != nil {
+			elector.Stop()
+		}
+		shutdownGracefully(server, grpcServer, lb, chain, shutdownTimeout)
 	}
 }
@@ -1,18 +1,23 @@
 package main
 
 import (
-	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
 	"github.com/0xReLogic/Helios/internal/adminapi"
+	"github.com/0xReLogic/Helios/internal/cluster"
 	"github.com/0xReLogic/Helios/internal/config"
 	"github.com/0xReLogic/Helios/internal/loadbalancer"
 	"github.com/0xReLogic/Helios/internal/logging"
 	"github.com/0xReLogic/Helios/internal/plugins"
+	"github.com/0xReLogic/Helios/internal/synthetics"
 )
 
 // setupMetricsServer starts the metrics HTTP server if enabled in config
@@ -37,11 +42,12 @@ func setupMetricsServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) {
 	metricsMux.HandleFunc("/health", metricsCollector.HealthHandler())
 
 	metricsServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", metricsPort),
-		Handler:      metricsMux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf(":%d", metricsPort),
+		Handler:           metricsMux,
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: readHeaderTimeout(cfg),
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	// Start metrics server in background
@@ -56,8 +62,22 @@ func setupMetricsServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) {
 	}()
 }
 
+// setupCluster starts leader election among the cluster peers if enabled in
+// config. The returned elector is nil otherwise.
+func setupCluster(cfg *config.Config) *cluster.Elector {
+	if !cfg.Cluster.Enabled {
+		return nil
+	}
+	elector, err := cluster.New(cfg.Cluster)
+	if err != nil {
+		logging.L().Fatal().Err(err).Msg("failed to set up cluster coordination")
+	}
+	elector.Start()
+	return elector
+}
+
 // setupAdminAPIServer starts the Admin API HTTP server if enabled in config
-func setupAdminAPIServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) {
+func setupAdminAPIServer(cfg *config.Config, lb *loadbalancer.LoadBalancer, elector *cluster.Elector) {
 	if !cfg.AdminAPI.Enabled {
 		return
 	}
@@ -68,13 +88,18 @@ func setupAdminAPIServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) {
 	}
 
 	mc := lb.GetMetricsCollector()
-	adminHandler := adminapi.NewMux(lb, cfg, mc)
+	var opts []adminapi.MuxOption
+	if elector != nil {
+		opts = append(opts, adminapi.WithCluster(elector))
+	}
+	adminHandler := adminapi.NewMux(lb, cfg, mc, opts...)
 	adminServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", adminPort),
-		Handler:      adminHandler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf(":%d", adminPort),
+		Handler:           adminHandler,
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: readHeaderTimeout(cfg),
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	go func() {
@@ -91,37 +116,111 @@ func setupAdminAPIServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) {
 	}()
 }
 
-// buildHandler constructs the HTTP handler with plugins and middleware
-func buildHandler(cfg *config.Config, lb *loadbalancer.LoadBalancer) (http.Handler, error) {
+// setupAdminGRPCServer starts the gRPC Admin API server if enabled in config
+func setupAdminGRPCServer(cfg *config.Config, lb *loadbalancer.LoadBalancer) *adminapi.GRPCServer {
+	grpcCfg := cfg.AdminAPI.GRPC
+	if !grpcCfg.Enabled {
+		return nil
+	}
+	logger := logging.L()
+
+	var opts []grpc.ServerOption
+	if grpcCfg.TLS.Enabled {
+		creds, err := credentials.NewServerTLSFromFile(grpcCfg.TLS.CertFile, grpcCfg.TLS.KeyFile)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to load admin api grpc tls credentials")
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	grpcServer, err := adminapi.NewGRPCServer(lb, cfg, lb.GetMetricsCollector(), opts...)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create admin api grpc server")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcCfg.Port))
+	if err != nil {
+		logger.Fatal().Err(err).Int("port", grpcCfg.Port).Msg("failed to listen for admin api grpc server")
+	}
+
+	go func() {
+		logger.Info().Int("port", grpcCfg.Port).Bool("tls", grpcCfg.TLS.Enabled).Msg("admin api grpc server starting")
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Error().Err(err).Msg("admin api grpc server error")
+		}
+	}()
+
+	return grpcServer
+}
+
+// buildHandler constructs the HTTP handler with plugins and middleware. The
+// returned chain is closed once the server has shut down; it is nil when
+// plugins are disabled.
+func buildHandler(cfg *config.Config, lb *loadbalancer.LoadBalancer) (http.Handler, *plugins.Chain, error) {
 	var handler http.Handler = lb
+	var chain *plugins.Chain
 	logger := logging.L()
 
 	// Apply plugin chain if enabled
 	if cfg.Plugins.Enabled && len(cfg.Plugins.Chain) > 0 {
+		plugins.SetMetricsCollector(lb.GetMetricsCollector())
+		plugins.SetBufferBudget(lb.BufferBudget())
+		plugins.SetTrustedProxies(lb.TrustedProxies())
 		chained, err := plugins.BuildChain(cfg.Plugins, handler)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build plugin chain: %w", err)
+			return nil, nil, fmt.Errorf("failed to build plugin chain: %w", err)
 		}
-		handler = chained
+		handler, chain = chained, chained
 
-		// Log configured plugin names in order
-		names := make([]string, 0, len(cfg.Plugins.Chain))
-		for _, p := range cfg.Plugins.Chain {
-			names = append(names, p.Name)
+		// Log the effective plugin order, which auto_order may have changed
+		order, err := plugins.ResolveOrder(cfg.Plugins)
+		if err != nil {
+			_ = chain.Close()
+			return nil, nil, fmt.Errorf("failed to build plugin chain: %w", err)
 		}
-		logger.Info().Strs("plugins", names).Msg("plugins enabled")
+		logger.Info().Strs("plugins", order.Names()).Msg("plugins enabled")
 	} else {
 		logger.Info().Msg("plugins disabled")
 	}
 
+	// Ask clients to reconnect elsewhere once shutdown begins
+	handler = lb.CloseOnDrain(handler)
+
+	// Give HTTP/1.0 clients a Host and predictable connection handling
+	handler = lb.LegacyHTTP10(handler)
+
 	// Add request context middleware
 	handler = logging.RequestContextMiddleware(cfg.Logging)(handler)
 
-	return handler, nil
+	return handler, chain, nil
+}
+
+// setupSyntheticMonitoring starts the synthetic check runner if enabled in
+// config. In a cluster only the leader runs the checks.
+func setupSyntheticMonitoring(cfg *config.Config, handler http.Handler, lb *loadbalancer.LoadBalancer, elector *cluster.Elector) *synthetics.Runner {
+	if !cfg.Synthetics.Enabled || len(cfg.Synthetics.Checks) == 0 {
+		return nil
+	}
+
+	runner := synthetics.NewRunner(cfg.Synthetics, synthetics.DefaultHost(cfg.Server), handler, lb.GetMetricsCollector())
+	if elector != nil {
+		runner.SetGate(elector.IsLeader)
+	}
+	runner.Start()
+	return runner
+}
+
+// readHeaderTimeout returns server.timeouts.read_header, independent of the
+// full read timeout so clients dribbling headers are cut off early
+func readHeaderTimeout(cfg *config.Config) time.Duration {
+	if cfg.Server.Timeouts.ReadHeader == 0 {
+		return 5 * time.Second // Default: protect against slowloris
+	}
+	return time.Duration(cfg.Server.Timeouts.ReadHeader) * time.Second
 }
 
 // createHTTPServer creates and configures the main HTTP server
-func createHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+func createHTTPServer(cfg *config.Config, handler http.Handler, lb *loadbalancer.LoadBalancer) *http.Server {
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 
 	// Apply timeout configurations with smart defaults
@@ -138,12 +237,16 @@ func createHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
 		idleTimeout = 60 * time.Second // Default: keep-alive timeout
 	}
 
+	headerTimeout := readHeaderTimeout(cfg)
+
 	server := &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: headerTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		ConnState:         lb.TrackHeaderReads(headerTimeout),
 	}
 
 	// Configure TLS if enabled
@@ -212,6 +315,7 @@ func startHTTPServer(server *http.Server, cfg *config.Config, serverErrors chan<
 			logger.Info().
 				Str("min_tls_version", "1.2").
 				Dur("read_timeout", readTimeout).
+				Dur("read_header_timeout", readHeaderTimeout(cfg)).
 				Dur("write_timeout", writeTimeout).
 				Dur("idle_timeout", idleTimeout).
 				Msg("server timeouts configured")
@@ -221,6 +325,7 @@ func startHTTPServer(server *http.Server, cfg *config.Config, serverErrors chan<
 			logger.Info().Int("port", cfg.Server.Port).Msg("listening for http")
 			logger.Info().
 				Dur("read_timeout", readTimeout).
+				Dur("read_header_timeout", readHeaderTimeout(cfg)).
 				Dur("write_timeout", writeTimeout).
 				Dur("idle_timeout", idleTimeout).
 				Msg("server timeouts configured")
@@ -230,6 +335,22 @@ func startHTTPServer(server *http.Server, cfg *config.Config, serverErrors chan<
 	}()
 }
 
+// warnUntrustedClientIPs warns when an ip_hash strategy is selected without
+// server.trusted_proxies. It then hashes the peer address, so behind a load
+// balancer or CDN every client goes to the same backend.
+func warnUntrustedClientIPs(cfg *config.Config) {
+	if len(cfg.Server.TrustedProxies) > 0 {
+		return
+	}
+	for _, strategy := range []string{cfg.LoadBalancer.Strategy, cfg.LoadBalancer.ShadowStrategy.Strategy} {
+		if strategy == "ip_hash" || strategy == "ip_hash_consistent" {
+			logging.L().Warn().Str("strategy", strategy).
+				Msg("server.trusted_proxies is empty: ip_hash ignores X-Forwarded-For and X-Real-IP and hashes the peer address; behind a load balancer or CDN all clients will map to one backend")
+			return
+		}
+	}
+}
+
 // logStartupInfo logs server startup information
 func logStartupInfo(cfg *config.Config) {
 	logger := logging.L()
@@ -261,24 +382,34 @@ func logStartupInfo(cfg *config.Config) {
 	}
 }
 
-// shutdownGracefully performs graceful shutdown of the server and load balancer
-func shutdownGracefully(server *http.Server, lb *loadbalancer.LoadBalancer, shutdownTimeout time.Duration) {
+// shutdownGracefully performs graceful shutdown of the server and load balancer.
+// The Admin API server is left running so GET /v1/shutdown/status and
+// POST /v1/shutdown/force stay reachable until the process exits.
+func shutdownGracefully(server *http.Server, grpcServer *adminapi.GRPCServer, lb *loadbalancer.LoadBalancer, chain *plugins.Chain, shutdownTimeout time.Duration) {
 	logger := logging.L()
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer cancel()
+	deadline := time.Now().Add(shutdownTimeout)
 
 	logger.Info().Dur("timeout", shutdownTimeout).Msg("shutting down server gracefully")
 
-	// Shutdown HTTP server
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error().Err(err).Msg("error during server shutdown")
-		if closeErr := server.Close(); closeErr != nil {
-			logger.Error().Err(closeErr).Msg("error closing server")
+	// Drain the main listener: in-flight requests, then WebSocket tunnels
+	lb.GracefulShutdown(server, shutdownTimeout)
+
+	// Drain the gRPC Admin API within the same shutdown budget
+	if grpcServer != nil {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
 		}
+		grpcServer.Shutdown(remaining)
 	}
 
 	// Stop load balancer
 	lb.Stop()
 
+	// Release plugin stores now that no request can reach them
+	if err := chain.Close(); err != nil {
+		logger.Warn().Err(err).Msg("failed to release plugin resources")
+	}
+
 	logger.Info().Msg("server shutdown complete")
 }
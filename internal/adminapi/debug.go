@@ -0,0 +1,216 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+const (
+	// defaultMaxCaptures applies when debug.max_captures is 0
+	defaultMaxCaptures = 10
+	// defaultCPUProfile is the CPU profile duration when the request sets none
+	defaultCPUProfile = 5 * time.Second
+	// maxCPUProfile caps the CPU profile duration a request may ask for,
+	// leaving room for the rest of the bundle within the Admin API server's
+	// 15s write timeout
+	maxCPUProfile = 10 * time.Second
+	// captureDirPrefix names capture bundles; the timestamp after it sorts
+	// chronologically
+	captureDirPrefix = "capture-"
+)
+
+// debugCapture writes a diagnostics bundle to debug.capture_dir
+func (a *api) debugCapture(w http.ResponseWriter, r *http.Request) {
+	if !a.cfg.Debug.Enabled {
+		http.Error(w, "debug capture is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var req DebugCaptureRequest
+	// The body is optional
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	cpuDuration := defaultCPUProfile
+	if req.CPUSeconds < 0 {
+		http.Error(w, "cpu_seconds must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if req.CPUSeconds > 0 {
+		cpuDuration = time.Duration(req.CPUSeconds * float64(time.Second))
+	}
+	if cpuDuration > maxCPUProfile {
+		http.Error(w, fmt.Sprintf("cpu_seconds must be at most %v", maxCPUProfile.Seconds()), http.StatusBadRequest)
+		return
+	}
+
+	if !a.captureMu.TryLock() {
+		http.Error(w, "a capture is already in progress", http.StatusConflict)
+		return
+	}
+	defer a.captureMu.Unlock()
+
+	dir := filepath.Join(a.cfg.Debug.CaptureDir, captureDirPrefix+time.Now().UTC().Format("20060102T150405.000000000Z"))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		http.Error(w, fmt.Sprintf("failed to create capture directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Point-in-time state first, then the CPU profile that takes a while
+	resp := DebugCaptureResponse{Path: dir}
+	resp.Files = append(resp.Files,
+		writeCaptureFile(dir, "heap.pprof", func(w io.Writer) error {
+			return pprof.Lookup("heap").WriteTo(w, 0)
+		}),
+		writeCaptureFile(dir, "goroutines.txt", func(w io.Writer) error {
+			return pprof.Lookup("goroutine").WriteTo(w, 2)
+		}),
+		writeCaptureFile(dir, "metrics.json", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(a.mc.GetMetrics())
+		}),
+		writeCaptureFile(dir, "config.yaml", func(w io.Writer) error {
+			masked, err := maskedConfig(a.cfg)
+			if err != nil {
+				return err
+			}
+			return yaml.NewEncoder(w).Encode(masked)
+		}),
+		writeCaptureFile(dir, "cpu.pprof", func(w io.Writer) error {
+			if err := pprof.StartCPUProfile(w); err != nil {
+				return err
+			}
+			select {
+			case <-time.After(cpuDuration):
+			case <-r.Context().Done():
+			}
+			pprof.StopCPUProfile()
+			return r.Context().Err()
+		}),
+	)
+
+	maxCaptures := a.cfg.Debug.MaxCaptures
+	if maxCaptures == 0 {
+		maxCaptures = defaultMaxCaptures
+	}
+	if err := pruneCaptures(a.cfg.Debug.CaptureDir, maxCaptures); err != nil {
+		logging.L().Warn().Err(err).Str("capture_dir", a.cfg.Debug.CaptureDir).Msg("failed to prune old debug captures")
+	}
+
+	logging.L().Info().Str("path", dir).Msg("debug capture written")
+	writeJSON(w, resp)
+}
+
+// writeCaptureFile writes one file of a capture bundle, reporting its size or
+// the error that prevented writing it
+func writeCaptureFile(dir, name string, write func(io.Writer) error) DebugCaptureFile {
+	file := DebugCaptureFile{Name: name}
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640) // #nosec G304 - dir is the configured capture dir, name is a constant
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		file.Error = err.Error()
+	}
+	if info, statErr := os.Stat(f.Name()); statErr == nil {
+		file.Bytes = info.Size()
+	}
+	return file
+}
+
+// pruneCaptures deletes the oldest capture bundles in dir beyond keep
+func pruneCaptures(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var captures []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), captureDirPrefix) {
+			captures = append(captures, entry.Name())
+		}
+	}
+	if len(captures) <= keep {
+		return nil
+	}
+	sort.Strings(captures)
+	for _, name := range captures[:len(captures)-keep] {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretKeyMarkers identify config keys whose values are masked in captures,
+// compared against the lowercased key with '_' and '-' removed
+var secretKeyMarkers = []string{"secret", "password", "passwd", "apikey", "authtoken", "credential", "privatekey"}
+
+// secretKeys are config keys masked in captures when matched exactly
+var secretKeys = map[string]bool{"token": true, "authorization": true, "cookie": true}
+
+// maskedConfig returns cfg as a generic YAML tree with secret values masked
+func maskedConfig(cfg *config.Config) (interface{}, error) {
+	raw, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+	return maskSecrets(tree), nil
+}
+
+// maskSecrets replaces the values of secret-looking keys in a YAML tree
+func maskSecrets(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSecretKey(key) && value != nil && value != "" {
+				v[key] = "********"
+				continue
+			}
+			v[key] = maskSecrets(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = maskSecrets(v[i])
+		}
+	}
+	return node
+}
+
+// isSecretKey reports whether a config key names a secret
+func isSecretKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	if secretKeys[normalized] {
+		return true
+	}
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(normalized, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+// newCaptureMux serves the Admin API with debug captures written to dir
+func newCaptureMux(t *testing.T, dir string, maxCaptures int) http.Handler {
+	t.Helper()
+	cfg := newTestConfig("s3cret-token")
+	cfg.Debug = config.DebugConfig{Enabled: true, CaptureDir: dir, MaxCaptures: maxCaptures}
+	cfg.Plugins.Chain = []config.PluginConfig{{Name: "custom-auth", Config: map[string]interface{}{"apiKey": "s3cret-key"}}}
+	return NewMux(newTestLB(t), cfg, metrics.NewMetricsCollector())
+}
+
+// capture posts a capture request and decodes a successful response
+func capture(t *testing.T, mux http.Handler, body string) (int, DebugCaptureResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/debug/capture", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer s3cret-token")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var resp DebugCaptureResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, resp
+}
+
+// captureDirs lists the capture bundles in dir
+func captureDirs(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read capture dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestDebugCapture_Disabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewMux(newTestLB(t), newTestConfig(""), metrics.NewMetricsCollector()).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/debug/capture", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 with debug disabled, got %d", rec.Code)
+	}
+}
+
+func TestDebugCapture_WritesBundle(t *testing.T) {
+	dir := t.TempDir()
+	mux := newCaptureMux(t, dir, 0)
+
+	done := make(chan struct{})
+	var code int
+	var resp DebugCaptureResponse
+	go func() {
+		defer close(done)
+		code, resp = capture(t, mux, `{"cpu_seconds": 0.5}`)
+	}()
+
+	// Overlapping captures are refused while the CPU profile runs
+	deadline := time.Now().Add(2 * time.Second)
+	for len(captureDirs(t, dir)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the capture to start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if second, _ := capture(t, mux, `{"cpu_seconds": 0.1}`); second != http.StatusConflict {
+		t.Errorf("expected 409 for an overlapping capture, got %d", second)
+	}
+
+	<-done
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if filepath.Dir(resp.Path) != dir {
+		t.Errorf("expected the bundle under %s, got %s", dir, resp.Path)
+	}
+	want := map[string]bool{"heap.pprof": true, "goroutines.txt": true, "cpu.pprof": true, "metrics.json": true, "config.yaml": true}
+	for _, f := range resp.Files {
+		if !want[f.Name] {
+			t.Errorf("unexpected file %s", f.Name)
+			continue
+		}
+		delete(want, f.Name)
+		if f.Error != "" {
+			t.Errorf("%s: %s", f.Name, f.Error)
+		}
+		info, err := os.Stat(filepath.Join(resp.Path, f.Name))
+		if err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+			continue
+		}
+		if info.Size() == 0 || info.Size() != f.Bytes {
+			t.Errorf("%s: expected %d non-zero bytes on disk, got %d", f.Name, f.Bytes, info.Size())
+		}
+	}
+	for name := range want {
+		t.Errorf("missing %s", name)
+	}
+
+	cfgDump, err := os.ReadFile(filepath.Join(resp.Path, "config.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read config dump: %v", err)
+	}
+	if strings.Contains(string(cfgDump), "s3cret") {
+		t.Errorf("expected secrets masked in the config dump:\n%s", cfgDump)
+	}
+	if !strings.Contains(string(cfgDump), "capture_dir") {
+		t.Errorf("expected the effective config in the dump:\n%s", cfgDump)
+	}
+}
+
+func TestDebugCapture_Retention(t *testing.T) {
+	dir := t.TempDir()
+	mux := newCaptureMux(t, dir, 2)
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		code, resp := capture(t, mux, `{"cpu_seconds": 0.01}`)
+		if code != http.StatusOK {
+			t.Fatalf("capture %d: expected 200, got %d", i, code)
+		}
+		paths = append(paths, resp.Path)
+	}
+
+	if got := captureDirs(t, dir); len(got) != 2 {
+		t.Fatalf("expected 2 captures kept, got %v", got)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the oldest capture pruned, stat error = %v", err)
+	}
+	for _, path := range paths[1:] {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s kept: %v", path, err)
+		}
+	}
+}
+
+func TestDebugCapture_RejectsBadDuration(t *testing.T) {
+	mux := newCaptureMux(t, t.TempDir(), 0)
+	for _, body := range []string{`{"cpu_seconds": -1}`, `{"cpu_seconds": 11}`, `not json`} {
+		if code, _ := capture(t, mux, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package adminapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	adminv1 "github.com/0xReLogic/Helios/api/admin/v1"
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/loadbalancer"
+	"github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+// GRPCServer serves the Admin API over gRPC. Every RPC is a thin adapter over
+// the same LoadBalancer and MetricsCollector methods used by the HTTP mux.
+type GRPCServer struct {
+	server   *grpc.Server
+	health   *health.Server
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGRPCServer creates a gRPC Admin API server. Extra server options (such
+// as transport credentials) are appended after the auth interceptors.
+func NewGRPCServer(lb *loadbalancer.LoadBalancer, cfg *config.Config, mc *metrics.MetricsCollector, opts ...grpc.ServerOption) (*GRPCServer, error) {
+	var ipFilter *IPFilter
+	if len(cfg.AdminAPI.IPAllowList) > 0 || len(cfg.AdminAPI.IPDenyList) > 0 {
+		filter, err := NewIPFilter(cfg.AdminAPI.IPAllowList, cfg.AdminAPI.IPDenyList)
+		if err != nil {
+			return nil, err
+		}
+		ipFilter = filter
+	}
+
+	a := &grpcAuth{token: cfg.AdminAPI.AuthToken, ipFilter: ipFilter}
+	serverOpts := append([]grpc.ServerOption{
+		grpc.UnaryInterceptor(a.unary),
+		grpc.StreamInterceptor(a.stream),
+	}, opts...)
+
+	s := &GRPCServer{
+		server: grpc.NewServer(serverOpts...),
+		health: health.NewServer(),
+		done:   make(chan struct{}),
+	}
+
+	adminv1.RegisterBackendServiceServer(s.server, &backendService{lb: lb})
+	adminv1.RegisterStrategyServiceServer(s.server, &strategyService{lb: lb})
+	adminv1.RegisterMetricsServiceServer(s.server, &metricsService{mc: mc})
+	adminv1.RegisterEventServiceServer(s.server, &eventService{lb: lb, done: s.done})
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	for name := range s.server.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	logging.L().Info().Msg("admin api grpc server initialized")
+	return s, nil
+}
+
+// Serve accepts connections on the listener until the server is stopped
+func (s *GRPCServer) Serve(l net.Listener) error {
+	return s.server.Serve(l)
+}
+
+// Shutdown ends event streams and drains in-flight RPCs. If draining takes
+// longer than timeout the remaining connections are closed forcibly.
+func (s *GRPCServer) Shutdown(timeout time.Duration) {
+	s.stopOnce.Do(func() {
+		s.health.Shutdown()
+		close(s.done)
+
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			logging.L().Warn().Dur("timeout", timeout).Msg("admin api grpc graceful stop timed out, forcing stop")
+			s.server.Stop()
+		}
+	})
+}
+
+// grpcAuth enforces the Admin API token and IP filter on every RPC
+type grpcAuth struct {
+	token    string
+	ipFilter *IPFilter
+}
+
+func (a *grpcAuth) authorize(ctx context.Context, fullMethod string) error {
+	if a.ipFilter != nil {
+		clientIP := peerIP(ctx)
+		if !a.ipFilter.IsAllowed(clientIP) {
+			logging.WithContext(ctx).Warn().
+				Str("client_ip", clientIP).
+				Str("method", fullMethod).
+				Msg("IP blocked by filter")
+			return status.Error(codes.PermissionDenied, "IP address not allowed")
+		}
+	}
+
+	// Health checks stay unauthenticated, matching /v1/health
+	if a.token == "" || isHealthMethod(fullMethod) {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !validBearerToken(values[0], a.token) {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return nil
+}
+
+func (a *grpcAuth) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *grpcAuth) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func isHealthMethod(fullMethod string) bool {
+	return fullMethod == healthpb.Health_Check_FullMethodName || fullMethod == healthpb.Health_Watch_FullMethodName
+}
+
+// peerIP extracts the client IP from the gRPC peer address
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+type backendService struct {
+	adminv1.UnimplementedBackendServiceServer
+	lb *loadbalancer.LoadBalancer
+}
+
+func (s *backendService) ListBackends(ctx context.Context, req *adminv1.ListBackendsRequest) (*adminv1.ListBackendsResponse, error) {
+	backends := s.lb.ListBackends()
+	resp := &adminv1.ListBackendsResponse{Backends: make([]*adminv1.Backend, 0, len(backends))}
+	for _, b := range backends {
+		resp.Backends = append(resp.Backends, &adminv1.Backend{
+			Name:                 b.Name,
+			Address:              b.Address,
+			Healthy:              b.Healthy,
+			ActiveConnections:    b.ActiveConnections,
+			Weight:               int32(b.Weight),
+			ConfiguredWeight:     int32(b.ConfiguredWeight),
+			WeightFromHint:       b.WeightFromHint,
+			Draining:             b.Draining,
+			ConsecutiveFailures:  int32(b.ConsecutiveFailures),
+			ConsecutiveSuccesses: int32(b.ConsecutiveSuccesses),
+			Group:                b.Group,
+		})
+	}
+	return resp, nil
+}
+
+func (s *backendService) AddBackend(ctx context.Context, req *adminv1.AddBackendRequest) (*adminv1.AddBackendResponse, error) {
+	backendCfg := backendConfigFromRequest(req)
+	if err := config.ValidateBackend(backendCfg); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.lb.AddBackend(backendCfg); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to add backend: %v", err)
+	}
+	return &adminv1.AddBackendResponse{}, nil
+}
+
+// backendConfigFromRequest maps an AddBackendRequest onto the BackendConfig
+// the HTTP API decodes from the same fields in JSON
+func backendConfigFromRequest(req *adminv1.AddBackendRequest) config.BackendConfig {
+	return config.BackendConfig{
+		Name:                      req.GetName(),
+		Address:                   req.GetAddress(),
+		Weight:                    int(req.GetWeight()),
+		MaxResponseHeaderBytes:    req.GetMaxResponseHeaderBytes(),
+		BackendBodyTimeoutSeconds: int(req.GetBackendBodyTimeoutSeconds()),
+		FlushIntervalMs:           int(req.GetFlushIntervalMs()),
+		BufferSizeKB:              int(req.GetBufferSizeKb()),
+		RedirectPolicy:            req.GetRedirectPolicy(),
+		MaxRedirects:              int(req.GetMaxRedirects()),
+		AllowedRedirectHosts:      req.GetAllowedRedirectHosts(),
+		ConnectionRecycling: config.ConnectionRecyclingConfig{
+			MaxConnectionAgeSeconds:  int(req.GetConnectionRecycling().GetMaxConnectionAgeSeconds()),
+			MaxRequestsPerConnection: int(req.GetConnectionRecycling().GetMaxRequestsPerConnection()),
+		},
+	}
+}
+
+func (s *backendService) RemoveBackend(ctx context.Context, req *adminv1.RemoveBackendRequest) (*adminv1.RemoveBackendResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	s.lb.RemoveBackend(req.GetName())
+	return &adminv1.RemoveBackendResponse{}, nil
+}
+
+func (s *backendService) SetWeight(ctx context.Context, req *adminv1.SetWeightRequest) (*adminv1.SetWeightResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if err := s.lb.SetBackendWeight(req.GetName(), int(req.GetWeight())); err != nil {
+		if errors.Is(err, loadbalancer.ErrBackendNotFound) {
+			return nil, status.Errorf(codes.NotFound, "failed to set weight: %v", err)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "failed to set weight: %v", err)
+	}
+	return &adminv1.SetWeightResponse{}, nil
+}
+
+type strategyService struct {
+	adminv1.UnimplementedStrategyServiceServer
+	lb *loadbalancer.LoadBalancer
+}
+
+func (s *strategyService) GetStrategy(ctx context.Context, req *adminv1.GetStrategyRequest) (*adminv1.GetStrategyResponse, error) {
+	resp := &adminv1.GetStrategyResponse{Strategy: s.lb.GetStrategy()}
+	if options := s.lb.GetStrategyOptions(); options != nil {
+		cfg, err := structpb.NewStruct(options)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode strategy config: %v", err)
+		}
+		resp.Config = cfg
+	}
+	return resp, nil
+}
+
+func (s *strategyService) SetStrategy(ctx context.Context, req *adminv1.SetStrategyRequest) (*adminv1.SetStrategyResponse, error) {
+	if req.GetStrategy() == "" {
+		return nil, status.Error(codes.InvalidArgument, "strategy is required")
+	}
+	var err error
+	if req.GetConfig() != nil {
+		err = s.lb.SetStrategyWithOptions(req.GetStrategy(), req.GetConfig().AsMap())
+	} else {
+		err = s.lb.SetStrategy(req.GetStrategy())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to set strategy: %v", err)
+	}
+	return &adminv1.SetStrategyResponse{}, nil
+}
+
+type metricsService struct {
+	adminv1.UnimplementedMetricsServiceServer
+	mc *metrics.MetricsCollector
+}
+
+func (s *metricsService) GetSnapshot(ctx context.Context, req *adminv1.GetSnapshotRequest) (*adminv1.GetSnapshotResponse, error) {
+	return snapshotToProto(s.mc.GetMetrics()), nil
+}
+
+// snapshotToProto converts the GET /v1/metrics snapshot field for field;
+// TestGRPC_FieldsMatchJSON fails when the two drift apart
+func snapshotToProto(m *metrics.Metrics) *adminv1.GetSnapshotResponse {
+	resp := &adminv1.GetSnapshotResponse{
+		TotalRequests:            m.TotalRequests,
+		SuccessfulRequests:       m.SuccessfulRequests,
+		FailedRequests:           m.FailedRequests,
+		AverageResponseTimeMs:    m.AverageResponseTime,
+		RateLimitedRequests:      m.RateLimitedRequests,
+		BackendMetrics:           make(map[string]*adminv1.BackendMetrics, len(m.BackendMetrics)),
+		StartTimeUnixNano:        unixNano(m.StartTime),
+		RateLimitRuleRejections:  m.RateLimitRuleRejections,
+		Http10Requests:           m.HTTP10Requests,
+		SecurityRejections:       m.SecurityRejections,
+		PendingHeaderConnections: m.PendingHeaderConnections,
+		SlowHeaderConnections:    m.SlowHeaderConnections,
+		RefusedHeaderConnections: m.RefusedHeaderConnections,
+		CircuitBreakerMetrics:    make(map[string]*adminv1.CircuitBreakerMetrics, len(m.CircuitBreakerMetrics)),
+		SyntheticMetrics:         make(map[string]*adminv1.SyntheticMetrics, len(m.SyntheticMetrics)),
+		PluginMetrics:            make(map[string]*adminv1.PluginCounters, len(m.PluginMetrics)),
+		ActiveBackendGroup:       m.ActiveBackendGroup,
+		BufferBudget: &adminv1.BufferBudgetMetrics{
+			LimitBytes: m.BufferBudget.LimitBytes,
+			UsedBytes:  m.BufferBudget.UsedBytes,
+			PeakBytes:  m.BufferBudget.PeakBytes,
+			Denials:    m.BufferBudget.Denials,
+		},
+		CostMetrics: make(map[string]*adminv1.CostMetrics, len(m.CostMetrics)),
+		Stores:      make(map[string]*adminv1.StoreStats, len(m.Stores)),
+		Uptime:      m.Uptime,
+	}
+	for name, bm := range m.BackendMetrics {
+		resp.BackendMetrics[name] = &adminv1.BackendMetrics{
+			Name:                    bm.Name,
+			TotalRequests:           bm.TotalRequests,
+			SuccessfulRequests:      bm.SuccessfulRequests,
+			FailedRequests:          bm.FailedRequests,
+			ActiveConnections:       bm.ActiveConnections,
+			AverageResponseTimeMs:   bm.AverageResponseTime,
+			IsHealthy:               bm.IsHealthy,
+			HeaderOverflows:         bm.HeaderOverflows,
+			LastHealthCheckUnixNano: unixNano(bm.LastHealthCheck),
+			BackendBodyStall:        bm.BodyStalls,
+			RedirectsFollowed:       bm.RedirectsFollowed,
+			RedirectsRewritten:      bm.RedirectsRewritten,
+			ConnectionsRecycled:     bm.ConnectionsRecycled,
+			ConsecutiveFailures:     int32(bm.ConsecutiveFailures),
+			ConsecutiveSuccesses:    int32(bm.ConsecutiveSuccesses),
+			ConnWaitMs:              bm.ConnWaitMs,
+			ConnWaitP95Ms:           bm.ConnWaitP95Ms,
+			ConnWaitWarnings:        bm.ConnWaitWarnings,
+			PoolExhaustions:         bm.PoolExhaustions,
+		}
+	}
+	for name, cb := range m.CircuitBreakerMetrics {
+		resp.CircuitBreakerMetrics[name] = &adminv1.CircuitBreakerMetrics{
+			Name:                    cb.Name,
+			State:                   cb.State,
+			FailureCount:            cb.FailureCount,
+			SuccessCount:            cb.SuccessCount,
+			RequestCount:            cb.RequestCount,
+			LastStateChangeUnixNano: unixNano(cb.LastStateChange),
+		}
+	}
+	for name, sm := range m.SyntheticMetrics {
+		resp.SyntheticMetrics[name] = &adminv1.SyntheticMetrics{
+			Name:              sm.Name,
+			Runs:              sm.Runs,
+			Passes:            sm.Passes,
+			Failures:          sm.Failures,
+			LastPassed:        sm.LastPassed,
+			LastLatencyMs:     sm.LastLatency,
+			AverageLatencyMs:  sm.AverageLatency,
+			LastFailureReason: sm.LastFailureReason,
+			LastRunUnixNano:   unixNano(sm.LastRun),
+		}
+	}
+	for plugin, counters := range m.PluginMetrics {
+		resp.PluginMetrics[plugin] = &adminv1.PluginCounters{Counters: counters}
+	}
+	for label, cm := range m.CostMetrics {
+		resp.CostMetrics[label] = &adminv1.CostMetrics{
+			Requests:         cm.Requests,
+			BytesIn:          cm.BytesIn,
+			BytesOut:         cm.BytesOut,
+			BackendLatencyMs: cm.BackendLatencyMs,
+			Errors:           cm.Errors,
+		}
+	}
+	for name, st := range m.Stores {
+		resp.Stores[name] = &adminv1.StoreStats{
+			Size:        int64(st.Size),
+			Hits:        st.Hits,
+			Misses:      st.Misses,
+			Evictions:   st.Evictions,
+			Expirations: st.Expirations,
+		}
+	}
+	return resp
+}
+
+// unixNano returns t in Unix nanoseconds, or 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+type eventService struct {
+	adminv1.UnimplementedEventServiceServer
+	lb   *loadbalancer.LoadBalancer
+	done <-chan struct{}
+}
+
+func (s *eventService) Subscribe(req *adminv1.SubscribeRequest, stream adminv1.EventService_SubscribeServer) error {
+	events, cancel := s.lb.SubscribeEvents()
+	defer cancel()
+
+	// Send headers once subscribed so clients know no event will be missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server shutting down")
+		case ev := <-events:
+			if err := stream.Send(&adminv1.Event{
+				Type:         ev.Type,
+				Backend:      ev.Backend,
+				Message:      ev.Message,
+				TimeUnixNano: ev.Time.UnixNano(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
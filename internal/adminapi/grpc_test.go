@@ -0,0 +1,504 @@
+package adminapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	adminv1 "github.com/0xReLogic/Helios/api/admin/v1"
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/loadbalancer"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+const testGRPCToken = "grpc-secret"
+
+// startBufconnServer runs the gRPC Admin API over an in-process listener
+func startBufconnServer(t *testing.T, lb *loadbalancer.LoadBalancer, cfg *config.Config, mc *metrics.MetricsCollector) *grpc.ClientConn {
+	t.Helper()
+	srv, err := NewGRPCServer(lb, cfg, mc)
+	if err != nil {
+		t.Fatalf("failed to create grpc server: %v", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(listener) }()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Shutdown(time.Second)
+	})
+	return conn
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// httpBackends fetches the backend list through the HTTP mux for parity checks
+func httpBackends(t *testing.T, mux http.Handler, token string) []loadbalancer.BackendInfo {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/backends", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from http list, got %d", rec.Code)
+	}
+	var infos []loadbalancer.BackendInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("invalid http json: %v", err)
+	}
+	return infos
+}
+
+func assertBackendParity(t *testing.T, grpcList []*adminv1.Backend, httpList []loadbalancer.BackendInfo) {
+	t.Helper()
+	if len(grpcList) != len(httpList) {
+		t.Fatalf("grpc returned %d backends, http returned %d", len(grpcList), len(httpList))
+	}
+	for i, b := range grpcList {
+		h := httpList[i]
+		if b.Name != h.Name || b.Address != h.Address || b.Healthy != h.Healthy ||
+			b.ActiveConnections != h.ActiveConnections || int(b.Weight) != h.Weight {
+			t.Fatalf("backend %d mismatch: grpc=%+v http=%+v", i, b, h)
+		}
+	}
+}
+
+func TestGRPC_BackendRoundTrip(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig(testGRPCToken)
+	conn := startBufconnServer(t, lb, cfg, mc)
+	mux := NewMux(lb, cfg, mc)
+
+	client := adminv1.NewBackendServiceClient(conn)
+	ctx := withToken(context.Background(), testGRPCToken)
+
+	if _, err := client.AddBackend(ctx, &adminv1.AddBackendRequest{Name: "b1", Address: "http://127.0.0.1:65001", Weight: 2}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	// Add through HTTP as well so both surfaces act on the same state
+	body, _ := json.Marshal(map[string]interface{}{"name": "b2", "address": "http://127.0.0.1:65002"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/backends/add", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testGRPCToken)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 from http add, got %d", rec.Code)
+	}
+
+	list, err := client.ListBackends(ctx, &adminv1.ListBackendsRequest{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(list.Backends) != 2 {
+		t.Fatalf("expected 2 backends, got %d", len(list.Backends))
+	}
+	assertBackendParity(t, list.Backends, httpBackends(t, mux, testGRPCToken))
+
+	if _, err := client.SetWeight(ctx, &adminv1.SetWeightRequest{Name: "b2", Weight: 5}); err != nil {
+		t.Fatalf("set weight failed: %v", err)
+	}
+	_, err = client.SetWeight(ctx, &adminv1.SetWeightRequest{Name: "missing", Weight: 5})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for unknown backend, got %v", err)
+	}
+
+	if _, err := client.RemoveBackend(ctx, &adminv1.RemoveBackendRequest{Name: "b1"}); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	list, err = client.ListBackends(ctx, &adminv1.ListBackendsRequest{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(list.Backends) != 1 || list.Backends[0].Name != "b2" || list.Backends[0].Weight != 5 {
+		t.Fatalf("unexpected backends after remove: %+v", list.Backends)
+	}
+	assertBackendParity(t, list.Backends, httpBackends(t, mux, testGRPCToken))
+
+	_, err = client.AddBackend(ctx, &adminv1.AddBackendRequest{Name: "no-address"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for missing address, got %v", err)
+	}
+}
+
+func TestGRPC_AddBackendParity(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig(testGRPCToken)
+	conn := startBufconnServer(t, lb, cfg, mc)
+	mux := NewMux(lb, cfg, mc)
+
+	// Every field set, so a field one API drops shows up as a difference
+	body := []byte(`{
+		"name": "b1",
+		"address": "http://127.0.0.1:65001",
+		"weight": 3,
+		"max_response_header_bytes": 65536,
+		"backend_body_timeout_seconds": 20,
+		"flush_interval_ms": -1,
+		"buffer_size_kb": 64,
+		"redirect_policy": "follow",
+		"max_redirects": 2,
+		"allowed_redirect_hosts": ["cdn.example.com"],
+		"connection_recycling": {"max_connection_age_seconds": 300, "max_requests_per_connection": 1000}
+	}`)
+
+	var viaHTTP config.BackendConfig
+	if err := json.Unmarshal(body, &viaHTTP); err != nil {
+		t.Fatalf("invalid http body: %v", err)
+	}
+	v := reflect.ValueOf(viaHTTP)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			t.Fatalf("test body leaves %s unset", v.Type().Field(i).Name)
+		}
+	}
+
+	var req adminv1.AddBackendRequest
+	if err := protojson.Unmarshal(body, &req); err != nil {
+		t.Fatalf("body does not map onto AddBackendRequest: %v", err)
+	}
+	if viaGRPC := backendConfigFromRequest(&req); !reflect.DeepEqual(viaGRPC, viaHTTP) {
+		t.Fatalf("grpc and http add different backends:\ngrpc=%+v\nhttp=%+v", viaGRPC, viaHTTP)
+	}
+
+	// Both surfaces accept the same settings
+	client := adminv1.NewBackendServiceClient(conn)
+	if _, err := client.AddBackend(withToken(context.Background(), testGRPCToken), &req); err != nil {
+		t.Fatalf("grpc add failed: %v", err)
+	}
+	httpBody := bytes.Replace(body, []byte(`"name": "b1"`), []byte(`"name": "b2"`), 1)
+	httpReq := httptest.NewRequest(http.MethodPost, "/v1/backends/add", bytes.NewReader(httpBody))
+	httpReq.Header.Set("Authorization", "Bearer "+testGRPCToken)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httpReq)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 from http add, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Out-of-range settings are rejected over gRPC too
+	req.Name, req.BufferSizeKb = "b3", 10000000
+	if _, err := client.AddBackend(withToken(context.Background(), testGRPCToken), &req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for buffer_size_kb, got %v", err)
+	}
+}
+
+func TestGRPC_StrategyParity(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig("")
+	conn := startBufconnServer(t, lb, cfg, mc)
+	mux := NewMux(lb, cfg, mc)
+
+	client := adminv1.NewStrategyServiceClient(conn)
+	ctx := context.Background()
+
+	if _, err := client.SetStrategy(ctx, &adminv1.SetStrategyRequest{Strategy: "least_connections"}); err != nil {
+		t.Fatalf("set strategy failed: %v", err)
+	}
+	resp, err := client.GetStrategy(ctx, &adminv1.GetStrategyRequest{})
+	if err != nil {
+		t.Fatalf("get strategy failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy", nil))
+	var httpResp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &httpResp); err != nil {
+		t.Fatalf("invalid http json: %v", err)
+	}
+	if resp.Strategy != "least_connections" || httpResp["strategy"] != resp.Strategy {
+		t.Fatalf("strategy mismatch: grpc=%q http=%q", resp.Strategy, httpResp["strategy"])
+	}
+
+	_, err = client.SetStrategy(ctx, &adminv1.SetStrategyRequest{Strategy: "bogus"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for unknown strategy, got %v", err)
+	}
+
+	// Options round-trip and match the HTTP view
+	options, err := structpb.NewStruct(map[string]interface{}{"cookie_name": "srv"})
+	if err != nil {
+		t.Fatalf("failed to build options: %v", err)
+	}
+	if _, err := client.SetStrategy(ctx, &adminv1.SetStrategyRequest{Strategy: testOptionsStrategy, Config: options}); err != nil {
+		t.Fatalf("set strategy with options failed: %v", err)
+	}
+	resp, err = client.GetStrategy(ctx, &adminv1.GetStrategyRequest{})
+	if err != nil {
+		t.Fatalf("get strategy failed: %v", err)
+	}
+	if resp.GetConfig().AsMap()["cookie_name"] != "srv" {
+		t.Fatalf("expected options to round-trip, got %v", resp.GetConfig().AsMap())
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy", nil))
+	var withOptions StrategyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &withOptions); err != nil {
+		t.Fatalf("invalid http json: %v", err)
+	}
+	if withOptions.Strategy != resp.GetStrategy() || withOptions.Config["cookie_name"] != "srv" {
+		t.Fatalf("strategy options mismatch: grpc=%v http=%+v", resp.GetConfig().AsMap(), withOptions)
+	}
+
+	bad, _ := structpb.NewStruct(map[string]interface{}{"nope": true})
+	_, err = client.SetStrategy(ctx, &adminv1.SetStrategyRequest{Strategy: "ip_hash", Config: bad})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for unknown option, got %v", err)
+	}
+}
+
+func TestGRPC_MetricsSnapshotParity(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	mc.RecordRequest()
+	mc.RecordResponse(true, 10*time.Millisecond)
+	mc.RecordBackendRequest("b1", true, 10*time.Millisecond)
+	cfg := newTestConfig("")
+	conn := startBufconnServer(t, lb, cfg, mc)
+	mux := NewMux(lb, cfg, mc)
+
+	resp, err := adminv1.NewMetricsServiceClient(conn).GetSnapshot(context.Background(), &adminv1.GetSnapshotRequest{})
+	if err != nil {
+		t.Fatalf("get snapshot failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+	var httpMetrics metrics.Metrics
+	if err := json.Unmarshal(rec.Body.Bytes(), &httpMetrics); err != nil {
+		t.Fatalf("invalid http json: %v", err)
+	}
+
+	if resp.TotalRequests != httpMetrics.TotalRequests || resp.SuccessfulRequests != httpMetrics.SuccessfulRequests {
+		t.Fatalf("request counters mismatch: grpc=%d/%d http=%d/%d",
+			resp.TotalRequests, resp.SuccessfulRequests, httpMetrics.TotalRequests, httpMetrics.SuccessfulRequests)
+	}
+	b1, ok := resp.BackendMetrics["b1"]
+	if !ok || b1.TotalRequests != httpMetrics.BackendMetrics["b1"].TotalRequests {
+		t.Fatalf("backend metrics mismatch: grpc=%+v http=%+v", b1, httpMetrics.BackendMetrics["b1"])
+	}
+}
+
+// TestGRPC_FieldsMatchJSON fails when a field is added to the HTTP JSON of
+// /v1/backends or /v1/metrics without a matching field in admin.proto, or
+// the other way round. Times travel as Unix nanoseconds under a
+// _unix_nano suffix.
+func TestGRPC_FieldsMatchJSON(t *testing.T) {
+	compareFields(t, "Backend", reflect.TypeOf(loadbalancer.BackendInfo{}), (&adminv1.Backend{}).ProtoReflect().Descriptor())
+	compareFields(t, "GetSnapshotResponse", reflect.TypeOf(metrics.Metrics{}), (&adminv1.GetSnapshotResponse{}).ProtoReflect().Descriptor())
+	compareFields(t, "AddBackendRequest", reflect.TypeOf(config.BackendConfig{}), (&adminv1.AddBackendRequest{}).ProtoReflect().Descriptor())
+}
+
+// compareFields matches the JSON keys of goType against the fields of md,
+// recursing into nested structs and maps of structs
+func compareFields(t *testing.T, path string, goType reflect.Type, md protoreflect.MessageDescriptor) {
+	t.Helper()
+	jsonFields := make(map[string]reflect.Type)
+	for i := 0; i < goType.NumField(); i++ {
+		f := goType.Field(i)
+		key := strings.Split(f.Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" || !f.IsExported() {
+			continue
+		}
+		jsonFields[key] = f.Type
+	}
+
+	protoFields := make(map[string]protoreflect.FieldDescriptor)
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		protoFields[strings.TrimSuffix(string(fd.Name()), "_unix_nano")] = fd
+	}
+
+	for key, ft := range jsonFields {
+		fd, ok := protoFields[key]
+		if !ok {
+			t.Errorf("%s: JSON field %q has no gRPC counterpart", path, key)
+			continue
+		}
+		if ft == reflect.TypeOf(time.Time{}) {
+			if !strings.HasSuffix(string(fd.Name()), "_unix_nano") || fd.Kind() != protoreflect.Int64Kind {
+				t.Errorf("%s: time field %q must be an int64 ending in _unix_nano, got %s %s", path, key, fd.Kind(), fd.Name())
+			}
+			continue
+		}
+
+		elem := ft
+		nested := fd.Message()
+		if ft.Kind() == reflect.Map {
+			if !fd.IsMap() {
+				t.Errorf("%s: JSON field %q is a map but gRPC field %s is not", path, key, fd.Name())
+				continue
+			}
+			elem = ft.Elem()
+			nested = fd.MapValue().Message()
+		}
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct && nested != nil {
+			compareFields(t, path+"."+key, elem, nested)
+		}
+	}
+	for key := range protoFields {
+		if _, ok := jsonFields[key]; !ok {
+			t.Errorf("%s: gRPC field %q has no JSON counterpart", path, key)
+		}
+	}
+}
+
+func TestGRPC_MetricsSnapshotCarriesEveryField(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	mc.RecordRequest()
+	mc.RecordResponse(true, 10*time.Millisecond)
+	mc.RecordBackendRequest("b1", true, 10*time.Millisecond)
+	mc.RecordHTTP10Request()
+	if err := lb.AddBackend(config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65004", Weight: 3}); err != nil {
+		t.Fatalf("add backend failed: %v", err)
+	}
+	cfg := newTestConfig("")
+	conn := startBufconnServer(t, lb, cfg, mc)
+
+	resp, err := adminv1.NewMetricsServiceClient(conn).GetSnapshot(context.Background(), &adminv1.GetSnapshotRequest{})
+	if err != nil {
+		t.Fatalf("get snapshot failed: %v", err)
+	}
+	if resp.Http10Requests != 1 {
+		t.Errorf("expected http10_requests 1, got %d", resp.Http10Requests)
+	}
+	if resp.Uptime == "" || resp.StartTimeUnixNano == 0 {
+		t.Errorf("expected uptime and start time, got %q/%d", resp.Uptime, resp.StartTimeUnixNano)
+	}
+	if resp.BufferBudget == nil {
+		t.Error("expected buffer_budget to be set")
+	}
+
+	backends, err := adminv1.NewBackendServiceClient(conn).ListBackends(context.Background(), &adminv1.ListBackendsRequest{})
+	if err != nil {
+		t.Fatalf("list backends failed: %v", err)
+	}
+	if len(backends.Backends) != 1 || backends.Backends[0].ConfiguredWeight != 3 {
+		t.Errorf("expected configured_weight 3, got %+v", backends.Backends)
+	}
+}
+
+func TestGRPC_AuthRejection(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	conn := startBufconnServer(t, lb, newTestConfig(testGRPCToken), mc)
+	client := adminv1.NewBackendServiceClient(conn)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"missing token", context.Background(), codes.Unauthenticated},
+		{"wrong token", withToken(context.Background(), "nope"), codes.Unauthenticated},
+		{"valid token", withToken(context.Background(), testGRPCToken), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ListBackends(tt.ctx, &adminv1.ListBackendsRequest{})
+			if status.Code(err) != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Event streams go through the stream interceptor
+	stream, err := adminv1.NewEventServiceClient(conn).Subscribe(context.Background(), &adminv1.SubscribeRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for stream, got %v", err)
+	}
+
+	// Health checks do not require the token
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v", resp.Status)
+	}
+}
+
+func TestGRPC_IPFilter(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig("")
+	cfg.AdminAPI.IPDenyList = []string{"0.0.0.0/0", "::/0"}
+	conn := startBufconnServer(t, lb, cfg, mc)
+
+	_, err := adminv1.NewBackendServiceClient(conn).ListBackends(context.Background(), &adminv1.ListBackendsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+}
+
+func TestGRPC_SubscribeEvents(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	conn := startBufconnServer(t, lb, newTestConfig(testGRPCToken), mc)
+
+	ctx, cancel := context.WithTimeout(withToken(context.Background(), testGRPCToken), 5*time.Second)
+	defer cancel()
+
+	stream, err := adminv1.NewEventServiceClient(conn).Subscribe(ctx, &adminv1.SubscribeRequest{})
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	// Headers are sent once the subscription is registered
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("failed to read stream headers: %v", err)
+	}
+
+	if err := lb.AddBackend(config.BackendConfig{Name: "evt", Address: "http://127.0.0.1:65003"}); err != nil {
+		t.Fatalf("add backend failed: %v", err)
+	}
+
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv failed: %v", err)
+	}
+	if ev.Type != loadbalancer.EventBackendAdded || ev.Backend != "evt" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if ev.TimeUnixNano == 0 {
+		t.Fatal("expected event timestamp to be set")
+	}
+}
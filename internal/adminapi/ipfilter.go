@@ -23,7 +23,7 @@ func NewIPFilter(allowList, denyList []string) (*IPFilter, error) {
 
 	// Parse allow list
 	for _, cidr := range allowList {
-		ipNet, err := parseCIDR(cidr)
+		ipNet, err := utils.ParseCIDR(cidr)
 		if err != nil {
 			return nil, err
 		}
@@ -32,7 +32,7 @@ func NewIPFilter(allowList, denyList []string) (*IPFilter, error) {
 
 	// Parse deny list
 	for _, cidr := range denyList {
-		ipNet, err := parseCIDR(cidr)
+		ipNet, err := utils.ParseCIDR(cidr)
 		if err != nil {
 			return nil, err
 		}
@@ -42,32 +42,6 @@ func NewIPFilter(allowList, denyList []string) (*IPFilter, error) {
 	return filter, nil
 }
 
-// parseCIDR parses a CIDR notation or single IP address
-func parseCIDR(cidr string) (*net.IPNet, error) {
-	// Check if it's already in CIDR notation
-	_, ipNet, err := net.ParseCIDR(cidr)
-	if err == nil {
-		return ipNet, nil
-	}
-
-	// Try parsing as a single IP address
-	ip := net.ParseIP(cidr)
-	if ip == nil {
-		return nil, err // Return original CIDR parse error
-	}
-
-	// Convert single IP to CIDR notation
-	if ip.To4() != nil {
-		// IPv4
-		_, ipNet, _ = net.ParseCIDR(cidr + "/32")
-	} else {
-		// IPv6
-		_, ipNet, _ = net.ParseCIDR(cidr + "/128")
-	}
-
-	return ipNet, nil
-}
-
 // IsAllowed checks if the given IP address is allowed
 func (f *IPFilter) IsAllowed(ip string) bool {
 	parsedIP := net.ParseIP(ip)
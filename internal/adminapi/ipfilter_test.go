@@ -215,55 +215,3 @@ func TestIPFilter_Middleware(t *testing.T) {
 		})
 	}
 }
-
-func TestParseCIDR(t *testing.T) {
-	tests := []struct {
-		name    string
-		input   string
-		wantErr bool
-	}{
-		{
-			name:    "valid CIDR",
-			input:   "192.168.1.0/24",
-			wantErr: false,
-		},
-		{
-			name:    "valid single IPv4",
-			input:   "192.168.1.1",
-			wantErr: false,
-		},
-		{
-			name:    "valid IPv6 CIDR",
-			input:   "2001:db8::/32",
-			wantErr: false,
-		},
-		{
-			name:    "valid single IPv6",
-			input:   "2001:db8::1",
-			wantErr: false,
-		},
-		{
-			name:    "invalid CIDR",
-			input:   "invalid",
-			wantErr: true,
-		},
-		{
-			name:    "invalid IP",
-			input:   "999.999.999.999",
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ipNet, err := parseCIDR(tt.input)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseCIDR() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !tt.wantErr && ipNet == nil {
-				t.Error("parseCIDR() returned nil without error")
-			}
-		})
-	}
-}
@@ -0,0 +1,268 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the OpenAPI version of the generated document
+const OpenAPIVersion = "3.0.3"
+
+// pathParamPattern matches {name} segments in a route path
+var pathParamPattern = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// OpenAPISpec returns the OpenAPI document for the Admin API, generated from
+// the route table. The output is deterministic so it can be committed and
+// checked for staleness.
+func OpenAPISpec() ([]byte, error) {
+	spec, err := json.MarshalIndent(buildOpenAPI((&api{}).routes()), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(spec, '\n'), nil
+}
+
+// buildOpenAPI assembles the OpenAPI document for a route table
+func buildOpenAPI(routes []route) map[string]interface{} {
+	g := &schemaGenerator{schemas: make(map[string]interface{}), names: make(map[string]reflect.Type)}
+
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		item, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			if params := pathParameters(rt.path); len(params) > 0 {
+				item["parameters"] = params
+			}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = g.operation(rt)
+	}
+
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "Helios Admin API",
+			"version":     "v1",
+			"description": "Runtime management of the Helios load balancer. Errors are returned as text/plain messages.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Required on protected endpoints when admin_api.auth_token is set",
+				},
+			},
+			"responses": map[string]interface{}{
+				"Error":            textResponse("Request failed; the body describes the error"),
+				"Unauthorized":     textResponse("Missing or invalid bearer token"),
+				"Forbidden":        textResponse("Client IP rejected by admin_api.ip_allow_list / ip_deny_list"),
+				"MethodNotAllowed": map[string]interface{}{"description": "Method not supported on this path"},
+			},
+		},
+	}
+}
+
+// operation builds the OpenAPI operation object for a route
+func (g *schemaGenerator) operation(rt route) map[string]interface{} {
+	responses := map[string]interface{}{
+		strconv.Itoa(http.StatusForbidden):        ref("responses", "Forbidden"),
+		strconv.Itoa(http.StatusMethodNotAllowed): ref("responses", "MethodNotAllowed"),
+	}
+	if rt.response != nil {
+		responses[strconv.Itoa(rt.status)] = map[string]interface{}{
+			"description": http.StatusText(rt.status),
+			"content":     jsonContent(g.schema(reflect.TypeOf(rt.response).Elem())),
+		}
+	} else {
+		responses[strconv.Itoa(rt.status)] = textResponse(http.StatusText(rt.status))
+	}
+	for _, status := range rt.errors {
+		responses[strconv.Itoa(status)] = ref("responses", "Error")
+	}
+
+	op := map[string]interface{}{
+		"operationId": rt.operationID,
+		"summary":     rt.summary,
+		"responses":   responses,
+	}
+	if rt.auth {
+		responses[strconv.Itoa(http.StatusUnauthorized)] = ref("responses", "Unauthorized")
+		op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	}
+	if rt.conditional {
+		responses[strconv.Itoa(http.StatusNotModified)] = map[string]interface{}{"description": "Unchanged since the ETag given in If-None-Match"}
+		op["parameters"] = []interface{}{
+			map[string]interface{}{
+				"name":        "fields",
+				"in":          "query",
+				"description": "Comma-separated dotted field paths to return, e.g. total_requests,backend_metrics.is_healthy",
+				"schema":      map[string]interface{}{"type": "string"},
+			},
+			map[string]interface{}{
+				"name":        "If-None-Match",
+				"in":          "header",
+				"description": "ETag of a previous response; 304 with no body if unchanged",
+				"schema":      map[string]interface{}{"type": "string"},
+			},
+		}
+	}
+	if rt.request != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(g.schema(reflect.TypeOf(rt.request).Elem())),
+		}
+	}
+	return op
+}
+
+// pathParameters declares the {name} segments of a path as path parameters
+func pathParameters(path string) []interface{} {
+	var params []interface{}
+	for _, m := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	return params
+}
+
+func ref(kind, name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/" + kind + "/" + name}
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func textResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
+// schemaGenerator derives OpenAPI schemas from Go types following
+// encoding/json rules. Named structs become components.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+	names   map[string]reflect.Type
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32", "minimum": 0}
+	case reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t)
+	}
+	// interface{} and anything else accept any JSON value
+	return map[string]interface{}{}
+}
+
+// component registers a named struct under components/schemas and returns a reference to it
+func (g *schemaGenerator) component(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	if existing, ok := g.names[name]; ok && existing != t {
+		// Same name in another package: qualify with the package name
+		name = t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:] + "." + name
+	}
+	if _, ok := g.names[name]; !ok {
+		g.names[name] = t
+		g.schemas[name] = map[string]interface{}{} // placeholder for recursive types
+		g.schemas[name] = g.structSchema(t)
+	}
+	return ref("schemas", name)
+}
+
+// structSchema describes a struct's exported fields; fields without omitempty are required
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	g.addFields(t, props, &required)
+
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened, as encoding/json does
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(ft, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package adminapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/0xReLogic/Helios/internal/cluster"
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+// committedSpecPath is the checked-in copy of the generated document
+const committedSpecPath = "../../api/admin/v1/openapi.json"
+
+func loadSpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatalf("failed to generate spec: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatalf("spec is not valid json: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPISpec_CommittedCopyUpToDate(t *testing.T) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatalf("failed to generate spec: %v", err)
+	}
+	committed, err := os.ReadFile(committedSpecPath)
+	if err != nil {
+		t.Fatalf("failed to read committed spec: %v", err)
+	}
+	if !bytes.Equal(spec, committed) {
+		t.Fatalf("%s is stale; regenerate it with:\n\tgo run ./cmd/helios -print-openapi > api/admin/v1/openapi.json", strings.TrimPrefix(committedSpecPath, "../../"))
+	}
+}
+
+func TestOpenAPISpec_Valid(t *testing.T) {
+	validateOpenAPIDocument(t, loadSpec(t))
+}
+
+// The mux is built from the same route table as the spec; guard that every
+// registered pattern is documented and every documented operation is served
+func TestOpenAPISpec_CoversRegisteredRoutes(t *testing.T) {
+	lb := newTestLB(t)
+	cfg := newTestConfig("secret")
+	elector, err := cluster.New(config.ClusterConfig{Enabled: true, NodeID: "helios-a", Peers: []string{"http://helios-b:9091"}, Token: "cluster-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux, patterns := newMux(lb, cfg, metrics.NewMetricsCollector(), WithCluster(elector))
+
+	doc := loadSpec(t)
+	paths := doc["paths"].(map[string]interface{})
+	var documented []string
+	for path := range paths {
+		documented = append(documented, path)
+	}
+	sort.Strings(documented)
+	if strings.Join(patterns, " ") != strings.Join(documented, " ") {
+		t.Fatalf("registered patterns %v do not match documented paths %v", patterns, documented)
+	}
+
+	for path, item := range paths {
+		for method, op := range item.(map[string]interface{}) {
+			if method == "parameters" {
+				continue
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(strings.ToUpper(method), path, strings.NewReader("{}")))
+			if rec.Code == http.StatusNotFound || rec.Code == http.StatusMethodNotAllowed {
+				t.Errorf("%s %s is documented but not served (status %d)", strings.ToUpper(method), path, rec.Code)
+			}
+			_, secured := op.(map[string]interface{})["security"]
+			if secured && rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s is documented as authenticated but returned %d without a token", strings.ToUpper(method), path, rec.Code)
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, path, nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("PATCH %s: expected 405, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestOpenAPISpec_ServedWithoutAuth(t *testing.T) {
+	mux := NewMux(newTestLB(t), newTestConfig("secret"), metrics.NewMetricsCollector())
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	spec, _ := OpenAPISpec()
+	if !bytes.Equal(rec.Body.Bytes(), spec) {
+		t.Fatal("served document differs from OpenAPISpec")
+	}
+}
+
+func TestBuildOpenAPI_PathParamsAndAuth(t *testing.T) {
+	doc := buildOpenAPI([]route{{
+		method: http.MethodPut, path: "/v1/backends/{name}/weight", operationID: "putWeight",
+		summary: "Set weight", auth: true,
+		request: (*SetWeightRequest)(nil), status: http.StatusOK,
+		errors: []int{http.StatusNotFound},
+	}})
+	validateOpenAPIDocument(t, doc)
+
+	item := doc["paths"].(map[string]interface{})["/v1/backends/{name}/weight"].(map[string]interface{})
+	params := item["parameters"].([]interface{})
+	if len(params) != 1 || params[0].(map[string]interface{})["name"] != "name" {
+		t.Fatalf("expected a single name path parameter, got %v", params)
+	}
+	op := item["put"].(map[string]interface{})
+	if _, ok := op["security"]; !ok {
+		t.Error("expected bearer security on an authenticated route")
+	}
+	responses := op["responses"].(map[string]interface{})
+	for _, status := range []string{"200", "401", "403", "404", "405"} {
+		if _, ok := responses[status]; !ok {
+			t.Errorf("expected a %s response", status)
+		}
+	}
+}
+
+// validateOpenAPIDocument validates doc against the OpenAPI 3.0
+// specification with kin-openapi, which resolves references and checks
+// schemas, parameters, responses and operation IDs
+func validateOpenAPIDocument(t *testing.T, doc map[string]interface{}) {
+	t.Helper()
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("document does not marshal: %v", err)
+	}
+
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(raw)
+	if err != nil {
+		t.Fatalf("document does not load as OpenAPI: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.0.") {
+		t.Errorf("openapi must be a 3.0.x version, got %q", spec.OpenAPI)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		t.Fatalf("document is not valid OpenAPI 3.0: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package adminapi
+
+import (
+	"net/http"
+
+	"github.com/0xReLogic/Helios/internal/cluster"
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/loadbalancer"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+// route describes one Admin API operation. newMux registers handlers from
+// the route table and OpenAPISpec is generated from it, so an endpoint cannot
+// be served without being documented.
+type route struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	auth        bool
+	request     interface{} // typed nil of the JSON request body, nil when there is none
+	response    interface{} // typed nil of the JSON response body, nil for a text/plain body
+	status      int         // success status
+	errors      []int       // text/plain error statuses the handler returns
+	conditional bool        // supports If-None-Match and ?fields= (utils.JSONResponse)
+	handler     http.HandlerFunc
+}
+
+// routes returns the Admin API route table
+func (a *api) routes() []route {
+	return []route{
+		{
+			method: http.MethodGet, path: "/v1/health", operationID: "getHealth",
+			summary:  "Liveness of the Admin API",
+			response: (*HealthResponse)(nil), status: http.StatusOK,
+			handler: a.health,
+		},
+		{
+			method: http.MethodGet, path: "/v1/openapi.json", operationID: "getOpenAPI",
+			summary:  "This OpenAPI document",
+			response: (*map[string]interface{})(nil), status: http.StatusOK,
+			errors:  []int{http.StatusInternalServerError},
+			handler: a.openAPI,
+		},
+		{
+			method: http.MethodGet, path: "/v1/metrics", operationID: "getMetrics",
+			summary: "Metrics snapshot", auth: true,
+			response: (*metrics.Metrics)(nil), status: http.StatusOK,
+			errors: []int{http.StatusBadRequest}, conditional: true,
+			handler: a.getMetrics,
+		},
+		{
+			method: http.MethodGet, path: "/v1/backends", operationID: "listBackends",
+			summary: "List backends", auth: true,
+			response: (*[]loadbalancer.BackendInfo)(nil), status: http.StatusOK,
+			errors: []int{http.StatusBadRequest}, conditional: true,
+			handler: a.listBackends,
+		},
+		{
+			method: http.MethodPost, path: "/v1/backends/add", operationID: "addBackend",
+			summary: "Add a backend", auth: true,
+			request: (*config.BackendConfig)(nil), status: http.StatusCreated,
+			errors:  []int{http.StatusBadRequest},
+			handler: a.addBackend,
+		},
+		{
+			method: http.MethodPost, path: "/v1/backends/remove", operationID: "removeBackend",
+			summary: "Remove a backend", auth: true,
+			request: (*RemoveBackendRequest)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest},
+			handler: a.removeBackend,
+		},
+		{
+			method: http.MethodDelete, path: "/v1/backends/remove", operationID: "deleteBackend",
+			summary: "Remove a backend", auth: true,
+			request: (*RemoveBackendRequest)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest},
+			handler: a.removeBackend,
+		},
+		{
+			method: http.MethodPost, path: "/v1/backends/weight", operationID: "setBackendWeight",
+			summary: "Change a backend's weight", auth: true,
+			request: (*SetWeightRequest)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handler: a.setWeight,
+		},
+		{
+			method: http.MethodGet, path: "/v1/strategy", operationID: "getStrategy",
+			summary: "Show the active load balancing strategy", auth: true,
+			response: (*StrategyResponse)(nil), status: http.StatusOK,
+			handler: a.getStrategy,
+		},
+		{
+			method: http.MethodPost, path: "/v1/strategy", operationID: "setStrategy",
+			summary: "Switch the load balancing strategy", auth: true,
+			request: (*SetStrategyRequest)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest},
+			handler: a.setStrategy,
+		},
+		{
+			method: http.MethodGet, path: "/v1/strategy/shadow", operationID: "getShadowReport",
+			summary: "Shadow strategy divergence report", auth: true,
+			response: (*loadbalancer.ShadowReport)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusNotFound},
+			handler: a.shadowReport,
+		},
+		{
+			method: http.MethodGet, path: "/v1/plugins", operationID: "getPlugins",
+			summary: "Effective plugin chain order", auth: true,
+			response: (*PluginsResponse)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusInternalServerError},
+			handler: a.plugins,
+		},
+		{
+			method: http.MethodGet, path: "/v1/costs", operationID: "getCosts",
+			summary: "Usage by cost attribution label", auth: true,
+			response: (*map[string]metrics.CostMetrics)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusNotFound},
+			handler: a.costs,
+		},
+		{
+			method: http.MethodGet, path: "/v1/shutdown/status", operationID: "getShutdownStatus",
+			summary: "Progress of a graceful shutdown", auth: true,
+			response: (*loadbalancer.ShutdownStatus)(nil), status: http.StatusOK,
+			handler: a.shutdownStatus,
+		},
+		{
+			method: http.MethodPost, path: "/v1/shutdown/force", operationID: "forceShutdown",
+			summary: "Skip the remaining graceful shutdown wait", auth: true,
+			status:  http.StatusOK,
+			errors:  []int{http.StatusConflict},
+			handler: a.forceShutdown,
+		},
+		{
+			method: http.MethodGet, path: "/v1/cluster/status", operationID: "getClusterStatus",
+			summary: "This instance's role in the cluster and its view of the peers", auth: true,
+			response: (*cluster.Status)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusNotFound},
+			handler: a.clusterStatus,
+		},
+		{
+			method: http.MethodPost, path: cluster.LeasePath, operationID: "takeClusterLease",
+			summary: "Take or renew the cluster leader lease; called by peers with cluster.token as bearer token",
+			request: (*cluster.LeaseRequest)(nil), response: (*cluster.LeaseResponse)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+			handler: a.clusterLease,
+		},
+		{
+			method: http.MethodPost, path: "/v1/debug/capture", operationID: "captureDebugBundle",
+			summary: "Write heap, goroutine and CPU profiles with metrics and config to debug.capture_dir", auth: true,
+			request: (*DebugCaptureRequest)(nil), response: (*DebugCaptureResponse)(nil), status: http.StatusOK,
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+			handler: a.debugCapture,
+		},
+	}
+}
@@ -1,146 +1,326 @@
 package adminapi
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/0xReLogic/Helios/internal/cluster"
 	"github.com/0xReLogic/Helios/internal/config"
 	"github.com/0xReLogic/Helios/internal/loadbalancer"
 	"github.com/0xReLogic/Helios/internal/logging"
 	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/plugins"
+	"github.com/0xReLogic/Helios/internal/utils"
 )
 
-// NewMux creates an HTTP handler for the Admin API
-func NewMux(lb *loadbalancer.LoadBalancer, cfg *config.Config, mc *metrics.MetricsCollector) http.Handler {
-	mux := http.NewServeMux()
+// api holds the dependencies of the Admin API handlers
+type api struct {
+	lb  *loadbalancer.LoadBalancer
+	cfg *config.Config
+	mc  *metrics.MetricsCollector
 
-	// Auth middleware
-	auth := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if cfg.AdminAPI.AuthToken == "" {
-				next.ServeHTTP(w, r)
-				return
-			}
-			authz := r.Header.Get("Authorization")
-			if !strings.HasPrefix(authz, "Bearer ") || strings.TrimPrefix(authz, "Bearer ") != cfg.AdminAPI.AuthToken {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte("unauthorized"))
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
+	cluster   *cluster.Elector // nil unless cluster is enabled
+	captureMu sync.Mutex       // held while a debug capture is being written
+}
+
+// MuxOption configures an optional part of the Admin API
+type MuxOption func(*api)
+
+// WithCluster serves the cluster coordination endpoints of an elector
+func WithCluster(e *cluster.Elector) MuxOption {
+	return func(a *api) {
+		a.cluster = e
 	}
+}
 
-	// Health endpoint (no auth)
-	mux.HandleFunc("/v1/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ok"}`))
-	})
+// NewMux creates an HTTP handler for the Admin API
+func NewMux(lb *loadbalancer.LoadBalancer, cfg *config.Config, mc *metrics.MetricsCollector, opts ...MuxOption) http.Handler {
+	mux, _ := newMux(lb, cfg, mc, opts...)
 
-	// Metrics endpoint (auth if token set)
-	mux.Handle("/v1/metrics", auth(http.HandlerFunc(mc.MetricsHandler())))
+	logging.L().Info().Msg("admin api mux initialized")
 
-	// List backends
-	mux.Handle("/v1/backends", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		backends := lb.ListBackends()
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(backends)
-	})))
-
-	// Add backend
-	mux.Handle("/v1/backends/add", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		var req config.BackendConfig
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
-			return
+	// Apply IP filter if configured
+	if len(cfg.AdminAPI.IPAllowList) > 0 || len(cfg.AdminAPI.IPDenyList) > 0 {
+		ipFilter, err := NewIPFilter(cfg.AdminAPI.IPAllowList, cfg.AdminAPI.IPDenyList)
+		if err != nil {
+			logging.L().Error().Err(err).Msg("failed to create IP filter")
+			return mux
 		}
-		if req.Name == "" || req.Address == "" {
-			http.Error(w, "name and address are required", http.StatusBadRequest)
-			return
+		logging.L().Info().
+			Int("allow_list_size", len(cfg.AdminAPI.IPAllowList)).
+			Int("deny_list_size", len(cfg.AdminAPI.IPDenyList)).
+			Msg("admin api IP filter enabled")
+		return ipFilter.Middleware(mux)
+	}
+
+	return mux
+}
+
+// newMux registers every route of the route table and returns the mux along
+// with the registered patterns
+func newMux(lb *loadbalancer.LoadBalancer, cfg *config.Config, mc *metrics.MetricsCollector, opts ...MuxOption) (*http.ServeMux, []string) {
+	a := &api{lb: lb, cfg: cfg, mc: mc}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	// Group operations by path; each path dispatches on the method
+	byPath := make(map[string]map[string]http.Handler)
+	for _, rt := range a.routes() {
+		h := http.Handler(rt.handler)
+		if rt.auth {
+			h = a.requireAuth(h)
 		}
-		if err := lb.AddBackend(req); err != nil {
-			http.Error(w, fmt.Sprintf("failed to add backend: %v", err), http.StatusBadRequest)
-			return
+		if byPath[rt.path] == nil {
+			byPath[rt.path] = make(map[string]http.Handler)
 		}
-		w.WriteHeader(http.StatusCreated)
-		_, _ = w.Write([]byte("added"))
-	})))
+		byPath[rt.path][rt.method] = h
+	}
 
-	// Remove backend
-	mux.Handle("/v1/backends/remove", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+	mux := http.NewServeMux()
+	patterns := make([]string, 0, len(byPath))
+	for path, methods := range byPath {
+		mux.Handle(path, methodHandler(methods))
+		patterns = append(patterns, path)
+	}
+	sort.Strings(patterns)
+	return mux, patterns
+}
+
+// methodHandler dispatches to the handler registered for the request method
+func methodHandler(methods map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := methods[r.Method]
+		if !ok {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		type rmReq struct {
-			Name string `json:"name"`
-		}
-		var req rmReq
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
-			return
-		}
-		if req.Name == "" {
-			http.Error(w, "name is required", http.StatusBadRequest)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requireAuth enforces the bearer token when admin_api.auth_token is set
+func (a *api) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.cfg.AdminAPI.AuthToken == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
-		lb.RemoveBackend(req.Name)
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("removed"))
-	})))
-
-	// Change strategy
-	mux.Handle("/v1/strategy", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		if !validBearerToken(r.Header.Get("Authorization"), a.cfg.AdminAPI.AuthToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("unauthorized"))
 			return
 		}
-		type setReq struct {
-			Strategy string `json:"strategy"`
-		}
-		var req setReq
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
-			return
+		next.ServeHTTP(w, r)
+	})
+}
+
+// health reports that the Admin API is up
+func (a *api) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, HealthResponse{Status: "ok"})
+}
+
+// getMetrics returns the metrics snapshot
+func (a *api) getMetrics(w http.ResponseWriter, r *http.Request) {
+	a.mc.MetricsHandler()(w, r)
+}
+
+// openAPI serves the generated OpenAPI document
+func (a *api) openAPI(w http.ResponseWriter, r *http.Request) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to generate spec: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(spec)
+}
+
+// listBackends returns a snapshot of the backend pool
+func (a *api) listBackends(w http.ResponseWriter, r *http.Request) {
+	utils.JSONResponse{}.Serve(w, r, a.lb.ListBackends())
+}
+
+// addBackend adds a backend to the pool
+func (a *api) addBackend(w http.ResponseWriter, r *http.Request) {
+	var req config.BackendConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateBackend(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.lb.AddBackend(req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to add backend: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte("added"))
+}
+
+// removeBackend removes a backend from the pool
+func (a *api) removeBackend(w http.ResponseWriter, r *http.Request) {
+	var req RemoveBackendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	a.lb.RemoveBackend(req.Name)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("removed"))
+}
+
+// setWeight changes a backend's weight
+func (a *api) setWeight(w http.ResponseWriter, r *http.Request) {
+	var req SetWeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := a.lb.SetBackendWeight(req.Name, req.Weight); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, loadbalancer.ErrBackendNotFound) {
+			status = http.StatusNotFound
 		}
-		if req.Strategy == "" {
-			http.Error(w, "strategy is required", http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("failed to set weight: %v", err), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("updated"))
+}
+
+// getStrategy returns the active strategy and its options
+func (a *api) getStrategy(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, StrategyResponse{Strategy: a.lb.GetStrategy(), Config: a.lb.GetStrategyOptions()})
+}
+
+// setStrategy switches the load balancing strategy
+func (a *api) setStrategy(w http.ResponseWriter, r *http.Request) {
+	var req SetStrategyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Strategy == "" {
+		http.Error(w, "strategy is required", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if req.Config != nil {
+		err = a.lb.SetStrategyWithOptions(req.Strategy, req.Config)
+	} else {
+		err = a.lb.SetStrategy(req.Strategy)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to set strategy: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("updated"))
+}
+
+// shadowReport returns the shadow strategy divergence report
+func (a *api) shadowReport(w http.ResponseWriter, r *http.Request) {
+	report, ok := a.lb.ShadowReport()
+	if !ok {
+		http.Error(w, "shadow strategy is not configured", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// costs reports the usage attributed to each cost_attribution label
+func (a *api) costs(w http.ResponseWriter, r *http.Request) {
+	report, ok := a.lb.CostReport()
+	if !ok {
+		http.Error(w, "cost attribution is not configured", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// clusterStatus reports this instance's role in the cluster
+func (a *api) clusterStatus(w http.ResponseWriter, r *http.Request) {
+	if a.cluster == nil {
+		http.Error(w, "cluster is not configured", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, a.cluster.Status())
+}
+
+// clusterLease answers a peer's request for the leader lease. Peers
+// authenticate with cluster.token rather than the admin token.
+func (a *api) clusterLease(w http.ResponseWriter, r *http.Request) {
+	if a.cluster == nil {
+		http.Error(w, "cluster is not configured", http.StatusNotFound)
+		return
+	}
+	a.cluster.LeaseHandler()(w, r)
+}
+
+// plugins reports the plugin chain in the order it runs
+func (a *api) plugins(w http.ResponseWriter, r *http.Request) {
+	resp := PluginsResponse{Enabled: a.cfg.Plugins.Enabled, Order: []string{}, Configured: []string{}, Available: plugins.List()}
+	sort.Strings(resp.Available)
+	if a.cfg.Plugins.Enabled {
+		order, err := plugins.ResolveOrder(a.cfg.Plugins)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if err := lb.SetStrategy(req.Strategy); err != nil {
-			http.Error(w, fmt.Sprintf("failed to set strategy: %v", err), http.StatusBadRequest)
-			return
+		resp.Order = order.Names()
+		resp.Reordered = order.Reordered
+		resp.Violations = order.Violations
+		for _, p := range a.cfg.Plugins.Chain {
+			resp.Configured = append(resp.Configured, p.Name)
 		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("updated"))
-	})))
+	}
+	writeJSON(w, resp)
+}
 
-	logging.L().Info().Msg("admin api mux initialized")
+// shutdownStatus reports the progress of a graceful shutdown
+func (a *api) shutdownStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.lb.ShutdownStatus())
+}
 
-	// Apply IP filter if configured
-	if len(cfg.AdminAPI.IPAllowList) > 0 || len(cfg.AdminAPI.IPDenyList) > 0 {
-		ipFilter, err := NewIPFilter(cfg.AdminAPI.IPAllowList, cfg.AdminAPI.IPDenyList)
-		if err != nil {
-			logging.L().Error().Err(err).Msg("failed to create IP filter")
-			return mux
-		}
-		logging.L().Info().
-			Int("allow_list_size", len(cfg.AdminAPI.IPAllowList)).
-			Int("deny_list_size", len(cfg.AdminAPI.IPDenyList)).
-			Msg("admin api IP filter enabled")
-		return ipFilter.Middleware(mux)
+// forceShutdown cuts the remaining in-flight requests and tunnels of a graceful shutdown
+func (a *api) forceShutdown(w http.ResponseWriter, r *http.Request) {
+	if !a.lb.ForceShutdown() {
+		http.Error(w, "shutdown has not started", http.StatusConflict)
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("forced"))
+}
 
-	return mux
+// writeJSON writes v as a JSON response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// validBearerToken reports whether an Authorization header value carries the
+// expected bearer token. The comparison is constant-time.
+func validBearerToken(authz, token string) bool {
+	if !strings.HasPrefix(authz, "Bearer ") {
+		return false
+	}
+	given := strings.TrimPrefix(authz, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
 }
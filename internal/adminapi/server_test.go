@@ -3,10 +3,16 @@ package adminapi
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/0xReLogic/Helios/internal/cluster"
 	"github.com/0xReLogic/Helios/internal/config"
 	"github.com/0xReLogic/Helios/internal/loadbalancer"
 	"github.com/0xReLogic/Helios/internal/metrics"
@@ -161,6 +167,167 @@ func TestAdminAPI_Backends_Add_List_Remove_WithAuth(t *testing.T) {
 	}
 }
 
+func TestAdminAPI_Backends_Add_RejectsInvalidSettings(t *testing.T) {
+	lb := newTestLB(t)
+	mux := NewMux(lb, newTestConfig("secret"), metrics.NewMetricsCollector())
+
+	tests := []struct {
+		name    string
+		backend config.BackendConfig
+		errMsg  string
+	}{
+		{"buffer too large", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", BufferSizeKB: 10000000}, "buffer_size_kb must be between 4 and 1024"},
+		{"buffer too small", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", BufferSizeKB: 1}, "buffer_size_kb must be between 4 and 1024"},
+		{"flush interval below -1", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", FlushIntervalMs: -2}, "flush_interval_ms must be -1 or greater"},
+		{"negative header limit", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", MaxResponseHeaderBytes: -1}, "max_response_header_bytes must be non-negative"},
+		{"negative body timeout", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", BackendBodyTimeoutSeconds: -1}, "backend_body_timeout_seconds must be non-negative"},
+		{"negative weight", config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65530", Weight: -1}, "weight must be non-negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, _ := json.Marshal(tt.backend)
+			req := httptest.NewRequest(http.MethodPost, "/v1/backends/add", bytes.NewReader(buf))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, rec.Body.String())
+			}
+		})
+	}
+	if got := lb.ListBackends(); len(got) != 0 {
+		t.Fatalf("expected no backends to be added, got %+v", got)
+	}
+}
+
+// testOptionsStrategy is registered for tests that need a strategy with options
+const testOptionsStrategy = "test_sticky"
+
+func init() {
+	loadbalancer.RegisterStrategy(testOptionsStrategy, func(options map[string]interface{}, _ loadbalancer.StrategyEnv) (loadbalancer.Strategy, error) {
+		for key := range options {
+			if key != "cookie_name" {
+				return nil, fmt.Errorf("unknown option(s): %s", key)
+			}
+		}
+		return loadbalancer.NewRoundRobinStrategy(), nil
+	})
+}
+
+func TestAdminAPI_Strategy_SetWithOptions(t *testing.T) {
+	lb := newTestLB(t)
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig("")
+	mux := NewMux(lb, cfg, mc)
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/strategy", bytes.NewReader([]byte(body))))
+		return rec.Code
+	}
+
+	if code := post(`{"strategy":"test_sticky","config":{"cookie_name":"srv"}}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := post(`{"strategy":"ip_hash","config":{"cookie_name":"srv"}}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for option unknown to ip_hash, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy", nil))
+	var got StrategyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	want := StrategyResponse{Strategy: testOptionsStrategy, Config: map[string]interface{}{"cookie_name": "srv"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestAdminAPI_StrategyShadow(t *testing.T) {
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig("")
+
+	// Not configured
+	rec := httptest.NewRecorder()
+	NewMux(newTestLB(t), cfg, mc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy/shadow", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without shadow strategy, got %d", rec.Code)
+	}
+
+	lb, err := loadbalancer.NewLoadBalancer(&config.Config{
+		LoadBalancer: config.LoadBalancerConfig{
+			Strategy:       "round_robin",
+			ShadowStrategy: config.ShadowStrategyConfig{Strategy: "least_connections", SamplePercent: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+
+	rec = httptest.NewRecorder()
+	NewMux(lb, cfg, mc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/strategy/shadow", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var got loadbalancer.ShadowReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if got.Strategy != "round_robin" || got.ShadowStrategy != "least_connections" || got.SamplePercent != 10 {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+}
+
+func TestAdminAPI_Shutdown(t *testing.T) {
+	lb := newTestLB(t)
+	mux := NewMux(lb, newTestConfig(""), metrics.NewMetricsCollector())
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/shutdown/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var status loadbalancer.ShutdownStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if status.Phase != loadbalancer.PhaseRunning {
+		t.Fatalf("expected phase running, got %q", status.Phase)
+	}
+
+	// Nothing to force before shutdown starts
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/shutdown/force", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before shutdown, got %d", rec.Code)
+	}
+
+	server := httptest.NewServer(lb)
+	defer server.Close()
+	lb.GracefulShutdown(server.Config, time.Second)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/shutdown/status", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if status.Phase != loadbalancer.PhaseDone || status.TimeoutMs != 1000 {
+		t.Fatalf("unexpected status after shutdown: %+v", status)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/shutdown/force", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 during shutdown, got %d", rec.Code)
+	}
+}
+
 func TestAdminAPI_Strategy_Set_WithAuth(t *testing.T) {
 	lb := newTestLB(t)
 	mc := metrics.NewMetricsCollector()
@@ -187,3 +354,226 @@ func TestAdminAPI_Strategy_Set_WithAuth(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec2.Code)
 	}
 }
+
+func TestAdminAPI_BackendsConditionalGET(t *testing.T) {
+	lb := newTestLB(t)
+	if err := lb.AddBackend(config.BackendConfig{Name: "app", Address: "http://127.0.0.1:9", Weight: 1}); err != nil {
+		t.Fatalf("failed to add backend: %v", err)
+	}
+	mux := NewMux(lb, newTestConfig(""), metrics.NewMetricsCollector())
+	get := func(target, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/v1/backends", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+	if rec := get("/v1/backends", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("expected 304 with no body, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := lb.SetBackendWeight("app", 5); err != nil {
+		t.Fatalf("failed to set weight: %v", err)
+	}
+	if rec := get("/v1/backends", etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("expected 200 with a new ETag after a change, got %d", rec.Code)
+	}
+
+	rec := get("/v1/backends?fields=name,weight", "")
+	var got []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got) != 1 || len(got[0]) != 2 || got[0]["name"] != "app" || got[0]["weight"] != float64(5) {
+		t.Errorf("expected only name and weight, got %v", got)
+	}
+	if rec := get("/v1/backends?fields=nmae", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown field, got %d", rec.Code)
+	}
+}
+
+func TestAdminAPI_Plugins(t *testing.T) {
+	cfg := newTestConfig("")
+	get := func() PluginsResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		NewMux(newTestLB(t), cfg, metrics.NewMetricsCollector()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/plugins", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp PluginsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return resp
+	}
+
+	resp := get()
+	if resp.Enabled || len(resp.Order) != 0 || len(resp.Available) == 0 {
+		t.Errorf("expected a disabled chain listing available plugins, got %+v", resp)
+	}
+
+	gzip := map[string]interface{}{"level": 6, "min_size": 1024, "content_types": []interface{}{"text/html"}}
+	cfg.Plugins = config.PluginsConfig{Enabled: true, AutoOrder: true, Chain: []config.PluginConfig{
+		{Name: "size_limit"}, {Name: "headers"}, {Name: "gzip", Config: gzip},
+	}}
+	resp = get()
+	if want := []string{"size_limit", "headers", "gzip"}; !reflect.DeepEqual(resp.Configured, want) {
+		t.Errorf("expected configured order %v, got %v", want, resp.Configured)
+	}
+	if want := []string{"headers", "gzip", "size_limit"}; !reflect.DeepEqual(resp.Order, want) {
+		t.Errorf("expected effective order %v, got %v", want, resp.Order)
+	}
+	if !resp.Reordered || len(resp.Violations) != 1 {
+		t.Errorf("expected the reorder and its violation to be reported, got %+v", resp)
+	}
+}
+
+func TestAdminAPI_Costs(t *testing.T) {
+	mc := metrics.NewMetricsCollector()
+	cfg := newTestConfig("")
+
+	// Not configured
+	rec := httptest.NewRecorder()
+	NewMux(newTestLB(t), cfg, mc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/costs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without cost attribution, got %d", rec.Code)
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	lb, err := loadbalancer.NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "app", Address: backend.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		CostAttribution: config.CostAttributionConfig{Rules: []config.CostRule{
+			{Label: "payments", PathPrefixes: []string{"/payments"}},
+			{Label: "search", Headers: map[string]string{"x-team": "search"}},
+			{Label: "catalog", PathPrefixes: []string{"/catalog", "/products"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+
+	send := func(method, path, team, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if team != "" {
+			req.Header.Set("X-Team", team)
+		}
+		lb.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for i := 0; i < 3; i++ {
+		send(http.MethodPost, "/payments/charge", "", "abcd")
+	}
+	send(http.MethodGet, "/payments/fail", "", "")
+	send(http.MethodGet, "/payments/refunds", "search", "") // first match wins
+	send(http.MethodGet, "/query", "search", "")
+	send(http.MethodGet, "/query", "search", "")
+	send(http.MethodGet, "/products/42", "", "")
+	send(http.MethodGet, "/status", "", "")
+	send(http.MethodGet, "/status", "ads", "")
+
+	rec = httptest.NewRecorder()
+	NewMux(lb, cfg, mc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/costs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var got map[string]metrics.CostMetrics
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+
+	type totals struct{ requests, bytesIn, bytesOut, errors uint64 }
+	want := map[string]totals{
+		"payments":                   {requests: 5, bytesIn: 12, bytesOut: 4*2 + 5, errors: 1}, // "ok" x4 and "boom\n"
+		"search":                     {requests: 2, bytesOut: 4},
+		"catalog":                    {requests: 1, bytesOut: 2},
+		config.UnattributedCostLabel: {requests: 2, bytesOut: 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected labels %v, got %v", want, got)
+	}
+	for label, w := range want {
+		c := got[label]
+		if (totals{c.Requests, c.BytesIn, c.BytesOut, c.Errors}) != w {
+			t.Errorf("%s: expected %+v, got %+v", label, w, c)
+		}
+		if c.BackendLatencyMs <= 0 {
+			t.Errorf("%s: expected backend latency to be accumulated", label)
+		}
+	}
+	if m := lb.GetMetricsCollector().GetMetrics(); m.CostMetrics["payments"].Requests != 5 {
+		t.Errorf("expected cost_metrics in the metrics snapshot, got %+v", m.CostMetrics)
+	}
+}
+
+func TestAdminAPI_Cluster(t *testing.T) {
+	cfg := newTestConfig("admin-token")
+	mc := metrics.NewMetricsCollector()
+	leaseBody := `{"node_id":"helios-a","lease_ms":5000}`
+
+	serve := func(mux http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without a cluster both endpoints are absent
+	plain := NewMux(newTestLB(t), cfg, mc)
+	if rec := serve(plain, http.MethodGet, "/v1/cluster/status", "admin-token", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for status without a cluster, got %d", rec.Code)
+	}
+	if rec := serve(plain, http.MethodPost, cluster.LeasePath, "cluster-token", leaseBody); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for lease without a cluster, got %d", rec.Code)
+	}
+
+	elector, err := cluster.New(config.ClusterConfig{Enabled: true, NodeID: "helios-b", Peers: []string{"http://helios-a:9091"}, Token: "cluster-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux(newTestLB(t), cfg, mc, WithCluster(elector))
+
+	// Leases take the cluster token, status the admin token
+	if rec := serve(mux, http.MethodPost, cluster.LeasePath, "admin-token", leaseBody); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a lease with the admin token, got %d", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/v1/cluster/status", "cluster-token", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for status with the cluster token, got %d", rec.Code)
+	}
+
+	rec := serve(mux, http.MethodPost, cluster.LeasePath, "cluster-token", leaseBody)
+	var lease cluster.LeaseResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &lease) != nil || !lease.Granted {
+		t.Fatalf("expected the lease to be granted, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(mux, http.MethodGet, "/v1/cluster/status", "admin-token", "")
+	var status cluster.Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if status.NodeID != "helios-b" || status.Role != cluster.RoleFollower || status.Leader != "helios-a" || status.LeaseRemainingMs <= 0 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if len(status.Peers) != 1 || status.Peers[0].URL != "http://helios-a:9091" {
+		t.Errorf("unexpected peers %+v", status.Peers)
+	}
+}
@@ -0,0 +1,63 @@
+package adminapi
+
+// Request and response bodies of the Admin API. The OpenAPI document is
+// generated from these types, so their json tags are the wire format: fields
+// without omitempty are listed as required.
+
+// HealthResponse is returned by GET /v1/health
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// RemoveBackendRequest is the body of POST /v1/backends/remove
+type RemoveBackendRequest struct {
+	Name string `json:"name"`
+}
+
+// SetWeightRequest is the body of POST /v1/backends/weight
+type SetWeightRequest struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// StrategyResponse is returned by GET /v1/strategy
+type StrategyResponse struct {
+	Strategy string                 `json:"strategy"`
+	Config   map[string]interface{} `json:"config,omitempty"`
+}
+
+// SetStrategyRequest is the body of POST /v1/strategy. When Config is
+// omitted the options configured under load_balancer.strategy_config are kept.
+type SetStrategyRequest struct {
+	Strategy string                 `json:"strategy"`
+	Config   map[string]interface{} `json:"config,omitempty"`
+}
+
+// PluginsResponse is returned by GET /v1/plugins
+type PluginsResponse struct {
+	Enabled    bool     `json:"enabled"`
+	Order      []string `json:"order"`                // Effective chain order, outermost first
+	Configured []string `json:"configured"`           // Chain order as configured
+	Reordered  bool     `json:"reordered"`            // plugins.auto_order changed the order
+	Violations []string `json:"violations,omitempty"` // Ordering constraints the configured order breaks
+	Available  []string `json:"available"`            // Built-in plugins, sorted
+}
+
+// DebugCaptureRequest is the optional body of POST /v1/debug/capture
+type DebugCaptureRequest struct {
+	CPUSeconds float64 `json:"cpu_seconds,omitempty"` // CPU profile duration (default 5, at most 10)
+}
+
+// DebugCaptureResponse is returned by POST /v1/debug/capture
+type DebugCaptureResponse struct {
+	Path  string             `json:"path"`
+	Files []DebugCaptureFile `json:"files"`
+}
+
+// DebugCaptureFile describes one file of a capture bundle. Error is set when
+// the file could not be fully written; the rest of the bundle is still kept.
+type DebugCaptureFile struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
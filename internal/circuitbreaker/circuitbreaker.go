@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/0xReLogic/Helios/internal/logging"
 )
 
 // State represents the circuit breaker state
@@ -29,6 +31,27 @@ func (s State) String() string {
 	}
 }
 
+// Counts is a snapshot of the circuit breaker counters
+type Counts struct {
+	Failures  uint32
+	Successes uint32
+	Requests  uint32
+}
+
+// StateChangeFunc is called after a state transition with the counters as they
+// were when the transition happened. It runs outside the breaker's lock, so it
+// may call back into the breaker. Callbacks run one at a time, in the order
+// the transitions happened.
+type StateChangeFunc func(name string, from State, to State, counts Counts)
+
+// stateChange is a transition recorded under the lock and reported after it is released
+type stateChange struct {
+	from    State
+	to      State
+	counts  Counts
+	stamped bool // counts filled in when the critical section that recorded it ended
+}
+
 // CircuitBreaker implements the circuit breaker pattern with optimized locking
 type CircuitBreaker struct {
 	name             string
@@ -37,7 +60,7 @@ type CircuitBreaker struct {
 	timeout          time.Duration // Time to wait before moving from open to half-open
 	failureThreshold uint32        // Number of failures to open the circuit
 	successThreshold uint32        // Number of successes to close the circuit in half-open state
-	onStateChange    func(name string, from State, to State)
+	onStateChange    StateChangeFunc
 
 	// Use RWMutex for better read concurrency (most requests just read state)
 	mutex           sync.RWMutex
@@ -48,6 +71,8 @@ type CircuitBreaker struct {
 	lastFailureTime time.Time
 	lastSuccessTime time.Time
 	nextAttempt     time.Time
+	pending         []stateChange // transitions not yet reported to onStateChange, oldest first
+	delivering      bool          // a goroutine is draining pending
 }
 
 var (
@@ -65,7 +90,7 @@ type Settings struct {
 	Timeout          time.Duration
 	FailureThreshold uint32
 	SuccessThreshold uint32
-	OnStateChange    func(name string, from State, to State)
+	OnStateChange    StateChangeFunc
 }
 
 // NewCircuitBreaker creates a new circuit breaker with the given settings
@@ -171,7 +196,7 @@ func (cb *CircuitBreaker) beforeRequest() error {
 				cb.requestCount = 0
 				cb.successCount = 0
 			}
-			cb.mutex.Unlock()
+			cb.unlockAndNotify()
 			return nil
 		}
 		return ErrCircuitBreakerOpen
@@ -195,7 +220,7 @@ func (cb *CircuitBreaker) beforeRequest() error {
 // afterRequest updates the circuit breaker state after a request
 func (cb *CircuitBreaker) afterRequest(success bool) {
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	defer cb.unlockAndNotify()
 
 	now := time.Now()
 
@@ -228,7 +253,8 @@ func (cb *CircuitBreaker) afterRequest(success bool) {
 	}
 }
 
-// setState changes the circuit breaker state and calls the callback
+// setState changes the circuit breaker state and records the transition for
+// unlockAndNotify. Must be called with the write lock held.
 func (cb *CircuitBreaker) setState(state State) {
 	if cb.state == state {
 		return
@@ -238,8 +264,44 @@ func (cb *CircuitBreaker) setState(state State) {
 	cb.state = state
 
 	if cb.onStateChange != nil {
-		cb.onStateChange(cb.name, prev, state)
+		cb.pending = append(cb.pending, stateChange{from: prev, to: state})
+	}
+}
+
+// unlockAndNotify releases the write lock and then reports the transitions
+// recorded while it was held, so a slow or re-entrant callback never runs
+// inside the critical section. Only one goroutine delivers at a time and it
+// drains the queue oldest first, so observers never see a stale transition
+// after a newer one; others, including re-entrant callers, just enqueue.
+func (cb *CircuitBreaker) unlockAndNotify() {
+	counts := Counts{Failures: cb.failureCount, Successes: cb.successCount, Requests: cb.requestCount}
+	for i := range cb.pending {
+		if !cb.pending[i].stamped {
+			cb.pending[i].counts, cb.pending[i].stamped = counts, true
+		}
 	}
+	if cb.delivering || len(cb.pending) == 0 {
+		cb.mutex.Unlock()
+		return
+	}
+
+	cb.delivering = true
+	for len(cb.pending) > 0 {
+		changes := cb.pending
+		cb.pending = nil
+		cb.mutex.Unlock()
+
+		for _, c := range changes {
+			c := c
+			logging.SafeCall("circuit_breaker.on_state_change", func() {
+				cb.onStateChange(cb.name, c.from, c.to, c.counts)
+			})
+		}
+
+		cb.mutex.Lock()
+	}
+	cb.delivering = false
+	cb.mutex.Unlock()
 }
 
 // State returns the current state of the circuit breaker
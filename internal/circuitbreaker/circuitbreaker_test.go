@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 )
@@ -138,3 +139,124 @@ func TestCircuitBreakerMaxRequests(t *testing.T) {
 		t.Errorf("Expected ErrTooManyRequests, got %v", err)
 	}
 }
+
+func TestCircuitBreakerCallbackCanReadCounts(t *testing.T) {
+	var cb *CircuitBreaker
+	var got Counts
+	transitions := make(chan State, 4)
+	cb = NewCircuitBreaker(Settings{
+		Name:             "test",
+		FailureThreshold: 2,
+		Timeout:          50 * time.Millisecond,
+		OnStateChange: func(name string, from, to State, counts Counts) {
+			// Re-entering the breaker used to deadlock on its own mutex
+			cb.Counts()
+			_ = cb.State()
+			got = counts
+			transitions <- to
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2; i++ {
+			_ = cb.Execute(func() error { return errors.New("failure") })
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("state change callback deadlocked the circuit breaker")
+	}
+	if to := <-transitions; to != StateOpen {
+		t.Fatalf("Expected transition to OPEN, got %s", to)
+	}
+	if got.Failures != 2 {
+		t.Errorf("Expected callback to receive 2 failures, got %d", got.Failures)
+	}
+}
+
+func TestCircuitBreakerCallbackPanicIsContained(t *testing.T) {
+	cb := NewCircuitBreaker(Settings{
+		Name:             "test",
+		FailureThreshold: 1,
+		Timeout:          50 * time.Millisecond,
+		OnStateChange: func(name string, from, to State, counts Counts) {
+			panic("callback failure")
+		},
+	})
+
+	err := cb.Execute(func() error { return errors.New("failure") })
+	if err == nil || err.Error() != "failure" {
+		t.Fatalf("Expected the request error to be returned, got %v", err)
+	}
+	if cb.State() != StateOpen {
+		t.Fatalf("Expected state OPEN despite panicking callback, got %s", cb.State())
+	}
+
+	// The breaker keeps working after the panic
+	time.Sleep(60 * time.Millisecond)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("Expected half-open request to succeed, got %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Expected state CLOSED, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerCallbacksDeliveredInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var seen []stateChange
+	cb := NewCircuitBreaker(Settings{
+		Name:             "test",
+		MaxRequests:      1000,
+		FailureThreshold: 1,
+		Timeout:          time.Microsecond,
+		OnStateChange: func(name string, from, to State, counts Counts) {
+			// A slow observer widens the window for a later transition to overtake this one
+			if to == StateOpen {
+				time.Sleep(50 * time.Microsecond)
+			}
+			mu.Lock()
+			seen = append(seen, stateChange{from: from, to: to})
+			mu.Unlock()
+		},
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				fail := (g+i)%3 == 0
+				_ = cb.Execute(func() error {
+					if fail {
+						return errors.New("failure")
+					}
+					return nil
+				})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) == 0 {
+		t.Fatal("expected state changes to be reported")
+	}
+	// Each reported transition must start where the previous one ended
+	prev := StateClosed
+	for i, c := range seen {
+		if c.from != prev {
+			t.Fatalf("transition %d reported %s -> %s after a transition to %s", i, c.from, c.to, prev)
+		}
+		prev = c.to
+	}
+	if prev != cb.State() {
+		t.Fatalf("last reported state %s, breaker is %s", prev, cb.State())
+	}
+}
@@ -0,0 +1,428 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+const (
+	// DefaultLease is how long a lease lasts without renewal
+	DefaultLease = 10 * time.Second
+
+	// LeasePath is the Admin API path peers request leases on
+	LeasePath = "/v1/cluster/lease"
+
+	// Roles reported in Status
+	RoleLeader   = "leader"
+	RoleFollower = "follower"
+)
+
+// LeaseRequest asks a peer to recognise the sender as leader for LeaseMs
+type LeaseRequest struct {
+	NodeID  string `json:"node_id"`
+	LeaseMs int64  `json:"lease_ms"`
+}
+
+// LeaseResponse answers a LeaseRequest. When the lease is refused, Holder is
+// the node the peer recognises and RemainingMs how long its lease has left.
+type LeaseResponse struct {
+	Granted     bool   `json:"granted"`
+	Holder      string `json:"holder"`
+	RemainingMs int64  `json:"remaining_ms"`
+}
+
+// PeerStatus is the outcome of the last lease request sent to a peer
+type PeerStatus struct {
+	URL         string    `json:"url"`
+	Reachable   bool      `json:"reachable"`
+	LastContact time.Time `json:"last_contact,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Status describes this instance's view of the cluster
+type Status struct {
+	NodeID           string       `json:"node_id"`
+	Role             string       `json:"role"`
+	Leader           string       `json:"leader,omitempty"` // Lease holder as seen here; empty when none
+	LeaseRemainingMs int64        `json:"lease_remaining_ms"`
+	LeaseMs          int64        `json:"lease_ms"`
+	Transitions      uint64       `json:"transitions"` // Role changes since start
+	LastTransition   time.Time    `json:"last_transition,omitempty"`
+	Peers            []PeerStatus `json:"peers"`
+}
+
+// Elector runs lease-based leader election among the configured peers, so
+// singleton work runs on one instance while all of them serve traffic.
+//
+// A node becomes leader by asking every peer for a lease. A peer grants it
+// unless it recognises a different holder whose lease is still live, and
+// records the grant against its own monotonic clock. Lease lengths are only
+// ever measured on one clock, so wall clock skew between instances doesn't
+// matter; the leader gives up its lease leaseMargin early so the peers'
+// records always outlast it even if clock rates differ slightly. Peers that
+// can't be reached don't block election: during a partition both sides may
+// lead, which is harmless for the work gated on IsLeader, and the conflict
+// resolves once they can talk again. Retries are jittered so candidates that
+// collide back off at different times.
+type Elector struct {
+	id     string
+	peers  []string
+	token  string
+	lease  time.Duration
+	renew  time.Duration
+	client *http.Client
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	mu             sync.Mutex
+	holder         string    // Node recognised as leader, possibly this one
+	holderUntil    time.Time // When the holder's lease lapses here
+	leaderUntil    time.Time // When this node's own leadership lapses
+	leading        bool      // Role last logged
+	transitions    uint64
+	lastTransition time.Time
+	peerStatus     map[string]*PeerStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// leaseMargin is the share of a lease the leader gives up early
+const leaseMargin = 10
+
+// New creates an elector for the cluster configuration. The node ID
+// defaults to the hostname.
+func New(cfg config.ClusterConfig) (*Elector, error) {
+	id := cfg.NodeID
+	if id == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("cluster.node_id is not set and the hostname is unavailable: %w", err)
+		}
+		id = host
+	}
+	lease := time.Duration(cfg.LeaseSeconds) * time.Second
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+
+	peers := make([]string, 0, len(cfg.Peers))
+	status := make(map[string]*PeerStatus, len(cfg.Peers))
+	for _, p := range cfg.Peers {
+		p = strings.TrimSuffix(p, "/")
+		peers = append(peers, p)
+		status[p] = &PeerStatus{URL: p}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Elector{
+		id:         id,
+		peers:      peers,
+		token:      cfg.Token,
+		lease:      lease,
+		renew:      lease / 3,
+		client:     &http.Client{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		peerStatus: status,
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// NodeID returns the name this instance takes leases under
+func (e *Elector) NodeID() string {
+	return e.id
+}
+
+// Start begins taking part in the election
+func (e *Elector) Start() {
+	e.wg.Add(1)
+	go e.run()
+	logging.L().Info().Str("node_id", e.id).Strs("peers", e.peers).Dur("lease", e.lease).Msg("cluster coordination enabled")
+}
+
+// Stop leaves the election. The lease is not handed back; peers take over
+// once it lapses.
+func (e *Elector) Stop() {
+	e.cancel()
+	e.wg.Wait()
+	e.mu.Lock()
+	e.leaderUntil = time.Time{}
+	if e.holder == e.id {
+		e.holder = ""
+	}
+	e.mu.Unlock()
+	e.noteRole()
+}
+
+// IsLeader reports whether this instance should run singleton work. A nil
+// Elector, when no cluster is configured, always leads.
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.isLeaderLocked(time.Now())
+}
+
+func (e *Elector) isLeaderLocked(now time.Time) bool {
+	return e.holder == e.id && now.Before(e.leaderUntil)
+}
+
+// run takes and renews the lease until the elector is stopped
+func (e *Elector) run() {
+	defer e.wg.Done()
+	timer := time.NewTimer(e.jitter(e.renew))
+	defer timer.Stop()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(e.round())
+	}
+}
+
+// round makes one attempt to take or renew the lease and returns how long
+// to wait before the next one
+func (e *Elector) round() time.Duration {
+	start := time.Now()
+	e.mu.Lock()
+	if e.holder != "" && e.holder != e.id && start.Before(e.holderUntil) {
+		// Another node leads; try again once its lease lapses here
+		wait := e.holderUntil.Sub(start)
+		e.mu.Unlock()
+		e.noteRole()
+		return wait + e.jitter(e.renew)
+	}
+	// Claim the lease locally first, so a peer asking at the same time is
+	// told there is a contender
+	renewing := e.isLeaderLocked(start)
+	e.holder, e.holderUntil = e.id, start.Add(e.lease)
+	e.mu.Unlock()
+
+	denial := e.requestLeases(start)
+
+	e.mu.Lock()
+	switch {
+	case e.holder != e.id:
+		// Conceded to a peer that asked while this round was in flight
+		e.leaderUntil = time.Time{}
+	case denial != nil:
+		e.leaderUntil = time.Time{}
+		e.holder = denial.Holder
+		e.holderUntil = time.Now().Add(time.Duration(denial.RemainingMs) * time.Millisecond)
+		if e.holder == e.id {
+			e.holder = ""
+		}
+	default:
+		e.leaderUntil = start.Add(e.lease - e.lease/leaseMargin)
+	}
+	leading := e.isLeaderLocked(time.Now())
+	e.mu.Unlock()
+	e.noteRole()
+
+	if leading {
+		return e.renew
+	}
+	if renewing {
+		logging.L().Warn().Str("node_id", e.id).Msg("cluster lease renewal refused")
+	}
+	return e.jitter(e.renew)
+}
+
+// requestLeases asks every peer for the lease and returns the first refusal,
+// or nil if no reachable peer refused
+func (e *Elector) requestLeases(start time.Time) *LeaseResponse {
+	body, _ := json.Marshal(LeaseRequest{NodeID: e.id, LeaseMs: e.lease.Milliseconds()})
+	ctx, cancel := context.WithDeadline(e.ctx, start.Add(e.renew))
+	defer cancel()
+
+	type reply struct {
+		peer string
+		resp LeaseResponse
+		err  error
+	}
+	replies := make(chan reply, len(e.peers))
+	for _, peer := range e.peers {
+		go func(peer string) {
+			resp, err := e.requestLease(ctx, peer, body)
+			replies <- reply{peer: peer, resp: resp, err: err}
+		}(peer)
+	}
+
+	var denial *LeaseResponse
+	for range e.peers {
+		r := <-replies
+		e.notePeer(r.peer, r.err)
+		if r.err != nil {
+			logging.L().Debug().Str("peer", r.peer).Err(r.err).Msg("cluster peer unreachable")
+			continue
+		}
+		if !r.resp.Granted && denial == nil {
+			resp := r.resp
+			denial = &resp
+		}
+	}
+	return denial
+}
+
+// requestLease sends one lease request to a peer
+func (e *Elector) requestLease(ctx context.Context, peer string, body []byte) (LeaseResponse, error) {
+	var lr LeaseResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peer+LeasePath, bytes.NewReader(body))
+	if err != nil {
+		return lr, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.token)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return lr, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lr, fmt.Errorf("lease request returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
+		return lr, fmt.Errorf("invalid lease response: %w", err)
+	}
+	return lr, nil
+}
+
+// Grant answers a peer's lease request. The lease is granted unless another
+// node's lease is live here. Two candidates asking each other at once are
+// settled by node ID, the lower one winning; an established leader never
+// concedes.
+func (e *Elector) Grant(req LeaseRequest) LeaseResponse {
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	live := e.holder != "" && now.Before(e.holderUntil)
+	candidate := e.holder == e.id && !e.isLeaderLocked(now)
+	if live && e.holder != req.NodeID && !(candidate && req.NodeID < e.id) {
+		return LeaseResponse{Holder: e.holder, RemainingMs: e.holderUntil.Sub(now).Milliseconds()}
+	}
+	if e.holder == e.id {
+		e.leaderUntil = time.Time{}
+	}
+	e.holder, e.holderUntil = req.NodeID, now.Add(time.Duration(req.LeaseMs)*time.Millisecond)
+	return LeaseResponse{Granted: true, Holder: req.NodeID, RemainingMs: req.LeaseMs}
+}
+
+// LeaseHandler serves LeasePath for peers, who authenticate with the shared
+// cluster token
+func (e *Elector) LeaseHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(e.token)) != 1 {
+			http.Error(w, "invalid cluster token", http.StatusUnauthorized)
+			return
+		}
+		var req LeaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.NodeID == "" || req.LeaseMs <= 0 {
+			http.Error(w, "node_id and a positive lease_ms are required", http.StatusBadRequest)
+			return
+		}
+		if req.NodeID == e.id {
+			http.Error(w, fmt.Sprintf("node_id %q is this node's own; node IDs must be unique", req.NodeID), http.StatusBadRequest)
+			return
+		}
+		resp := e.Grant(req)
+		e.noteRole()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// Status returns this instance's view of the cluster
+func (e *Elector) Status() Status {
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := Status{
+		NodeID:         e.id,
+		Role:           RoleFollower,
+		LeaseMs:        e.lease.Milliseconds(),
+		Transitions:    e.transitions,
+		LastTransition: e.lastTransition,
+		Peers:          make([]PeerStatus, 0, len(e.peers)),
+	}
+	if e.isLeaderLocked(now) {
+		st.Role = RoleLeader
+		st.Leader = e.id
+		st.LeaseRemainingMs = e.leaderUntil.Sub(now).Milliseconds()
+	} else if e.holder != "" && e.holder != e.id && now.Before(e.holderUntil) {
+		st.Leader = e.holder
+		st.LeaseRemainingMs = e.holderUntil.Sub(now).Milliseconds()
+	}
+	for _, p := range e.peers {
+		st.Peers = append(st.Peers, *e.peerStatus[p])
+	}
+	return st
+}
+
+// noteRole logs a change of role since it was last noted
+func (e *Elector) noteRole() {
+	now := time.Now()
+	e.mu.Lock()
+	leading := e.isLeaderLocked(now)
+	if leading == e.leading {
+		e.mu.Unlock()
+		return
+	}
+	e.leading = leading
+	e.transitions++
+	e.lastTransition = now
+	holder := e.holder
+	e.mu.Unlock()
+
+	if leading {
+		logging.L().Info().Str("node_id", e.id).Str("role", RoleLeader).Msg("cluster role changed")
+		return
+	}
+	logging.L().Info().Str("node_id", e.id).Str("role", RoleFollower).Str("leader", holder).Msg("cluster role changed")
+}
+
+// notePeer records the outcome of a lease request to a peer
+func (e *Elector) notePeer(peer string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ps := e.peerStatus[peer]
+	ps.Reachable = err == nil
+	if err != nil {
+		ps.LastError = err.Error()
+		return
+	}
+	ps.LastError = ""
+	ps.LastContact = time.Now()
+}
+
+// jitter returns a random duration between half and one and a half times d
+func (e *Elector) jitter(d time.Duration) time.Duration {
+	e.randMu.Lock()
+	defer e.randMu.Unlock()
+	return d/2 + time.Duration(e.rand.Int63n(int64(d)+1))
+}
@@ -0,0 +1,320 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+const (
+	testToken = "cluster-secret"
+	testLease = 300 * time.Millisecond
+)
+
+// fakeNetwork delivers lease requests between in-process electors by host
+// name, and can take a node off the network
+type fakeNetwork struct {
+	mu    sync.Mutex
+	nodes map[string]*Elector
+	down  map[string]bool
+}
+
+func (n *fakeNetwork) RoundTrip(req *http.Request) (*http.Response, error) {
+	n.mu.Lock()
+	e, ok := n.nodes[req.URL.Host]
+	down := n.down[req.URL.Host]
+	n.mu.Unlock()
+	if !ok || down {
+		return nil, errors.New("connection refused")
+	}
+	rec := httptest.NewRecorder()
+	e.LeaseHandler()(rec, req)
+	return rec.Result(), nil
+}
+
+func (n *fakeNetwork) setDown(host string) {
+	n.mu.Lock()
+	n.down[host] = true
+	n.mu.Unlock()
+}
+
+// newTestCluster creates electors for the named nodes, each listing the
+// others as peers at http://<name>, connected through a fake network
+func newTestCluster(t *testing.T, names ...string) (*fakeNetwork, map[string]*Elector) {
+	t.Helper()
+	network := &fakeNetwork{nodes: make(map[string]*Elector), down: make(map[string]bool)}
+	for _, name := range names {
+		var peers []string
+		for _, other := range names {
+			if other != name {
+				peers = append(peers, "http://"+other)
+			}
+		}
+		e, err := New(config.ClusterConfig{Enabled: true, NodeID: name, Peers: peers, Token: testToken})
+		if err != nil {
+			t.Fatalf("New(%s): %v", name, err)
+		}
+		e.lease, e.renew = testLease, testLease/3
+		e.client = &http.Client{Transport: network}
+		network.nodes[name] = e
+	}
+	for _, e := range network.nodes {
+		e.Start()
+		t.Cleanup(e.Stop)
+	}
+	return network, network.nodes
+}
+
+// leaders returns the names of the nodes that currently lead
+func leaders(nodes map[string]*Elector) []string {
+	var names []string
+	for name, e := range nodes {
+		if e.IsLeader() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// waitForLeader waits up to timeout for exactly one node to lead
+func waitForLeader(t *testing.T, nodes map[string]*Elector, timeout time.Duration) string {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if l := leaders(nodes); len(l) == 1 {
+			return l[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no single leader within %v, leaders: %v", timeout, leaders(nodes))
+	return ""
+}
+
+func TestElection_ExactlyOneLeader(t *testing.T) {
+	_, nodes := newTestCluster(t, "helios-a", "helios-b")
+
+	leader := waitForLeader(t, nodes, 5*testLease)
+
+	// Leadership is stable while both nodes are up
+	deadline := time.Now().Add(3 * testLease)
+	for time.Now().Before(deadline) {
+		if l := leaders(nodes); len(l) != 1 || l[0] != leader {
+			t.Fatalf("expected %s to stay the only leader, got %v", leader, l)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for name, e := range nodes {
+		st := e.Status()
+		if st.Leader != leader {
+			t.Errorf("%s: expected leader %s, got %q", name, leader, st.Leader)
+		}
+		wantRole := RoleFollower
+		if name == leader {
+			wantRole = RoleLeader
+		}
+		if st.Role != wantRole {
+			t.Errorf("%s: expected role %s, got %s", name, wantRole, st.Role)
+		}
+		if len(st.Peers) != 1 {
+			t.Fatalf("%s: expected one peer, got %+v", name, st.Peers)
+		}
+		if name == leader && !st.Peers[0].Reachable {
+			t.Errorf("%s: expected the leader to reach its peer, got %+v", name, st.Peers[0])
+		}
+	}
+}
+
+func TestElection_FollowerTakesOver(t *testing.T) {
+	logs := captureLogs(t)
+	network, nodes := newTestCluster(t, "helios-a", "helios-b")
+
+	leader := waitForLeader(t, nodes, 5*testLease)
+	follower := "helios-a"
+	if leader == follower {
+		follower = "helios-b"
+	}
+
+	// The leader dies: it stops renewing and can no longer be reached
+	stopped := time.Now()
+	nodes[leader].Stop()
+	network.setDown(leader)
+
+	// The follower's view of the lease lapses within one lease, then it
+	// takes over after at most one and a half renew intervals of jitter
+	window := testLease + 3*testLease/2
+	if got := waitForLeader(t, map[string]*Elector{follower: nodes[follower]}, 2*window); got != follower {
+		t.Fatalf("expected %s to take over, got %s", follower, got)
+	}
+	if took := time.Since(stopped); took > window+testLease/2 {
+		t.Errorf("failover took %v, expected within %v", took, window)
+	}
+	if nodes[leader].IsLeader() {
+		t.Error("expected the stopped node not to lead")
+	}
+
+	st := nodes[follower].Status()
+	if st.Role != RoleLeader || st.Leader != follower || st.Transitions == 0 {
+		t.Errorf("unexpected status after failover: %+v", st)
+	}
+	if len(st.Peers) != 1 || st.Peers[0].Reachable || st.Peers[0].LastError == "" {
+		t.Errorf("expected the dead peer to be reported unreachable, got %+v", st.Peers)
+	}
+
+	var tookOver bool
+	for _, line := range logs() {
+		if line["message"] == "cluster role changed" && line["node_id"] == follower && line["role"] == RoleLeader {
+			tookOver = true
+		}
+	}
+	if !tookOver {
+		t.Error("expected the follower's promotion to be logged")
+	}
+}
+
+func TestElection_SplitBrainResolves(t *testing.T) {
+	network, nodes := newTestCluster(t, "helios-a", "helios-b")
+	network.setDown("helios-a")
+	network.setDown("helios-b")
+
+	// Partitioned, both sides lead
+	deadline := time.Now().Add(5 * testLease)
+	for len(leaders(nodes)) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected both partitioned nodes to lead, got %v", leaders(nodes))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Once they can talk again, one of them steps down
+	network.mu.Lock()
+	network.down = make(map[string]bool)
+	network.mu.Unlock()
+	waitForLeader(t, nodes, 10*testLease)
+}
+
+func TestGrant(t *testing.T) {
+	e, err := New(config.ClusterConfig{NodeID: "helios-b", Peers: []string{"http://helios-a"}, Token: testToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := e.Grant(LeaseRequest{NodeID: "helios-c", LeaseMs: 1000}); !resp.Granted {
+		t.Fatalf("expected a free lease to be granted, got %+v", resp)
+	}
+	if resp := e.Grant(LeaseRequest{NodeID: "helios-c", LeaseMs: 1000}); !resp.Granted {
+		t.Fatalf("expected the holder to renew, got %+v", resp)
+	}
+	resp := e.Grant(LeaseRequest{NodeID: "helios-a", LeaseMs: 1000})
+	if resp.Granted || resp.Holder != "helios-c" || resp.RemainingMs <= 0 || resp.RemainingMs > 1000 {
+		t.Fatalf("expected a refusal naming helios-c, got %+v", resp)
+	}
+
+	// A candidate concedes to a lower node ID but not a higher one
+	e.mu.Lock()
+	e.holder, e.holderUntil = e.id, time.Now().Add(time.Second)
+	e.mu.Unlock()
+	if resp := e.Grant(LeaseRequest{NodeID: "helios-c", LeaseMs: 1000}); resp.Granted {
+		t.Errorf("expected a candidate to refuse a higher node ID, got %+v", resp)
+	}
+	if resp := e.Grant(LeaseRequest{NodeID: "helios-a", LeaseMs: 1000}); !resp.Granted {
+		t.Errorf("expected a candidate to concede to a lower node ID, got %+v", resp)
+	}
+
+	// An established leader never concedes
+	e.mu.Lock()
+	e.holder, e.holderUntil, e.leaderUntil = e.id, time.Now().Add(time.Second), time.Now().Add(time.Second)
+	e.mu.Unlock()
+	if resp := e.Grant(LeaseRequest{NodeID: "helios-a", LeaseMs: 1000}); resp.Granted || resp.Holder != "helios-b" {
+		t.Errorf("expected the leader to refuse, got %+v", resp)
+	}
+}
+
+func TestLeaseHandler(t *testing.T) {
+	e, err := New(config.ClusterConfig{NodeID: "helios-b", Peers: []string{"http://helios-a"}, Token: testToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{"granted", testToken, `{"node_id":"helios-a","lease_ms":1000}`, http.StatusOK},
+		{"wrong token", "admin-token", `{"node_id":"helios-a","lease_ms":1000}`, http.StatusUnauthorized},
+		{"invalid json", testToken, `{`, http.StatusBadRequest},
+		{"missing lease", testToken, `{"node_id":"helios-a"}`, http.StatusBadRequest},
+		{"own node id", testToken, `{"node_id":"helios-b","lease_ms":1000}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, LeasePath, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			e.LeaseHandler()(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK {
+				var resp LeaseResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Granted {
+					t.Errorf("expected a granted lease, got %s (%v)", rec.Body.String(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestNilElectorLeads(t *testing.T) {
+	var e *Elector
+	if !e.IsLeader() {
+		t.Error("expected a nil elector, with no cluster configured, to lead")
+	}
+}
+
+// captureLogs routes logs to a JSON file for the rest of the test and
+// returns a function reading back the lines written so far
+func captureLogs(t *testing.T) func() []map[string]interface{} {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "helios.log")
+	err := logging.Init(config.LoggingConfig{
+		Level:   "info",
+		Format:  "json",
+		Outputs: []config.LogOutputConfig{{Type: "file", Path: path}},
+	})
+	if err != nil {
+		t.Fatalf("failed to configure logging: %v", err)
+	}
+	t.Cleanup(func() { _ = logging.Init(config.LoggingConfig{Level: "info", Format: "text"}) })
+
+	return func() []map[string]interface{} {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open log file: %v", err)
+		}
+		defer f.Close()
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+}
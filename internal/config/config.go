@@ -2,16 +2,25 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/0xReLogic/Helios/internal/utils"
 )
 
 // Config represents the main configuration structure for Helios
 type Config struct {
 	Server         ServerConfig         `yaml:"server"`
 	Backends       []BackendConfig      `yaml:"backends"`
+	BackendGroups  []BackendGroupConfig `yaml:"backend_groups"`
 	LoadBalancer   LoadBalancerConfig   `yaml:"load_balancer"`
+	Proxy          ProxyConfig          `yaml:"proxy"`
+	Limits         LimitsConfig         `yaml:"limits"`
 	HealthChecks   HealthChecksConfig   `yaml:"health_checks"`
 	RateLimit      RateLimitConfig      `yaml:"rate_limit"`
 	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
@@ -19,6 +28,11 @@ type Config struct {
 	AdminAPI       AdminAPIConfig       `yaml:"admin_api"`
 	Plugins        PluginsConfig        `yaml:"plugins"`
 	Logging        LoggingConfig        `yaml:"logging"`
+	Synthetics     SyntheticsConfig     `yaml:"synthetics"`
+
+	CostAttribution CostAttributionConfig `yaml:"cost_attribution"`
+	Debug           DebugConfig           `yaml:"debug"`
+	Cluster         ClusterConfig         `yaml:"cluster"`
 }
 
 // ServerConfig holds the server configuration
@@ -26,11 +40,37 @@ type ServerConfig struct {
 	Port     int           `yaml:"port"`
 	TLS      TLSConfig     `yaml:"tls,omitempty"`
 	Timeouts TimeoutConfig `yaml:"timeouts,omitempty"`
+
+	LegacyHTTP10        LegacyHTTP10Config `yaml:"legacy_http10,omitempty"`
+	SlowlorisProtection SlowlorisConfig    `yaml:"slowloris_protection,omitempty"`
+
+	// TrustedProxies lists the CIDRs or IPs of proxies whose X-Forwarded-For
+	// and X-Real-IP headers identify the client to the ip_hash strategies.
+	// Empty trusts none, so they hash the connection's peer address. Their
+	// X-Forwarded-Proto is also believed when rewriting redirects.
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+
+	// AllowedHosts lists the hosts, optionally with a port, requests may be
+	// addressed to. When set, other Host headers and absolute-form request
+	// targets are rejected. Empty accepts any host.
+	AllowedHosts []string `yaml:"allowed_hosts,omitempty"`
+}
+
+// SlowlorisConfig limits connections that have not finished sending request headers
+type SlowlorisConfig struct {
+	MaxPending int `yaml:"max_pending"` // Refuse new connections once this many await headers (0 = no limit)
+}
+
+// LegacyHTTP10Config controls how requests from HTTP/1.0 clients are handled
+type LegacyHTTP10Config struct {
+	DefaultHost string `yaml:"default_host"` // Host to forward when the client sent none
+	ForceClose  bool   `yaml:"force_close"`  // Always close the connection after the response
 }
 
 // TimeoutConfig holds HTTP server timeout settings
 type TimeoutConfig struct {
 	Read        int `yaml:"read"`         // ReadTimeout in seconds
+	ReadHeader  int `yaml:"read_header"`  // ReadHeaderTimeout in seconds
 	Write       int `yaml:"write"`        // WriteTimeout in seconds
 	Idle        int `yaml:"idle"`         // IdleTimeout in seconds
 	Handler     int `yaml:"handler"`      // Handler timeout in seconds (end-to-end request)
@@ -49,15 +89,150 @@ type TLSConfig struct {
 
 // BackendConfig holds the backend server configuration
 type BackendConfig struct {
-	Name    string `yaml:"name"`
-	Address string `yaml:"address"`
-	Weight  int    `yaml:"weight,omitempty"`
+	Name    string `yaml:"name" json:"name"`
+	Address string `yaml:"address" json:"address"`
+	Weight  int    `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxResponseHeaderBytes overrides proxy.max_response_header_bytes for this backend (0 = use global)
+	MaxResponseHeaderBytes int64 `yaml:"max_response_header_bytes,omitempty" json:"max_response_header_bytes,omitempty"`
+	// BackendBodyTimeoutSeconds overrides proxy.backend_body_timeout_seconds for this backend (0 = use global)
+	BackendBodyTimeoutSeconds int `yaml:"backend_body_timeout_seconds,omitempty" json:"backend_body_timeout_seconds,omitempty"`
+	// FlushIntervalMs overrides proxy.flush_interval_ms for this backend (0 = use global)
+	FlushIntervalMs int `yaml:"flush_interval_ms,omitempty" json:"flush_interval_ms,omitempty"`
+	// BufferSizeKB overrides proxy.buffer_size_kb for this backend (0 = use global)
+	BufferSizeKB int `yaml:"buffer_size_kb,omitempty" json:"buffer_size_kb,omitempty"`
+
+	// RedirectPolicy controls backend redirects: pass_through (default), rewrite or follow
+	RedirectPolicy string `yaml:"redirect_policy,omitempty" json:"redirect_policy,omitempty"`
+	// MaxRedirects caps redirects followed internally under the follow policy (0 = 5)
+	MaxRedirects int `yaml:"max_redirects,omitempty" json:"max_redirects,omitempty"`
+	// AllowedRedirectHosts lists hosts other than the backend's own that may be followed or rewritten
+	AllowedRedirectHosts []string `yaml:"allowed_redirect_hosts,omitempty" json:"allowed_redirect_hosts,omitempty"`
+
+	// ConnectionRecycling retires pooled connections to this backend by age or request count
+	ConnectionRecycling ConnectionRecyclingConfig `yaml:"connection_recycling,omitempty" json:"connection_recycling,omitempty"`
+}
+
+// ConnectionRecyclingConfig retires long-lived pooled backend connections,
+// for backends that leak resources per connection
+type ConnectionRecyclingConfig struct {
+	// MaxConnectionAgeSeconds closes pooled connections once they are this old (0 = never)
+	MaxConnectionAgeSeconds int `yaml:"max_connection_age_seconds,omitempty" json:"max_connection_age_seconds,omitempty"`
+	// MaxRequestsPerConnection closes a connection after it has carried this many requests (0 = no limit)
+	MaxRequestsPerConnection int `yaml:"max_requests_per_connection,omitempty" json:"max_requests_per_connection,omitempty"`
+}
+
+// BackendGroupConfig groups backends that are routed to as a unit. Traffic
+// goes to the most preferred group with enough healthy members; backends in
+// no group form an implicit "default" group preferred after all others.
+type BackendGroupConfig struct {
+	Name     string   `yaml:"name"`
+	Priority int      `yaml:"priority"` // Lower is preferred
+	Members  []string `yaml:"members"`  // Backend names
+	// MinHealthy is the number of healthy members the group needs to take traffic (0 = 1)
+	MinHealthy int `yaml:"min_healthy"`
+	// FailbackDelaySeconds is how long the group must stay healthy before
+	// traffic fails back to it from a less preferred group (0 = immediately)
+	FailbackDelaySeconds int `yaml:"failback_delay_seconds"`
+}
+
+// DefaultBackendGroup is the implicit group of backends not listed in backend_groups
+const DefaultBackendGroup = "default"
+
+// Redirect policies for BackendConfig.RedirectPolicy
+const (
+	RedirectPassThrough = "pass_through"
+	RedirectRewrite     = "rewrite"
+	RedirectFollow      = "follow"
+)
+
+// ProxyConfig holds global settings for the backend-facing reverse proxy
+type ProxyConfig struct {
+	// MaxResponseHeaderBytes limits the size of backend response headers (0 = Go's default)
+	MaxResponseHeaderBytes int64 `yaml:"max_response_header_bytes"`
+	// MaxConnsPerHost caps concurrent connections to each backend (0 = 100)
+	MaxConnsPerHost int `yaml:"max_conns_per_host"`
+	// ConnWaitWarnMs logs and counts requests that waited longer than this for a pooled connection (0 = disabled)
+	ConnWaitWarnMs int `yaml:"conn_wait_warn_ms"`
+	// MaxConnWaitMs abandons a backend attempt whose connection wait exceeds this budget (0 = wait indefinitely)
+	MaxConnWaitMs int `yaml:"max_conn_wait_ms"`
+	// BackendBodyTimeoutSeconds aborts a response once the backend sends no body bytes for this long (0 = disabled)
+	BackendBodyTimeoutSeconds int `yaml:"backend_body_timeout_seconds"`
+	// BackendBodyStall controls how stalled response bodies are treated
+	BackendBodyStall BodyStallConfig `yaml:"backend_body_stall"`
+	// FlushIntervalMs flushes proxied responses to the client at this interval (-1 = after every write, 0 = buffered)
+	FlushIntervalMs int `yaml:"flush_interval_ms"`
+	// BufferSizeKB sizes the pooled buffers used to copy response bodies (0 = Go's default 32KB allocations)
+	BufferSizeKB int `yaml:"buffer_size_kb"`
+}
+
+// BodyStallConfig holds settings for responses aborted by the body idle timeout
+type BodyStallConfig struct {
+	// CountAsFailure counts a stall towards the passive health check threshold
+	CountAsFailure bool `yaml:"count_as_failure"`
+}
+
+// LimitsConfig holds process-wide resource limits
+type LimitsConfig struct {
+	// MaxBufferBytesTotal caps the memory all buffering features (gzip, etag,
+	// idempotency) may hold at once; over it they stop buffering (0 = unlimited)
+	MaxBufferBytesTotal int64 `yaml:"max_buffer_bytes_total"`
+}
+
+// UnattributedCostLabel collects usage that matched no cost_attribution rule
+const UnattributedCostLabel = "unattributed"
+
+// DebugConfig enables diagnostic captures through the Admin API
+// (POST /v1/debug/capture)
+type DebugConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	CaptureDir  string `yaml:"capture_dir"`  // Directory receiving one subdirectory per capture
+	MaxCaptures int    `yaml:"max_captures"` // Oldest captures beyond this are deleted (0 = default of 10)
+}
+
+// ClusterConfig coordinates active-active Helios instances so periodic work,
+// such as synthetic checks, runs on one elected leader at a time. Instances
+// take a lease from each other over the Admin API.
+type ClusterConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	NodeID       string   `yaml:"node_id"`       // Unique name of this instance (default: hostname)
+	Peers        []string `yaml:"peers"`         // Admin API base URLs of the other instances
+	Token        string   `yaml:"token"`         // Shared secret peers present on /v1/cluster/lease
+	LeaseSeconds int      `yaml:"lease_seconds"` // How long a lease lasts without renewal (0 = default of 10)
+}
+
+// CostAttributionConfig attributes request usage to labels (e.g. owning
+// teams) for chargeback. Rules are evaluated in order; the first match wins.
+type CostAttributionConfig struct {
+	Rules []CostRule `yaml:"rules"`
+}
+
+// CostRule attributes the requests it matches to Label. A request matches
+// when its path starts with any of PathPrefixes and it carries every header
+// in Headers; an empty condition is not checked.
+type CostRule struct {
+	Label        string            `yaml:"label"`
+	PathPrefixes []string          `yaml:"path_prefixes"`
+	Headers      map[string]string `yaml:"headers"` // Header name to required value
+	// Merge lets this rule reuse the label of an earlier rule; without it a
+	// repeated label is rejected as a likely copy-paste mistake
+	Merge bool `yaml:"merge"`
 }
 
 // LoadBalancerConfig holds the load balancer configuration
 type LoadBalancerConfig struct {
-	Strategy      string              `yaml:"strategy"`
-	WebSocketPool WebSocketPoolConfig `yaml:"websocket_pool"`
+	Strategy string `yaml:"strategy"`
+	// StrategyConfig holds per-strategy options keyed by strategy name; only the selected strategy's block is used
+	StrategyConfig map[string]map[string]interface{} `yaml:"strategy_config,omitempty"`
+	ShadowStrategy ShadowStrategyConfig              `yaml:"shadow_strategy"`
+	WebSocketPool  WebSocketPoolConfig               `yaml:"websocket_pool"`
+}
+
+// ShadowStrategyConfig evaluates a second strategy on a sample of requests
+// without routing by it; an empty Strategy disables shadowing
+type ShadowStrategyConfig struct {
+	Strategy      string  `yaml:"strategy"`
+	SamplePercent float64 `yaml:"sample_percent"` // 0 = every request
 }
 
 // WebSocketPoolConfig holds WebSocket connection pool settings
@@ -80,6 +255,14 @@ type ActiveHealthCheckConfig struct {
 	Interval int    `yaml:"interval"`
 	Timeout  int    `yaml:"timeout"`
 	Path     string `yaml:"path"`
+	Rise     int    `yaml:"rise"` // Consecutive successes to restore an unhealthy backend (default: 2)
+	Fall     int    `yaml:"fall"` // Consecutive failures to mark a backend unhealthy (default: 3)
+	// TrustBackendHints applies the X-Helios-Weight and X-Helios-Drain
+	// headers of successful checks to the backend
+	TrustBackendHints bool `yaml:"trust_backend_hints"`
+	HintMinWeight     int  `yaml:"hint_min_weight"` // Lowest weight a hint can set (0 = 1)
+	HintMaxWeight     int  `yaml:"hint_max_weight"` // Highest weight a hint can set (0 = 100)
+	HintMinChecks     int  `yaml:"hint_min_checks"` // Checks between changes to the same hint; faster changes are ignored (0 = 3)
 }
 
 // PassiveHealthCheckConfig holds the passive health check configuration
@@ -94,6 +277,25 @@ type RateLimitConfig struct {
 	Enabled    bool `yaml:"enabled"`
 	MaxTokens  int  `yaml:"max_tokens"`
 	RefillRate int  `yaml:"refill_rate_seconds"`
+	// Rules replaces the single per-IP bucket above with independent limits;
+	// a request must pass every rule that matches it
+	Rules []RateLimitRule `yaml:"rules"`
+}
+
+// RateLimitRule is one named token bucket limit
+type RateLimitRule struct {
+	Name         string         `yaml:"name"`
+	Key          string         `yaml:"key"` // ip, path, method, header:<name>, joined by "+" (default: ip)
+	MaxTokens    int            `yaml:"max_tokens"`
+	RefillRate   int            `yaml:"refill_rate_seconds"`
+	RefillRateMs int            `yaml:"refill_rate_ms"` // Sub-second alternative to refill_rate_seconds
+	Match        RateLimitMatch `yaml:"match"`
+}
+
+// RateLimitMatch limits a rule to some requests; empty lists match everything
+type RateLimitMatch struct {
+	PathPrefixes []string `yaml:"path_prefixes"`
+	Methods      []string `yaml:"methods"`
 }
 
 // CircuitBreakerConfig holds the circuit breaker configuration
@@ -115,11 +317,20 @@ type MetricsConfig struct {
 
 // AdminAPIConfig holds the Admin API configuration
 type AdminAPIConfig struct {
-	Enabled     bool     `yaml:"enabled"`
-	Port        int      `yaml:"port"`
-	AuthToken   string   `yaml:"auth_token,omitempty"`
-	IPAllowList []string `yaml:"ip_allow_list,omitempty"`
-	IPDenyList  []string `yaml:"ip_deny_list,omitempty"`
+	Enabled     bool            `yaml:"enabled"`
+	Port        int             `yaml:"port"`
+	AuthToken   string          `yaml:"auth_token,omitempty"`
+	IPAllowList []string        `yaml:"ip_allow_list,omitempty"`
+	IPDenyList  []string        `yaml:"ip_deny_list,omitempty"`
+	GRPC        AdminGRPCConfig `yaml:"grpc,omitempty"`
+}
+
+// AdminGRPCConfig holds the optional gRPC Admin API configuration.
+// It shares the auth token and IP filter of the HTTP Admin API.
+type AdminGRPCConfig struct {
+	Enabled bool      `yaml:"enabled"`
+	Port    int       `yaml:"port"`
+	TLS     TLSConfig `yaml:"tls,omitempty"`
 }
 
 // PluginConfig represents a single plugin in the chain
@@ -132,15 +343,40 @@ type PluginConfig struct {
 type PluginsConfig struct {
 	Enabled bool           `yaml:"enabled"`
 	Chain   []PluginConfig `yaml:"chain"`
+	// EnforceOrder fails startup when the chain breaks a built-in plugin's
+	// ordering constraints instead of only warning
+	EnforceOrder bool `yaml:"enforce_order"`
+	// AutoOrder reorders a chain that breaks ordering constraints; it takes
+	// precedence over EnforceOrder
+	AutoOrder bool `yaml:"auto_order"`
 }
 
 // LoggingConfig holds the structured logging configuration
 type LoggingConfig struct {
-	Level         string          `yaml:"level"`
-	Format        string          `yaml:"format"`
-	IncludeCaller bool            `yaml:"include_caller"`
-	RequestID     RequestIDConfig `yaml:"request_id"`
-	Trace         TraceConfig     `yaml:"trace"`
+	Level         string            `yaml:"level"`
+	Format        string            `yaml:"format"`
+	IncludeCaller bool              `yaml:"include_caller"`
+	RequestID     RequestIDConfig   `yaml:"request_id"`
+	Trace         TraceConfig       `yaml:"trace"`
+	Outputs       []LogOutputConfig `yaml:"outputs,omitempty"`
+}
+
+// LogOutputConfig describes a single log sink (defaults to stdout when no outputs are configured)
+type LogOutputConfig struct {
+	Type  string `yaml:"type"`            // stdout, stderr, file, syslog
+	Level string `yaml:"level,omitempty"` // Minimum level written to this sink (default: all)
+
+	// File sink options
+	Path       string `yaml:"path,omitempty"`
+	MaxSizeMB  int    `yaml:"max_size_mb,omitempty"` // Rotate once the file reaches this size (0 = 100MB)
+	MaxBackups int    `yaml:"max_backups,omitempty"` // Rotated files to keep (0 = 5)
+	Compress   bool   `yaml:"compress,omitempty"`    // Gzip rotated files
+
+	// Syslog sink options
+	Network  string `yaml:"network,omitempty"` // "", "udp", "tcp" or "unix"; empty uses the local syslog daemon
+	Address  string `yaml:"address,omitempty"`
+	Tag      string `yaml:"tag,omitempty"`
+	Facility string `yaml:"facility,omitempty"` // e.g. daemon, local0..local7 (default: daemon)
 }
 
 // RequestIDConfig controls request identifier generation and propagation
@@ -155,6 +391,36 @@ type TraceConfig struct {
 	Header  string `yaml:"header"`
 }
 
+// SyntheticsConfig holds synthetic monitoring settings
+type SyntheticsConfig struct {
+	Enabled        bool                   `yaml:"enabled"`
+	MaxConcurrency int                    `yaml:"max_concurrency"` // Max checks executing at once (0 = default)
+	Checks         []SyntheticCheckConfig `yaml:"checks"`
+}
+
+// SyntheticCheckConfig describes a single scripted request and its assertions
+type SyntheticCheckConfig struct {
+	Name            string                   `yaml:"name"`
+	IntervalSeconds int                      `yaml:"interval_seconds"`
+	Request         SyntheticRequestConfig   `yaml:"request"`
+	Assertions      SyntheticAssertionConfig `yaml:"assertions"`
+}
+
+// SyntheticRequestConfig describes the request a synthetic check sends
+type SyntheticRequestConfig struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// SyntheticAssertionConfig holds the conditions a synthetic response must satisfy
+type SyntheticAssertionConfig struct {
+	ExpectedStatus int    `yaml:"expected_status"`
+	MaxLatencyMs   int    `yaml:"max_latency_ms"`
+	BodyContains   string `yaml:"body_contains,omitempty"`
+}
+
 // LoadConfig loads configuration from the specified YAML file
 func LoadConfig(filePath string) (*Config, error) {
 	// #nosec G304 - filePath is provided by trusted admin/user at startup
@@ -176,11 +442,23 @@ func LoadConfig(filePath string) (*Config, error) {
 	return &config, nil
 }
 
+// pluginValidator checks the plugin chain. The plugins package depends on
+// config, so it registers the check instead of being called directly.
+var pluginValidator func(PluginsConfig) error
+
+// RegisterPluginValidator installs the plugin chain check run by Validate
+func RegisterPluginValidator(fn func(PluginsConfig) error) {
+	pluginValidator = fn
+}
+
 // Validate performs comprehensive validation of the configuration
 func (c *Config) Validate() error {
 	if err := c.validateBackends(); err != nil {
 		return err
 	}
+	if err := c.validateBackendGroups(); err != nil {
+		return err
+	}
 	if err := c.validateServer(); err != nil {
 		return err
 	}
@@ -190,6 +468,12 @@ func (c *Config) Validate() error {
 	if err := c.validateLoadBalancer(); err != nil {
 		return err
 	}
+	if err := c.validateProxy(); err != nil {
+		return err
+	}
+	if err := c.validateLimits(); err != nil {
+		return err
+	}
 	if err := c.validateHealthChecks(); err != nil {
 		return err
 	}
@@ -208,6 +492,21 @@ func (c *Config) Validate() error {
 	if err := c.validateLogging(); err != nil {
 		return err
 	}
+	if err := c.validateSynthetics(); err != nil {
+		return err
+	}
+	if err := c.validatePlugins(); err != nil {
+		return err
+	}
+	if err := c.validateCostAttribution(); err != nil {
+		return err
+	}
+	if err := c.validateDebug(); err != nil {
+		return err
+	}
+	if err := c.validateCluster(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -220,11 +519,107 @@ func (c *Config) validateBackends() error {
 		if backend.Name == "" {
 			return fmt.Errorf("backend %d: name is required", i)
 		}
-		if backend.Address == "" {
-			return fmt.Errorf("backend %s: address is required", backend.Name)
+		if err := ValidateBackend(backend); err != nil {
+			return fmt.Errorf("backend %s: %w", backend.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateBackend checks a single backend's settings. It is shared by config
+// loading and the Admin API paths that add backends at runtime.
+func ValidateBackend(backend BackendConfig) error {
+	if backend.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if backend.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	if backend.Weight < 0 {
+		return fmt.Errorf("weight must be non-negative (got %d)", backend.Weight)
+	}
+	if backend.MaxResponseHeaderBytes < 0 {
+		return fmt.Errorf("max_response_header_bytes must be non-negative (got %d)", backend.MaxResponseHeaderBytes)
+	}
+	if backend.BackendBodyTimeoutSeconds < 0 {
+		return fmt.Errorf("backend_body_timeout_seconds must be non-negative (got %d)", backend.BackendBodyTimeoutSeconds)
+	}
+	if backend.FlushIntervalMs < -1 {
+		return fmt.Errorf("flush_interval_ms must be -1 or greater (got %d)", backend.FlushIntervalMs)
+	}
+	if err := validateBufferSizeKB(backend.BufferSizeKB); err != nil {
+		return err
+	}
+	if err := ValidateRedirectPolicy(backend); err != nil {
+		return err
+	}
+	if backend.ConnectionRecycling.MaxConnectionAgeSeconds < 0 {
+		return fmt.Errorf("connection_recycling.max_connection_age_seconds must be non-negative (got %d)", backend.ConnectionRecycling.MaxConnectionAgeSeconds)
+	}
+	if backend.ConnectionRecycling.MaxRequestsPerConnection < 0 {
+		return fmt.Errorf("connection_recycling.max_requests_per_connection must be non-negative (got %d)", backend.ConnectionRecycling.MaxRequestsPerConnection)
+	}
+	return nil
+}
+
+func (c *Config) validateBackendGroups() error {
+	backends := make(map[string]bool, len(c.Backends))
+	for _, b := range c.Backends {
+		backends[b.Name] = true
+	}
+	names := make(map[string]bool, len(c.BackendGroups))
+	priorities := make(map[int]string, len(c.BackendGroups))
+	memberOf := make(map[string]string)
+	for i, g := range c.BackendGroups {
+		if g.Name == "" {
+			return fmt.Errorf("backend group %d: name is required", i)
+		}
+		if g.Name == DefaultBackendGroup {
+			return fmt.Errorf("backend group name %q is reserved for ungrouped backends", DefaultBackendGroup)
+		}
+		if names[g.Name] {
+			return fmt.Errorf("duplicate backend group name: %s", g.Name)
 		}
-		if backend.Weight < 0 {
-			return fmt.Errorf("backend %s: weight must be non-negative (got %d)", backend.Name, backend.Weight)
+		names[g.Name] = true
+		if other, ok := priorities[g.Priority]; ok {
+			return fmt.Errorf("backend groups %s and %s have the same priority %d", other, g.Name, g.Priority)
+		}
+		priorities[g.Priority] = g.Name
+		if len(g.Members) == 0 {
+			return fmt.Errorf("backend group %s: at least one member is required", g.Name)
+		}
+		for _, m := range g.Members {
+			if !backends[m] {
+				return fmt.Errorf("backend group %s: unknown backend %s", g.Name, m)
+			}
+			if other, ok := memberOf[m]; ok {
+				return fmt.Errorf("backend %s is in both backend groups %s and %s", m, other, g.Name)
+			}
+			memberOf[m] = g.Name
+		}
+		if g.MinHealthy < 0 || g.MinHealthy > len(g.Members) {
+			return fmt.Errorf("backend group %s: min_healthy must be between 0 and the number of members (got %d)", g.Name, g.MinHealthy)
+		}
+		if g.FailbackDelaySeconds < 0 {
+			return fmt.Errorf("backend group %s: failback_delay_seconds must be non-negative (got %d)", g.Name, g.FailbackDelaySeconds)
+		}
+	}
+	return nil
+}
+
+// ValidateRedirectPolicy checks a backend's redirect_policy, max_redirects and allowed_redirect_hosts
+func ValidateRedirectPolicy(backend BackendConfig) error {
+	switch backend.RedirectPolicy {
+	case "", RedirectPassThrough, RedirectRewrite, RedirectFollow:
+	default:
+		return fmt.Errorf("invalid redirect_policy: %s (valid: pass_through, rewrite, follow)", backend.RedirectPolicy)
+	}
+	if backend.MaxRedirects < 0 {
+		return fmt.Errorf("max_redirects must be non-negative (got %d)", backend.MaxRedirects)
+	}
+	for _, host := range backend.AllowedRedirectHosts {
+		if host == "" || strings.Contains(host, "/") {
+			return fmt.Errorf("allowed_redirect_hosts entries must be host or host:port (got %q)", host)
 		}
 	}
 	return nil
@@ -244,13 +639,73 @@ func (c *Config) validateServer() error {
 			return fmt.Errorf("TLS enabled but key file not specified")
 		}
 	}
+
+	if host := c.Server.LegacyHTTP10.DefaultHost; host != "" && strings.ContainsAny(host, " \t/?#@") {
+		return fmt.Errorf("server.legacy_http10.default_host must be a host or host:port (got %q)", host)
+	}
+	if c.Server.SlowlorisProtection.MaxPending < 0 {
+		return fmt.Errorf("server.slowloris_protection.max_pending must be non-negative (got %d)", c.Server.SlowlorisProtection.MaxPending)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := utils.ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("server.trusted_proxies entry %q must be a CIDR or IP address", proxy)
+		}
+	}
+	for _, host := range c.Server.AllowedHosts {
+		if err := validateAllowedHost(host); err != nil {
+			return fmt.Errorf("server.allowed_hosts entry %q %w", host, err)
+		}
+	}
 	return nil
 }
 
+// validateAllowedHost checks a server.allowed_hosts entry is a bare host with
+// an optional port
+func validateAllowedHost(host string) error {
+	if host == "" || strings.ContainsAny(host, "/@?# ") {
+		return fmt.Errorf("must be a host name or IP, optionally with a port")
+	}
+	h, port, err := net.SplitHostPort(host)
+	if err != nil {
+		// No port, or an unbracketed IPv6 address
+		return nil
+	}
+	if h == "" {
+		return fmt.Errorf("must include a host before the port")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("has an invalid port")
+	}
+	return nil
+}
+
+// hostAllowed reports whether hostport matches an allowed_hosts entry the
+// way the load balancer does: a host without a port is on the default port
+// of the listener's scheme, and an entry without a port matches any
+func (s ServerConfig) hostAllowed(hostport string) bool {
+	host, port := utils.NormalizeHost(hostport)
+	if port == "" {
+		port = "80"
+		if s.TLS.Enabled {
+			port = "443"
+		}
+	}
+	for _, entry := range s.AllowedHosts {
+		h, p := utils.NormalizeHost(entry)
+		if h == host && (p == "" || p == port) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) validateTimeouts() error {
 	if c.Server.Timeouts.Read < 0 {
 		return fmt.Errorf("server read timeout must be non-negative (got %d)", c.Server.Timeouts.Read)
 	}
+	if c.Server.Timeouts.ReadHeader < 0 {
+		return fmt.Errorf("server read header timeout must be non-negative (got %d)", c.Server.Timeouts.ReadHeader)
+	}
 	if c.Server.Timeouts.Write < 0 {
 		return fmt.Errorf("server write timeout must be non-negative (got %d)", c.Server.Timeouts.Write)
 	}
@@ -275,17 +730,32 @@ func (c *Config) validateTimeouts() error {
 	return nil
 }
 
+// strategyNames lists the strategies Validate accepts. Their options are
+// checked by the loadbalancer package's strategy registry, whose tests keep
+// the two in step.
+var strategyNames = []string{"round_robin", "least_connections", "weighted_round_robin", "ip_hash", "ip_hash_consistent"}
+
+// validStrategy reports whether name is one of strategyNames
+func validStrategy(name string) bool {
+	for _, n := range strategyNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) validateLoadBalancer() error {
-	// Validate load balancer strategy
-	validStrategies := map[string]bool{
-		"round_robin":          true,
-		"least_connections":    true,
-		"weighted_round_robin": true,
-		"ip_hash":              true,
-		"ip_hash_consistent":   true,
+	if c.LoadBalancer.Strategy != "" && !validStrategy(c.LoadBalancer.Strategy) {
+		return fmt.Errorf("invalid load balancer strategy: %s (valid: %s)", c.LoadBalancer.Strategy, strings.Join(strategyNames, ", "))
+	}
+
+	shadow := c.LoadBalancer.ShadowStrategy
+	if shadow.Strategy != "" && !validStrategy(shadow.Strategy) {
+		return fmt.Errorf("invalid shadow strategy: %s (valid: %s)", shadow.Strategy, strings.Join(strategyNames, ", "))
 	}
-	if c.LoadBalancer.Strategy != "" && !validStrategies[c.LoadBalancer.Strategy] {
-		return fmt.Errorf("invalid load balancer strategy: %s (valid: round_robin, least_connections, weighted_round_robin, ip_hash, ip_hash_consistent)", c.LoadBalancer.Strategy)
+	if shadow.SamplePercent < 0 || shadow.SamplePercent > 100 {
+		return fmt.Errorf("shadow strategy sample_percent must be between 0 and 100 (got %g)", shadow.SamplePercent)
 	}
 
 	// Validate WebSocket pool configuration if enabled
@@ -306,6 +776,129 @@ func (c *Config) validateLoadBalancer() error {
 	return nil
 }
 
+func (c *Config) validateProxy() error {
+	if c.Proxy.MaxResponseHeaderBytes < 0 {
+		return fmt.Errorf("proxy max_response_header_bytes must be non-negative (got %d)", c.Proxy.MaxResponseHeaderBytes)
+	}
+	if c.Proxy.MaxConnsPerHost < 0 {
+		return fmt.Errorf("proxy max_conns_per_host must be non-negative (got %d)", c.Proxy.MaxConnsPerHost)
+	}
+	if c.Proxy.ConnWaitWarnMs < 0 {
+		return fmt.Errorf("proxy conn_wait_warn_ms must be non-negative (got %d)", c.Proxy.ConnWaitWarnMs)
+	}
+	if c.Proxy.MaxConnWaitMs < 0 {
+		return fmt.Errorf("proxy max_conn_wait_ms must be non-negative (got %d)", c.Proxy.MaxConnWaitMs)
+	}
+	if c.Proxy.BackendBodyTimeoutSeconds < 0 {
+		return fmt.Errorf("proxy backend_body_timeout_seconds must be non-negative (got %d)", c.Proxy.BackendBodyTimeoutSeconds)
+	}
+	if c.Proxy.FlushIntervalMs < -1 {
+		return fmt.Errorf("proxy flush_interval_ms must be -1 or greater (got %d)", c.Proxy.FlushIntervalMs)
+	}
+	if err := validateBufferSizeKB(c.Proxy.BufferSizeKB); err != nil {
+		return fmt.Errorf("proxy %w", err)
+	}
+	return nil
+}
+
+func (c *Config) validateLimits() error {
+	if c.Limits.MaxBufferBytesTotal < 0 {
+		return fmt.Errorf("limits max_buffer_bytes_total must be non-negative (got %d)", c.Limits.MaxBufferBytesTotal)
+	}
+	return nil
+}
+
+func (c *Config) validateCostAttribution() error {
+	labels := make(map[string]bool)
+	for i, rule := range c.CostAttribution.Rules {
+		if rule.Label == "" {
+			return fmt.Errorf("cost_attribution rule %d: label is required", i)
+		}
+		if rule.Label == UnattributedCostLabel {
+			return fmt.Errorf("cost_attribution rule %d: label %q is reserved for unmatched requests", i, UnattributedCostLabel)
+		}
+		if labels[rule.Label] && !rule.Merge {
+			return fmt.Errorf("cost_attribution rule %d: label %q is already used by an earlier rule (set merge: true to combine them)", i, rule.Label)
+		}
+		labels[rule.Label] = true
+
+		if len(rule.PathPrefixes) == 0 && len(rule.Headers) == 0 {
+			return fmt.Errorf("cost_attribution rule %d (%s): needs path_prefixes or headers", i, rule.Label)
+		}
+		for _, prefix := range rule.PathPrefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				return fmt.Errorf("cost_attribution rule %d (%s): path prefix %q must start with /", i, rule.Label, prefix)
+			}
+		}
+		for name := range rule.Headers {
+			if name == "" || strings.ContainsAny(name, " \t:") {
+				return fmt.Errorf("cost_attribution rule %d (%s): invalid header name %q", i, rule.Label, name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateDebug() error {
+	if c.Debug.MaxCaptures < 0 {
+		return fmt.Errorf("debug.max_captures must be non-negative (got %d)", c.Debug.MaxCaptures)
+	}
+	if c.Debug.Enabled && c.Debug.CaptureDir == "" {
+		return fmt.Errorf("debug.capture_dir is required when debug is enabled")
+	}
+	return nil
+}
+
+func (c *Config) validateCluster() error {
+	if c.Cluster.LeaseSeconds < 0 {
+		return fmt.Errorf("cluster.lease_seconds must be non-negative (got %d)", c.Cluster.LeaseSeconds)
+	}
+	if !c.Cluster.Enabled {
+		return nil
+	}
+	if !c.AdminAPI.Enabled {
+		return fmt.Errorf("cluster requires admin_api to be enabled; peers take leases through it")
+	}
+	if c.Cluster.Token == "" {
+		return fmt.Errorf("cluster.token is required when cluster is enabled")
+	}
+	if len(c.Cluster.Peers) == 0 {
+		return fmt.Errorf("cluster.peers must list at least one peer when cluster is enabled")
+	}
+	for i, peer := range c.Cluster.Peers {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("cluster.peers[%d]: %q is not an http(s) URL", i, peer)
+		}
+	}
+	return nil
+}
+
+// validateBufferSizeKB accepts 0 (unset) or a size between 4KB and 1MB
+func validateBufferSizeKB(kb int) error {
+	if kb != 0 && (kb < 4 || kb > 1024) {
+		return fmt.Errorf("buffer_size_kb must be between 4 and 1024 (got %d)", kb)
+	}
+	return nil
+}
+
+// validateHints checks the bounds applied to backend-published hints
+func (a ActiveHealthCheckConfig) validateHints() error {
+	if a.HintMinWeight < 0 {
+		return fmt.Errorf("active health check hint_min_weight must be non-negative (got %d)", a.HintMinWeight)
+	}
+	if a.HintMaxWeight < 0 {
+		return fmt.Errorf("active health check hint_max_weight must be non-negative (got %d)", a.HintMaxWeight)
+	}
+	if a.HintMaxWeight > 0 && a.HintMaxWeight < a.HintMinWeight {
+		return fmt.Errorf("active health check hint_max_weight (%d) must be at least hint_min_weight (%d)", a.HintMaxWeight, a.HintMinWeight)
+	}
+	if a.HintMinChecks < 0 {
+		return fmt.Errorf("active health check hint_min_checks must be non-negative (got %d)", a.HintMinChecks)
+	}
+	return nil
+}
+
 func (c *Config) validateHealthChecks() error {
 	// Validate active health checks
 	if c.HealthChecks.Active.Enabled {
@@ -321,6 +914,15 @@ func (c *Config) validateHealthChecks() error {
 		if c.HealthChecks.Active.Path == "" {
 			return fmt.Errorf("active health check path is required when enabled")
 		}
+		if c.HealthChecks.Active.Rise < 0 {
+			return fmt.Errorf("active health check rise must be non-negative (got %d)", c.HealthChecks.Active.Rise)
+		}
+		if c.HealthChecks.Active.Fall < 0 {
+			return fmt.Errorf("active health check fall must be non-negative (got %d)", c.HealthChecks.Active.Fall)
+		}
+		if err := c.HealthChecks.Active.validateHints(); err != nil {
+			return err
+		}
 	}
 
 	// Validate passive health checks
@@ -336,13 +938,81 @@ func (c *Config) validateHealthChecks() error {
 }
 
 func (c *Config) validateRateLimit() error {
-	if c.RateLimit.Enabled {
+	if !c.RateLimit.Enabled {
+		return nil
+	}
+	if len(c.RateLimit.Rules) == 0 {
 		if c.RateLimit.MaxTokens <= 0 {
 			return fmt.Errorf("rate limit max tokens must be positive (got %d)", c.RateLimit.MaxTokens)
 		}
 		if c.RateLimit.RefillRate <= 0 {
 			return fmt.Errorf("rate limit refill rate must be positive (got %d)", c.RateLimit.RefillRate)
 		}
+		return nil
+	}
+
+	if c.RateLimit.MaxTokens != 0 || c.RateLimit.RefillRate != 0 {
+		return fmt.Errorf("rate_limit.max_tokens and refill_rate_seconds cannot be combined with rate_limit.rules")
+	}
+	seen := make(map[string]bool, len(c.RateLimit.Rules))
+	for i, rule := range c.RateLimit.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rate limit rule %d: name is required", i)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("duplicate rate limit rule name: %s", rule.Name)
+		}
+		seen[rule.Name] = true
+		if err := validateRateLimitRule(rule); err != nil {
+			return fmt.Errorf("rate limit rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// rateLimitKeySources lists the key sources a rate limit key may combine,
+// besides "header:<name>"
+var rateLimitKeySources = map[string]bool{"ip": true, "path": true, "method": true}
+
+// ParseRateLimitKey splits a rate limit key spec such as "ip+header:X-Tenant"
+// into its sources, rejecting unknown ones. An empty spec means "ip".
+func ParseRateLimitKey(spec string) ([]string, error) {
+	if spec == "" {
+		spec = "ip"
+	}
+	var sources []string
+	for _, source := range strings.Split(spec, "+") {
+		source = strings.TrimSpace(source)
+		if !rateLimitKeySources[source] && !(strings.HasPrefix(source, "header:") && len(source) > len("header:")) {
+			return nil, fmt.Errorf("invalid key source %q (valid: ip, path, method, header:<name>)", source)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func validateRateLimitRule(rule RateLimitRule) error {
+	if rule.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be positive (got %d)", rule.MaxTokens)
+	}
+	if rule.RefillRate < 0 || rule.RefillRateMs < 0 {
+		return fmt.Errorf("refill rate must be non-negative")
+	}
+	if (rule.RefillRate > 0) == (rule.RefillRateMs > 0) {
+		return fmt.Errorf("exactly one of refill_rate_seconds and refill_rate_ms must be set")
+	}
+	if _, err := ParseRateLimitKey(rule.Key); err != nil {
+		return err
+	}
+	for _, prefix := range rule.Match.PathPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("match path prefix must start with / (got %q)", prefix)
+		}
+	}
+	for _, method := range rule.Match.Methods {
+		if method == "" || strings.ContainsAny(method, " \t/") {
+			return fmt.Errorf("invalid match method %q", method)
+		}
 	}
 	return nil
 }
@@ -383,6 +1053,18 @@ func (c *Config) validateAdminAPI() error {
 			return fmt.Errorf("admin API port must be between 1 and 65535 (got %d)", c.AdminAPI.Port)
 		}
 	}
+	grpcCfg := c.AdminAPI.GRPC
+	if grpcCfg.Enabled {
+		if grpcCfg.Port <= 0 || grpcCfg.Port > 65535 {
+			return fmt.Errorf("admin API gRPC port must be between 1 and 65535 (got %d)", grpcCfg.Port)
+		}
+		if c.AdminAPI.Enabled && grpcCfg.Port == c.AdminAPI.Port {
+			return fmt.Errorf("admin API gRPC port must differ from the HTTP admin port (got %d)", grpcCfg.Port)
+		}
+		if grpcCfg.TLS.Enabled && (grpcCfg.TLS.CertFile == "" || grpcCfg.TLS.KeyFile == "") {
+			return fmt.Errorf("admin API gRPC TLS enabled but cert or key file not specified")
+		}
+	}
 	return nil
 }
 
@@ -405,5 +1087,115 @@ func (c *Config) validateLogging() error {
 	if c.Logging.Format != "" && !validLogFormats[c.Logging.Format] {
 		return fmt.Errorf("invalid log format: %s (valid: json, console)", c.Logging.Format)
 	}
+
+	for i, out := range c.Logging.Outputs {
+		if out.Level != "" && !validLogLevels[out.Level] {
+			return fmt.Errorf("logging output %d: invalid level: %s (valid: debug, info, warn, error, fatal)", i, out.Level)
+		}
+		switch out.Type {
+		case "stdout", "stderr":
+		case "file":
+			if out.Path == "" {
+				return fmt.Errorf("logging output %d: file output requires a path", i)
+			}
+			if out.MaxSizeMB < 0 {
+				return fmt.Errorf("logging output %d: max_size_mb must be non-negative (got %d)", i, out.MaxSizeMB)
+			}
+			if out.MaxBackups < 0 {
+				return fmt.Errorf("logging output %d: max_backups must be non-negative (got %d)", i, out.MaxBackups)
+			}
+		case "syslog":
+			if out.Network != "" && out.Address == "" {
+				return fmt.Errorf("logging output %d: syslog network %s requires an address", i, out.Network)
+			}
+			if out.Facility != "" && !validSyslogFacilities[strings.ToLower(out.Facility)] {
+				return fmt.Errorf("logging output %d: invalid syslog facility: %s", i, out.Facility)
+			}
+		default:
+			return fmt.Errorf("logging output %d: invalid type: %q (valid: stdout, stderr, file, syslog)", i, out.Type)
+		}
+	}
 	return nil
 }
+
+// validSyslogFacilities lists the facility names accepted by syslog outputs
+var validSyslogFacilities = map[string]bool{
+	"kern": true, "user": true, "mail": true, "daemon": true, "auth": true,
+	"syslog": true, "lpr": true, "news": true, "uucp": true, "cron": true,
+	"authpriv": true, "ftp": true,
+	"local0": true, "local1": true, "local2": true, "local3": true,
+	"local4": true, "local5": true, "local6": true, "local7": true,
+}
+
+func (c *Config) validateSynthetics() error {
+	if !c.Synthetics.Enabled {
+		return nil
+	}
+	if c.Synthetics.MaxConcurrency < 0 {
+		return fmt.Errorf("synthetics max_concurrency must be non-negative (got %d)", c.Synthetics.MaxConcurrency)
+	}
+
+	seen := make(map[string]bool, len(c.Synthetics.Checks))
+	for i, check := range c.Synthetics.Checks {
+		if check.Name == "" {
+			return fmt.Errorf("synthetic check %d: name is required", i)
+		}
+		if seen[check.Name] {
+			return fmt.Errorf("synthetic check %s: duplicate name", check.Name)
+		}
+		seen[check.Name] = true
+
+		if check.IntervalSeconds <= 0 {
+			return fmt.Errorf("synthetic check %s: interval_seconds must be positive (got %d)", check.Name, check.IntervalSeconds)
+		}
+		if !strings.HasPrefix(check.Request.Path, "/") {
+			return fmt.Errorf("synthetic check %s: request path must start with / (got %q)", check.Name, check.Request.Path)
+		}
+		if _, err := url.ParseRequestURI(check.Request.Path); err != nil {
+			return fmt.Errorf("synthetic check %s: invalid request path: %w", check.Name, err)
+		}
+		if check.Request.Method != "" && !isToken(check.Request.Method) {
+			return fmt.Errorf("synthetic check %s: invalid request method %q", check.Name, check.Request.Method)
+		}
+		if host := syntheticHost(check.Request.Headers); host != "" && len(c.Server.AllowedHosts) > 0 && !c.Server.hostAllowed(host) {
+			return fmt.Errorf("synthetic check %s: Host %q is not in server.allowed_hosts", check.Name, host)
+		}
+		if check.Assertions.ExpectedStatus != 0 && (check.Assertions.ExpectedStatus < 100 || check.Assertions.ExpectedStatus > 599) {
+			return fmt.Errorf("synthetic check %s: expected_status must be a valid HTTP status (got %d)", check.Name, check.Assertions.ExpectedStatus)
+		}
+		if check.Assertions.MaxLatencyMs < 0 {
+			return fmt.Errorf("synthetic check %s: max_latency_ms must be non-negative (got %d)", check.Name, check.Assertions.MaxLatencyMs)
+		}
+	}
+	return nil
+}
+
+// syntheticHost returns the Host header a synthetic check sets, if any
+func syntheticHost(headers map[string]string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, "Host") {
+			return v
+		}
+	}
+	return ""
+}
+
+// isToken reports whether s is an RFC 7230 token, the syntax of an HTTP method
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r >= 0x7f || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Config) validatePlugins() error {
+	if pluginValidator == nil {
+		return nil
+	}
+	return pluginValidator(c.Plugins)
+}
@@ -275,6 +275,174 @@ func TestValidateTLSConfiguration(t *testing.T) {
 	}
 }
 
+func TestValidateLegacyHTTP10(t *testing.T) {
+	tests := []struct {
+		name    string
+		legacy  LegacyHTTP10Config
+		wantErr bool
+	}{
+		{"unset", LegacyHTTP10Config{}, false},
+		{"host", LegacyHTTP10Config{DefaultHost: "legacy.internal", ForceClose: true}, false},
+		{"host and port", LegacyHTTP10Config{DefaultHost: "legacy.internal:8080"}, false},
+		{"url", LegacyHTTP10Config{DefaultHost: "http://legacy.internal"}, true},
+		{"whitespace", LegacyHTTP10Config{DefaultHost: "legacy internal"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080, LegacyHTTP10: tt.legacy},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSlowlorisProtection(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxPending int
+		wantErr    bool
+	}{
+		{"unlimited", 0, false},
+		{"limited", 500, false},
+		{"negative", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080, SlowlorisProtection: SlowlorisConfig{MaxPending: tt.maxPending}},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"cidrs and ips", []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8", "::1"}, false},
+		{"hostname", []string{"proxy.internal"}, true},
+		{"bad mask", []string{"10.0.0.0/33"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080, TrustedProxies: tt.proxies},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAllowedHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"hosts and ports", []string{"example.com", "api.example.com:8443", "10.0.0.1", "[::1]:8080", "::1"}, false},
+		{"empty", []string{""}, true},
+		{"url", []string{"https://example.com"}, true},
+		{"path", []string{"example.com/app"}, true},
+		{"bad port", []string{"example.com:http"}, true},
+		{"port out of range", []string{"example.com:70000"}, true},
+		{"port only", []string{":8080"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080, AllowedHosts: tt.hosts},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDebug(t *testing.T) {
+	tests := []struct {
+		name    string
+		debug   DebugConfig
+		wantErr bool
+	}{
+		{"disabled", DebugConfig{}, false},
+		{"enabled", DebugConfig{Enabled: true, CaptureDir: "/var/lib/helios/captures", MaxCaptures: 5}, false},
+		{"enabled without dir", DebugConfig{Enabled: true}, true},
+		{"negative max captures", DebugConfig{CaptureDir: "/tmp", MaxCaptures: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				Debug:    tt.debug,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCluster(t *testing.T) {
+	peers := []string{"http://10.0.0.2:9091"}
+	tests := []struct {
+		name    string
+		cluster ClusterConfig
+		admin   bool
+		wantErr bool
+	}{
+		{"disabled", ClusterConfig{}, false, false},
+		{"enabled", ClusterConfig{Enabled: true, Peers: peers, Token: "s3cret", LeaseSeconds: 5}, true, false},
+		{"without admin api", ClusterConfig{Enabled: true, Peers: peers, Token: "s3cret"}, false, true},
+		{"without token", ClusterConfig{Enabled: true, Peers: peers}, true, true},
+		{"without peers", ClusterConfig{Enabled: true, Token: "s3cret"}, true, true},
+		{"peer not a url", ClusterConfig{Enabled: true, Peers: []string{"10.0.0.2:9091"}, Token: "s3cret"}, true, true},
+		{"negative lease", ClusterConfig{LeaseSeconds: -1}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				AdminAPI: AdminAPIConfig{Enabled: tt.admin, Port: 9091},
+				Cluster:  tt.cluster,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateLoadBalancerStrategy(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -304,6 +472,35 @@ func TestValidateLoadBalancerStrategy(t *testing.T) {
 	}
 }
 
+func TestValidateShadowStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		shadow  ShadowStrategyConfig
+		wantErr bool
+	}{
+		{"disabled", ShadowStrategyConfig{}, false},
+		{"all requests", ShadowStrategyConfig{Strategy: "least_connections"}, false},
+		{"sampled", ShadowStrategyConfig{Strategy: "ip_hash", SamplePercent: 12.5}, false},
+		{"invalid strategy", ShadowStrategyConfig{Strategy: "random"}, true},
+		{"negative sample", ShadowStrategyConfig{Strategy: "round_robin", SamplePercent: -1}, true},
+		{"sample above 100", ShadowStrategyConfig{Strategy: "round_robin", SamplePercent: 101}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:       ServerConfig{Port: 8080},
+				Backends:     []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				LoadBalancer: LoadBalancerConfig{Strategy: "round_robin", ShadowStrategy: tt.shadow},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateWebSocketPool(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -455,6 +652,22 @@ func TestValidateTimeoutConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "read timeout must be non-negative",
 		},
+		{
+			name: "negative read header timeout",
+			config: TimeoutConfig{
+				Read:        15,
+				ReadHeader:  -1,
+				Write:       15,
+				Idle:        60,
+				Handler:     30,
+				Shutdown:    30,
+				BackendDial: 10,
+				BackendRead: 30,
+				BackendIdle: 90,
+			},
+			wantErr: true,
+			errMsg:  "read header timeout must be non-negative",
+		},
 		{
 			name: "negative write timeout",
 			config: TimeoutConfig{
@@ -596,6 +809,14 @@ func TestValidateActiveHealthChecks(t *testing.T) {
 		{testZeroTimeout, ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 0, Path: testHealthPath}, true},
 		{"timeout >= interval", ActiveHealthCheckConfig{Enabled: true, Interval: 5, Timeout: 10, Path: testHealthPath}, true},
 		{"missing path", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5}, true},
+		{"rise and fall", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, Rise: 2, Fall: 3}, false},
+		{"negative rise", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, Rise: -1}, true},
+		{"negative fall", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, Fall: -1}, true},
+		{"hint bounds", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, TrustBackendHints: true, HintMinWeight: 2, HintMaxWeight: 10, HintMinChecks: 1}, false},
+		{"negative hint min weight", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, HintMinWeight: -1}, true},
+		{"negative hint max weight", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, HintMaxWeight: -1}, true},
+		{"hint max below min", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, HintMinWeight: 5, HintMaxWeight: 4}, true},
+		{"negative hint min checks", ActiveHealthCheckConfig{Enabled: true, Interval: 10, Timeout: 5, Path: testHealthPath, HintMinChecks: -1}, true},
 	}
 
 	for _, tt := range tests {
@@ -654,6 +875,20 @@ func TestValidateRateLimit(t *testing.T) {
 		{testValidConfig, RateLimitConfig{Enabled: true, MaxTokens: 100, RefillRate: 1}, false},
 		{"zero max tokens", RateLimitConfig{Enabled: true, MaxTokens: 0, RefillRate: 1}, true},
 		{"zero refill rate", RateLimitConfig{Enabled: true, MaxTokens: 100, RefillRate: 0}, true},
+		{"valid rules", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{
+			{Name: "per-ip", MaxTokens: 1000, RefillRate: 60},
+			{Name: "expensive", Key: "ip+header:X-Tenant", MaxTokens: 100, RefillRateMs: 600, Match: RateLimitMatch{PathPrefixes: []string{"/expensive"}, Methods: []string{"POST"}}},
+		}}, false},
+		{"legacy fields with rules", RateLimitConfig{Enabled: true, MaxTokens: 100, RefillRate: 1, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1, RefillRate: 1}}}, true},
+		{"rule without name", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{MaxTokens: 1, RefillRate: 1}}}, true},
+		{"duplicate rule name", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1, RefillRate: 1}, {Name: "a", MaxTokens: 2, RefillRate: 1}}}, true},
+		{"rule zero max tokens", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", RefillRate: 1}}}, true},
+		{"rule both refill rates", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1, RefillRate: 1, RefillRateMs: 100}}}, true},
+		{"rule no refill rate", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1}}}, true},
+		{"rule invalid key", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", Key: "cookie", MaxTokens: 1, RefillRate: 1}}}, true},
+		{"rule empty header key", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", Key: "ip+header:", MaxTokens: 1, RefillRate: 1}}}, true},
+		{"rule relative path prefix", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1, RefillRate: 1, Match: RateLimitMatch{PathPrefixes: []string{"api"}}}}}, true},
+		{"rule invalid method", RateLimitConfig{Enabled: true, Rules: []RateLimitRule{{Name: "a", MaxTokens: 1, RefillRate: 1, Match: RateLimitMatch{Methods: []string{"GET POST"}}}}}, true},
 	}
 
 	for _, tt := range tests {
@@ -736,6 +971,10 @@ func TestValidateAdminAPI(t *testing.T) {
 		{"disabled", AdminAPIConfig{Enabled: false}, false},
 		{testValidConfig, AdminAPIConfig{Enabled: true, Port: 8081}, false},
 		{"invalid port", AdminAPIConfig{Enabled: true, Port: 0}, true},
+		{"grpc enabled", AdminAPIConfig{Enabled: true, Port: 8081, GRPC: AdminGRPCConfig{Enabled: true, Port: 9092}}, false},
+		{"grpc invalid port", AdminAPIConfig{GRPC: AdminGRPCConfig{Enabled: true, Port: 70000}}, true},
+		{"grpc port clashes with http", AdminAPIConfig{Enabled: true, Port: 8081, GRPC: AdminGRPCConfig{Enabled: true, Port: 8081}}, true},
+		{"grpc tls missing key", AdminAPIConfig{GRPC: AdminGRPCConfig{Enabled: true, Port: 9092, TLS: TLSConfig{Enabled: true, CertFile: "cert.pem"}}}, true},
 	}
 
 	for _, tt := range tests {
@@ -763,6 +1002,11 @@ func TestValidateLogging(t *testing.T) {
 		{"empty level and format", LoggingConfig{}, false},
 		{"invalid level", LoggingConfig{Level: "invalid"}, true},
 		{"invalid format", LoggingConfig{Format: "invalid"}, true},
+		{"file output", LoggingConfig{Outputs: []LogOutputConfig{{Type: "file", Path: "/var/log/helios.log", Level: "warn"}}}, false},
+		{"file output without path", LoggingConfig{Outputs: []LogOutputConfig{{Type: "file"}}}, true},
+		{"unknown output type", LoggingConfig{Outputs: []LogOutputConfig{{Type: "kafka"}}}, true},
+		{"invalid output level", LoggingConfig{Outputs: []LogOutputConfig{{Type: "stdout", Level: "loud"}}}, true},
+		{"invalid syslog facility", LoggingConfig{Outputs: []LogOutputConfig{{Type: "syslog", Facility: "local9"}}}, true},
 	}
 
 	for _, tt := range tests {
@@ -779,3 +1023,361 @@ func TestValidateLogging(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMaxResponseHeaderBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   ProxyConfig
+		backend BackendConfig
+		wantErr bool
+	}{
+		{"defaults", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, false},
+		{"global and override", ProxyConfig{MaxResponseHeaderBytes: 262144}, BackendConfig{Name: "test", Address: testLocalhostHTTP, MaxResponseHeaderBytes: 65536}, false},
+		{"negative global", ProxyConfig{MaxResponseHeaderBytes: -1}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+		{"negative override", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP, MaxResponseHeaderBytes: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{tt.backend},
+				Proxy:    tt.proxy,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConnWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   ProxyConfig
+		wantErr bool
+	}{
+		{"defaults", ProxyConfig{}, false},
+		{testValidConfig, ProxyConfig{MaxConnsPerHost: 50, ConnWaitWarnMs: 100, MaxConnWaitMs: 500}, false},
+		{"negative max conns", ProxyConfig{MaxConnsPerHost: -1}, true},
+		{"negative warn", ProxyConfig{ConnWaitWarnMs: -1}, true},
+		{"negative max wait", ProxyConfig{MaxConnWaitMs: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				Proxy:    tt.proxy,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBackendBodyTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   ProxyConfig
+		backend BackendConfig
+		wantErr bool
+	}{
+		{"defaults", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, false},
+		{"global and override", ProxyConfig{BackendBodyTimeoutSeconds: 30, BackendBodyStall: BodyStallConfig{CountAsFailure: true}}, BackendConfig{Name: "test", Address: testLocalhostHTTP, BackendBodyTimeoutSeconds: 120}, false},
+		{"negative global", ProxyConfig{BackendBodyTimeoutSeconds: -1}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+		{"negative override", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP, BackendBodyTimeoutSeconds: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{tt.backend},
+				Proxy:    tt.proxy,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateProxyTuning(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   ProxyConfig
+		backend BackendConfig
+		wantErr bool
+	}{
+		{"defaults", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, false},
+		{"global and override", ProxyConfig{FlushIntervalMs: 100, BufferSizeKB: 64}, BackendConfig{Name: "test", Address: testLocalhostHTTP, FlushIntervalMs: -1, BufferSizeKB: 4}, false},
+		{"max buffer", ProxyConfig{BufferSizeKB: 1024}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, false},
+		{"invalid global flush", ProxyConfig{FlushIntervalMs: -2}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+		{"invalid override flush", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP, FlushIntervalMs: -5}, true},
+		{"global buffer too small", ProxyConfig{BufferSizeKB: 2}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+		{"global buffer too large", ProxyConfig{BufferSizeKB: 2048}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+		{"override buffer too small", ProxyConfig{}, BackendConfig{Name: "test", Address: testLocalhostHTTP, BufferSizeKB: 3}, true},
+		{"negative buffer", ProxyConfig{BufferSizeKB: -1}, BackendConfig{Name: "test", Address: testLocalhostHTTP}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{tt.backend},
+				Proxy:    tt.proxy,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConnectionRecycling(t *testing.T) {
+	tests := []struct {
+		name      string
+		recycling ConnectionRecyclingConfig
+		wantErr   bool
+	}{
+		{"disabled", ConnectionRecyclingConfig{}, false},
+		{"age and requests", ConnectionRecyclingConfig{MaxConnectionAgeSeconds: 300, MaxRequestsPerConnection: 1000}, false},
+		{"negative age", ConnectionRecyclingConfig{MaxConnectionAgeSeconds: -1}, true},
+		{"negative requests", ConnectionRecyclingConfig{MaxRequestsPerConnection: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP, ConnectionRecycling: tt.recycling}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBackendGroups(t *testing.T) {
+	backends := []BackendConfig{
+		{Name: "p1", Address: testLocalhostHTTP},
+		{Name: "p2", Address: testLocalhostHTTP},
+		{Name: "s1", Address: testLocalhostHTTP},
+	}
+	tests := []struct {
+		name    string
+		groups  []BackendGroupConfig
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"primary and standby", []BackendGroupConfig{
+			{Name: "primary", Priority: 1, Members: []string{"p1", "p2"}, MinHealthy: 2, FailbackDelaySeconds: 30},
+			{Name: "standby", Priority: 2, Members: []string{"s1"}},
+		}, false},
+		{"missing name", []BackendGroupConfig{{Priority: 1, Members: []string{"p1"}}}, true},
+		{"reserved name", []BackendGroupConfig{{Name: "default", Members: []string{"p1"}}}, true},
+		{"duplicate name", []BackendGroupConfig{{Name: "a", Priority: 1, Members: []string{"p1"}}, {Name: "a", Priority: 2, Members: []string{"p2"}}}, true},
+		{"duplicate priority", []BackendGroupConfig{{Name: "a", Priority: 1, Members: []string{"p1"}}, {Name: "b", Priority: 1, Members: []string{"p2"}}}, true},
+		{"no members", []BackendGroupConfig{{Name: "a"}}, true},
+		{"unknown member", []BackendGroupConfig{{Name: "a", Members: []string{"p9"}}}, true},
+		{"member in two groups", []BackendGroupConfig{{Name: "a", Priority: 1, Members: []string{"p1"}}, {Name: "b", Priority: 2, Members: []string{"p1"}}}, true},
+		{"min healthy above members", []BackendGroupConfig{{Name: "a", Members: []string{"p1"}, MinHealthy: 2}}, true},
+		{"negative failback delay", []BackendGroupConfig{{Name: "a", Members: []string{"p1"}, FailbackDelaySeconds: -1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:        ServerConfig{Port: 8080},
+				Backends:      backends,
+				BackendGroups: tt.groups,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  LimitsConfig
+		wantErr bool
+	}{
+		{"unlimited", LimitsConfig{}, false},
+		{"buffer budget", LimitsConfig{MaxBufferBytesTotal: 256 << 20}, false},
+		{"negative buffer budget", LimitsConfig{MaxBufferBytesTotal: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				Limits:   tt.limits,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCostAttribution(t *testing.T) {
+	payments := CostRule{Label: "payments", PathPrefixes: []string{"/payments"}}
+	tests := []struct {
+		name    string
+		rules   []CostRule
+		wantErr string
+	}{
+		{"unset", nil, ""},
+		{"prefix and header", []CostRule{payments, {Label: "search", Headers: map[string]string{"X-Team": "search"}}}, ""},
+		{"merged label", []CostRule{payments, {Label: "payments", Headers: map[string]string{"X-Team": "billing"}, Merge: true}}, ""},
+		{"duplicate label", []CostRule{payments, {Label: "payments", PathPrefixes: []string{"/billing"}}}, "already used by an earlier rule"},
+		{"missing label", []CostRule{{PathPrefixes: []string{"/x"}}}, "label is required"},
+		{"reserved label", []CostRule{{Label: UnattributedCostLabel, PathPrefixes: []string{"/x"}}}, "reserved"},
+		{"no conditions", []CostRule{{Label: "everything"}}, "needs path_prefixes or headers"},
+		{"relative prefix", []CostRule{{Label: "api", PathPrefixes: []string{"api"}}}, "must start with /"},
+		{"bad header name", []CostRule{{Label: "api", Headers: map[string]string{"X Team": "a"}}}, "invalid header name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:          ServerConfig{Port: 8080},
+				Backends:        []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				CostAttribution: CostAttributionConfig{Rules: tt.rules},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRedirectPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend BackendConfig
+		wantErr bool
+	}{
+		{"default", BackendConfig{}, false},
+		{"pass through", BackendConfig{RedirectPolicy: RedirectPassThrough}, false},
+		{"rewrite", BackendConfig{RedirectPolicy: RedirectRewrite, AllowedRedirectHosts: []string{"backend-2.internal"}}, false},
+		{"follow", BackendConfig{RedirectPolicy: RedirectFollow, MaxRedirects: 3, AllowedRedirectHosts: []string{"backend-2.internal:8080"}}, false},
+		{"unknown policy", BackendConfig{RedirectPolicy: "loop"}, true},
+		{"negative max redirects", BackendConfig{RedirectPolicy: RedirectFollow, MaxRedirects: -1}, true},
+		{"empty allowed host", BackendConfig{RedirectPolicy: RedirectFollow, AllowedRedirectHosts: []string{""}}, true},
+		{"allowed host with path", BackendConfig{RedirectPolicy: RedirectFollow, AllowedRedirectHosts: []string{"http://backend-2.internal/"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.backend.Name = "test"
+			tt.backend.Address = testLocalhostHTTP
+			cfg := &Config{
+				Server:   ServerConfig{Port: 8080},
+				Backends: []BackendConfig{tt.backend},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSynthetics(t *testing.T) {
+	validCheck := SyntheticCheckConfig{
+		Name:            "home",
+		IntervalSeconds: 30,
+		Request:         SyntheticRequestConfig{Method: "GET", Path: "/"},
+		Assertions:      SyntheticAssertionConfig{ExpectedStatus: 200, MaxLatencyMs: 500},
+	}
+	tests := []struct {
+		name    string
+		config  SyntheticsConfig
+		wantErr bool
+	}{
+		{"disabled", SyntheticsConfig{Enabled: false, Checks: []SyntheticCheckConfig{{}}}, false},
+		{testValidConfig, SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{validCheck}}, false},
+		{"missing name", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{IntervalSeconds: 1, Request: SyntheticRequestConfig{Path: "/"}}}}, true},
+		{"duplicate name", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{validCheck, validCheck}}, true},
+		{"zero interval", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{Name: "a", Request: SyntheticRequestConfig{Path: "/"}}}}, true},
+		{"relative path", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{Name: "a", IntervalSeconds: 1, Request: SyntheticRequestConfig{Path: "health"}}}}, true},
+		{"unparseable path", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{Name: "a", IntervalSeconds: 1, Request: SyntheticRequestConfig{Path: "/%zz"}}}}, true},
+		{"invalid method", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{Name: "a", IntervalSeconds: 1, Request: SyntheticRequestConfig{Method: "BAD METHOD", Path: "/"}}}}, true},
+		{"invalid status", SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{{Name: "a", IntervalSeconds: 1, Request: SyntheticRequestConfig{Path: "/"}, Assertions: SyntheticAssertionConfig{ExpectedStatus: 42}}}}, true},
+		{"negative concurrency", SyntheticsConfig{Enabled: true, MaxConcurrency: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:     ServerConfig{Port: 8080},
+				Backends:   []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				Synthetics: tt.config,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSynthetics_AllowedHosts(t *testing.T) {
+	check := func(headers map[string]string) SyntheticCheckConfig {
+		return SyntheticCheckConfig{
+			Name:            "home",
+			IntervalSeconds: 30,
+			Request:         SyntheticRequestConfig{Path: "/", Headers: headers},
+		}
+	}
+	tests := []struct {
+		name    string
+		hosts   []string
+		headers map[string]string
+		wantErr bool
+	}{
+		{"no allowed hosts", nil, map[string]string{"Host": "other.example.com"}, false},
+		{"no host header", []string{"app.example.com"}, nil, false},
+		{"allowed host", []string{"app.example.com"}, map[string]string{"Host": "APP.example.com"}, false},
+		{"allowed host lowercase key", []string{"app.example.com:8080"}, map[string]string{"host": "app.example.com:8080"}, false},
+		{"host not allowed", []string{"app.example.com"}, map[string]string{"Host": "other.example.com"}, true},
+		{"port not allowed", []string{"app.example.com:8443"}, map[string]string{"Host": "app.example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:     ServerConfig{Port: 8080, AllowedHosts: tt.hosts},
+				Backends:   []BackendConfig{{Name: "test", Address: testLocalhostHTTP}},
+				Synthetics: SyntheticsConfig{Enabled: true, Checks: []SyntheticCheckConfig{check(tt.headers)}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf(testValidateError, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// Health check response headers a backend can use to publish its own
+// capacity and deployment state (health_checks.active.trust_backend_hints)
+const (
+	HeaderWeightHint = "X-Helios-Weight"
+	HeaderDrainHint  = "X-Helios-Drain"
+)
+
+const (
+	// defaultHintMinWeight is the lowest weight a hint can set when
+	// hint_min_weight is unset
+	defaultHintMinWeight = 1
+	// defaultHintMaxWeight is the highest weight a hint can set when
+	// hint_max_weight is unset
+	defaultHintMaxWeight = 100
+	// defaultHintMinChecks is the number of checks a hint must hold before it
+	// can change again when hint_min_checks is unset
+	defaultHintMinChecks = 3
+)
+
+// backendHints is the state a backend published through health check
+// headers. Guarded by Backend.Mutex.
+type backendHints struct {
+	weight     int  // Weight set by X-Helios-Weight; 0 follows the configured weight
+	draining   bool // Set by X-Helios-Drain; no new requests until a later check clears it
+	weightGate hintGate
+	drainGate  hintGate
+}
+
+// hintGate rate-limits changes to one hint so a flapping backend can't
+// bounce its own state on every check
+type hintGate struct {
+	checks  int  // Successful checks since the hint last changed
+	changed bool // The hint has changed at least once
+}
+
+// allows reports whether the hint may change now. The first change always
+// applies; later ones wait until the previous one held for minChecks checks.
+func (g *hintGate) allows(minChecks int) bool {
+	return !g.changed || g.checks >= minChecks
+}
+
+// changedNow records that the hint changed on this check
+func (g *hintGate) changedNow() {
+	g.checks, g.changed = 0, true
+}
+
+// hintChange is an applied or deferred change to one backend hint
+type hintChange struct {
+	hint     string
+	old, new string
+	deferred bool
+}
+
+// parseWeightHint reads X-Helios-Weight. present is false when the header is
+// absent, in which case the backend follows its configured weight.
+func parseWeightHint(h http.Header) (weight int, present bool, err error) {
+	v := strings.TrimSpace(h.Get(HeaderWeightHint))
+	if v == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, true, fmt.Errorf("invalid %s %q: must be a positive integer", HeaderWeightHint, v)
+	}
+	return n, true, nil
+}
+
+// parseDrainHint reads X-Helios-Drain. An absent header clears draining.
+func parseDrainHint(h http.Header) (bool, error) {
+	v := strings.TrimSpace(h.Get(HeaderDrainHint))
+	if v == "" {
+		return false, nil
+	}
+	drain, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", HeaderDrainHint, v)
+	}
+	return drain, nil
+}
+
+// clampHintWeight bounds a hinted weight to hint_min_weight and hint_max_weight
+func (hc *healthChecker) clampHintWeight(weight int) int {
+	if weight < hc.hintMinWeight {
+		return hc.hintMinWeight
+	}
+	if weight > hc.hintMaxWeight {
+		return hc.hintMaxWeight
+	}
+	return weight
+}
+
+// applyBackendHints updates a backend's weight and drain state from the
+// headers of a successful health check. Malformed values are ignored and
+// leave the current state in place.
+func (lb *LoadBalancer) applyBackendHints(backend *Backend, header http.Header) {
+	hc := lb.healthChecks
+	if !hc.trustHints {
+		return
+	}
+
+	weight, weightPresent, weightErr := parseWeightHint(header)
+	if weightErr != nil {
+		logging.L().Debug().Str("backend", backend.Name).Err(weightErr).Msg("ignoring malformed backend hint")
+	}
+	drain, drainErr := parseDrainHint(header)
+	if drainErr != nil {
+		logging.L().Debug().Str("backend", backend.Name).Err(drainErr).Msg("ignoring malformed backend hint")
+	}
+
+	// Hold the write lock so strategies never observe a weight mid-update,
+	// as SetBackendWeight does
+	lb.mutex.Lock()
+	backend.Mutex.Lock()
+	h := &backend.hints
+	h.weightGate.checks++
+	h.drainGate.checks++
+
+	var changes []hintChange
+	if weightErr == nil {
+		want := 0
+		if weightPresent {
+			want = hc.clampHintWeight(weight)
+		}
+		if want != h.weight {
+			old := backend.Weight
+			effective := want
+			if effective == 0 {
+				effective = backend.configWeight
+			}
+			change := hintChange{hint: "weight", old: strconv.Itoa(old), new: strconv.Itoa(effective)}
+			if h.weightGate.allows(hc.hintMinChecks) {
+				h.weight, backend.Weight = want, effective
+				h.weightGate.changedNow()
+			} else {
+				change.deferred = true
+			}
+			changes = append(changes, change)
+		}
+	}
+	if drainErr == nil && drain != h.draining {
+		change := hintChange{hint: "drain", old: strconv.FormatBool(h.draining), new: strconv.FormatBool(drain)}
+		if h.drainGate.allows(hc.hintMinChecks) {
+			h.draining = drain
+			h.drainGate.changedNow()
+			if lb.groups != nil {
+				lb.groups.syncLocked(backend)
+			}
+		} else {
+			change.deferred = true
+		}
+		changes = append(changes, change)
+	}
+	backend.Mutex.Unlock()
+	lb.mutex.Unlock()
+
+	for _, c := range changes {
+		if c.deferred {
+			logging.L().Debug().Str("backend", backend.Name).Str("hint", c.hint).Str("old", c.old).Str("new", c.new).
+				Int("min_checks", hc.hintMinChecks).Msg("backend hint changed too soon, ignoring")
+			continue
+		}
+		logging.L().Info().Str("backend", backend.Name).Str("hint", c.hint).Str("old", c.old).Str("new", c.new).Msg("backend hint applied")
+		switch {
+		case c.hint == "weight":
+			lb.publishEvent(EventWeightChanged, backend.Name, fmt.Sprintf("weight=%s (backend hint)", c.new))
+		case c.new == "true":
+			lb.publishEvent(EventBackendDraining, backend.Name, "backend hint")
+		default:
+			lb.publishEvent(EventBackendDrainCleared, backend.Name, "backend hint")
+		}
+	}
+}
+
+// isDraining reports whether the backend asked to receive no new requests
+func (backend *Backend) isDraining() bool {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	return backend.hints.draining
+}
+
+// Available reports whether the backend takes new requests: it is healthy
+// and has not asked to drain. Strategies must use this rather than reading
+// IsHealthy directly.
+func (backend *Backend) Available() bool {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	return backend.IsHealthy && !backend.hints.draining
+}
+
+// isBackendAvailable reports whether new requests may go to a backend,
+// restoring it first if its unhealthy period has expired
+func (lb *LoadBalancer) isBackendAvailable(backend *Backend) bool {
+	return lb.IsBackendHealthy(backend) && !backend.isDraining()
+}
@@ -0,0 +1,204 @@
+package loadbalancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// hintingBackend names itself in response bodies and answers health checks
+// with whatever hint headers the test currently sets
+type hintingBackend struct {
+	name  string
+	mu    sync.Mutex
+	hints http.Header
+}
+
+func (h *hintingBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/health" {
+		h.mu.Lock()
+		for k, v := range h.hints {
+			w.Header()[k] = v
+		}
+		h.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	_, _ = io.WriteString(w, h.name)
+}
+
+// publish replaces the hint headers sent on later health checks
+func (h *hintingBackend) publish(kv ...string) {
+	hints := make(http.Header)
+	for i := 0; i+1 < len(kv); i += 2 {
+		hints.Set(kv[i], kv[i+1])
+	}
+	h.mu.Lock()
+	h.hints = hints
+	h.mu.Unlock()
+}
+
+// newHintTestLB creates weighted round robin over backends a and b, both
+// weight 1, trusting hints. Active checks are driven by the test through
+// probe rather than the ticker.
+func newHintTestLB(t *testing.T, active config.ActiveHealthCheckConfig) (*LoadBalancer, map[string]*hintingBackend, func()) {
+	t.Helper()
+	hinting := make(map[string]*hintingBackend)
+	var backends []config.BackendConfig
+	for _, name := range []string{"a", "b"} {
+		hb := &hintingBackend{name: name}
+		server := httptest.NewServer(hb)
+		t.Cleanup(server.Close)
+		hinting[name] = hb
+		backends = append(backends, config.BackendConfig{Name: name, Address: server.URL, Weight: 1})
+	}
+
+	active.Path, active.Timeout = "/health", 1
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     backends,
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "weighted_round_robin"},
+		HealthChecks: config.HealthChecksConfig{Active: active},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	probe := func() {
+		for _, b := range lb.strategy.GetBackends() {
+			lb.checkBackendHealth(b)
+		}
+	}
+	return lb, hinting, probe
+}
+
+// split sends n requests and counts the responses from each backend
+func split(t *testing.T, lb *LoadBalancer, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+		counts[rec.Body.String()]++
+	}
+	return counts
+}
+
+// backendInfo returns the Admin API snapshot of the named backend
+func backendInfo(t *testing.T, lb *LoadBalancer, name string) BackendInfo {
+	t.Helper()
+	for _, info := range lb.ListBackends() {
+		if info.Name == name {
+			return info
+		}
+	}
+	t.Fatalf("backend %s not listed", name)
+	return BackendInfo{}
+}
+
+func TestBackendWeightHintShiftsTraffic(t *testing.T) {
+	lb, hinting, probe := newHintTestLB(t, config.ActiveHealthCheckConfig{TrustBackendHints: true, HintMinChecks: 1})
+
+	if got := split(t, lb, 40); got["a"] != 20 || got["b"] != 20 {
+		t.Fatalf("expected an even split before any hint, got %v", got)
+	}
+
+	hinting["a"].publish(HeaderWeightHint, "3")
+	probe()
+	probe()
+	if got := split(t, lb, 40); got["a"] != 30 || got["b"] != 10 {
+		t.Fatalf("expected a 3:1 split after the weight hint, got %v", got)
+	}
+	info := backendInfo(t, lb, "a")
+	if info.Weight != 3 || info.ConfiguredWeight != 1 || !info.WeightFromHint {
+		t.Errorf("expected the hinted weight to be reported, got %+v", info)
+	}
+
+	// The Admin API changes the configured weight, the hint keeps precedence
+	if err := lb.SetBackendWeight("a", 2); err != nil {
+		t.Fatal(err)
+	}
+	if info := backendInfo(t, lb, "a"); info.Weight != 3 || info.ConfiguredWeight != 2 {
+		t.Errorf("expected the hint to keep precedence over the Admin API, got %+v", info)
+	}
+
+	// Dropping the header falls back to the configured weight
+	hinting["a"].publish()
+	probe()
+	if info := backendInfo(t, lb, "a"); info.Weight != 2 || info.WeightFromHint {
+		t.Errorf("expected the configured weight after the hint is dropped, got %+v", info)
+	}
+}
+
+func TestBackendDrainHint(t *testing.T) {
+	lb, hinting, probe := newHintTestLB(t, config.ActiveHealthCheckConfig{TrustBackendHints: true, HintMinChecks: 1})
+
+	hinting["a"].publish(HeaderDrainHint, "true")
+	probe()
+	if got := split(t, lb, 20); got["a"] != 0 || got["b"] != 20 {
+		t.Fatalf("expected a draining backend to get no new requests, got %v", got)
+	}
+	if info := backendInfo(t, lb, "a"); !info.Draining || !info.Healthy {
+		t.Errorf("expected a healthy, draining backend, got %+v", info)
+	}
+
+	hinting["a"].publish(HeaderDrainHint, "false")
+	probe()
+	if got := split(t, lb, 20); got["a"] != 10 || got["b"] != 10 {
+		t.Fatalf("expected clearing the drain hint to restore the backend, got %v", got)
+	}
+	if info := backendInfo(t, lb, "a"); info.Draining {
+		t.Errorf("expected draining to be cleared, got %+v", info)
+	}
+}
+
+func TestBackendHintsRateLimitedAndBounded(t *testing.T) {
+	lb, hinting, probe := newHintTestLB(t, config.ActiveHealthCheckConfig{
+		TrustBackendHints: true, HintMinWeight: 2, HintMaxWeight: 5, HintMinChecks: 3,
+	})
+
+	// The first change applies at once, clamped to hint_max_weight
+	hinting["a"].publish(HeaderWeightHint, "50")
+	probe()
+	if got := backendInfo(t, lb, "a").Weight; got != 5 {
+		t.Fatalf("expected the hint to be clamped to 5, got %d", got)
+	}
+
+	// Changing again sooner than hint_min_checks is ignored
+	hinting["a"].publish(HeaderWeightHint, "1")
+	probe()
+	probe()
+	if got := backendInfo(t, lb, "a").Weight; got != 5 {
+		t.Fatalf("expected a flapping hint to be ignored, got weight %d", got)
+	}
+	probe()
+	if got := backendInfo(t, lb, "a").Weight; got != 2 {
+		t.Fatalf("expected the hint to apply after 3 checks, clamped to 2, got %d", got)
+	}
+
+	// Malformed values leave the current state in place
+	hinting["a"].publish(HeaderWeightHint, "heavy", HeaderDrainHint, "soon")
+	probe()
+	probe()
+	probe()
+	if info := backendInfo(t, lb, "a"); info.Weight != 2 || info.Draining {
+		t.Errorf("expected malformed hints to be ignored, got %+v", info)
+	}
+}
+
+func TestBackendHintsIgnoredUnlessTrusted(t *testing.T) {
+	lb, hinting, probe := newHintTestLB(t, config.ActiveHealthCheckConfig{})
+
+	hinting["a"].publish(HeaderWeightHint, "3", HeaderDrainHint, "true")
+	probe()
+	if info := backendInfo(t, lb, "a"); info.Weight != 1 || info.WeightFromHint || info.Draining {
+		t.Errorf("expected hints to be ignored without trust_backend_hints, got %+v", info)
+	}
+}
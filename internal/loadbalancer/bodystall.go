@@ -0,0 +1,104 @@
+package loadbalancer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// errBodyStall is returned from a watched response body once the backend has
+// sent nothing for the configured idle period
+var errBodyStall = errors.New("backend response body stalled")
+
+// stallWatchdogBody wraps a backend response body and aborts the copy when a
+// single Read waits longer than idle for data. Only time spent blocked on the
+// backend counts, so a slow client or a long but steady download never trips it.
+type stallWatchdogBody struct {
+	body    io.ReadCloser
+	idle    time.Duration
+	timer   *time.Timer
+	stalled int32
+	onStall func()
+}
+
+func newStallWatchdogBody(body io.ReadCloser, idle time.Duration, onStall func()) *stallWatchdogBody {
+	w := &stallWatchdogBody{body: body, idle: idle, onStall: onStall}
+	w.timer = time.AfterFunc(idle, w.trip)
+	w.timer.Stop()
+	return w
+}
+
+// trip records the stall, then closes the backend body to unblock the pending Read
+func (w *stallWatchdogBody) trip() {
+	if !atomic.CompareAndSwapInt32(&w.stalled, 0, 1) {
+		return
+	}
+	w.onStall()
+	_ = w.body.Close()
+}
+
+func (w *stallWatchdogBody) Read(p []byte) (int, error) {
+	if atomic.LoadInt32(&w.stalled) == 1 {
+		return 0, errBodyStall
+	}
+	w.timer.Reset(w.idle)
+	n, err := w.body.Read(p)
+	w.timer.Stop()
+	if atomic.LoadInt32(&w.stalled) == 1 {
+		return n, errBodyStall
+	}
+	return n, err
+}
+
+func (w *stallWatchdogBody) Close() error {
+	w.timer.Stop()
+	return w.body.Close()
+}
+
+// watchBodyStalls returns a ReverseProxy ModifyResponse hook that guards the
+// response body of backend with an idle timeout
+func (lb *LoadBalancer) watchBodyStalls(backend *Backend, idle time.Duration) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		// Upgraded connections hand the body over as a raw stream
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			return nil
+		}
+		r := resp.Request
+		resp.Body = newStallWatchdogBody(resp.Body, idle, func() {
+			lb.handleBodyStall(backend, r, idle)
+		})
+		return nil
+	}
+}
+
+// handleBodyStall records a stalled response. Headers have already been sent,
+// so the client sees the connection close on a truncated body.
+func (lb *LoadBalancer) handleBodyStall(backend *Backend, r *http.Request, idle time.Duration) {
+	if lb.metricsCollector != nil {
+		lb.metricsCollector.RecordBackendBodyStall(backend.Name)
+	}
+	logging.WithContext(r.Context()).Warn().
+		Str("backend", backend.Name).
+		Str("path", r.URL.Path).
+		Dur("idle", idle).
+		Msg("backend response body stalled, aborting response")
+
+	if lb.healthChecks.passiveEnabled && lb.proxyConfig().BackendBodyStall.CountAsFailure {
+		lb.countPassiveFailure(backend)
+	}
+}
+
+// backendBodyTimeout returns how long a backend's response body may stall
+// before the copy is aborted (0 = no limit)
+func (lb *LoadBalancer) backendBodyTimeout(backendCfg config.BackendConfig) time.Duration {
+	seconds := backendCfg.BackendBodyTimeoutSeconds
+	if seconds <= 0 {
+		seconds = lb.proxyConfig().BackendBodyTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
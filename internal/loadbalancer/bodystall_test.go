@@ -0,0 +1,110 @@
+package loadbalancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// newBodyStallFrontend serves lb through a real HTTP server so that an aborted
+// response closes the client connection as it would in production
+func newBodyStallFrontend(t *testing.T, cfg *config.Config) (*LoadBalancer, *httptest.Server) {
+	t.Helper()
+	lb, err := NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	front := httptest.NewServer(lb)
+	t.Cleanup(front.Close)
+	return lb, front
+}
+
+func TestBodyStall_IdleBackendAborted(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	t.Cleanup(backend.Close)
+
+	lb, front := newBodyStallFrontend(t, &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Proxy: config.ProxyConfig{
+			BackendBodyTimeoutSeconds: 1,
+			BackendBodyStall:          config.BodyStallConfig{CountAsFailure: true},
+		},
+		HealthChecks: config.HealthChecksConfig{
+			Passive: config.PassiveHealthCheckConfig{Enabled: true, UnhealthyThreshold: 1, UnhealthyTimeout: 30},
+		},
+		Backends: []config.BackendConfig{{Name: "stalling", Address: backend.URL}},
+	})
+
+	start := time.Now()
+	resp, err := http.Get(front.URL)
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected a truncated response for a stalled backend")
+	}
+	if elapsed < 900*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("expected the stall to trip after roughly 1s, took %v", elapsed)
+	}
+
+	m := lb.GetMetricsCollector().GetMetrics().BackendMetrics["stalling"]
+	if m == nil || m.BodyStalls != 1 {
+		t.Fatalf("expected one body stall to be recorded, got %+v", m)
+	}
+	if lb.ListBackends()[0].Healthy {
+		t.Error("expected stall to count as a passive health check failure")
+	}
+}
+
+func TestBodyStall_SlowSteadyStreamCompletes(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 4; i++ {
+			_, _ = w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Second)
+		}
+	}))
+	t.Cleanup(backend.Close)
+
+	lb, front := newBodyStallFrontend(t, &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends: []config.BackendConfig{
+			// Per-backend override; total duration exceeds the idle period
+			{Name: "steady", Address: backend.URL, BackendBodyTimeoutSeconds: 2},
+		},
+	})
+
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("steady stream was aborted: %v", err)
+	}
+	if string(body) != "xxxx" {
+		t.Errorf("expected full body, got %q", body)
+	}
+
+	if m := lb.GetMetricsCollector().GetMetrics().BackendMetrics["steady"]; m != nil && m.BodyStalls != 0 {
+		t.Errorf("expected no body stalls, got %d", m.BodyStalls)
+	}
+}
@@ -0,0 +1,60 @@
+package loadbalancer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// bufferPool implements httputil.BufferPool with fixed-size buffers reused
+// across proxied responses
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+func newBufferPool(size int) *bufferPool {
+	p := &bufferPool{size: size}
+	p.pool.New = func() interface{} {
+		b := make([]byte, size)
+		return &b
+	}
+	return p
+}
+
+// Get returns a buffer of the pool's size
+func (p *bufferPool) Get() []byte {
+	return *p.pool.Get().(*[]byte)
+}
+
+// Put returns a buffer to the pool; buffers of another size are dropped
+func (p *bufferPool) Put(b []byte) {
+	if cap(b) != p.size {
+		return
+	}
+	b = b[:p.size]
+	p.pool.Put(&b)
+}
+
+// flushInterval returns the ReverseProxy FlushInterval for a backend; a
+// negative setting flushes after every write
+func (lb *LoadBalancer) flushInterval(backendCfg config.BackendConfig) time.Duration {
+	ms := backendCfg.FlushIntervalMs
+	if ms == 0 {
+		ms = lb.proxyConfig().FlushIntervalMs
+	}
+	if ms < 0 {
+		return -1 // flush after every write
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// bufferSize resolves the response copy buffer size in bytes for a backend (0 = Go's default)
+func (lb *LoadBalancer) bufferSize(backendCfg config.BackendConfig) int {
+	kb := backendCfg.BufferSizeKB
+	if kb == 0 {
+		kb = lb.proxyConfig().BufferSizeKB
+	}
+	return kb * 1024
+}
@@ -0,0 +1,157 @@
+package loadbalancer
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// newSSEFrontend proxies an SSE-style backend that emits one event and then
+// holds the stream open until release is closed
+func newSSEFrontend(t *testing.T, backendCfg config.BackendConfig, release <-chan struct{}) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("data: last\n\n"))
+	}))
+	t.Cleanup(backend.Close)
+
+	backendCfg.Name = "events"
+	backendCfg.Address = backend.URL
+	lb, err := NewLoadBalancer(&config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends:     []config.BackendConfig{backendCfg},
+	})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	front := httptest.NewServer(lb)
+	t.Cleanup(front.Close)
+	return front
+}
+
+// firstEvent reads the first SSE data line in the background
+func firstEvent(t *testing.T, url string) <-chan string {
+	t.Helper()
+	lines := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			lines <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		lines <- strings.TrimSpace(line)
+	}()
+	return lines
+}
+
+func TestFlushInterval_ImmediateDeliversEvents(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	front := newSSEFrontend(t, config.BackendConfig{FlushIntervalMs: -1}, release)
+
+	select {
+	case line := <-firstEvent(t, front.URL):
+		if line != "data: first" {
+			t.Fatalf("unexpected first line %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not flushed to the client while the stream stayed open")
+	}
+}
+
+func TestFlushInterval_DefaultBatchesEvents(t *testing.T) {
+	release := make(chan struct{})
+	front := newSSEFrontend(t, config.BackendConfig{}, release)
+
+	lines := firstEvent(t, front.URL)
+	select {
+	case line := <-lines:
+		t.Fatalf("expected events to be held until the response completed, got %q", line)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case line := <-lines:
+		if line != "data: first" {
+			t.Fatalf("unexpected first line %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("batched events never arrived")
+	}
+}
+
+func TestBufferPool_ReusesSizedBuffers(t *testing.T) {
+	p := newBufferPool(8 * 1024)
+	b := p.Get()
+	if len(b) != 8*1024 {
+		t.Fatalf("expected 8KB buffer, got %d", len(b))
+	}
+	p.Put(b[:10])
+	if got := p.Get(); len(got) != 8*1024 {
+		t.Fatalf("expected returned buffer to be restored to full length, got %d", len(got))
+	}
+
+	// Foreign buffers are dropped rather than handed out at the wrong size
+	p.Put(make([]byte, 512))
+	if got := p.Get(); len(got) != 8*1024 {
+		t.Fatalf("expected pool to keep its size, got %d", len(got))
+	}
+}
+
+// discardResponseWriter drops the body so benchmarks measure proxy allocations only
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header         { return d.header }
+func (d *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardResponseWriter) WriteHeader(int)             {}
+
+func BenchmarkProxyLargeResponse(b *testing.B) {
+	payload := []byte(strings.Repeat("x", 4*1024*1024))
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer backend.Close()
+
+	for _, bc := range []struct {
+		name         string
+		bufferSizeKB int
+	}{
+		{"default", 0},
+		{"pooled_64KB", 64},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			lb, err := NewLoadBalancer(&config.Config{
+				LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+				Backends:     []config.BackendConfig{{Name: "bulk", Address: backend.URL, BufferSizeKB: bc.bufferSizeKB}},
+			})
+			if err != nil {
+				b.Fatalf("failed to create lb: %v", err)
+			}
+			defer lb.Stop()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lb.ServeHTTP(&discardResponseWriter{header: make(http.Header)}, req)
+			}
+		})
+	}
+}
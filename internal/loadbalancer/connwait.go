@@ -0,0 +1,192 @@
+package loadbalancer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+const (
+	// errCodePoolExhausted is returned when no backend could supply a connection within max_conn_wait_ms
+	errCodePoolExhausted = "backend_pool_exhausted"
+
+	// defaultMaxConnsPerHost is the per-backend connection cap used when proxy.max_conns_per_host is unset
+	defaultMaxConnsPerHost = 100
+
+	// maxPoolFailoverAttempts bounds how many backends a request tries after pool exhaustion
+	maxPoolFailoverAttempts = 3
+)
+
+// errPoolExhausted signals that a backend attempt was abandoned while waiting
+// for a pooled connection. Nothing has been written to the client yet.
+var errPoolExhausted = errors.New("backend connection pool exhausted")
+
+// connWaitState tracks connection acquisition; the zero value means no attempt has started
+type connWaitState int
+
+const (
+	connWaitPending connWaitState = iota + 1
+	connWaitAcquired
+	connWaitExpired
+)
+
+// connWaitTracker measures how long a proxied request waits for a connection
+// from the transport pool and cancels the attempt once the wait budget is spent.
+type connWaitTracker struct {
+	mu      sync.Mutex
+	state   connWaitState
+	start   time.Time
+	wait    time.Duration
+	timer   *time.Timer
+	maxWait time.Duration
+	cancel  context.CancelFunc
+}
+
+type connWaitKey struct{}
+
+// withConnWaitTracking returns a copy of r instrumented with a connWaitTracker.
+// The returned cancel function must be called when the attempt is finished.
+func withConnWaitTracking(r *http.Request, maxWait time.Duration) (*http.Request, *connWaitTracker, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	t := &connWaitTracker{maxWait: maxWait, cancel: cancel}
+
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { t.getConn() },
+		GotConn: func(httptrace.GotConnInfo) { t.gotConn() },
+	}
+	ctx = context.WithValue(ctx, connWaitKey{}, t)
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
+	return r.WithContext(ctx), t, func() {
+		t.stopTimer()
+		cancel()
+	}
+}
+
+// connWaitFromContext returns the tracker attached to a proxied request, if any
+func connWaitFromContext(ctx context.Context) *connWaitTracker {
+	t, _ := ctx.Value(connWaitKey{}).(*connWaitTracker)
+	return t
+}
+
+func (t *connWaitTracker) getConn() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state == connWaitExpired {
+		return
+	}
+	t.state = connWaitPending
+	t.start = time.Now()
+	if t.maxWait > 0 {
+		if t.timer != nil {
+			t.timer.Stop()
+		}
+		t.timer = time.AfterFunc(t.maxWait, t.expire)
+	}
+}
+
+func (t *connWaitTracker) gotConn() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state != connWaitPending {
+		return
+	}
+	t.state = connWaitAcquired
+	t.wait = time.Since(t.start)
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func (t *connWaitTracker) expire() {
+	t.mu.Lock()
+	if t.state != connWaitPending {
+		t.mu.Unlock()
+		return
+	}
+	t.state = connWaitExpired
+	t.wait = time.Since(t.start)
+	t.mu.Unlock()
+	t.cancel()
+}
+
+func (t *connWaitTracker) stopTimer() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// result reports the measured wait and whether a connection was acquired or the budget expired
+func (t *connWaitTracker) result() (wait time.Duration, acquired, expired bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.wait, t.state == connWaitAcquired, t.state == connWaitExpired
+}
+
+// expired reports whether the attempt was cancelled for exceeding the wait budget
+func (t *connWaitTracker) expired() bool {
+	_, _, expired := t.result()
+	return expired
+}
+
+// recordConnWait records the connection wait for an attempt and logs slow or
+// abandoned acquisitions. Returns errPoolExhausted if the attempt was abandoned.
+func (lb *LoadBalancer) recordConnWait(backend *Backend, t *connWaitTracker, r *http.Request) error {
+	wait, acquired, expired := t.result()
+	logger := logging.WithContext(r.Context())
+
+	if expired {
+		lb.metricsCollector.RecordBackendPoolExhausted(backend.Name)
+		logger.Warn().
+			Str("backend", backend.Name).
+			Int32("active_connections", backend.GetActiveConnections()).
+			Dur("conn_wait", wait).
+			Msg("backend connection pool exhausted, abandoning attempt")
+		return errPoolExhausted
+	}
+	if !acquired {
+		return nil
+	}
+
+	warnAfter := time.Duration(lb.proxyConfig().ConnWaitWarnMs) * time.Millisecond
+	exceeded := warnAfter > 0 && wait > warnAfter
+	lb.metricsCollector.RecordBackendConnWait(backend.Name, wait, exceeded)
+	if exceeded {
+		logger.Warn().
+			Str("backend", backend.Name).
+			Int32("active_connections", backend.GetActiveConnections()).
+			Dur("conn_wait", wait).
+			Msg("slow backend connection acquisition")
+	}
+	return nil
+}
+
+// canFailover reports whether a request can be replayed against another
+// backend. Requests with a body cannot, because the transport closes it.
+func canFailover(r *http.Request) bool {
+	return r.ContentLength == 0
+}
+
+// maxConnsPerHost resolves the per-backend connection cap
+func (lb *LoadBalancer) maxConnsPerHost() int {
+	if n := lb.proxyConfig().MaxConnsPerHost; n > 0 {
+		return n
+	}
+	return defaultMaxConnsPerHost
+}
+
+// proxyConfig returns the proxy settings, tolerating a load balancer built without config
+func (lb *LoadBalancer) proxyConfig() config.ProxyConfig {
+	if lb.config == nil {
+		return config.ProxyConfig{}
+	}
+	return lb.config.Proxy
+}
@@ -0,0 +1,159 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// newBlockingBackend starts a backend that signals each arrival and holds the
+// response until release is closed
+func newBlockingBackend(t *testing.T, arrived chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte("slow"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newConnWaitLB(t *testing.T, proxyCfg config.ProxyConfig, backends ...config.BackendConfig) *LoadBalancer {
+	t.Helper()
+	cfg := &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Proxy:        proxyCfg,
+		Backends:     backends,
+	}
+	lb, err := NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb
+}
+
+// saturate issues requests in the background until the slow backend holds n
+// in-flight requests. Requests routed elsewhere complete normally.
+func saturate(t *testing.T, lb *LoadBalancer, arrived <-chan struct{}, n int, wg *sync.WaitGroup) {
+	t.Helper()
+	for held := 0; held < n; {
+		done := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done)
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+		select {
+		case <-arrived:
+			held++
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out saturating backend")
+		}
+	}
+}
+
+func TestConnWait_MetricsRise(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	lb := newConnWaitLB(t, config.ProxyConfig{MaxConnsPerHost: 2, ConnWaitWarnMs: 50},
+		config.BackendConfig{Name: "slow", Address: srv.URL})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+
+	m := lb.GetMetricsCollector().GetMetrics().BackendMetrics["slow"]
+	if m == nil {
+		t.Fatal("expected metrics for backend")
+	}
+	if m.ConnWaitP95Ms < 50 {
+		t.Errorf("expected p95 connection wait above 50ms, got %.1f", m.ConnWaitP95Ms)
+	}
+	if m.ConnWaitMs <= 0 {
+		t.Errorf("expected positive average connection wait, got %.1f", m.ConnWaitMs)
+	}
+	if m.ConnWaitWarnings == 0 {
+		t.Error("expected slow connection acquisitions to be counted")
+	}
+}
+
+func TestConnWait_FailoverToSecondBackend(t *testing.T) {
+	arrived := make(chan struct{}, 8)
+	release := make(chan struct{})
+	slow := newBlockingBackend(t, arrived, release)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fast"))
+	}))
+	t.Cleanup(fast.Close)
+
+	lb := newConnWaitLB(t, config.ProxyConfig{MaxConnsPerHost: 2, MaxConnWaitMs: 100},
+		config.BackendConfig{Name: "slow", Address: slow.URL},
+		config.BackendConfig{Name: "fast", Address: fast.URL})
+
+	var wg sync.WaitGroup
+	saturate(t, lb, arrived, 2, &wg)
+
+	// Both backends get picked in turn; none of these may stall on the slow pool
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "fast" {
+			t.Fatalf("request %d: expected 200 from fast backend, got %d %q", i, rec.Code, rec.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("request %d stalled for %v", i, elapsed)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+
+	m := lb.GetMetricsCollector().GetMetrics().BackendMetrics["slow"]
+	if m == nil || m.PoolExhaustions == 0 {
+		t.Fatalf("expected pool exhaustion to be recorded for slow backend, got %+v", m)
+	}
+}
+
+func TestConnWait_PoolExhaustedResponse(t *testing.T) {
+	arrived := make(chan struct{}, 8)
+	release := make(chan struct{})
+	slow := newBlockingBackend(t, arrived, release)
+
+	lb := newConnWaitLB(t, config.ProxyConfig{MaxConnsPerHost: 1, MaxConnWaitMs: 50},
+		config.BackendConfig{Name: "slow", Address: slow.URL})
+
+	var wg sync.WaitGroup
+	saturate(t, lb, arrived, 1, &wg)
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	close(release)
+	wg.Wait()
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Helios-Error"); got != errCodePoolExhausted {
+		t.Errorf("expected error code %s, got %q", errCodePoolExhausted, got)
+	}
+}
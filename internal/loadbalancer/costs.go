@@ -0,0 +1,137 @@
+package loadbalancer
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/proxyinfo"
+)
+
+// costRule attributes matching requests to a label
+type costRule struct {
+	label        string
+	pathPrefixes []string
+	headers      map[string]string // canonical header name -> required value
+}
+
+// matches reports whether r satisfies every condition of the rule
+func (cr *costRule) matches(r *http.Request) bool {
+	if len(cr.pathPrefixes) > 0 {
+		matched := false
+		for _, prefix := range cr.pathPrefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for name, value := range cr.headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// costAttributor resolves the cost_attribution label of requests
+type costAttributor struct {
+	rules []costRule
+}
+
+// newCostAttributor compiles the configured rules, or returns nil when
+// cost_attribution is not configured
+func newCostAttributor(cfg config.CostAttributionConfig) *costAttributor {
+	if len(cfg.Rules) == 0 {
+		return nil
+	}
+	ca := &costAttributor{}
+	for _, rc := range cfg.Rules {
+		rule := costRule{label: rc.Label, pathPrefixes: rc.PathPrefixes}
+		if len(rc.Headers) > 0 {
+			rule.headers = make(map[string]string, len(rc.Headers))
+			for name, value := range rc.Headers {
+				rule.headers[http.CanonicalHeaderKey(name)] = value
+			}
+		}
+		ca.rules = append(ca.rules, rule)
+	}
+	return ca
+}
+
+// labels returns every label a request can be attributed to
+func (ca *costAttributor) labels() []string {
+	labels := []string{config.UnattributedCostLabel}
+	seen := map[string]bool{config.UnattributedCostLabel: true}
+	for _, rule := range ca.rules {
+		if !seen[rule.label] {
+			seen[rule.label] = true
+			labels = append(labels, rule.label)
+		}
+	}
+	return labels
+}
+
+// resolve returns the label of the first rule matching r
+func (ca *costAttributor) resolve(r *http.Request) string {
+	for i := range ca.rules {
+		if ca.rules[i].matches(r) {
+			return ca.rules[i].label
+		}
+	}
+	return config.UnattributedCostLabel
+}
+
+// countingBody counts the request body bytes read by the proxy
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.n += int64(n)
+	return n, err
+}
+
+// attributeCost resolves the request's cost label and attaches it to the
+// request context. The returned function records the request's usage once
+// it has been served.
+func (lb *LoadBalancer) attributeCost(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	info := proxyinfo.FromContext(r.Context())
+	if info == nil {
+		info = &proxyinfo.Info{}
+		r = r.WithContext(proxyinfo.NewContext(r.Context(), info))
+	}
+	info.CostLabel = lb.costs.resolve(r)
+
+	var body *countingBody
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &countingBody{ReadCloser: r.Body}
+		r.Body = body
+	}
+	rec := proxyinfo.Wrap(w)
+	start := rec.BytesWritten()
+
+	return rec, r, func() {
+		var bytesIn int64
+		if body != nil {
+			bytesIn = body.n
+		}
+		lb.metricsCollector.RecordCost(info.CostLabel, bytesIn, rec.BytesWritten()-start, info.UpstreamLatency, rec.Status() >= 500)
+	}
+}
+
+// CostReport returns the usage attributed to each cost_attribution label,
+// or false when cost attribution is not configured
+func (lb *LoadBalancer) CostReport() (map[string]metrics.CostMetrics, bool) {
+	if lb.costs == nil {
+		return nil, false
+	}
+	return lb.metricsCollector.GetCostMetrics(), true
+}
@@ -0,0 +1,95 @@
+package loadbalancer
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the load balancer
+const (
+	EventBackendAdded        = "backend_added"
+	EventBackendRemoved      = "backend_removed"
+	EventBackendHealthy      = "backend_healthy"
+	EventBackendUnhealthy    = "backend_unhealthy"
+	EventWeightChanged       = "weight_changed"
+	EventBackendDraining     = "backend_draining"
+	EventBackendDrainCleared = "backend_drain_cleared"
+	EventStrategyChanged     = "strategy_changed"
+	EventGroupFailover       = "group_failover"
+	EventGroupFailback       = "group_failback"
+)
+
+// defaultEventBuffer is the per-subscriber channel capacity
+const defaultEventBuffer = 64
+
+// Event describes a state change in the load balancer
+type Event struct {
+	Type    string    `json:"type"`
+	Backend string    `json:"backend,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// eventBus fans out events to subscribers without ever blocking the publisher.
+// Slow subscribers miss events rather than stalling the request path.
+type eventBus struct {
+	mu     sync.RWMutex
+	subs   map[uint64]chan Event
+	nextID uint64
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[uint64]chan Event)}
+}
+
+// subscribe registers a new subscriber and returns its channel and a cancel function
+func (eb *eventBus) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, defaultEventBuffer)
+
+	eb.mu.Lock()
+	id := eb.nextID
+	eb.nextID++
+	eb.subs[id] = ch
+	eb.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			eb.mu.Lock()
+			delete(eb.subs, id)
+			eb.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// publish delivers an event to every subscriber that has room for it
+func (eb *eventBus) publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	for _, ch := range eb.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns a channel of load balancer events and a function
+// that must be called to unsubscribe once the caller is done
+func (lb *LoadBalancer) SubscribeEvents() (<-chan Event, func()) {
+	return lb.events.subscribe()
+}
+
+// publishEvent emits an event if the event bus is initialised
+func (lb *LoadBalancer) publishEvent(eventType, backend, message string) {
+	if lb.events == nil {
+		return
+	}
+	lb.events.publish(Event{Type: eventType, Backend: backend, Message: message})
+}
@@ -0,0 +1,58 @@
+package loadbalancer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func TestEventsPublishedForRuntimeChanges(t *testing.T) {
+	lb, err := NewLoadBalancer(&config.Config{LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"}})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+
+	events, cancel := lb.SubscribeEvents()
+	defer cancel()
+
+	if err := lb.AddBackend(config.BackendConfig{Name: "b1", Address: "http://127.0.0.1:65010"}); err != nil {
+		t.Fatalf("add backend failed: %v", err)
+	}
+	if err := lb.SetBackendWeight("b1", 3); err != nil {
+		t.Fatalf("set weight failed: %v", err)
+	}
+	if err := lb.SetStrategy("weighted_round_robin"); err != nil {
+		t.Fatalf("set strategy failed: %v", err)
+	}
+	lb.RemoveBackend("b1")
+
+	want := []string{EventBackendAdded, EventWeightChanged, EventStrategyChanged, EventBackendRemoved}
+	for _, typ := range want {
+		select {
+		case ev := <-events:
+			if ev.Type != typ {
+				t.Fatalf("expected event %q, got %q", typ, ev.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", typ)
+		}
+	}
+}
+
+func TestSetBackendWeightErrors(t *testing.T) {
+	lb, err := NewLoadBalancer(&config.Config{LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"}})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+
+	if err := lb.SetBackendWeight("missing", 2); !errors.Is(err, ErrBackendNotFound) {
+		t.Fatalf("expected ErrBackendNotFound, got %v", err)
+	}
+	if err := lb.SetBackendWeight("missing", 0); err == nil || errors.Is(err, ErrBackendNotFound) {
+		t.Fatalf("expected weight validation error, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// backendGroup is a set of backends routed to as a unit, with its own
+// instance of the load balancing strategy
+type backendGroup struct {
+	name          string
+	priority      int
+	minHealthy    int
+	failbackDelay time.Duration
+	strategy      Strategy // guarded by LoadBalancer.mutex, like LoadBalancer.strategy
+
+	// available counts members taking traffic, kept current on health and
+	// drain changes so routing doesn't scan every member per request
+	available atomic.Int32
+}
+
+// groupRouter sends traffic to the most preferred backend group that has
+// enough healthy members. Failing over to a less preferred group is
+// immediate; failing back waits out the preferred group's failback delay so
+// a flapping backend doesn't bounce traffic between groups.
+type groupRouter struct {
+	groups   []*backendGroup // most preferred first; the implicit default group last
+	memberOf map[string]*backendGroup
+	env      StrategyEnv // for the strategy instances built per group
+
+	// recoverAt is the earliest time, in Unix nanoseconds, a member's
+	// unhealthy period runs out (0 = none pending). Expired members are
+	// restored then, as a request reaching them would have done.
+	recoverAt atomic.Int64
+
+	mu           sync.Mutex
+	active       *backendGroup
+	pending      *backendGroup // preferred group waiting out its failback delay
+	pendingSince time.Time
+	now          func() time.Time
+}
+
+// newGroupRouter builds the groups configured under backend_groups, or
+// returns nil when none are configured
+func newGroupRouter(cfg *config.Config, env StrategyEnv) (*groupRouter, error) {
+	if len(cfg.BackendGroups) == 0 {
+		return nil, nil
+	}
+	gr := &groupRouter{memberOf: make(map[string]*backendGroup), env: env, now: time.Now}
+	for _, gc := range cfg.BackendGroups {
+		minHealthy := gc.MinHealthy
+		if minHealthy <= 0 {
+			minHealthy = 1
+		}
+		g := &backendGroup{
+			name:          gc.Name,
+			priority:      gc.Priority,
+			minHealthy:    minHealthy,
+			failbackDelay: time.Duration(gc.FailbackDelaySeconds) * time.Second,
+		}
+		for _, member := range gc.Members {
+			gr.memberOf[member] = g
+		}
+		gr.groups = append(gr.groups, g)
+	}
+	sort.Slice(gr.groups, func(i, j int) bool { return gr.groups[i].priority < gr.groups[j].priority })
+	gr.groups = append(gr.groups, &backendGroup{name: config.DefaultBackendGroup, priority: math.MaxInt, minHealthy: 1})
+	// Backends start out healthy, so traffic starts on the most preferred group
+	gr.active = gr.groups[0]
+
+	name := cfg.LoadBalancer.Strategy
+	if name == "" {
+		name = defaultStrategy
+	}
+	if err := gr.setStrategy(name, cfg.LoadBalancer.StrategyConfig[name]); err != nil {
+		return nil, err
+	}
+	return gr, nil
+}
+
+// groupOf returns the group a backend belongs to
+func (gr *groupRouter) groupOf(name string) *backendGroup {
+	if g, ok := gr.memberOf[name]; ok {
+		return g
+	}
+	return gr.groups[len(gr.groups)-1]
+}
+
+// setStrategy gives every group a new instance of the named strategy,
+// keeping its members. Caller must hold LoadBalancer.mutex for writing.
+func (gr *groupRouter) setStrategy(name string, options map[string]interface{}) error {
+	strategies := make([]Strategy, len(gr.groups))
+	for i := range gr.groups {
+		s, err := NewStrategy(name, options, gr.env)
+		if err != nil {
+			return err
+		}
+		strategies[i] = s
+	}
+	for i, g := range gr.groups {
+		if g.strategy != nil {
+			for _, b := range g.strategy.GetBackends() {
+				strategies[i].AddBackend(b)
+			}
+		}
+		g.strategy = strategies[i]
+	}
+	return nil
+}
+
+// addBackend adds a backend to its group. Caller must hold LoadBalancer.mutex for writing.
+func (gr *groupRouter) addBackend(backend *Backend) {
+	g := gr.groupOf(backend.Name)
+	g.strategy.AddBackend(backend)
+
+	backend.Mutex.Lock()
+	backend.group = g
+	gr.syncLocked(backend)
+	backend.Mutex.Unlock()
+}
+
+// removeBackend removes a backend from its group. Caller must hold LoadBalancer.mutex for writing.
+func (gr *groupRouter) removeBackend(backend *Backend) {
+	gr.groupOf(backend.Name).strategy.RemoveBackend(backend)
+
+	backend.Mutex.Lock()
+	if backend.counted {
+		backend.group.available.Add(-1)
+	}
+	backend.group, backend.counted = nil, false
+	backend.Mutex.Unlock()
+}
+
+// syncLocked brings the backend's group count in line with its health and
+// drain state, and schedules its recovery if it is down for a timed period.
+// Caller must hold backend.Mutex for writing.
+func (gr *groupRouter) syncLocked(backend *Backend) {
+	if backend.group == nil {
+		return
+	}
+	available := backend.IsHealthy && !backend.hints.draining
+	if available != backend.counted {
+		backend.counted = available
+		if available {
+			backend.group.available.Add(1)
+		} else {
+			backend.group.available.Add(-1)
+		}
+	}
+	if !backend.IsHealthy && !backend.heldDown {
+		gr.wakeAt(backend.UnhealthyUntil)
+	}
+}
+
+// wakeAt moves recoverAt forward to t if t comes sooner
+func (gr *groupRouter) wakeAt(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	at := t.UnixNano()
+	for {
+		cur := gr.recoverAt.Load()
+		if cur != 0 && cur <= at {
+			return
+		}
+		if gr.recoverAt.CompareAndSwap(cur, at) {
+			return
+		}
+	}
+}
+
+// activeName returns the name of the group currently taking traffic
+func (gr *groupRouter) activeName() string {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+	return gr.active.name
+}
+
+// activeBackends returns the members of the group currently taking traffic.
+// Caller must hold LoadBalancer.mutex for reading.
+func (gr *groupRouter) activeBackends() []*Backend {
+	gr.mu.Lock()
+	active := gr.active
+	gr.mu.Unlock()
+	return active.strategy.GetBackends()
+}
+
+// choose settles on the group to route to given each group's healthy member
+// count, switching groups as needed. It returns the chosen group and the
+// group it replaced, if any.
+func (gr *groupRouter) choose(healthy []int) (chosen, previous *backendGroup) {
+	best := -1
+	for i, g := range gr.groups {
+		if healthy[i] >= g.minHealthy {
+			best = i
+			break
+		}
+	}
+	if best < 0 {
+		// No group meets its minimum: degrade to any group with a healthy member
+		for i := range gr.groups {
+			if healthy[i] > 0 {
+				best = i
+				break
+			}
+		}
+	}
+
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	cur := gr.active
+	if best < 0 {
+		// Nothing is healthy: stay put
+		return cur, nil
+	}
+	candidate := gr.groups[best]
+
+	switch {
+	case candidate == cur:
+		gr.pending = nil
+		return cur, nil
+	case candidate.priority < cur.priority && gr.isEligible(cur, healthy) && candidate.failbackDelay > 0:
+		// Fail back only once the preferred group has stayed healthy for its delay
+		now := gr.now()
+		if gr.pending != candidate {
+			gr.pending, gr.pendingSince = candidate, now
+			return cur, nil
+		}
+		if now.Sub(gr.pendingSince) < candidate.failbackDelay {
+			return cur, nil
+		}
+	}
+
+	gr.active, gr.pending = candidate, nil
+	return candidate, cur
+}
+
+// isEligible reports whether a group meets its healthy member minimum
+func (gr *groupRouter) isEligible(g *backendGroup, healthy []int) bool {
+	for i, other := range gr.groups {
+		if other == g {
+			return healthy[i] >= g.minHealthy
+		}
+	}
+	return false
+}
+
+// nextGroupBackend picks a backend from the group currently taking traffic.
+// It reads each group's maintained count rather than scanning members.
+// Caller must hold lb.mutex for reading.
+func (lb *LoadBalancer) nextGroupBackend(r *http.Request) *Backend {
+	gr := lb.groups
+	if at := gr.recoverAt.Load(); at != 0 && time.Now().UnixNano() >= at {
+		lb.recoverGroupMembers()
+	}
+	healthy := make([]int, len(gr.groups))
+	for i, g := range gr.groups {
+		healthy[i] = int(g.available.Load())
+	}
+
+	group, previous := gr.choose(healthy)
+	if previous != nil {
+		lb.announceGroupSwitch(previous, group)
+	}
+	return group.strategy.NextBackend(r)
+}
+
+// recoverGroupMembers restores members whose unhealthy period has run out
+// and schedules the next recovery. Only one goroutine sweeps per deadline.
+// Caller must hold lb.mutex for reading.
+func (lb *LoadBalancer) recoverGroupMembers() {
+	gr := lb.groups
+	if gr.recoverAt.Swap(0) == 0 {
+		return
+	}
+	for _, g := range gr.groups {
+		for _, b := range g.strategy.GetBackends() {
+			if lb.IsBackendHealthy(b) {
+				continue
+			}
+			// Still down: keep its deadline scheduled
+			b.Mutex.RLock()
+			if !b.heldDown {
+				gr.wakeAt(b.UnhealthyUntil)
+			}
+			b.Mutex.RUnlock()
+		}
+	}
+}
+
+// announceGroupSwitch logs, records and publishes a change of active group
+func (lb *LoadBalancer) announceGroupSwitch(from, to *backendGroup) {
+	eventType := EventGroupFailover
+	if to.priority < from.priority {
+		eventType = EventGroupFailback
+	}
+	logging.L().Warn().Str("from", from.name).Str("to", to.name).Str("event", eventType).Msg("active backend group changed")
+	if lb.metricsCollector != nil {
+		lb.metricsCollector.SetActiveBackendGroup(lb.groups.activeName())
+	}
+	lb.publishEvent(eventType, "", fmt.Sprintf("%s -> %s", from.name, to.name))
+}
+
+// ActiveBackendGroup returns the backend group currently taking traffic, or
+// "" when backend_groups is not configured
+func (lb *LoadBalancer) ActiveBackendGroup() string {
+	if lb.groups == nil {
+		return ""
+	}
+	return lb.groups.activeName()
+}
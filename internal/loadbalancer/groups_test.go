@@ -0,0 +1,280 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// fakeClock is a manually advanced clock for failback delays
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// newGroupTestLB creates primaries p1, p2 and standbys s1, s2, plus an
+// ungrouped backend u1 when withDefault is set
+func newGroupTestLB(t *testing.T, failbackDelay int, withDefault bool) (*LoadBalancer, *fakeClock) {
+	t.Helper()
+	names := []string{"p1", "p2", "s1", "s2"}
+	if withDefault {
+		names = append(names, "u1")
+	}
+	var backends []config.BackendConfig
+	for _, name := range names {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		t.Cleanup(server.Close)
+		backends = append(backends, config.BackendConfig{Name: name, Address: server.URL})
+	}
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends: backends,
+		BackendGroups: []config.BackendGroupConfig{
+			// Listed out of order: priority decides
+			{Name: "standby", Priority: 2, Members: []string{"s1", "s2"}},
+			{Name: "primary", Priority: 1, Members: []string{"p1", "p2"}, FailbackDelaySeconds: failbackDelay},
+		},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	clock := &fakeClock{now: time.Now()}
+	lb.groups.now = clock.Now
+	return lb, clock
+}
+
+func groupBackend(t *testing.T, lb *LoadBalancer, name string) *Backend {
+	t.Helper()
+	for _, b := range lb.strategy.GetBackends() {
+		if b.Name == name {
+			return b
+		}
+	}
+	t.Fatalf("backend %s not found", name)
+	return nil
+}
+
+func restoreBackend(lb *LoadBalancer, b *Backend) {
+	b.Mutex.Lock()
+	b.UnhealthyUntil = time.Time{}
+	lb.applyHealthLocked(b, true, false, time.Time{})
+	b.Mutex.Unlock()
+}
+
+// expectRoutedTo routes n requests and fails if any lands outside names
+func expectRoutedTo(t *testing.T, lb *LoadBalancer, n int, names ...string) {
+	t.Helper()
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[name] = true
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < n; i++ {
+		b := lb.findHealthyBackend(req, nil)
+		if b == nil {
+			t.Fatalf("request %d: no backend selected, want one of %v", i, names)
+		}
+		if !allowed[b.Name] {
+			t.Fatalf("request %d: routed to %s, want one of %v", i, b.Name, names)
+		}
+	}
+}
+
+func TestBackendGroupsPrimaryTakesAllTraffic(t *testing.T) {
+	lb, _ := newGroupTestLB(t, 0, true)
+
+	expectRoutedTo(t, lb, 20, "p1", "p2")
+	if got := lb.ActiveBackendGroup(); got != "primary" {
+		t.Errorf("expected primary to be active, got %q", got)
+	}
+
+	// One primary down is still enough with min_healthy 1
+	lb.MarkBackendUnhealthy(groupBackend(t, lb, "p1"), time.Hour)
+	expectRoutedTo(t, lb, 10, "p2")
+
+	groups := make(map[string]string)
+	for _, info := range lb.ListBackends() {
+		groups[info.Name] = info.Group
+	}
+	want := map[string]string{"p1": "primary", "p2": "primary", "s1": "standby", "s2": "standby", "u1": config.DefaultBackendGroup}
+	for name, group := range want {
+		if groups[name] != group {
+			t.Errorf("expected %s in group %s, got %q", name, group, groups[name])
+		}
+	}
+}
+
+func TestBackendGroupsFailoverAndFailback(t *testing.T) {
+	lb, clock := newGroupTestLB(t, 30, true)
+	events, cancel := lb.SubscribeEvents()
+	defer cancel()
+
+	p1, p2 := groupBackend(t, lb, "p1"), groupBackend(t, lb, "p2")
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	lb.MarkBackendUnhealthy(p2, time.Hour)
+
+	expectRoutedTo(t, lb, 10, "s1", "s2")
+	if got := lb.ActiveBackendGroup(); got != "standby" {
+		t.Fatalf("expected standby to be active, got %q", got)
+	}
+	if got := lb.GetMetricsCollector().GetMetrics().ActiveBackendGroup; got != "standby" {
+		t.Errorf("expected metrics to report standby, got %q", got)
+	}
+	expectGroupEvent(t, events, EventGroupFailover, "primary -> standby")
+
+	// A recovered primary must stay healthy for the failback delay
+	restoreBackend(lb, p1)
+	expectRoutedTo(t, lb, 5, "s1", "s2")
+	clock.Advance(20 * time.Second)
+	expectRoutedTo(t, lb, 5, "s1", "s2")
+
+	// Flapping restarts the window
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	expectRoutedTo(t, lb, 5, "s1", "s2")
+	restoreBackend(lb, p1)
+	expectRoutedTo(t, lb, 5, "s1", "s2")
+	clock.Advance(20 * time.Second)
+	expectRoutedTo(t, lb, 5, "s1", "s2")
+
+	clock.Advance(11 * time.Second)
+	expectRoutedTo(t, lb, 5, "p1")
+	if got := lb.ActiveBackendGroup(); got != "primary" {
+		t.Fatalf("expected failback to primary, got %q", got)
+	}
+	expectGroupEvent(t, events, EventGroupFailback, "standby -> primary")
+
+	// Standbys down too while primaries are out: ungrouped backends take over
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	lb.MarkBackendUnhealthy(groupBackend(t, lb, "s1"), time.Hour)
+	lb.MarkBackendUnhealthy(groupBackend(t, lb, "s2"), time.Hour)
+	expectRoutedTo(t, lb, 5, "u1")
+	if got := lb.ActiveBackendGroup(); got != config.DefaultBackendGroup {
+		t.Errorf("expected the default group to be active, got %q", got)
+	}
+}
+
+func TestBackendGroupsImmediateFailbackWithoutDelay(t *testing.T) {
+	lb, _ := newGroupTestLB(t, 0, false)
+	p1, p2 := groupBackend(t, lb, "p1"), groupBackend(t, lb, "p2")
+
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	lb.MarkBackendUnhealthy(p2, time.Hour)
+	expectRoutedTo(t, lb, 4, "s1", "s2")
+
+	restoreBackend(lb, p2)
+	expectRoutedTo(t, lb, 4, "p2")
+}
+
+func TestBackendGroupsStrategySwitchKeepsMembership(t *testing.T) {
+	lb, _ := newGroupTestLB(t, 0, false)
+	if err := lb.SetStrategy("least_connections"); err != nil {
+		t.Fatalf("SetStrategy: %v", err)
+	}
+	expectRoutedTo(t, lb, 10, "p1", "p2")
+
+	lb.RemoveBackend("p1")
+	lb.MarkBackendUnhealthy(groupBackend(t, lb, "p2"), time.Hour)
+	expectRoutedTo(t, lb, 4, "s1", "s2")
+}
+
+func expectGroupEvent(t *testing.T, events <-chan Event, eventType, message string) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == eventType {
+				if ev.Message != message {
+					t.Errorf("expected %s event %q, got %q", eventType, message, ev.Message)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatalf("no %s event published", eventType)
+		}
+	}
+}
+
+// groupCounts reads the maintained available-member count of every group
+func groupCounts(lb *LoadBalancer) map[string]int {
+	counts := make(map[string]int)
+	for _, g := range lb.groups.groups {
+		counts[g.name] = int(g.available.Load())
+	}
+	return counts
+}
+
+func TestBackendGroupsAvailableCountsTrackTransitions(t *testing.T) {
+	lb, _ := newGroupTestLB(t, 0, true)
+	lb.healthChecks.trustHints = true
+	lb.healthChecks.hintMinChecks = 0
+	expect := func(primary, standby, ungrouped int) {
+		t.Helper()
+		got := groupCounts(lb)
+		want := map[string]int{"primary": primary, "standby": standby, config.DefaultBackendGroup: ungrouped}
+		for name, n := range want {
+			if got[name] != n {
+				t.Fatalf("expected %d available in %s, got %d (%v)", n, name, got[name], got)
+			}
+		}
+	}
+	expect(2, 2, 1)
+
+	p1 := groupBackend(t, lb, "p1")
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	expect(1, 2, 1)
+	// Tripping an unhealthy backend again must not count twice
+	lb.MarkBackendUnhealthy(p1, time.Hour)
+	expect(1, 2, 1)
+
+	s1 := groupBackend(t, lb, "s1")
+	lb.applyBackendHints(s1, http.Header{"X-Helios-Drain": []string{"true"}})
+	expect(1, 1, 1)
+	lb.applyBackendHints(s1, http.Header{})
+	expect(1, 2, 1)
+
+	restoreBackend(lb, p1)
+	expect(2, 2, 1)
+
+	lb.RemoveBackend("u1")
+	expect(2, 2, 0)
+	if err := lb.AddBackend(config.BackendConfig{Name: "u1", Address: "http://127.0.0.1:65010"}); err != nil {
+		t.Fatalf("AddBackend: %v", err)
+	}
+	expect(2, 2, 1)
+}
+
+func TestBackendGroupsTimedTripRecoversWithoutTraffic(t *testing.T) {
+	lb, _ := newGroupTestLB(t, 0, false)
+	p1, p2 := groupBackend(t, lb, "p1"), groupBackend(t, lb, "p2")
+
+	// Standby takes traffic, so no request reaches the primaries to restore them
+	lb.MarkBackendUnhealthy(p1, 20*time.Millisecond)
+	lb.MarkBackendUnhealthy(p2, time.Hour)
+	expectRoutedTo(t, lb, 4, "s1", "s2")
+
+	time.Sleep(30 * time.Millisecond)
+	expectRoutedTo(t, lb, 4, "p1")
+	if got := groupCounts(lb)["primary"]; got != 1 {
+		t.Fatalf("expected p1 to be counted again after its unhealthy period, got %d", got)
+	}
+}
@@ -0,0 +1,114 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+// newHeaderBackend starts a backend that emits a Set-Cookie header of the given size
+func newHeaderBackend(t *testing.T, headerSize int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session="+strings.Repeat("a", headerSize))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newHeaderLimitLB(t *testing.T, global int64, backends ...config.BackendConfig) *LoadBalancer {
+	t.Helper()
+	cfg := &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Proxy:        config.ProxyConfig{MaxResponseHeaderBytes: global},
+		HealthChecks: config.HealthChecksConfig{
+			Passive: config.PassiveHealthCheckConfig{Enabled: true, UnhealthyThreshold: 1, UnhealthyTimeout: 30},
+		},
+		Backends: backends,
+	}
+	lb, err := NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb
+}
+
+func TestMaxResponseHeaderBytes_Overflow(t *testing.T) {
+	srv := newHeaderBackend(t, 8*1024)
+	lb := newHeaderLimitLB(t, 4*1024, config.BackendConfig{Name: "noisy", Address: srv.URL})
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	var body utils.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body %q: %v", rec.Body.String(), err)
+	}
+	if body.Code != errCodeHeaderOverflow {
+		t.Errorf("expected code %s, got %s", errCodeHeaderOverflow, body.Code)
+	}
+
+	bm := lb.GetMetricsCollector().GetMetrics().BackendMetrics["noisy"]
+	if bm == nil || bm.HeaderOverflows != 1 {
+		t.Fatalf("expected header overflow counter 1, got %+v", bm)
+	}
+
+	// Passive health checks treat the overflow as a backend failure
+	backend := lb.strategy.GetBackends()[0]
+	if lb.IsBackendHealthy(backend) {
+		t.Error("expected backend to be marked unhealthy after header overflow")
+	}
+}
+
+func TestMaxResponseHeaderBytes_UnderLimit(t *testing.T) {
+	srv := newHeaderBackend(t, 1024)
+	lb := newHeaderLimitLB(t, 4*1024, config.BackendConfig{Name: "quiet", Address: srv.URL})
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != "ok" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestMaxResponseHeaderBytes_PerBackendOverride(t *testing.T) {
+	srv := newHeaderBackend(t, 8*1024)
+	// The global limit would reject the response but the backend override allows it
+	lb := newHeaderLimitLB(t, 4*1024, config.BackendConfig{
+		Name:                   "override",
+		Address:                srv.URL,
+		MaxResponseHeaderBytes: 64 * 1024,
+	})
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected per-backend override to allow response, got %d", rec.Code)
+	}
+}
+
+func TestMaxResponseHeaderBytes_WithoutConfig(t *testing.T) {
+	lb := &LoadBalancer{}
+	if got := lb.maxResponseHeaderBytes(config.BackendConfig{}); got != 0 {
+		t.Errorf("expected Go's default without config, got %d", got)
+	}
+	if got := lb.maxResponseHeaderBytes(config.BackendConfig{MaxResponseHeaderBytes: 4096}); got != 4096 {
+		t.Errorf("expected the per-backend override, got %d", got)
+	}
+}
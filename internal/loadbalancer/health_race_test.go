@@ -0,0 +1,347 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// strictConcurrencyEnv enables the strict concurrency test mode: the health
+// race suite runs longer with more goroutines. Combine it with -race:
+//
+//	HELIOS_STRICT_CONCURRENCY=1 go test -race -run HealthRace ./internal/loadbalancer
+const strictConcurrencyEnv = "HELIOS_STRICT_CONCURRENCY"
+
+// healthRaceBudget returns how long the health race suite runs and how many
+// goroutines drive each kind of health signal
+func healthRaceBudget() (time.Duration, int) {
+	switch {
+	case os.Getenv(strictConcurrencyEnv) != "":
+		return 8 * time.Second, 8
+	case testing.Short():
+		return 300 * time.Millisecond, 2
+	default:
+		return 2 * time.Second, 4
+	}
+}
+
+// fakeChecker stands in for the active health checker: each probe result
+// comes from a per-backend switch the test flips, with no HTTP involved
+type fakeChecker struct {
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+func (fc *fakeChecker) setFailing(name string, failing bool) {
+	fc.mu.Lock()
+	fc.failing[name] = failing
+	fc.mu.Unlock()
+}
+
+func (fc *fakeChecker) probe(lb *LoadBalancer, backend *Backend) {
+	fc.mu.Lock()
+	ok := !fc.failing[backend.Name]
+	fc.mu.Unlock()
+	lb.recordActiveResult(backend, ok)
+}
+
+// raceViolations collects invariant violations from the suite's goroutines
+type raceViolations struct {
+	count int64
+	mu    sync.Mutex
+	first []string
+}
+
+func (rv *raceViolations) add(format string, args ...interface{}) {
+	if atomic.AddInt64(&rv.count, 1) > 10 {
+		return
+	}
+	rv.mu.Lock()
+	rv.first = append(rv.first, fmt.Sprintf(format, args...))
+	rv.mu.Unlock()
+}
+
+// checkHealthSnapshot reports a backend whose metrics disagree with its
+// health flag, or that is held down while healthy
+func checkHealthSnapshot(rv *raceViolations, name string, snap healthSnapshot) {
+	if !snap.metricsKnown {
+		rv.add("%s: no health status in the metrics", name)
+	} else if snap.metricsHealthy != snap.healthy {
+		rv.add("%s: metrics report healthy=%v while the backend is healthy=%v", name, snap.metricsHealthy, snap.healthy)
+	}
+	if snap.heldDown && snap.healthy {
+		rv.add("%s: held down by active checks while healthy", name)
+	}
+}
+
+// TestHealthRace drives active check results, passive failures, admin
+// changes and request routing against one load balancer at once, checking
+// the health invariants continuously. The "anchor" backend never fails, so
+// routing must always find a backend.
+func TestHealthRace(t *testing.T) {
+	if err := logging.Init(config.LoggingConfig{Level: "error"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = logging.Init(config.LoggingConfig{Level: "info", Format: "text"}) })
+
+	backends := []config.BackendConfig{{Name: "anchor", Address: "http://127.0.0.1:1"}}
+	for i := 0; i < 4; i++ {
+		backends = append(backends, config.BackendConfig{Name: fmt.Sprintf("flaky-%d", i), Address: "http://127.0.0.1:1"})
+	}
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     backends,
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		HealthChecks: config.HealthChecksConfig{
+			Active:  config.ActiveHealthCheckConfig{Rise: 2, Fall: 2},
+			Passive: config.PassiveHealthCheckConfig{Enabled: true, UnhealthyThreshold: 2, UnhealthyTimeout: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	// Short passive trips so backends cycle through recovery many times
+	lb.healthChecks.passiveTimeout = 5 * time.Millisecond
+
+	duration, workers := healthRaceBudget()
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d, %d goroutines per signal for %v", seed, workers, duration)
+
+	checker := &fakeChecker{failing: make(map[string]bool)}
+	rv := &raceViolations{}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+	// current returns the backends in the pool, the way the health checker lists them
+	current := func() []*Backend {
+		lb.mutex.RLock()
+		defer lb.mutex.RUnlock()
+		return lb.strategy.GetBackends()
+	}
+	// victim picks a backend other than the anchor
+	victim := func(rng *rand.Rand) *Backend {
+		pool := current()
+		b := pool[rng.Intn(len(pool))]
+		if b.Name == "anchor" {
+			return nil
+		}
+		return b
+	}
+	// run starts n goroutines calling body until the suite stops, each with
+	// its own random source derived from the seed
+	nextSeed := seed
+	run := func(n int, body func(rng *rand.Rand)) {
+		for i := 0; i < n; i++ {
+			nextSeed++
+			wg.Add(1)
+			go func(rng *rand.Rand) {
+				defer wg.Done()
+				for !stopped() {
+					body(rng)
+				}
+			}(rand.New(rand.NewSource(nextSeed)))
+		}
+	}
+
+	// (a) Active checks, with backends flipping between passing and failing
+	run(1, func(rng *rand.Rand) {
+		if b := victim(rng); b != nil {
+			checker.setFailing(b.Name, rng.Intn(2) == 0)
+		}
+		time.Sleep(time.Millisecond)
+	})
+	run(workers, func(rng *rand.Rand) {
+		for _, b := range current() {
+			checker.probe(lb, b)
+		}
+	})
+
+	// (b) Passive 5xx observations and direct timed trips of varying length
+	run(workers, func(rng *rand.Rand) {
+		b := victim(rng)
+		if b == nil {
+			return
+		}
+		if rng.Intn(4) == 0 {
+			lb.MarkBackendUnhealthy(b, time.Duration(rng.Intn(10))*time.Millisecond)
+		} else {
+			lb.countPassiveFailure(b)
+		}
+	})
+
+	// (c) Admin API changes: backends come and go and are reweighted
+	var added int64
+	run(1, func(rng *rand.Rand) {
+		n := atomic.AddInt64(&added, 1)
+		name := fmt.Sprintf("churn-%d", n)
+		if err := lb.AddBackend(config.BackendConfig{Name: name, Address: "http://127.0.0.1:1"}); err != nil {
+			rv.add("adding %s: %v", name, err)
+		}
+		if n > 3 {
+			lb.RemoveBackend(fmt.Sprintf("churn-%d", n-3))
+		}
+		if b := victim(rng); b != nil {
+			_ = lb.SetBackendWeight(b.Name, 1+rng.Intn(5))
+		}
+		time.Sleep(time.Millisecond)
+	})
+
+	// (d) Request routing: a backend is always found while the anchor is
+	// healthy, and the one picked has consistent health state
+	var routed int64
+	run(workers, func(rng *rand.Rand) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		b := lb.findHealthyBackend(req, nil)
+		if b == nil {
+			rv.add("no backend found while anchor is healthy")
+			return
+		}
+		atomic.AddInt64(&routed, 1)
+		b.IncrementConnections()
+		checkHealthSnapshot(rv, b.Name, lb.snapshotHealth(b))
+		b.DecrementConnections()
+	})
+
+	// Observer: metrics agree with every backend's flag, and UnhealthyUntil
+	// never moves backwards
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lastUntil := make(map[*Backend]time.Time)
+		for !stopped() {
+			for _, b := range current() {
+				snap := lb.snapshotHealth(b)
+				checkHealthSnapshot(rv, b.Name, snap)
+				if snap.unhealthyUntil.Before(lastUntil[b]) {
+					rv.add("%s: UnhealthyUntil moved back from %v to %v", b.Name, lastUntil[b], snap.unhealthyUntil)
+				}
+				lastUntil[b] = snap.unhealthyUntil
+			}
+			if !lb.IsBackendHealthy(current()[0]) {
+				rv.add("anchor reported unhealthy")
+			}
+		}
+	}()
+
+	time.Sleep(duration)
+	close(stop)
+	wg.Wait()
+
+	if n := atomic.LoadInt64(&rv.count); n > 0 {
+		for _, v := range rv.first {
+			t.Error(v)
+		}
+		t.Fatalf("%d invariant violations", n)
+	}
+	if atomic.LoadInt64(&routed) == 0 {
+		t.Fatal("no requests were routed")
+	}
+	for _, b := range current() {
+		checkHealthSnapshot(rv, b.Name, lb.snapshotHealth(b))
+	}
+	if rv.count > 0 {
+		t.Errorf("inconsistent health state after the run: %v", rv.first)
+	}
+}
+
+// TestHealthRace_TimedRecoveryKeepsActiveHold covers the interleaving where
+// the active checker trips a backend between a timed recovery's expiry check
+// and its write: the hold must win
+func TestHealthRace_TimedRecoveryKeepsActiveHold(t *testing.T) {
+	lb, backend, _ := newThresholdTestLB(t, 1, 1)
+
+	lb.MarkBackendUnhealthy(backend, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	// The passive period has expired; active checks trip the backend before
+	// anyone observes the expiry
+	lb.recordActiveResult(backend, false)
+
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected the active hold to outlast the expired passive period")
+	}
+	snap := lb.snapshotHealth(backend)
+	if !snap.heldDown || snap.metricsHealthy {
+		t.Errorf("expected held down and reported unhealthy, got %+v", snap)
+	}
+	lb.recordActiveResult(backend, true)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected an active success to restore the backend")
+	}
+}
+
+// TestHealthRace_PassiveTripOfHeldDownBackendRecovers checks a backend held
+// down by active checks and then tripped by passive checks is not stranded:
+// the passive trip makes it timed, so it recovers when the timeout expires
+func TestHealthRace_PassiveTripOfHeldDownBackendRecovers(t *testing.T) {
+	lb, backend, _ := newThresholdTestLB(t, 1, 1)
+
+	lb.recordActiveResult(backend, false)
+	lb.MarkBackendUnhealthy(backend, 10*time.Millisecond)
+	if backend.isHeldDown() {
+		t.Fatal("expected the passive trip to replace the active hold")
+	}
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected the backend to stay down until the passive timeout")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected the backend to recover once the passive timeout expired")
+	}
+}
+
+func TestMarkBackendUnhealthy_NeverShortensPeriod(t *testing.T) {
+	lb, backend, _ := newThresholdTestLB(t, 0, 0)
+
+	lb.MarkBackendUnhealthy(backend, time.Hour)
+	long := lb.snapshotHealth(backend).unhealthyUntil
+	lb.MarkBackendUnhealthy(backend, time.Millisecond)
+	if got := lb.snapshotHealth(backend).unhealthyUntil; !got.Equal(long) {
+		t.Errorf("expected the unhealthy period to stay at %v, got %v", long, got)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if lb.IsBackendHealthy(backend) {
+		t.Error("expected a shorter trip not to cut the longer one short")
+	}
+}
+
+func TestFindHealthyBackend_FallsBackToAnyHealthy(t *testing.T) {
+	var backends []config.BackendConfig
+	for i := 0; i < 5; i++ {
+		backends = append(backends, config.BackendConfig{Name: fmt.Sprintf("b%d", i), Address: "http://127.0.0.1:1"})
+	}
+	lb, err := NewLoadBalancer(&config.Config{Backends: backends, LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"}})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	// Round robin tries three backends per pick; only the last one is healthy
+	for _, b := range lb.strategy.GetBackends()[:4] {
+		lb.MarkBackendUnhealthy(b, time.Hour)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 10; i++ {
+		if b := lb.findHealthyBackend(req, nil); b == nil || b.Name != "b4" {
+			t.Fatalf("pick %d: expected b4, got %v", i, b)
+		}
+	}
+	if b := lb.findHealthyBackend(req, map[string]bool{"b4": true}); b != nil {
+		t.Errorf("expected no backend once b4 is excluded, got %s", b.Name)
+	}
+}
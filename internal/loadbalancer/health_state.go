@@ -0,0 +1,66 @@
+package loadbalancer
+
+import "time"
+
+// applyHealthLocked is the single place a backend's health state changes.
+// It sets the health flag and whether active checks hold the backend down,
+// moves UnhealthyUntil forward to until (it never moves back), and mirrors
+// the flag into the metrics. Caller must hold backend.Mutex for writing, so
+// anyone holding the lock sees the flag and the metrics agree. Returns
+// whether the health flag changed.
+func (lb *LoadBalancer) applyHealthLocked(backend *Backend, healthy, heldDown bool, until time.Time) bool {
+	changed := backend.IsHealthy != healthy
+	backend.IsHealthy = healthy
+	backend.heldDown = heldDown && !healthy
+	if until.After(backend.UnhealthyUntil) {
+		backend.UnhealthyUntil = until
+	}
+	if lb.metricsCollector != nil {
+		lb.metricsCollector.UpdateBackendHealth(backend.Name, healthy)
+	}
+	if lb.groups != nil {
+		lb.groups.syncLocked(backend)
+	}
+	return changed
+}
+
+// recoverableLocked reports whether a backend's timed unhealthy period has
+// expired. Backends held down by active checks only recover through them.
+// Caller must hold backend.Mutex.
+func (backend *Backend) recoverableLocked(now time.Time) bool {
+	return !backend.IsHealthy && !backend.heldDown && now.After(backend.UnhealthyUntil)
+}
+
+// isHeldDown reports whether the backend was marked unhealthy by the active
+// checker and stays down until rise consecutive successes
+func (backend *Backend) isHeldDown() bool {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	return backend.heldDown
+}
+
+// healthSnapshot is a backend's health state read together with the health
+// the metrics report for it
+type healthSnapshot struct {
+	healthy        bool
+	heldDown       bool
+	unhealthyUntil time.Time
+	metricsHealthy bool
+	metricsKnown   bool // The metrics have a health status for the backend
+}
+
+// snapshotHealth reads a backend's health state and its metrics health under
+// the backend lock, so the two are observed at the same instant
+func (lb *LoadBalancer) snapshotHealth(backend *Backend) healthSnapshot {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	snap := healthSnapshot{
+		healthy:        backend.IsHealthy,
+		heldDown:       backend.heldDown,
+		unhealthyUntil: backend.UnhealthyUntil,
+	}
+	if lb.metricsCollector != nil {
+		snap.metricsHealthy, snap.metricsKnown = lb.metricsCollector.BackendHealth(backend.Name)
+	}
+	return snap
+}
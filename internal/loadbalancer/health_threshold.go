@@ -0,0 +1,114 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+const (
+	// defaultActiveRise is the number of consecutive successful probes needed
+	// to restore a backend marked unhealthy by the active checker
+	defaultActiveRise = 2
+	// defaultActiveFall is the number of consecutive failed probes needed to
+	// mark a backend unhealthy
+	defaultActiveFall = 3
+)
+
+// healthStreak counts a backend's consecutive active health check results
+type healthStreak struct {
+	failures  int
+	successes int
+}
+
+// streakLocked returns the streak for a backend, creating it if needed.
+// Caller must hold streakMu.
+func (hc *healthChecker) streakLocked(name string) *healthStreak {
+	st, ok := hc.streaks[name]
+	if !ok {
+		st = &healthStreak{}
+		hc.streaks[name] = st
+	}
+	return st
+}
+
+// streak returns a backend's current consecutive failure and success counts
+func (hc *healthChecker) streak(name string) (int, int) {
+	if hc == nil {
+		return 0, 0
+	}
+	hc.streakMu.Lock()
+	defer hc.streakMu.Unlock()
+	if st, ok := hc.streaks[name]; ok {
+		return st.failures, st.successes
+	}
+	return 0, 0
+}
+
+// resetStreak clears a backend's counters after a state change made outside
+// the active checker, so earlier probes don't count twice
+func (hc *healthChecker) resetStreak(name string) {
+	if hc == nil {
+		return
+	}
+	hc.streakMu.Lock()
+	delete(hc.streaks, name)
+	hc.streakMu.Unlock()
+}
+
+// recordActiveResult counts an active probe result and marks the backend
+// unhealthy after fall consecutive failures or healthy again after rise
+// consecutive successes. A backend tripped this way is held down: it stays
+// unhealthy until rise successes instead of a timeout.
+func (lb *LoadBalancer) recordActiveResult(backend *Backend, ok bool) {
+	hc := lb.healthChecks
+	// The backend lock is held across the count and the state change, so a
+	// concurrent passive trip or timed recovery can't slip in between
+	backend.Mutex.Lock()
+	hc.streakMu.Lock()
+	st := hc.streakLocked(backend.Name)
+	if ok {
+		st.successes++
+		st.failures = 0
+	} else {
+		st.failures++
+		st.successes = 0
+	}
+
+	var tripped, restored bool
+	var observed int
+	switch {
+	case !ok && !backend.heldDown && st.failures >= hc.activeFall:
+		tripped, observed = true, st.failures
+		st.failures = 0
+	case ok && backend.heldDown && st.successes >= hc.activeRise:
+		restored, observed = true, st.successes
+		st.successes = 0
+	}
+	failures, successes := st.failures, st.successes
+	hc.streakMu.Unlock()
+
+	if lb.metricsCollector != nil {
+		lb.metricsCollector.UpdateBackendHealthStreak(backend.Name, failures, successes)
+	}
+	switch {
+	case tripped:
+		lb.applyHealthLocked(backend, false, true, time.Time{})
+	case restored:
+		lb.applyHealthLocked(backend, true, false, time.Time{})
+	case lb.metricsCollector != nil:
+		// No state change; refresh the last health check time
+		lb.metricsCollector.UpdateBackendHealth(backend.Name, backend.IsHealthy)
+	}
+	backend.Mutex.Unlock()
+
+	switch {
+	case tripped:
+		logging.L().Warn().Str("backend", backend.Name).Int("consecutive_failures", observed).Msg("backend marked unhealthy via active check")
+		lb.publishEvent(EventBackendUnhealthy, backend.Name, fmt.Sprintf("%d consecutive failed active checks", observed))
+	case restored:
+		logging.L().Info().Str("backend", backend.Name).Int("consecutive_successes", observed).Msg("backend marked healthy via active check")
+		lb.publishEvent(EventBackendHealthy, backend.Name, fmt.Sprintf("%d consecutive successful active checks", observed))
+	}
+}
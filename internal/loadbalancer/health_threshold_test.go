@@ -0,0 +1,185 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// scriptedHealthBackend answers health checks from a script of pass (true)
+// and fail (false) results, one per probe
+type scriptedHealthBackend struct {
+	mu     sync.Mutex
+	script []bool
+}
+
+func (s *scriptedHealthBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	pass := true
+	if len(s.script) > 0 {
+		pass, s.script = s.script[0], s.script[1:]
+	}
+	s.mu.Unlock()
+	if !pass {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// newThresholdTestLB creates a load balancer with one scripted backend. Active
+// checks are driven by the test through probe rather than the ticker.
+func newThresholdTestLB(t *testing.T, rise, fall int) (*LoadBalancer, *Backend, func(results ...bool)) {
+	t.Helper()
+	scripted := &scriptedHealthBackend{}
+	server := httptest.NewServer(scripted)
+	t.Cleanup(server.Close)
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "flaky", Address: server.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		HealthChecks: config.HealthChecksConfig{
+			Active: config.ActiveHealthCheckConfig{Path: "/health", Timeout: 1, Rise: rise, Fall: fall},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	backend := lb.strategy.GetBackends()[0]
+	probe := func(results ...bool) {
+		t.Helper()
+		for _, ok := range results {
+			scripted.mu.Lock()
+			scripted.script = []bool{ok}
+			scripted.mu.Unlock()
+			lb.checkBackendHealth(backend)
+		}
+	}
+	return lb, backend, probe
+}
+
+const (
+	pass = true
+	fail = false
+)
+
+func TestActiveHealthIsolatedFailuresDoNotTrip(t *testing.T) {
+	lb, backend, probe := newThresholdTestLB(t, 0, 0)
+
+	for i := 0; i < 5; i++ {
+		probe(fail, pass, fail, fail, pass)
+		if !lb.IsBackendHealthy(backend) {
+			t.Fatalf("round %d: isolated failures should not trip with fall=3", i)
+		}
+	}
+
+	probe(fail, fail)
+	info := lb.ListBackends()[0]
+	if info.ConsecutiveFailures != 2 || info.ConsecutiveSuccesses != 0 {
+		t.Errorf("expected 2/3 failures to be exposed, got failures=%d successes=%d", info.ConsecutiveFailures, info.ConsecutiveSuccesses)
+	}
+	if !info.Healthy {
+		t.Error("expected backend to stay healthy at 2/3 failures")
+	}
+	bm := lb.GetMetricsCollector().GetMetrics().BackendMetrics["flaky"]
+	if bm == nil || bm.ConsecutiveFailures != 2 {
+		t.Errorf("expected metrics to report 2 consecutive failures, got %+v", bm)
+	}
+}
+
+func TestActiveHealthFallThenRise(t *testing.T) {
+	lb, backend, probe := newThresholdTestLB(t, 0, 0)
+
+	probe(pass, fail, fail)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected backend healthy after two failures")
+	}
+	probe(fail)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected three consecutive failures to trip the backend")
+	}
+	if info := lb.ListBackends()[0]; info.ConsecutiveFailures != 0 {
+		t.Errorf("expected failure count reset on trip, got %d", info.ConsecutiveFailures)
+	}
+
+	// The first lucky success does not restore it, and a failure restarts the count
+	probe(pass)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected backend to stay unhealthy after one success with rise=2")
+	}
+	probe(fail, pass)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected the rise count to restart after a failure")
+	}
+	probe(pass)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected two consecutive successes to restore the backend")
+	}
+	if info := lb.ListBackends()[0]; info.ConsecutiveSuccesses != 0 {
+		t.Errorf("expected success count reset on restore, got %d", info.ConsecutiveSuccesses)
+	}
+}
+
+func TestActiveHealthCustomThresholds(t *testing.T) {
+	lb, backend, probe := newThresholdTestLB(t, 3, 1)
+
+	probe(fail)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected a single failure to trip with fall=1")
+	}
+	probe(pass, pass)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected backend to stay unhealthy after two successes with rise=3")
+	}
+	probe(pass)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected three successes to restore the backend")
+	}
+}
+
+func TestPassiveTripResetsActiveStreak(t *testing.T) {
+	lb, backend, probe := newThresholdTestLB(t, 0, 0)
+
+	probe(fail, fail)
+	lb.MarkBackendUnhealthy(backend, 50*time.Millisecond)
+	if info := lb.ListBackends()[0]; info.ConsecutiveFailures != 0 {
+		t.Errorf("expected passive trip to reset the active streak, got %d failures", info.ConsecutiveFailures)
+	}
+
+	// Passive trips are timed and are not probed while they last
+	probe(pass, pass)
+	if lb.IsBackendHealthy(backend) {
+		t.Fatal("expected passive trip to last until its timeout")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected backend restored once the passive timeout expired")
+	}
+
+	// Failures before the passive trip don't count toward the next one
+	probe(fail, fail)
+	if !lb.IsBackendHealthy(backend) {
+		t.Fatal("expected backend healthy at 2/3 failures after recovery")
+	}
+}
+
+func TestRemoveBackendForgetsPassiveFailures(t *testing.T) {
+	lb, backend, _ := newThresholdTestLB(t, 0, 0)
+	lb.healthChecks.passiveThreshold = 3
+
+	lb.countPassiveFailure(backend)
+	lb.RemoveBackend(backend.Name)
+
+	lb.healthChecks.unhealthyBackendMu.RLock()
+	_, ok := lb.healthChecks.unhealthyBackends[backend.Name]
+	lb.healthChecks.unhealthyBackendMu.RUnlock()
+	if ok {
+		t.Error("expected the removed backend's passive failure count to be dropped")
+	}
+}
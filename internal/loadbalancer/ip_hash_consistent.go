@@ -2,10 +2,10 @@ package loadbalancer
 
 import (
 	"hash/fnv"
-	"net"
 	"net/http"
-	"strings"
 	"sync"
+
+	"github.com/0xReLogic/Helios/internal/utils"
 )
 
 // IPHashConsistentStrategy implements IP hash with Jump Consistent Hash algorithm.
@@ -29,6 +29,8 @@ import (
 type IPHashConsistentStrategy struct {
 	backends []*Backend
 	mutex    sync.RWMutex
+	remap    *remapGrace          // nil unless remap_grace_seconds is set
+	proxies  utils.TrustedProxies // Peers whose forwarding headers name the client
 }
 
 // NewIPHashConsistentStrategy creates a new Jump Consistent Hash strategy.
@@ -67,7 +69,7 @@ func (iph *IPHashConsistentStrategy) NextBackend(r *http.Request) *Backend {
 	// Get healthy backends
 	healthyBackends := make([]*Backend, 0)
 	for _, b := range iph.backends {
-		if b.IsHealthy {
+		if b.Available() {
 			healthyBackends = append(healthyBackends, b)
 		}
 	}
@@ -76,28 +78,8 @@ func (iph *IPHashConsistentStrategy) NextBackend(r *http.Request) *Backend {
 		return nil
 	}
 
-	// Get the client's IP address
-	// In a real-world scenario, you might want to trust X-Forwarded-For or X-Real-IP
-	// based on your infrastructure setup.
-	ipStr := r.Header.Get("X-Forwarded-For")
-	if ipStr == "" {
-		ipStr = r.Header.Get("X-Real-IP")
-	}
-	if ipStr == "" {
-		// Fallback to RemoteAddr
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			// If SplitHostPort fails, it might be because there is no port.
-			ipStr = r.RemoteAddr
-		} else {
-			ipStr = ip
-		}
-	}
-
-	// If X-Forwarded-For has multiple IPs, take the first one.
-	if strings.Contains(ipStr, ",") {
-		ipStr = strings.Split(ipStr, ",")[0]
-	}
+	// Hash the client IP; forwarding headers only count from trusted proxies
+	ipStr := iph.proxies.ClientIP(r)
 
 	// Hash the IP address
 	hash := fnv.New32a()
@@ -108,7 +90,7 @@ func (iph *IPHashConsistentStrategy) NextBackend(r *http.Request) *Backend {
 	// Use Jump Consistent Hash to select backend
 	// This ensures minimal remapping when backends are added/removed
 	index := jumpHash(uint64(hashValue), int32(len(healthyBackends))) // #nosec G115 - len() is always non-negative, safe conversion
-	return healthyBackends[index]
+	return iph.remap.pick(ipStr, healthyBackends[index], healthyBackends)
 }
 
 // AddBackend adds a backend to the pool.
@@ -123,13 +105,7 @@ func (iph *IPHashConsistentStrategy) RemoveBackend(backend *Backend) {
 	iph.mutex.Lock()
 	defer iph.mutex.Unlock()
 
-	for i, b := range iph.backends {
-		if b == backend {
-			iph.backends[i] = iph.backends[len(iph.backends)-1]
-			iph.backends = iph.backends[:len(iph.backends)-1]
-			return
-		}
-	}
+	iph.backends = withoutBackend(iph.backends, backend)
 }
 
 // GetBackends returns all backends in the pool.
@@ -1,9 +1,15 @@
 package loadbalancer
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/utils"
 )
 
 func TestIPHashStrategy(t *testing.T) {
@@ -103,3 +109,199 @@ func TestIPHashStrategy_AllUnhealthy(t *testing.T) {
 		t.Error("Expected nil when all backends are unhealthy")
 	}
 }
+
+func TestIPHashStrategies_IgnoreForgedXForwardedFor(t *testing.T) {
+	trusted, err := utils.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("failed to parse trusted proxies: %v", err)
+	}
+	envs := []struct {
+		name         string
+		env          StrategyEnv
+		proxyTrusted bool
+	}{
+		{"no trusted proxies", StrategyEnv{}, false},
+		{"trusted proxies", StrategyEnv{TrustedProxies: trusted}, true},
+	}
+
+	for _, name := range []string{"ip_hash", "ip_hash_consistent"} {
+		for _, tt := range envs {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				strategy, err := NewStrategy(name, nil, tt.env)
+				if err != nil {
+					t.Fatalf("failed to create strategy: %v", err)
+				}
+				for _, n := range []string{"A", "B", "C", "D"} {
+					strategy.AddBackend(&Backend{Name: n, URL: &url.URL{}, IsHealthy: true})
+				}
+
+				plain := httptest.NewRequest("GET", "/", nil)
+				plain.RemoteAddr = "203.0.113.7:4000"
+				want := strategy.NextBackend(plain)
+
+				// Whatever the attacker claims, they are hashed by their own address
+				for i := 0; i < 50; i++ {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "203.0.113.7:4000"
+					req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+					req.Header.Set("X-Real-IP", fmt.Sprintf("192.0.2.%d", i))
+					if got := strategy.NextBackend(req); got != want {
+						t.Fatalf("forged headers moved the client from %s to %s", want.Name, got.Name)
+					}
+				}
+
+				// Only a trusted proxy's header is honoured
+				proxied := httptest.NewRequest("GET", "/", nil)
+				proxied.RemoteAddr = "10.1.2.3:5000"
+				proxied.Header.Set("X-Forwarded-For", "203.0.113.7")
+				direct := httptest.NewRequest("GET", "/", nil)
+				direct.RemoteAddr = "10.1.2.3:5000"
+				if tt.proxyTrusted {
+					direct = plain
+				}
+				if got, want := strategy.NextBackend(proxied), strategy.NextBackend(direct); got != want {
+					t.Errorf("expected the proxied request on %s, got %s", want.Name, got.Name)
+				}
+			})
+		}
+	}
+}
+
+// TestNewLoadBalancer_PassesTrustedProxiesToStrategy checks server.trusted_proxies
+// reaches the strategy without any process-wide setting
+func TestNewLoadBalancer_PassesTrustedProxiesToStrategy(t *testing.T) {
+	lb, err := NewLoadBalancer(&config.Config{
+		Server:       config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+		Backends:     []config.BackendConfig{{Name: "a", Address: "http://127.0.0.1:1"}, {Name: "b", Address: "http://127.0.0.1:2"}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "ip_hash"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create load balancer: %v", err)
+	}
+	defer lb.Stop()
+
+	proxied := httptest.NewRequest("GET", "/", nil)
+	proxied.RemoteAddr = "10.1.2.3:5000"
+	proxied.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := lb.strategy.(*IPHashStrategy).proxies.ClientIP(proxied); got != "203.0.113.7" {
+		t.Errorf("expected the strategy to trust the configured proxy, resolved %s", got)
+	}
+}
+
+// requestFrom returns a request from the given client IP
+func requestFrom(ip string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	return req
+}
+
+func TestIPHashStrategies_RemapGrace(t *testing.T) {
+	for _, name := range []string{"ip_hash", "ip_hash_consistent"} {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := NewStrategy(name, map[string]interface{}{"remap_grace_seconds": 30}, StrategyEnv{})
+			if err != nil {
+				t.Fatalf("failed to create strategy: %v", err)
+			}
+			unpinned, _ := NewStrategy(name, nil, StrategyEnv{})
+
+			var remap *remapGrace
+			switch s := strategy.(type) {
+			case *IPHashStrategy:
+				remap = s.remap
+			case *IPHashConsistentStrategy:
+				remap = s.remap
+			}
+			now := time.Unix(1700000000, 0)
+			remap.now = func() time.Time { return now }
+
+			for _, n := range []string{"A", "B", "C"} {
+				b := &Backend{Name: n, URL: &url.URL{}, IsHealthy: true}
+				strategy.AddBackend(b)
+				unpinned.AddBackend(b)
+			}
+			before := make(map[string]*Backend)
+			for i := 0; i < 200; i++ {
+				ip := fmt.Sprintf("198.51.100.%d", i)
+				before[ip] = strategy.NextBackend(requestFrom(ip))
+			}
+
+			// Scale out: the plain hash now moves some clients
+			d := &Backend{Name: "D", URL: &url.URL{}, IsHealthy: true}
+			strategy.AddBackend(d)
+			unpinned.AddBackend(d)
+			moved := 0
+			for ip, prev := range before {
+				if unpinned.NextBackend(requestFrom(ip)) != prev {
+					moved++
+				}
+				if got := strategy.NextBackend(requestFrom(ip)); got != prev {
+					t.Fatalf("client %s moved from %s to %s within the grace period", ip, prev.Name, got.Name)
+				}
+			}
+			if moved == 0 {
+				t.Fatal("expected adding a backend to remap some clients")
+			}
+
+			// New clients get the new mapping straight away
+			for i := 0; i < 50; i++ {
+				ip := fmt.Sprintf("192.0.2.%d", i)
+				if got, want := strategy.NextBackend(requestFrom(ip)), unpinned.NextBackend(requestFrom(ip)); got != want {
+					t.Fatalf("new client %s sent to %s, expected %s", ip, got.Name, want.Name)
+				}
+			}
+
+			// Once the grace period is over everyone follows the hash
+			now = now.Add(31 * time.Second)
+			for ip := range before {
+				if got, want := strategy.NextBackend(requestFrom(ip)), unpinned.NextBackend(requestFrom(ip)); got != want {
+					t.Fatalf("client %s still on %s after the grace period, expected %s", ip, got.Name, want.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRemapGrace_UnhealthyPreviousBackend(t *testing.T) {
+	rg := newRemapGrace(time.Minute)
+	a := &Backend{Name: "A", URL: &url.URL{}, IsHealthy: true}
+	b := &Backend{Name: "B", URL: &url.URL{}, IsHealthy: true}
+
+	rg.pick("198.51.100.1", a, []*Backend{a, b})
+	if got := rg.pick("198.51.100.1", b, []*Backend{a, b}); got != a {
+		t.Fatalf("expected the client kept on A, got %s", got.Name)
+	}
+	// A drops out of the healthy set: move immediately
+	if got := rg.pick("198.51.100.1", b, []*Backend{b}); got != b {
+		t.Fatalf("expected the client moved to B, got %s", got.Name)
+	}
+}
+
+func TestRemapGrace_Bounded(t *testing.T) {
+	rg := newRemapGrace(time.Minute)
+	rg.capacity = 3
+	a := &Backend{Name: "A", URL: &url.URL{}, IsHealthy: true}
+	for i := 0; i < 10; i++ {
+		rg.pick(fmt.Sprintf("198.51.100.%d", i), a, []*Backend{a})
+	}
+	if len(rg.entries) != 3 || rg.order.Len() != 3 {
+		t.Fatalf("expected 3 remembered clients, got %d/%d", len(rg.entries), rg.order.Len())
+	}
+	if _, ok := rg.entries["198.51.100.9"]; !ok {
+		t.Error("expected the most recent client to be remembered")
+	}
+	if _, ok := rg.entries["198.51.100.0"]; ok {
+		t.Error("expected the oldest client to be forgotten")
+	}
+}
+
+func TestRemapGrace_DisabledByDefault(t *testing.T) {
+	for _, options := range []map[string]interface{}{nil, {"remap_grace_seconds": 0}} {
+		strategy, err := NewStrategy("ip_hash", options, StrategyEnv{})
+		if err != nil {
+			t.Fatalf("failed to create strategy: %v", err)
+		}
+		if s := strategy.(*IPHashStrategy); s.remap != nil {
+			t.Errorf("expected no remap tracking for options %v", options)
+		}
+	}
+}
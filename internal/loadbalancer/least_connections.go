@@ -55,14 +55,7 @@ func (lc *LeastConnectionsStrategy) RemoveBackend(backend *Backend) {
 	lc.mutex.Lock()
 	defer lc.mutex.Unlock()
 
-	for i, b := range lc.backends {
-		if b == backend {
-			// Remove the backend by swapping with the last element and truncating
-			lc.backends[i] = lc.backends[len(lc.backends)-1]
-			lc.backends = lc.backends[:len(lc.backends)-1]
-			return
-		}
-	}
+	lc.backends = withoutBackend(lc.backends, backend)
 }
 
 // GetBackends returns all backends in the pool
@@ -0,0 +1,40 @@
+package loadbalancer
+
+import (
+	"net/http"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// LegacyHTTP10 normalizes requests from HTTP/1.0 clients before they reach
+// next. Clients that sent no Host get server.legacy_http10.default_host so
+// backends don't see an empty one, and with force_close the connection is
+// closed after the response instead of relying on HTTP/1.0 keep-alive.
+// HTTP/1.0 responses are never chunked: net/http delimits them with
+// Content-Length or by closing the connection.
+func (lb *LoadBalancer) LegacyHTTP10(next http.Handler) http.Handler {
+	var legacy config.LegacyHTTP10Config
+	if lb.config != nil {
+		legacy = lb.config.Server.LegacyHTTP10
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoAtLeast(1, 1) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if lb.metricsCollector != nil {
+			lb.metricsCollector.RecordHTTP10Request()
+		}
+		logging.WithContext(r.Context()).Debug().Str("proto", r.Proto).Bool("host_missing", r.Host == "").Msg("HTTP/1.0 request")
+
+		if r.Host == "" && legacy.DefaultHost != "" {
+			r.Host = legacy.DefaultHost
+		}
+		if legacy.ForceClose {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
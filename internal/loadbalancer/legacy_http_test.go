@@ -0,0 +1,174 @@
+package loadbalancer
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// streamBody is too large for net/http to size on its own
+var streamBody = strings.Repeat("x", 64<<10)
+
+// newLegacyHTTPTestServer serves a load balancer behind LegacyHTTP10 on a
+// real listener. The backend records the Host it receives; /stream replies
+// without a Content-Length, everything else with one.
+func newLegacyHTTPTestServer(t *testing.T, legacy config.LegacyHTTP10Config) (*httptest.Server, *LoadBalancer, func() string) {
+	t.Helper()
+	var mu sync.Mutex
+	var lastHost string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastHost = r.Host
+		mu.Unlock()
+		if r.URL.Path == "/stream" {
+			_, _ = io.WriteString(w, streamBody)
+			return
+		}
+		w.Header().Set("Content-Length", "5")
+		_, _ = io.WriteString(w, "fixed")
+	}))
+	t.Cleanup(backend.Close)
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Server:       config.ServerConfig{LegacyHTTP10: legacy},
+		Backends:     []config.BackendConfig{{Name: "app", Address: backend.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	server := httptest.NewServer(lb.LegacyHTTP10(lb))
+	t.Cleanup(server.Close)
+
+	backendHost := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return lastHost
+	}
+	return server, lb, backendHost
+}
+
+// rawRequest writes raw request bytes on a fresh connection and reads the response
+func rawRequest(t *testing.T, server *httptest.Server, raw string) (net.Conn, *bufio.Reader, *http.Response, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := io.WriteString(conn, raw); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	_ = resp.Body.Close()
+	return conn, br, resp, string(body)
+}
+
+// expectClosed fails unless the server closes the connection
+func expectClosed(t *testing.T, conn net.Conn, br *bufio.Reader) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := br.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the server to close the connection, got %v", err)
+	}
+}
+
+func TestLegacyHTTP10StreamedResponse(t *testing.T) {
+	server, lb, backendHost := newLegacyHTTPTestServer(t, config.LegacyHTTP10Config{DefaultHost: "legacy.internal"})
+
+	conn, br, resp, body := rawRequest(t, server, "GET /stream HTTP/1.0\r\n\r\n")
+	if resp.StatusCode != http.StatusOK || body != streamBody {
+		t.Fatalf("unexpected response %d with %d byte body", resp.StatusCode, len(body))
+	}
+	if len(resp.TransferEncoding) != 0 {
+		t.Errorf("expected no Transfer-Encoding for HTTP/1.0, got %v", resp.TransferEncoding)
+	}
+	if got := backendHost(); got != "legacy.internal" {
+		t.Errorf("expected backend to receive the default Host, got %q", got)
+	}
+	expectClosed(t, conn, br)
+
+	if got := lb.GetMetricsCollector().GetMetrics().HTTP10Requests; got != 1 {
+		t.Errorf("expected 1 HTTP/1.0 request counted, got %d", got)
+	}
+}
+
+func TestLegacyHTTP10ForceClose(t *testing.T) {
+	// Without force_close, a keep-alive HTTP/1.0 request with a known length stays open
+	server, _, _ := newLegacyHTTPTestServer(t, config.LegacyHTTP10Config{})
+	conn, _, resp, _ := rawRequest(t, server, "GET /fixed HTTP/1.0\r\nConnection: keep-alive\r\n\r\n")
+	if resp.Close {
+		t.Fatal("expected keep-alive to be honoured without force_close")
+	}
+	_ = conn.Close()
+
+	server, _, backendHost := newLegacyHTTPTestServer(t, config.LegacyHTTP10Config{ForceClose: true})
+	conn, br, resp, body := rawRequest(t, server, "GET /fixed HTTP/1.0\r\nConnection: keep-alive\r\n\r\n")
+	if body != "fixed" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if !resp.Close {
+		t.Error("expected Connection: close with force_close")
+	}
+	expectClosed(t, conn, br)
+
+	// No default_host configured: the backend gets its own address, never an empty Host
+	if got := backendHost(); got == "" {
+		t.Error("expected a non-empty Host at the backend")
+	}
+}
+
+func TestLegacyHTTP10DoesNotAffectHTTP11(t *testing.T) {
+	server, lb, backendHost := newLegacyHTTPTestServer(t, config.LegacyHTTP10Config{DefaultHost: "legacy.internal", ForceClose: true})
+
+	conn, br, resp, body := rawRequest(t, server, "GET /stream HTTP/1.1\r\nHost: app.example\r\n\r\n")
+	if body != streamBody {
+		t.Fatalf("unexpected %d byte body", len(body))
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("expected chunked encoding for HTTP/1.1, got %v", resp.TransferEncoding)
+	}
+	if resp.Close {
+		t.Error("expected HTTP/1.1 connection to stay open")
+	}
+	if got := backendHost(); got != "app.example" {
+		t.Errorf("expected backend to receive the client's Host, got %q", got)
+	}
+
+	// The connection is reusable
+	if _, err := io.WriteString(conn, "GET /fixed HTTP/1.1\r\nHost: app.example\r\n\r\n"); err != nil {
+		t.Fatalf("write second request: %v", err)
+	}
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read second response: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 on reused connection, got %d", resp.StatusCode)
+	}
+
+	if got := lb.GetMetricsCollector().GetMetrics().HTTP10Requests; got != 0 {
+		t.Errorf("expected no HTTP/1.0 requests counted, got %d", got)
+	}
+}
@@ -3,6 +3,7 @@ package loadbalancer
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -15,7 +16,9 @@ import (
 	"github.com/0xReLogic/Helios/internal/circuitbreaker"
 	"github.com/0xReLogic/Helios/internal/config"
 	"github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/membudget"
 	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/proxyinfo"
 	"github.com/0xReLogic/Helios/internal/ratelimiter"
 	"github.com/0xReLogic/Helios/internal/utils"
 )
@@ -28,6 +31,9 @@ type Strategy interface {
 	GetBackends() []*Backend
 }
 
+// ErrBackendNotFound is returned when an operation targets an unknown backend
+var ErrBackendNotFound = errors.New("backend not found")
+
 // BackendInfo is a lightweight snapshot used by the Admin API
 type BackendInfo struct {
 	Name              string `json:"name"`
@@ -35,6 +41,18 @@ type BackendInfo struct {
 	Healthy           bool   `json:"healthy"`
 	ActiveConnections int32  `json:"active_connections"`
 	Weight            int    `json:"weight"`
+	// Weight from config or the Admin API; Weight differs from it while the
+	// backend publishes an X-Helios-Weight hint
+	ConfiguredWeight int  `json:"configured_weight"`
+	WeightFromHint   bool `json:"weight_from_hint,omitempty"`
+	// The backend asked through X-Helios-Drain to receive no new requests
+	Draining bool `json:"draining,omitempty"`
+	// Consecutive active health check results, compared against
+	// health_checks.active.fall and rise
+	ConsecutiveFailures  int `json:"consecutive_failures"`
+	ConsecutiveSuccesses int `json:"consecutive_successes"`
+	// Backend group the backend belongs to, when backend_groups is configured
+	Group string `json:"group,omitempty"`
 }
 
 // ListBackends returns a snapshot of backends for the Admin API
@@ -50,34 +68,49 @@ func (lb *LoadBalancer) ListBackends() []BackendInfo {
 			Name:              b.Name,
 			Address:           b.URL.String(),
 			Healthy:           b.IsHealthy,
-			ActiveConnections: b.ActiveConnections,
+			ActiveConnections: b.GetActiveConnections(),
 			Weight:            b.Weight,
+			ConfiguredWeight:  b.configWeight,
+			WeightFromHint:    b.hints.weight != 0,
+			Draining:          b.hints.draining,
 		}
 		b.Mutex.RUnlock()
+		info.ConsecutiveFailures, info.ConsecutiveSuccesses = lb.healthChecks.streak(b.Name)
+		if lb.groups != nil {
+			info.Group = lb.groups.groupOf(b.Name).name
+		}
 		infos = append(infos, info)
 	}
 	return infos
 }
 
-// SetStrategy switches the load balancing strategy at runtime
+// SetStrategy switches the load balancing strategy at runtime, using any
+// options configured for it under load_balancer.strategy_config
 func (lb *LoadBalancer) SetStrategy(name string) error {
+	return lb.setStrategy(name, nil, false)
+}
+
+// SetStrategyWithOptions switches the load balancing strategy at runtime and
+// replaces its configured options
+func (lb *LoadBalancer) SetStrategyWithOptions(name string, options map[string]interface{}) error {
+	return lb.setStrategy(name, options, true)
+}
+
+func (lb *LoadBalancer) setStrategy(name string, options map[string]interface{}, replaceOptions bool) error {
 	lb.mutex.Lock()
 	defer lb.mutex.Unlock()
 
-	var newStrategy Strategy
-	switch name {
-	case "round_robin":
-		newStrategy = NewRoundRobinStrategy()
-	case "least_connections":
-		newStrategy = NewLeastConnectionsStrategy()
-	case "weighted_round_robin":
-		newStrategy = NewWeightedRoundRobinStrategy()
-	case "ip_hash":
-		newStrategy = NewIPHashStrategy()
-	case "ip_hash_consistent":
-		newStrategy = NewIPHashConsistentStrategy()
-	default:
-		return fmt.Errorf("unknown strategy: %s", name)
+	if !replaceOptions {
+		options = lb.config.LoadBalancer.StrategyConfig[name]
+	}
+	newStrategy, err := NewStrategy(name, options, lb.strategyEnv)
+	if err != nil {
+		return err
+	}
+	if lb.groups != nil {
+		if err := lb.groups.setStrategy(name, options); err != nil {
+			return err
+		}
 	}
 
 	// Move existing backends to the new strategy
@@ -87,10 +120,71 @@ func (lb *LoadBalancer) SetStrategy(name string) error {
 
 	lb.strategy = newStrategy
 	lb.config.LoadBalancer.Strategy = name
+	if lb.shadow != nil {
+		lb.shadow.reset()
+	}
+	if replaceOptions {
+		// Copy on write: the previous map may still be referenced by readers of the config
+		strategyConfig := make(map[string]map[string]interface{}, len(lb.config.LoadBalancer.StrategyConfig)+1)
+		for k, v := range lb.config.LoadBalancer.StrategyConfig {
+			strategyConfig[k] = v
+		}
+		strategyConfig[name] = copyOptions(options)
+		lb.config.LoadBalancer.StrategyConfig = strategyConfig
+	}
 	logging.L().Info().Str("strategy", name).Msg("load balancing strategy switched")
+	lb.publishEvent(EventStrategyChanged, "", name)
 	return nil
 }
 
+// GetStrategy returns the name of the active load balancing strategy
+func (lb *LoadBalancer) GetStrategy() string {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+	if lb.config.LoadBalancer.Strategy == "" {
+		return defaultStrategy
+	}
+	return lb.config.LoadBalancer.Strategy
+}
+
+// GetStrategyOptions returns a copy of the options of the active strategy
+func (lb *LoadBalancer) GetStrategyOptions() map[string]interface{} {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+	name := lb.config.LoadBalancer.Strategy
+	if name == "" {
+		name = defaultStrategy
+	}
+	return copyOptions(lb.config.LoadBalancer.StrategyConfig[name])
+}
+
+// SetBackendWeight updates the configured weight of a backend at runtime.
+// A weight hint published by the backend keeps taking precedence.
+func (lb *LoadBalancer) SetBackendWeight(name string, weight int) error {
+	if weight < 1 {
+		return fmt.Errorf("weight must be at least 1 (got %d)", weight)
+	}
+
+	// Hold the write lock so strategies never observe a weight mid-update
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for _, b := range lb.strategy.GetBackends() {
+		if b.Name == name {
+			b.Mutex.Lock()
+			b.configWeight = weight
+			if b.hints.weight == 0 {
+				b.Weight = weight
+			}
+			b.Mutex.Unlock()
+			logging.L().Info().Str("backend", name).Int("weight", weight).Msg("backend weight updated")
+			lb.publishEvent(EventWeightChanged, name, fmt.Sprintf("weight=%d", weight))
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrBackendNotFound, name)
+}
+
 // Backend represents a backend server
 type Backend struct {
 	Name              string
@@ -101,6 +195,16 @@ type Backend struct {
 	ActiveConnections int32        // Number of active connections
 	Weight            int          // Weight for weighted load balancing strategies
 	Mutex             sync.RWMutex // Mutex for thread-safe operations
+
+	flushes  bool          // Pass flushes through to the client (flush_interval_ms != 0)
+	recycler *connRecycler // Retires pooled connections per connection_recycling; nil if not configured
+	heldDown bool          // Marked unhealthy by active checks; guarded by Mutex
+
+	configWeight int          // Weight from config or the Admin API; guarded by Mutex
+	hints        backendHints // State published through health check headers; guarded by Mutex
+
+	group   *backendGroup // Group counting the backend while it is a member; guarded by Mutex
+	counted bool          // Included in group.available; guarded by Mutex
 }
 
 // healthChecker manages health checks for backends
@@ -109,11 +213,19 @@ type healthChecker struct {
 	activeInterval     time.Duration
 	activeTimeout      time.Duration
 	activePath         string
+	activeRise         int
+	activeFall         int
+	trustHints         bool // Apply backend-published hints from health check headers
+	hintMinWeight      int
+	hintMaxWeight      int
+	hintMinChecks      int
 	passiveEnabled     bool
 	passiveThreshold   int
 	passiveTimeout     time.Duration
-	unhealthyBackends  map[string]int // Maps backend name to failure count
+	unhealthyBackends  map[string]int // Maps backend name to failure count; bounded by the configured backends
 	unhealthyBackendMu sync.RWMutex
+	streaks            map[string]*healthStreak // Active check streaks by backend name
+	streakMu           sync.Mutex
 }
 
 // LoadBalancer manages the backend servers and implements load balancing
@@ -122,18 +234,46 @@ type LoadBalancer struct {
 	mutex            sync.RWMutex
 	config           *config.Config
 	healthChecks     *healthChecker
-	rateLimiter      ratelimiter.RateLimiter
+	rateLimits       *ratelimiter.RuleSet
+	legacyRateLimit  bool // rate_limit has no rules: reject as the single-bucket limiter did
 	circuitBreaker   *circuitbreaker.CircuitBreaker
 	metricsCollector *metrics.MetricsCollector
 	ctx              context.Context
 	cancel           context.CancelFunc
 	healthCheckWg    sync.WaitGroup
 	wsPool           *WebSocketPool
+	events           *eventBus
+	shadow           *shadowEvaluator // nil unless load_balancer.shadow_strategy is set
+	shutdown         *shutdownCoordinator
+	bufferBudget     *membudget.Budget // Shared by buffering plugins (limits.max_buffer_bytes_total)
+	groups           *groupRouter      // nil unless backend_groups is configured
+	costs            *costAttributor   // nil unless cost_attribution is configured
+	allowedHosts     []allowedHost     // server.allowed_hosts; nil accepts any host
+	strategyEnv      StrategyEnv       // Passed to every strategy built, including on SetStrategy
 }
 
 // NewLoadBalancer creates a new load balancer with the specified strategy
 func NewLoadBalancer(cfg *config.Config) (*LoadBalancer, error) {
-	strategy := createStrategy(cfg.LoadBalancer.Strategy)
+	if err := ValidateStrategies(cfg.LoadBalancer); err != nil {
+		return nil, err
+	}
+	proxies, err := utils.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("server.trusted_proxies: %w", err)
+	}
+	env := StrategyEnv{TrustedProxies: proxies}
+	strategy, err := strategyFromConfig(cfg.LoadBalancer, env)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := newShadowEvaluator(cfg.LoadBalancer, env)
+	if err != nil {
+		return nil, fmt.Errorf("shadow_strategy: %w", err)
+	}
+	groups, err := newGroupRouter(cfg, env)
+	if err != nil {
+		return nil, fmt.Errorf("backend_groups: %w", err)
+	}
 	healthChecks := createHealthChecker(cfg)
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -144,10 +284,26 @@ func NewLoadBalancer(cfg *config.Config) (*LoadBalancer, error) {
 		metricsCollector: metrics.NewMetricsCollector(),
 		ctx:              ctx,
 		cancel:           cancel,
+		events:           newEventBus(),
+		shadow:           shadow,
+		shutdown:         newShutdownCoordinator(),
+		bufferBudget:     membudget.New(cfg.Limits.MaxBufferBytesTotal),
+		groups:           groups,
+		costs:            newCostAttributor(cfg.CostAttribution),
+		allowedHosts:     newAllowedHosts(cfg.Server.AllowedHosts),
+		strategyEnv:      env,
+	}
+	lb.metricsCollector.SetBufferBudget(lb.bufferBudget)
+	lb.metricsCollector.SetActiveBackendGroup(lb.ActiveBackendGroup())
+	if lb.costs != nil {
+		lb.metricsCollector.InitCostLabels(lb.costs.labels())
 	}
 
 	lb.setupWebSocketPool(cfg)
-	lb.setupRateLimiter(cfg)
+	if err := lb.setupRateLimiter(cfg); err != nil {
+		cancel()
+		return nil, fmt.Errorf("rate_limit: %w", err)
+	}
 	lb.setupCircuitBreaker(cfg)
 
 	// Add backends from configuration
@@ -162,33 +318,61 @@ func NewLoadBalancer(cfg *config.Config) (*LoadBalancer, error) {
 	return lb, nil
 }
 
-func createStrategy(strategyName string) Strategy {
-	switch strategyName {
-	case "round_robin":
-		return NewRoundRobinStrategy()
-	case "least_connections":
-		return NewLeastConnectionsStrategy()
-	case "weighted_round_robin":
-		return NewWeightedRoundRobinStrategy()
-	case "ip_hash":
-		return NewIPHashStrategy()
-	case "ip_hash_consistent":
-		return NewIPHashConsistentStrategy()
-	default:
-		return NewRoundRobinStrategy()
+// strategyFromConfig builds the selected strategy with its options block.
+// Option blocks for other strategies are ignored.
+func strategyFromConfig(lbCfg config.LoadBalancerConfig, env StrategyEnv) (Strategy, error) {
+	name := lbCfg.Strategy
+	if name == "" {
+		name = defaultStrategy
 	}
+	for other := range lbCfg.StrategyConfig {
+		if other != name {
+			logging.L().Debug().Str("strategy", other).Str("selected", name).Msg("ignoring options for unselected strategy")
+		}
+	}
+	return NewStrategy(name, lbCfg.StrategyConfig[name], env)
 }
 
 func createHealthChecker(cfg *config.Config) *healthChecker {
+	rise := cfg.HealthChecks.Active.Rise
+	if rise <= 0 {
+		rise = defaultActiveRise
+	}
+	fall := cfg.HealthChecks.Active.Fall
+	if fall <= 0 {
+		fall = defaultActiveFall
+	}
+	hintMinWeight := cfg.HealthChecks.Active.HintMinWeight
+	if hintMinWeight <= 0 {
+		hintMinWeight = defaultHintMinWeight
+	}
+	hintMaxWeight := cfg.HealthChecks.Active.HintMaxWeight
+	if hintMaxWeight <= 0 {
+		hintMaxWeight = defaultHintMaxWeight
+		if hintMaxWeight < hintMinWeight {
+			hintMaxWeight = hintMinWeight
+		}
+	}
+	hintMinChecks := cfg.HealthChecks.Active.HintMinChecks
+	if hintMinChecks <= 0 {
+		hintMinChecks = defaultHintMinChecks
+	}
 	return &healthChecker{
 		activeEnabled:     cfg.HealthChecks.Active.Enabled,
 		activeInterval:    time.Duration(cfg.HealthChecks.Active.Interval) * time.Second,
 		activeTimeout:     time.Duration(cfg.HealthChecks.Active.Timeout) * time.Second,
 		activePath:        cfg.HealthChecks.Active.Path,
+		activeRise:        rise,
+		activeFall:        fall,
+		trustHints:        cfg.HealthChecks.Active.TrustBackendHints,
+		hintMinWeight:     hintMinWeight,
+		hintMaxWeight:     hintMaxWeight,
+		hintMinChecks:     hintMinChecks,
 		passiveEnabled:    cfg.HealthChecks.Passive.Enabled,
 		passiveThreshold:  cfg.HealthChecks.Passive.UnhealthyThreshold,
 		passiveTimeout:    time.Duration(cfg.HealthChecks.Passive.UnhealthyTimeout) * time.Second,
 		unhealthyBackends: make(map[string]int),
+		streaks:           make(map[string]*healthStreak),
 	}
 }
 
@@ -218,22 +402,57 @@ func (lb *LoadBalancer) setupWebSocketPool(cfg *config.Config) {
 		Msg("WebSocket connection pool enabled")
 }
 
-func (lb *LoadBalancer) setupRateLimiter(cfg *config.Config) {
+func (lb *LoadBalancer) setupRateLimiter(cfg *config.Config) error {
 	if !cfg.RateLimit.Enabled {
-		return
+		return nil
 	}
 
-	maxTokens := cfg.RateLimit.MaxTokens
-	if maxTokens <= 0 {
-		maxTokens = 100
-	}
-	refillRate := time.Duration(cfg.RateLimit.RefillRate) * time.Second
-	if refillRate <= 0 {
-		refillRate = time.Second
+	rules := cfg.RateLimit.Rules
+	if len(rules) == 0 {
+		// The single-bucket shape is a per-IP rule applying to every request
+		maxTokens := cfg.RateLimit.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = 100
+		}
+		refillRate := cfg.RateLimit.RefillRate
+		if refillRate <= 0 {
+			refillRate = 1
+		}
+		rules = []config.RateLimitRule{{Name: "default", Key: "ip", MaxTokens: maxTokens, RefillRate: refillRate}}
+		lb.legacyRateLimit = true
 	}
 
-	lb.rateLimiter = ratelimiter.NewTokenBucketRateLimiter(maxTokens, refillRate)
-	logging.L().Info().Int("max_tokens", maxTokens).Dur("refill_rate", refillRate).Msg("rate limiting enabled")
+	built := make([]*ratelimiter.Rule, 0, len(rules))
+	for _, rc := range rules {
+		refillRate := time.Duration(rc.RefillRate) * time.Second
+		if rc.RefillRateMs > 0 {
+			refillRate = time.Duration(rc.RefillRateMs) * time.Millisecond
+		}
+		rule, err := ratelimiter.NewRule(ratelimiter.RuleOptions{
+			Name:         rc.Name,
+			Key:          rc.Key,
+			MaxTokens:    rc.MaxTokens,
+			RefillRate:   refillRate,
+			PathPrefixes: rc.Match.PathPrefixes,
+			Methods:      rc.Match.Methods,
+		})
+		if err != nil {
+			ratelimiter.NewRuleSet(built...).Close()
+			return err
+		}
+		built = append(built, rule)
+		lb.metricsCollector.RegisterStore("rate_limit:"+rc.Name, rule.Buckets())
+		logging.L().Info().
+			Str("rule", rc.Name).
+			Str("key", rc.Key).
+			Int("max_tokens", rc.MaxTokens).
+			Dur("refill_rate", refillRate).
+			Strs("path_prefixes", rc.Match.PathPrefixes).
+			Strs("methods", rc.Match.Methods).
+			Msg("rate limiting enabled")
+	}
+	lb.rateLimits = ratelimiter.NewRuleSet(built...)
+	return nil
 }
 
 func (lb *LoadBalancer) setupCircuitBreaker(cfg *config.Config) {
@@ -248,13 +467,12 @@ func (lb *LoadBalancer) setupCircuitBreaker(cfg *config.Config) {
 		Timeout:          time.Duration(cfg.CircuitBreaker.TimeoutSeconds) * time.Second,
 		FailureThreshold: uint32(cfg.CircuitBreaker.FailureThreshold), // #nosec G115 - config validated to be positive
 		SuccessThreshold: uint32(cfg.CircuitBreaker.SuccessThreshold), // #nosec G115 - config validated to be positive
-		OnStateChange: func(name string, from circuitbreaker.State, to circuitbreaker.State) {
+		OnStateChange: func(name string, from circuitbreaker.State, to circuitbreaker.State, counts circuitbreaker.Counts) {
 			logging.L().Info().Str("circuit_breaker", name).Str("from", from.String()).Str("to", to.String()).Msg("circuit breaker state changed")
-			failureCount, successCount, requestCount := lb.circuitBreaker.Counts()
 			lb.metricsCollector.UpdateCircuitBreakerState(name, to.String(), metrics.CircuitBreakerCounts{
-				FailureCount: failureCount,
-				SuccessCount: successCount,
-				RequestCount: requestCount,
+				FailureCount: counts.Failures,
+				SuccessCount: counts.Successes,
+				RequestCount: counts.Requests,
 			})
 		},
 	}
@@ -283,7 +501,11 @@ func (lb *LoadBalancer) setupCircuitBreaker(cfg *config.Config) {
 func (lb *LoadBalancer) startHealthChecks() {
 	if lb.healthChecks.activeEnabled {
 		go lb.startActiveHealthChecks()
-		logging.L().Info().Dur("interval", lb.healthChecks.activeInterval).Msg("active health checks enabled")
+		logging.L().Info().
+			Dur("interval", lb.healthChecks.activeInterval).
+			Int("rise", lb.healthChecks.activeRise).
+			Int("fall", lb.healthChecks.activeFall).
+			Msg("active health checks enabled")
 	} else {
 		logging.L().Info().Msg("active health checks disabled")
 	}
@@ -342,8 +564,10 @@ func (lb *LoadBalancer) checkBackendHealth(backend *Backend) {
 	default:
 	}
 
-	// Skip health check if the backend is already marked as unhealthy
-	if !lb.IsBackendHealthy(backend) {
+	// Skip backends tripped by passive checks; they recover when their
+	// timeout expires. Backends tripped by active checks keep being probed
+	// until rise consecutive successes restore them.
+	if !lb.IsBackendHealthy(backend) && !backend.isHeldDown() {
 		return
 	}
 
@@ -381,31 +605,18 @@ func (lb *LoadBalancer) performHealthCheck(backend *Backend) (*http.Response, er
 // handleHealthCheckFailure handles a failed health check
 func (lb *LoadBalancer) handleHealthCheckFailure(backend *Backend, err error) {
 	logging.L().Error().Str("backend", backend.Name).Err(err).Msg("health check failed")
-	lb.MarkBackendUnhealthy(backend, lb.healthChecks.passiveTimeout)
+	lb.recordActiveResult(backend, false)
 }
 
 // processHealthCheckResponse processes the health check response
 func (lb *LoadBalancer) processHealthCheckResponse(backend *Backend, resp *http.Response) {
 	if resp.StatusCode != http.StatusOK {
 		logging.L().Warn().Str("backend", backend.Name).Int("status", resp.StatusCode).Msg("health check returned non-ok status")
-		lb.MarkBackendUnhealthy(backend, lb.healthChecks.passiveTimeout)
+		lb.recordActiveResult(backend, false)
 		return
 	}
-
-	// If we get here, the backend is healthy
-	backend.Mutex.Lock()
-	wasUnhealthy := !backend.IsHealthy
-	backend.IsHealthy = true
-	backend.Mutex.Unlock()
-
-	// Update metrics to reflect healthy status
-	if lb.metricsCollector != nil {
-		lb.metricsCollector.UpdateBackendHealth(backend.Name, true)
-	}
-
-	if wasUnhealthy {
-		logging.L().Info().Str("backend", backend.Name).Msg("backend marked healthy via active check")
-	}
+	lb.recordActiveResult(backend, true)
+	lb.applyBackendHints(backend, resp.Header)
 }
 
 // AddBackend adds a new backend server to the load balancer
@@ -446,9 +657,9 @@ func (lb *LoadBalancer) AddBackend(backendCfg config.BackendConfig) error {
 		}).DialContext,
 
 		// Connection pooling (prevent connection exhaustion)
-		MaxIdleConns:        100, // Total idle connections
-		MaxIdleConnsPerHost: 10,  // Per-host idle connections
-		MaxConnsPerHost:     100, // Limit concurrent connections per host
+		MaxIdleConns:        100,                  // Total idle connections
+		MaxIdleConnsPerHost: 10,                   // Per-host idle connections
+		MaxConnsPerHost:     lb.maxConnsPerHost(), // Limit concurrent connections per host
 		IdleConnTimeout:     idleConnTimeout,
 
 		// Timeouts
@@ -456,12 +667,33 @@ func (lb *LoadBalancer) AddBackend(backendCfg config.BackendConfig) error {
 		ResponseHeaderTimeout: readTimeout,
 		ExpectContinueTimeout: 1 * time.Second,
 
+		// Guard against oversized header sets (0 = Go's default)
+		MaxResponseHeaderBytes: lb.maxResponseHeaderBytes(backendCfg),
+
 		// Performance optimizations
 		ForceAttemptHTTP2:  true,  // Use HTTP/2 when available
 		DisableCompression: false, // Let backend handle compression
 	}
 
-	proxy.Transport = transport
+	// Retire pooled connections by age or request count per connection_recycling
+	var roundTripper http.RoundTripper = transport
+	recycler := lb.newConnRecycler(backendCfg, transport)
+	if recycler != nil {
+		roundTripper = recycler.roundTripper(transport)
+	}
+
+	// Backend redirects are passed through, rewritten or followed per redirect_policy
+	proxy.Transport, err = lb.withRedirectPolicy(roundTripper, backendCfg, backendURL)
+	if err != nil {
+		return fmt.Errorf("backend %s: %w", backendCfg.Name, err)
+	}
+	proxy.ErrorHandler = lb.proxyErrorHandler(backendCfg.Name)
+
+	// Streaming backends want prompt flushes, bulk downloads larger copy buffers
+	proxy.FlushInterval = lb.flushInterval(backendCfg)
+	if size := lb.bufferSize(backendCfg); size > 0 {
+		proxy.BufferPool = newBufferPool(size)
+	}
 
 	// Create the backend
 	// If weight is not specified or is invalid, default to 1
@@ -477,19 +709,44 @@ func (lb *LoadBalancer) AddBackend(backendCfg config.BackendConfig) error {
 		UnhealthyUntil:    time.Time{}, // Zero time means it's healthy
 		ActiveConnections: 0,
 		Weight:            weight,
+		configWeight:      weight,
+		flushes:           proxy.FlushInterval != 0,
+		recycler:          recycler,
+	}
+	recycler.start(lb.ctx)
+
+	// Abort responses whose body stops arriving (headers alone are bounded by ResponseHeaderTimeout)
+	if idle := lb.backendBodyTimeout(backendCfg); idle > 0 {
+		proxy.ModifyResponse = lb.watchBodyStalls(backend, idle)
 	}
 
 	// Add to the strategy
 	lb.strategy.AddBackend(backend)
+	if lb.groups != nil {
+		lb.groups.addBackend(backend)
+	}
+	if lb.shadow != nil {
+		lb.shadow.addBackend(backend)
+	}
 
 	// Initialize metrics for backend health
 	if lb.metricsCollector != nil {
 		lb.metricsCollector.UpdateBackendHealth(backend.Name, backend.IsHealthy)
 	}
 
+	lb.publishEvent(EventBackendAdded, backend.Name, backendURL.String())
 	return nil
 }
 
+// maxResponseHeaderBytes returns the response header limit for a backend's
+// transport (0 = Go's default)
+func (lb *LoadBalancer) maxResponseHeaderBytes(backendCfg config.BackendConfig) int64 {
+	if backendCfg.MaxResponseHeaderBytes > 0 {
+		return backendCfg.MaxResponseHeaderBytes
+	}
+	return lb.proxyConfig().MaxResponseHeaderBytes
+}
+
 // RemoveBackend removes a backend server from the load balancer
 func (lb *LoadBalancer) RemoveBackend(name string) {
 	lb.mutex.Lock()
@@ -499,6 +756,16 @@ func (lb *LoadBalancer) RemoveBackend(name string) {
 	for _, backend := range lb.strategy.GetBackends() {
 		if backend.Name == name {
 			lb.strategy.RemoveBackend(backend)
+			backend.recycler.close()
+			if lb.groups != nil {
+				lb.groups.removeBackend(backend)
+			}
+			if lb.shadow != nil {
+				lb.shadow.removeBackend(backend)
+			}
+			lb.healthChecks.resetStreak(name)
+			lb.healthChecks.resetPassiveFailures(name)
+			lb.publishEvent(EventBackendRemoved, name, "")
 			break
 		}
 	}
@@ -508,54 +775,61 @@ func (lb *LoadBalancer) RemoveBackend(name string) {
 func (lb *LoadBalancer) NextBackend(r *http.Request) *Backend {
 	lb.mutex.RLock()
 	defer lb.mutex.RUnlock()
+	if lb.groups != nil {
+		return lb.nextGroupBackend(r)
+	}
 	return lb.strategy.NextBackend(r)
 }
 
-// MarkBackendUnhealthy marks a backend as unhealthy for a specified duration
+// MarkBackendUnhealthy marks a backend as unhealthy for a specified duration.
+// A backend that is already unhealthy stays down until the later of its
+// current deadline and the new one.
 func (lb *LoadBalancer) MarkBackendUnhealthy(backend *Backend, duration time.Duration) {
 	backend.Mutex.Lock()
-	defer backend.Mutex.Unlock()
-
-	backend.IsHealthy = false
-	backend.UnhealthyUntil = time.Now().Add(duration)
-
-	// Update metrics to reflect unhealthy status
+	changed := lb.applyHealthLocked(backend, false, false, time.Now().Add(duration))
+	// The trip is timed, so earlier active checks must not also count
+	// toward restoring or re-tripping the backend
+	lb.healthChecks.resetStreak(backend.Name)
 	if lb.metricsCollector != nil {
-		lb.metricsCollector.UpdateBackendHealth(backend.Name, false)
+		lb.metricsCollector.UpdateBackendHealthStreak(backend.Name, 0, 0)
 	}
+	backend.Mutex.Unlock()
 
+	// Notify outside the backend lock so observers can read backend state
+	if !changed {
+		logging.L().Debug().Str("backend", backend.Name).Dur("unhealthy_for", duration).Msg("backend unhealthy period extended")
+		return
+	}
 	logging.L().Warn().Str("backend", backend.Name).Dur("unhealthy_for", duration).Msg("backend marked unhealthy")
+	lb.publishEvent(EventBackendUnhealthy, backend.Name, duration.String())
 }
 
-// IsBackendHealthy checks if a backend is currently healthy
+// IsBackendHealthy checks if a backend is currently healthy, restoring it
+// once its unhealthy period has expired
 func (lb *LoadBalancer) IsBackendHealthy(backend *Backend) bool {
 	backend.Mutex.RLock()
 	isHealthy := backend.IsHealthy
-	unhealthyUntil := backend.UnhealthyUntil
+	recoverable := backend.recoverableLocked(time.Now())
 	backend.Mutex.RUnlock()
 
-	// If it's marked as unhealthy, check if the unhealthy period has expired
-	if !isHealthy && time.Now().After(unhealthyUntil) {
-		// The unhealthy period has expired, mark it as healthy again
-		backend.Mutex.Lock()
-		// Double-check after acquiring write lock to prevent race condition
-		if !backend.IsHealthy && time.Now().After(backend.UnhealthyUntil) {
-			backend.IsHealthy = true
-			backend.Mutex.Unlock()
-
-			// Update metrics to reflect healthy status
-			if lb.metricsCollector != nil {
-				lb.metricsCollector.UpdateBackendHealth(backend.Name, true)
-			}
+	if isHealthy || !recoverable {
+		return isHealthy
+	}
 
-			logging.L().Info().Str("backend", backend.Name).Msg("backend marked healthy")
-			return true
-		}
+	// Re-check under the write lock: another goroutine may have restored
+	// the backend, or tripped it again, since the read
+	backend.Mutex.Lock()
+	if !backend.recoverableLocked(time.Now()) {
+		isHealthy = backend.IsHealthy
 		backend.Mutex.Unlock()
-		return false
+		return isHealthy
 	}
+	lb.applyHealthLocked(backend, true, false, time.Time{})
+	backend.Mutex.Unlock()
 
-	return isHealthy
+	logging.L().Info().Str("backend", backend.Name).Msg("backend marked healthy")
+	lb.publishEvent(EventBackendHealthy, backend.Name, "")
+	return true
 }
 
 // IncrementConnections increments the active connection count for a backend
@@ -573,28 +847,77 @@ func (backend *Backend) GetActiveConnections() int32 {
 	return atomic.LoadInt32(&backend.ActiveConnections)
 }
 
+// Healthy returns the backend's current health flag. Strategies choosing
+// where to send requests use Available, which also honours drain hints.
+func (backend *Backend) Healthy() bool {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	return backend.IsHealthy
+}
+
+// GetWeight returns the backend's current weight
+func (backend *Backend) GetWeight() int {
+	backend.Mutex.RLock()
+	defer backend.Mutex.RUnlock()
+	return backend.Weight
+}
+
+// withoutBackend returns a new slice with target removed, preserving order.
+// The input slice is never modified, so a snapshot taken before the removal
+// stays valid and the rotation order of the remaining backends is unchanged.
+func withoutBackend(backends []*Backend, target *Backend) []*Backend {
+	for i, b := range backends {
+		if b == target {
+			next := make([]*Backend, 0, len(backends)-1)
+			next = append(next, backends[:i]...)
+			return append(next, backends[i+1:]...)
+		}
+	}
+	return backends
+}
+
 // GetMetricsCollector returns the metrics collector
 func (lb *LoadBalancer) GetMetricsCollector() *metrics.MetricsCollector {
 	return lb.metricsCollector
 }
 
+// BufferBudget returns the memory budget shared by buffering features
+func (lb *LoadBalancer) BufferBudget() *membudget.Budget {
+	return lb.bufferBudget
+}
+
+// TrustedProxies returns the parsed server.trusted_proxies the ip_hash
+// strategies resolve clients with, for other features that need the same policy
+func (lb *LoadBalancer) TrustedProxies() utils.TrustedProxies {
+	return lb.strategyEnv.TrustedProxies
+}
+
 // checkRateLimit checks if the request should be rate limited
 // Returns true if request should be allowed, false if rate limited
 func (lb *LoadBalancer) checkRateLimit(w http.ResponseWriter, r *http.Request) bool {
-	if lb.rateLimiter == nil {
+	if lb.rateLimits == nil {
 		return true
 	}
 
-	clientIP := utils.GetClientIP(r)
-	if !lb.rateLimiter.Allow(clientIP) {
+	rule := lb.rateLimits.Check(r)
+	if rule == nil {
+		return true
+	}
+
+	logger := logging.WithContext(r.Context())
+	if lb.legacyRateLimit {
+		// Keep the response existing single-bucket clients expect
 		lb.metricsCollector.RecordRateLimitedRequest()
-		logger := logging.WithContext(r.Context())
-		logger.Warn().Str("client_ip", clientIP).Msg("request rate limited")
+		logger.Warn().Str("client_ip", utils.GetClientIP(r)).Msg("request rate limited")
 		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 		return false
 	}
 
-	return true
+	lb.metricsCollector.RecordRateLimitRuleRejection(rule.Name)
+	logger.Warn().Str("client_ip", utils.GetClientIP(r)).Str("rule", rule.Name).Msg("request rate limited")
+	w.Header().Set("X-RateLimit-Rule", rule.Name)
+	http.Error(w, "Rate limit exceeded: "+rule.Name, http.StatusTooManyRequests)
+	return false
 }
 
 // ServeHTTP implements the http.Handler interface
@@ -602,8 +925,26 @@ func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	logger := logging.WithContext(r.Context())
 
+	// Refuse forward-proxy style requests before anything else sees them
+	if !lb.checkRequestTarget(w, r) {
+		return
+	}
+	defer lb.shutdown.enter()()
+
+	// Synthetic checks stay out of traffic metrics and chargeback
+	synthetic := proxyinfo.IsSynthetic(r)
+
+	// Attribute the request's usage for chargeback
+	if lb.costs != nil && !synthetic {
+		var recordCost func()
+		w, r, recordCost = lb.attributeCost(w, r)
+		defer recordCost()
+	}
+
 	// Record the request
-	lb.metricsCollector.RecordRequest()
+	if !synthetic {
+		lb.metricsCollector.RecordRequest()
+	}
 
 	// Check rate limiting
 	if !lb.checkRateLimit(w, r) {
@@ -632,7 +973,9 @@ func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			default:
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 			}
-			lb.metricsCollector.RecordResponse(false, time.Since(startTime))
+			if !synthetic {
+				lb.metricsCollector.RecordResponse(false, time.Since(startTime))
+			}
 			return
 		}
 	} else {
@@ -645,33 +988,93 @@ func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleRequest handles the actual request processing
 func (lb *LoadBalancer) handleRequest(w http.ResponseWriter, r *http.Request, startTime time.Time) error {
-	backend := lb.findHealthyBackend(r)
-	if backend == nil {
-		logging.WithContext(r.Context()).Warn().Str("path", r.URL.Path).Msg("no healthy backend available")
-		http.Error(w, "No healthy backend servers available", http.StatusServiceUnavailable)
-		return nil
+	info := proxyinfo.FromContext(r.Context())
+	var tried map[string]bool
+	for attempt := 0; attempt < maxPoolFailoverAttempts; attempt++ {
+		backend := lb.findHealthyBackend(r, tried)
+		if backend == nil {
+			if tried != nil {
+				// Every reachable backend ran out of pooled connections
+				break
+			}
+			logging.WithContext(r.Context()).Warn().Str("path", r.URL.Path).Msg("no healthy backend available")
+			http.Error(w, "No healthy backend servers available", http.StatusServiceUnavailable)
+			return nil
+		}
+
+		info.SetBackend(backend.Name, attempt)
+
+		// Record where the shadow strategy would have sent the first pick
+		shadowed := attempt == 0 && lb.shadow != nil && lb.shadow.observe(r, backend, lb.shadowCandidate)
+
+		// Process the request with the selected backend
+		proxyStart := time.Now()
+		err := lb.proxyRequest(backend, w, r, startTime)
+		if shadowed {
+			lb.shadow.observeLatency(backend.Name, time.Since(proxyStart))
+		}
+		if !errors.Is(err, errPoolExhausted) {
+			return err
+		}
+		if !canFailover(r) {
+			break
+		}
+		if tried == nil {
+			tried = make(map[string]bool)
+		}
+		tried[backend.Name] = true
 	}
 
-	// Process the request with the selected backend
-	return lb.proxyRequest(backend, w, r, startTime)
+	utils.WriteError(w, http.StatusServiceUnavailable, errCodePoolExhausted, "Backend connection pool exhausted")
+	return nil
 }
 
-// findHealthyBackend attempts to find a healthy backend with retries
-func (lb *LoadBalancer) findHealthyBackend(r *http.Request) *Backend {
+// findHealthyBackend attempts to find a healthy backend with retries,
+// skipping any backend listed in exclude
+func (lb *LoadBalancer) findHealthyBackend(r *http.Request, exclude map[string]bool) *Backend {
 	for i := 0; i < 3; i++ { // Try up to 3 times to find a healthy backend
 		backend := lb.NextBackend(r)
 		if backend == nil {
 			return nil
 		}
 
-		if lb.IsBackendHealthy(backend) {
+		if exclude[backend.Name] {
+			continue
+		}
+
+		if lb.isBackendAvailable(backend) {
+			return backend
+		}
+	}
+
+	// Strategies that don't skip unhealthy backends, like round robin, can
+	// land on unhealthy ones every time while another is healthy
+	return lb.anyHealthyBackend(exclude)
+}
+
+// anyHealthyBackend returns the first healthy backend taking traffic that is
+// not listed in exclude, or nil if there is none
+func (lb *LoadBalancer) anyHealthyBackend(exclude map[string]bool) *Backend {
+	lb.mutex.RLock()
+	var backends []*Backend
+	if lb.groups != nil {
+		backends = lb.groups.activeBackends()
+	} else {
+		backends = lb.strategy.GetBackends()
+	}
+	lb.mutex.RUnlock()
+
+	for _, backend := range backends {
+		if !exclude[backend.Name] && lb.isBackendAvailable(backend) {
 			return backend
 		}
 	}
 	return nil
 }
 
-// proxyRequest forwards the request to a backend and handles the response
+// proxyRequest forwards the request to a backend and handles the response.
+// Returns errPoolExhausted without writing a response if the backend could
+// not supply a connection within the configured wait budget.
 func (lb *LoadBalancer) proxyRequest(backend *Backend, w http.ResponseWriter, r *http.Request, startTime time.Time) error {
 	// Track the active connection
 	backend.IncrementConnections()
@@ -681,15 +1084,40 @@ func (lb *LoadBalancer) proxyRequest(backend *Backend, w http.ResponseWriter, r
 	rw := &responseWriter{
 		ResponseWriter: w,
 		statusCode:     http.StatusOK, // Default status code
+		flushes:        backend.flushes,
+		backend:        backend.Name,
+		shutdown:       lb.shutdown,
 	}
 
+	// Measure (and bound) the wait for a pooled connection
+	maxWait := time.Duration(lb.proxyConfig().MaxConnWaitMs) * time.Millisecond
+	tracked, tracker, cancel := withConnWaitTracking(r, maxWait)
+
+	// A stalled body aborts the copy with http.ErrAbortHandler; release the
+	// connection slot before the panic closes the client connection
+	defer func() {
+		if p := recover(); p != nil {
+			cancel()
+			backend.DecrementConnections()
+			lb.metricsCollector.UpdateBackendConnections(backend.Name, backend.GetActiveConnections())
+			panic(p)
+		}
+	}()
+
 	// Forward the request to the selected backend
-	backend.ReverseProxy.ServeHTTP(rw, r)
+	upstreamStart := time.Now()
+	backend.ReverseProxy.ServeHTTP(rw, tracked)
+	cancel()
+	proxyinfo.FromContext(r.Context()).SetUpstreamLatency(time.Since(upstreamStart))
 
 	// Decrement the connection count when done
 	backend.DecrementConnections()
 	lb.metricsCollector.UpdateBackendConnections(backend.Name, backend.GetActiveConnections())
 
+	if err := lb.recordConnWait(backend, tracker, r); err != nil {
+		return err
+	}
+
 	// Record metrics and handle passive health checks
 	lb.recordRequestMetrics(backend, rw.statusCode, startTime, r)
 
@@ -700,8 +1128,10 @@ func (lb *LoadBalancer) proxyRequest(backend *Backend, w http.ResponseWriter, r
 func (lb *LoadBalancer) recordRequestMetrics(backend *Backend, statusCode int, startTime time.Time, r *http.Request) {
 	responseTime := time.Since(startTime)
 	success := statusCode < 500
-	lb.metricsCollector.RecordResponse(success, responseTime)
-	lb.metricsCollector.RecordBackendRequest(backend.Name, success, responseTime)
+	if !proxyinfo.IsSynthetic(r) {
+		lb.metricsCollector.RecordResponse(success, responseTime)
+		lb.metricsCollector.RecordBackendRequest(backend.Name, success, responseTime)
+	}
 
 	// Check if the backend returned an error status code (5xx) and passive health checks are enabled
 	if statusCode >= 500 && lb.healthChecks.passiveEnabled {
@@ -709,6 +1139,11 @@ func (lb *LoadBalancer) recordRequestMetrics(backend *Backend, statusCode int, s
 		return
 	}
 
+	// A logging plugin replacing this line logs the request itself
+	if info := proxyinfo.FromContext(r.Context()); info != nil && info.SuppressCoreLog {
+		return
+	}
+
 	logger := logging.WithContext(r.Context())
 	latencyMs := float64(responseTime) / float64(time.Millisecond)
 	logger.Info().
@@ -722,18 +1157,23 @@ func (lb *LoadBalancer) recordRequestMetrics(backend *Backend, statusCode int, s
 
 // handlePassiveHealthCheck handles passive health check logic for failed requests
 func (lb *LoadBalancer) handlePassiveHealthCheck(backend *Backend, statusCode int, r *http.Request) {
-	logger := logging.WithContext(r.Context())
-	// Increment failure count for this backend
-	lb.healthChecks.unhealthyBackendMu.Lock()
-	lb.healthChecks.unhealthyBackends[backend.Name]++
-	failureCount := lb.healthChecks.unhealthyBackends[backend.Name]
-	lb.healthChecks.unhealthyBackendMu.Unlock()
+	failureCount := lb.countPassiveFailure(backend)
 
-	logger.Warn().Str("backend", backend.Name).
+	logging.WithContext(r.Context()).Warn().Str("backend", backend.Name).
 		Int("status", statusCode).
 		Int("failure_count", failureCount).
 		Int("threshold", lb.healthChecks.passiveThreshold).
 		Msg("backend returned server error")
+}
+
+// countPassiveFailure increments the passive failure count for a backend and
+// marks it unhealthy once the threshold is reached. Returns the new count.
+func (lb *LoadBalancer) countPassiveFailure(backend *Backend) int {
+	// Increment failure count for this backend
+	lb.healthChecks.unhealthyBackendMu.Lock()
+	lb.healthChecks.unhealthyBackends[backend.Name]++
+	failureCount := lb.healthChecks.unhealthyBackends[backend.Name]
+	lb.healthChecks.unhealthyBackendMu.Unlock()
 
 	// If failure count exceeds threshold, mark as unhealthy
 	if failureCount >= lb.healthChecks.passiveThreshold {
@@ -744,12 +1184,26 @@ func (lb *LoadBalancer) handlePassiveHealthCheck(backend *Backend, statusCode in
 		lb.healthChecks.unhealthyBackends[backend.Name] = 0
 		lb.healthChecks.unhealthyBackendMu.Unlock()
 	}
+	return failureCount
+}
+
+// resetPassiveFailures forgets a removed backend's passive failure count
+func (hc *healthChecker) resetPassiveFailures(name string) {
+	if hc == nil {
+		return
+	}
+	hc.unhealthyBackendMu.Lock()
+	delete(hc.unhealthyBackends, name)
+	hc.unhealthyBackendMu.Unlock()
 }
 
 // responseWriter is a custom ResponseWriter that captures the status code
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	flushes    bool // Forward Flush calls; otherwise the response stays buffered
+	backend    string
+	shutdown   *shutdownCoordinator // registers hijacked connections as tunnels
 }
 
 // WriteHeader captures the status code
@@ -758,13 +1212,27 @@ func (rw *responseWriter) WriteHeader(statusCode int) {
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Flush forwards to the underlying writer when the backend has flushing enabled
+func (rw *responseWriter) Flush() {
+	if !rw.flushes {
+		return
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // Hijack implements the http.Hijacker interface to support websockets
 func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	h, ok := rw.ResponseWriter.(http.Hijacker)
 	if !ok {
 		return nil, nil, fmt.Errorf("response writer does not implement http.Hijacker")
 	}
-	return h.Hijack()
+	conn, brw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return rw.shutdown.trackTunnel(conn, rw.backend), brw, nil
 }
 
 // Stop gracefully shuts down the load balancer and waits for all health check goroutines to finish
@@ -772,6 +1240,7 @@ func (lb *LoadBalancer) Stop() {
 	logging.L().Info().Msg("shutting down load balancer")
 	lb.cancel()
 	lb.healthCheckWg.Wait()
+	lb.rateLimits.Close()
 
 	// Shutdown WebSocket pool if enabled
 	if lb.wsPool != nil {
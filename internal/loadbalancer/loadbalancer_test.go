@@ -206,7 +206,8 @@ func TestServeHTTP(t *testing.T) {
 	}
 
 	// Create a test request
-	req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "localhost:8080"
 	recorder := httptest.NewRecorder()
 
 	// Send the request to the load balancer
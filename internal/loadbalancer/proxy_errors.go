@@ -0,0 +1,64 @@
+package loadbalancer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+const (
+	// errCodeHeaderOverflow is returned when a backend's response headers exceed the configured limit
+	errCodeHeaderOverflow = "backend_header_overflow"
+)
+
+// isHeaderOverflowError reports whether err came from http.Transport rejecting
+// a response whose headers exceeded MaxResponseHeaderBytes.
+// The transport returns an unexported, untyped error for this case, so the
+// message is the only stable signal available.
+func isHeaderOverflowError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "server response headers exceeded")
+}
+
+// proxyErrorHandler returns the ReverseProxy ErrorHandler for a backend.
+// Known transport failures are mapped to distinct error codes; everything
+// else keeps the default 502 behaviour of httputil.ReverseProxy.
+func (lb *LoadBalancer) proxyErrorHandler(backendName string) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		logger := logging.WithContext(r.Context())
+
+		// Pool exhaustion is reported by the caller, which may fail over
+		// to another backend, so nothing must be written here
+		if t := connWaitFromContext(r.Context()); t != nil && t.expired() {
+			return
+		}
+
+		if isHeaderOverflowError(err) {
+			if lb.metricsCollector != nil {
+				lb.metricsCollector.RecordBackendHeaderOverflow(backendName)
+			}
+			logger.Warn().Str("backend", backendName).Err(err).Msg("backend response headers exceeded limit")
+			utils.WriteError(w, http.StatusBadGateway, errCodeHeaderOverflow, "Backend response headers too large")
+			return
+		}
+
+		switch {
+		case errors.Is(err, errTooManyRedirects):
+			logger.Warn().Str("backend", backendName).Err(err).Msg("backend redirect chain too long")
+			utils.WriteError(w, http.StatusBadGateway, errCodeTooManyRedirects, "Backend redirected too many times")
+			return
+		case errors.Is(err, errRedirectNotAllowed):
+			logger.Warn().Str("backend", backendName).Err(err).Msg("backend redirect target not allowed")
+			utils.WriteError(w, http.StatusBadGateway, errCodeRedirectNotAllowed, "Backend redirected to a host that is not allowed")
+			return
+		}
+
+		logger.Error().Str("backend", backendName).Err(err).Msg("error proxying request")
+		w.WriteHeader(http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,130 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func newRateLimitTestLB(t *testing.T, rl config.RateLimitConfig) *LoadBalancer {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(backend.Close)
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "app", Address: backend.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		RateLimit:    rl,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb
+}
+
+func serveFrom(lb *LoadBalancer, method, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, req)
+	return rec
+}
+
+func expectRateLimitedBy(t *testing.T, rec *httptest.ResponseRecorder, rule string) {
+	t.Helper()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 from rule %s, got %d", rule, rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Rule"); got != rule {
+		t.Errorf("expected X-RateLimit-Rule %q, got %q", rule, got)
+	}
+	if !strings.Contains(rec.Body.String(), rule) {
+		t.Errorf("expected body to name rule %q, got %q", rule, rec.Body.String())
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	lb := newRateLimitTestLB(t, config.RateLimitConfig{
+		Enabled: true,
+		Rules: []config.RateLimitRule{
+			{Name: "per-ip", MaxTokens: 4, RefillRate: 3600},
+			{Name: "expensive", MaxTokens: 2, RefillRate: 3600, Match: config.RateLimitMatch{PathPrefixes: []string{"/expensive"}}},
+		},
+	})
+	const client = "192.0.2.1:1234"
+
+	// Both rules match: the scoped bucket empties first
+	for i := 0; i < 2; i++ {
+		if rec := serveFrom(lb, http.MethodGet, "/expensive/report", client); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	expectRateLimitedBy(t, serveFrom(lb, http.MethodGet, "/expensive/report", client), "expensive")
+
+	// Requests outside the prefix skip the scoped rule until the per-IP bucket empties
+	if rec := serveFrom(lb, http.MethodGet, "/cheap", client); rec.Code != http.StatusOK {
+		t.Fatalf("expected non-matching request to skip the scoped rule, got %d", rec.Code)
+	}
+	expectRateLimitedBy(t, serveFrom(lb, http.MethodGet, "/cheap", client), "per-ip")
+	// Now the per-IP rule, evaluated first, rejects even matching requests
+	expectRateLimitedBy(t, serveFrom(lb, http.MethodGet, "/expensive/report", client), "per-ip")
+
+	// Other clients have their own buckets
+	if rec := serveFrom(lb, http.MethodGet, "/expensive/report", "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected another client to be allowed, got %d", rec.Code)
+	}
+
+	m := lb.GetMetricsCollector().GetMetrics()
+	if m.RateLimitRuleRejections["expensive"] != 1 || m.RateLimitRuleRejections["per-ip"] != 2 {
+		t.Errorf("unexpected per-rule rejections: %v", m.RateLimitRuleRejections)
+	}
+	if m.RateLimitedRequests != 3 {
+		t.Errorf("expected 3 rate limited requests, got %d", m.RateLimitedRequests)
+	}
+}
+
+func TestRateLimitLegacyConfig(t *testing.T) {
+	lb := newRateLimitTestLB(t, config.RateLimitConfig{Enabled: true, MaxTokens: 2, RefillRate: 3600})
+
+	for i := 0; i < 2; i++ {
+		if rec := serveFrom(lb, http.MethodGet, "/", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	// Configs without rules keep the original response: no rule is named
+	rec := serveFrom(lb, http.MethodPost, "/other", "192.0.2.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Rule"); got != "" {
+		t.Errorf("expected no X-RateLimit-Rule header, got %q", got)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "Rate limit exceeded" {
+		t.Errorf("expected body %q, got %q", "Rate limit exceeded", got)
+	}
+	if rec := serveFrom(lb, http.MethodGet, "/", "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected another client to be allowed, got %d", rec.Code)
+	}
+	if got := lb.GetMetricsCollector().GetMetrics().RateLimitedRequests; got != 1 {
+		t.Errorf("expected 1 rate limited request, got %d", got)
+	}
+}
+
+func TestRateLimitInvalidRuleKey(t *testing.T) {
+	_, err := NewLoadBalancer(&config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		RateLimit: config.RateLimitConfig{
+			Enabled: true,
+			Rules:   []config.RateLimitRule{{Name: "bad", Key: "cookie", MaxTokens: 1, RefillRate: 1}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "rate_limit") {
+		t.Fatalf("expected rate_limit error for invalid key, got %v", err)
+	}
+}
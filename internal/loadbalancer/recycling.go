@@ -0,0 +1,224 @@
+package loadbalancer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// connRecycler retires pooled connections to one backend per its
+// connection_recycling settings.
+//
+// http.Transport can't evict individual pooled connections, so connections
+// are wrapped at dial time to record when they were opened, and request
+// traces count the requests each carries and note when it goes back to the
+// idle pool. A request that gets a connection at its request or age limit is
+// sent with Connection: close, so the backend closes the connection after
+// responding. For connections that sit idle past max age, a sweeper closes
+// the transport's whole idle pool; younger idle connections go with it and
+// are simply redialed.
+type connRecycler struct {
+	backend     string
+	maxAge      time.Duration
+	maxRequests int
+	transport   *http.Transport
+	onRecycle   func(n int)
+
+	mu    sync.Mutex
+	conns map[string]*recycledConn // Open connections by local and remote address
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// recycledConn is a backend connection tracked by a connRecycler. requests
+// and idle are guarded by the recycler's mutex.
+type recycledConn struct {
+	net.Conn
+	rc        *connRecycler
+	key       string
+	born      time.Time
+	requests  int
+	idle      bool
+	closeOnce sync.Once
+}
+
+// newConnRecycler installs connection tracking on transport, or returns nil
+// when connection_recycling is not configured for the backend
+func (lb *LoadBalancer) newConnRecycler(backendCfg config.BackendConfig, transport *http.Transport) *connRecycler {
+	cfg := backendCfg.ConnectionRecycling
+	if cfg.MaxConnectionAgeSeconds <= 0 && cfg.MaxRequestsPerConnection <= 0 {
+		return nil
+	}
+	name := backendCfg.Name
+	rc := &connRecycler{
+		backend:     name,
+		maxAge:      time.Duration(cfg.MaxConnectionAgeSeconds) * time.Second,
+		maxRequests: cfg.MaxRequestsPerConnection,
+		transport:   transport,
+		conns:       make(map[string]*recycledConn),
+		stop:        make(chan struct{}),
+		onRecycle: func(n int) {
+			if lb.metricsCollector != nil && n > 0 {
+				lb.metricsCollector.RecordBackendConnectionsRecycled(name, uint64(n)) // #nosec G115 - n is a non-negative count
+			}
+		},
+	}
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return rc.track(conn), nil
+	}
+	return rc
+}
+
+// connKey identifies a connection by its endpoints
+func connKey(conn net.Conn) string {
+	return conn.LocalAddr().String() + "->" + conn.RemoteAddr().String()
+}
+
+// track starts tracking a newly dialed connection
+func (rc *connRecycler) track(conn net.Conn) net.Conn {
+	c := &recycledConn{Conn: conn, rc: rc, key: connKey(conn), born: time.Now()}
+	rc.mu.Lock()
+	rc.conns[c.key] = c
+	rc.mu.Unlock()
+	return c
+}
+
+// Close stops tracking the connection and closes it
+func (c *recycledConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.rc.mu.Lock()
+		if c.rc.conns[c.key] == c {
+			delete(c.rc.conns, c.key)
+		}
+		c.rc.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+// acquired counts a request on the connection with the given key and reports
+// whether the connection should be closed after it
+func (rc *connRecycler) acquired(key string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	c := rc.conns[key]
+	if c == nil {
+		return false
+	}
+	c.idle = false
+	c.requests++
+	return (rc.maxRequests > 0 && c.requests >= rc.maxRequests) ||
+		(rc.maxAge > 0 && time.Since(c.born) >= rc.maxAge)
+}
+
+// released marks the connection with the given key as back in the idle pool
+func (rc *connRecycler) released(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if c := rc.conns[key]; c != nil {
+		c.idle = true
+	}
+}
+
+// roundTripper wraps next so requests are sent with Connection: close when
+// the connection they get is due to be retired
+func (rc *connRecycler) roundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var mu sync.Mutex
+		var key string
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				k := connKey(info.Conn)
+				mu.Lock()
+				key = k
+				mu.Unlock()
+				if rc.acquired(k) {
+					// GotConn runs before the request is written
+					req.Header.Set("Connection", "close")
+					rc.onRecycle(1)
+				}
+			},
+			PutIdleConn: func(err error) {
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				k := key
+				mu.Unlock()
+				rc.released(k)
+			},
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		return next.RoundTrip(req)
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// start runs the idle connection sweeper until ctx is done or close is called
+func (rc *connRecycler) start(ctx context.Context) {
+	if rc == nil || rc.maxAge <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(rc.maxAge / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rc.stop:
+				return
+			case <-ticker.C:
+				rc.sweep()
+			}
+		}
+	}()
+}
+
+// sweep closes the idle pool once any idle connection is past max age
+func (rc *connRecycler) sweep() {
+	rc.mu.Lock()
+	idle, expired := 0, false
+	for _, c := range rc.conns {
+		if !c.idle {
+			continue
+		}
+		idle++
+		if time.Since(c.born) >= rc.maxAge {
+			expired = true
+		}
+	}
+	rc.mu.Unlock()
+	if !expired {
+		return
+	}
+
+	rc.transport.CloseIdleConnections()
+	rc.onRecycle(idle)
+	logging.L().Debug().Str("backend", rc.backend).Int("connections", idle).Dur("max_age", rc.maxAge).Msg("recycled idle backend connections")
+}
+
+// close stops the sweeper
+func (rc *connRecycler) close() {
+	if rc == nil {
+		return
+	}
+	rc.stopOnce.Do(func() { close(rc.stop) })
+}
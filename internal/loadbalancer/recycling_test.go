@@ -0,0 +1,132 @@
+package loadbalancer
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// connLog records the client address of every request a backend serves and
+// the number of connections opened to it
+type connLog struct {
+	mu     sync.Mutex
+	addrs  []string
+	opened int
+}
+
+func (cl *connLog) snapshot() ([]string, int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return append([]string(nil), cl.addrs...), cl.opened
+}
+
+// newRecyclingLB proxies to a backend recording its connections, with the
+// given connection_recycling settings
+func newRecyclingLB(t *testing.T, recycling config.ConnectionRecyclingConfig) (*LoadBalancer, *connLog) {
+	t.Helper()
+	cl := &connLog{}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cl.mu.Lock()
+		cl.addrs = append(cl.addrs, r.RemoteAddr)
+		cl.mu.Unlock()
+		_, _ = io.WriteString(w, "ok")
+	}))
+	backend.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			cl.mu.Lock()
+			cl.opened++
+			cl.mu.Unlock()
+		}
+	}
+	backend.Start()
+	t.Cleanup(backend.Close)
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "appliance", Address: backend.URL, ConnectionRecycling: recycling}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb, cl
+}
+
+func sendRecyclingRequest(t *testing.T, lb *LoadBalancer) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestConnectionRecycling_MaxRequestsPerConnection(t *testing.T) {
+	lb, cl := newRecyclingLB(t, config.ConnectionRecyclingConfig{MaxRequestsPerConnection: 2})
+
+	for i := 0; i < 6; i++ {
+		sendRecyclingRequest(t, lb)
+	}
+
+	addrs, opened := cl.snapshot()
+	if opened != 3 {
+		t.Errorf("expected 3 connections for 6 requests, got %d", opened)
+	}
+	for i := 0; i < len(addrs); i += 2 {
+		if addrs[i] != addrs[i+1] {
+			t.Errorf("requests %d and %d should share a connection: %s vs %s", i+1, i+2, addrs[i], addrs[i+1])
+		}
+		if i > 0 && addrs[i] == addrs[i-1] {
+			t.Errorf("request %d should arrive on a fresh connection, reused %s", i+1, addrs[i])
+		}
+	}
+	if got := lb.GetMetricsCollector().GetMetrics().BackendMetrics["appliance"].ConnectionsRecycled; got != 3 {
+		t.Errorf("expected 3 recycled connections, got %d", got)
+	}
+}
+
+func TestConnectionRecycling_MaxConnectionAge(t *testing.T) {
+	lb, cl := newRecyclingLB(t, config.ConnectionRecyclingConfig{MaxConnectionAgeSeconds: 1})
+
+	// Keep a trickle of requests going well past the max age
+	deadline := time.Now().Add(2500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		sendRecyclingRequest(t, lb)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	addrs, opened := cl.snapshot()
+	if opened < 2 {
+		t.Fatalf("expected connections to be re-established, got %d for %d requests", opened, len(addrs))
+	}
+	// Without recycling one keep-alive connection would carry every request
+	if opened > 6 {
+		t.Errorf("expected connections reused until they age out, got %d for %d requests", opened, len(addrs))
+	}
+	if got := lb.GetMetricsCollector().GetMetrics().BackendMetrics["appliance"].ConnectionsRecycled; got == 0 {
+		t.Error("expected recycled connections to be counted")
+	}
+}
+
+func TestConnectionRecycling_IdleSweep(t *testing.T) {
+	lb, cl := newRecyclingLB(t, config.ConnectionRecyclingConfig{MaxConnectionAgeSeconds: 1})
+
+	sendRecyclingRequest(t, lb)
+	// The connection ages out while idle in the pool
+	time.Sleep(1500 * time.Millisecond)
+	sendRecyclingRequest(t, lb)
+
+	addrs, opened := cl.snapshot()
+	if opened != 2 || addrs[0] == addrs[1] {
+		t.Errorf("expected the idle connection closed and a new one opened, got %d connections: %v", opened, addrs)
+	}
+	if got := lb.GetMetricsCollector().GetMetrics().BackendMetrics["appliance"].ConnectionsRecycled; got != 1 {
+		t.Errorf("expected 1 recycled connection, got %d", got)
+	}
+}
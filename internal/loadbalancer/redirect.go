@@ -0,0 +1,227 @@
+package loadbalancer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+const (
+	// defaultMaxRedirects caps followed redirects when max_redirects is unset
+	defaultMaxRedirects = 5
+
+	// redirectDrainLimit bounds how much of a redirect body is read so its
+	// connection can be reused
+	redirectDrainLimit = 4 << 10
+
+	// errCodeTooManyRedirects is returned when a followed redirect chain exceeds max_redirects
+	errCodeTooManyRedirects = "backend_too_many_redirects"
+	// errCodeRedirectNotAllowed is returned when a backend redirects to a host outside allowed_redirect_hosts
+	errCodeRedirectNotAllowed = "backend_redirect_not_allowed"
+)
+
+var (
+	errTooManyRedirects   = errors.New("backend redirect chain exceeded max_redirects")
+	errRedirectNotAllowed = errors.New("backend redirected to a host outside allowed_redirect_hosts")
+)
+
+// redirectTransport applies a backend's redirect_policy to 3xx responses.
+// Under rewrite, Location headers pointing at the backend or an allowed host
+// are rewritten to the host the client used. Under follow, the redirect is
+// re-issued to the backend side and the final response is returned as if no
+// redirect happened.
+//
+// Helios does not buffer request bodies, so requests carrying a body are never
+// followed; their redirects are rewritten instead so internal hosts don't leak.
+type redirectTransport struct {
+	next         http.RoundTripper
+	policy       string
+	origin       *url.URL
+	allowed      map[string]bool
+	maxRedirects int
+	trusted      utils.TrustedProxies // Peers whose X-Forwarded-Proto is believed
+	onFollow     func()
+	onRewrite    func()
+}
+
+// withRedirectPolicy wraps a backend transport according to its redirect_policy.
+// pass_through returns the transport unchanged.
+func (lb *LoadBalancer) withRedirectPolicy(next http.RoundTripper, backendCfg config.BackendConfig, origin *url.URL) (http.RoundTripper, error) {
+	if err := config.ValidateRedirectPolicy(backendCfg); err != nil {
+		return nil, err
+	}
+	if backendCfg.RedirectPolicy == "" || backendCfg.RedirectPolicy == config.RedirectPassThrough {
+		return next, nil
+	}
+
+	maxRedirects := backendCfg.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	allowed := make(map[string]bool, len(backendCfg.AllowedRedirectHosts))
+	for _, host := range backendCfg.AllowedRedirectHosts {
+		allowed[strings.ToLower(host)] = true
+	}
+
+	name := backendCfg.Name
+	return &redirectTransport{
+		next:         next,
+		policy:       backendCfg.RedirectPolicy,
+		origin:       origin,
+		allowed:      allowed,
+		maxRedirects: maxRedirects,
+		trusted:      lb.strategyEnv.TrustedProxies,
+		onFollow: func() {
+			if lb.metricsCollector != nil {
+				lb.metricsCollector.RecordBackendRedirectFollowed(name)
+			}
+		},
+		onRewrite: func() {
+			if lb.metricsCollector != nil {
+				lb.metricsCollector.RecordBackendRedirectRewritten(name)
+			}
+		},
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !isRedirect(resp) {
+		return resp, err
+	}
+	if t.policy == config.RedirectFollow && !hasBody(req) {
+		return t.follow(req, resp)
+	}
+	t.rewrite(req, resp)
+	return resp, nil
+}
+
+// follow re-issues redirected requests until a non-redirect response arrives
+func (t *redirectTransport) follow(req *http.Request, resp *http.Response) (*http.Response, error) {
+	for hops := 0; isRedirect(resp); hops++ {
+		target, err := resp.Location()
+		if err != nil {
+			// Unparseable Location: hand the response to the client untouched
+			return resp, nil
+		}
+		discardBody(resp)
+		if hops >= t.maxRedirects {
+			return nil, fmt.Errorf("%w (%d)", errTooManyRedirects, t.maxRedirects)
+		}
+		if !t.allowedTarget(target) {
+			return nil, fmt.Errorf("%w: %s", errRedirectNotAllowed, target.Host)
+		}
+
+		req = t.redirectRequest(req, resp.StatusCode, target)
+		resp, err = t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.onFollow()
+	}
+	return resp, nil
+}
+
+// redirectRequest builds the request for the next hop. 307 and 308 keep the
+// method; 301, 302 and 303 switch to GET (HEAD stays HEAD) per RFC 9110.
+func (t *redirectTransport) redirectRequest(req *http.Request, status int, target *url.URL) *http.Request {
+	next := req.Clone(req.Context())
+	next.URL = target
+	next.Body = nil
+	next.ContentLength = 0
+
+	// Same-origin hops keep the client's Host for virtual hosting; other
+	// hosts are addressed by their own name and don't get the client's
+	// credentials
+	if !sameOrigin(target, t.origin) {
+		next.Host = ""
+		next.Header.Del("Authorization")
+		next.Header.Del("Cookie")
+		next.Header.Del("Proxy-Authorization")
+	}
+
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther:
+		if next.Method != http.MethodHead {
+			next.Method = http.MethodGet
+		}
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	}
+	return next
+}
+
+// rewrite points a Location at the backend or an allowed host back at the
+// scheme and host the client used
+func (t *redirectTransport) rewrite(req *http.Request, resp *http.Response) {
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !loc.IsAbs() || req.Host == "" {
+		return
+	}
+	if !sameOrigin(loc, t.origin) && !t.allowedHost(loc) {
+		return
+	}
+	loc.Scheme = publicScheme(req, t.trusted)
+	loc.Host = req.Host
+	resp.Header.Set("Location", loc.String())
+	t.onRewrite()
+}
+
+// allowedTarget reports whether a redirect may be followed
+func (t *redirectTransport) allowedTarget(target *url.URL) bool {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return false
+	}
+	return sameOrigin(target, t.origin) || t.allowedHost(target)
+}
+
+// allowedHost matches a URL against allowed_redirect_hosts; entries without
+// a port match any port
+func (t *redirectTransport) allowedHost(u *url.URL) bool {
+	return t.allowed[strings.ToLower(u.Host)] || t.allowed[strings.ToLower(u.Hostname())]
+}
+
+func isRedirect(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return resp.Header.Get("Location") != ""
+	}
+	return false
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// publicScheme returns the scheme the client used to reach Helios. Only a
+// trusted proxy's X-Forwarded-Proto is believed; otherwise it is the scheme
+// of the connection.
+func publicScheme(req *http.Request, trusted utils.TrustedProxies) string {
+	if trusted.TrustsPeer(req) {
+		if proto := req.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// discardBody drains a small amount of a response body and closes it
+func discardBody(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, redirectDrainLimit)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,315 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// redirectFixture is a backend that redirects to itself and to an internal peer
+type redirectFixture struct {
+	backend *httptest.Server
+	peer    *httptest.Server
+	hits    int32
+}
+
+func newRedirectFixture(t *testing.T) *redirectFixture {
+	t.Helper()
+	f := &redirectFixture{}
+	f.peer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("peer " + r.Method + " " + r.URL.Path))
+	}))
+	t.Cleanup(f.peer.Close)
+
+	f.backend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&f.hits, 1)
+		switch r.URL.Path {
+		case "/self":
+			http.Redirect(w, r, f.backend.URL+"/landing", http.StatusFound)
+		case "/peer":
+			http.Redirect(w, r, f.peer.URL+"/final", http.StatusFound)
+		case "/external":
+			http.Redirect(w, r, "https://login.example.org/authorize", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/see-other":
+			http.Redirect(w, r, "/echo", http.StatusSeeOther)
+		case "/temporary":
+			http.Redirect(w, r, "/echo", http.StatusTemporaryRedirect)
+		default:
+			_, _ = w.Write([]byte("backend " + r.Method + " " + r.URL.Path))
+		}
+	}))
+	t.Cleanup(f.backend.Close)
+	return f
+}
+
+func (f *redirectFixture) peerHost(t *testing.T) string {
+	u, err := url.Parse(f.peer.URL)
+	if err != nil {
+		t.Fatalf("invalid peer url: %v", err)
+	}
+	return u.Host
+}
+
+func (f *redirectFixture) newLB(t *testing.T, backendCfg config.BackendConfig) *LoadBalancer {
+	t.Helper()
+	backendCfg.Name = "redirecting"
+	backendCfg.Address = f.backend.URL
+	lb, err := NewLoadBalancer(&config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends:     []config.BackendConfig{backendCfg},
+	})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb
+}
+
+func serveRedirect(lb *LoadBalancer, method, path, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Host = "public.example.com"
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRedirectPolicy_PassThrough(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{})
+
+	rec := serveRedirect(lb, http.MethodGet, "/peer", "")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Location"); got != f.peer.URL+"/final" {
+		t.Fatalf("expected Location to pass through, got %q", got)
+	}
+}
+
+func TestRedirectPolicy_Rewrite(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{
+		RedirectPolicy:       config.RedirectRewrite,
+		AllowedRedirectHosts: []string{f.peerHost(t)},
+	})
+
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"/self", "http://public.example.com/landing"},
+		{"/peer", "http://public.example.com/final"},
+		// Hosts that are neither the backend nor allowed are left alone
+		{"/external", "https://login.example.org/authorize"},
+	}
+	for _, tt := range tests {
+		rec := serveRedirect(lb, http.MethodGet, tt.path, "")
+		if rec.Code != http.StatusFound {
+			t.Fatalf("%s: expected 302, got %d", tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: expected Location %q, got %q", tt.path, tt.location, got)
+		}
+	}
+
+	if got := lb.GetMetricsCollector().GetMetrics().BackendMetrics["redirecting"].RedirectsRewritten; got != 2 {
+		t.Errorf("expected 2 rewritten redirects, got %d", got)
+	}
+}
+
+func TestRedirectPolicy_RewriteForwardedProto(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		location       string
+	}{
+		// httptest requests come from 192.0.2.1
+		{"untrusted peer", nil, "http://public.example.com/landing"},
+		{"trusted proxy", []string{"192.0.2.0/24"}, "https://public.example.com/landing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb, err := NewLoadBalancer(&config.Config{
+				Server:       config.ServerConfig{TrustedProxies: tt.trustedProxies},
+				LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+				Backends: []config.BackendConfig{{
+					Name:           "redirecting",
+					Address:        f.backend.URL,
+					RedirectPolicy: config.RedirectRewrite,
+				}},
+			})
+			if err != nil {
+				t.Fatalf("failed to create lb: %v", err)
+			}
+			t.Cleanup(lb.Stop)
+
+			req := httptest.NewRequest(http.MethodGet, "/self", nil)
+			req.Host = "public.example.com"
+			req.Header.Set("X-Forwarded-Proto", "https")
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("expected Location %q, got %q", tt.location, got)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicy_Follow(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{
+		RedirectPolicy:       config.RedirectFollow,
+		AllowedRedirectHosts: []string{f.peerHost(t)},
+	})
+
+	rec := serveRedirect(lb, http.MethodGet, "/peer", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "peer GET /final" {
+		t.Fatalf("expected the peer response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Location") != "" {
+		t.Error("expected no Location on a followed response")
+	}
+
+	rec = serveRedirect(lb, http.MethodGet, "/self", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "backend GET /landing" {
+		t.Fatalf("expected the same-origin target, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	if got := lb.GetMetricsCollector().GetMetrics().BackendMetrics["redirecting"].RedirectsFollowed; got != 2 {
+		t.Errorf("expected 2 followed redirects, got %d", got)
+	}
+}
+
+func TestRedirectPolicy_FollowRefusesDisallowedHost(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{RedirectPolicy: config.RedirectFollow})
+
+	for _, path := range []string{"/peer", "/external"} {
+		rec := serveRedirect(lb, http.MethodGet, path, "")
+		if rec.Code != http.StatusBadGateway {
+			t.Fatalf("%s: expected 502, got %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("X-Helios-Error"); got != errCodeRedirectNotAllowed {
+			t.Errorf("%s: expected error code %s, got %q", path, errCodeRedirectNotAllowed, got)
+		}
+	}
+}
+
+func TestRedirectPolicy_FollowDepthCap(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{RedirectPolicy: config.RedirectFollow, MaxRedirects: 3})
+
+	rec := serveRedirect(lb, http.MethodGet, "/loop", "")
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Helios-Error"); got != errCodeTooManyRedirects {
+		t.Errorf("expected error code %s, got %q", errCodeTooManyRedirects, got)
+	}
+	// The original request plus three followed hops
+	if got := atomic.LoadInt32(&f.hits); got != 4 {
+		t.Errorf("expected 4 backend hits, got %d", got)
+	}
+}
+
+func TestRedirectPolicy_FollowMethodSemantics(t *testing.T) {
+	f := newRedirectFixture(t)
+	lb := f.newLB(t, config.BackendConfig{RedirectPolicy: config.RedirectFollow})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"303 switches to GET", "/see-other", "backend GET /echo"},
+		{"307 keeps the method", "/temporary", "backend DELETE /echo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveRedirect(lb, http.MethodDelete, tt.path, "")
+			if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+				t.Fatalf("expected %q, got %d %q", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// A request body cannot be replayed without buffering, so the redirect is
+	// rewritten for the client instead of being followed
+	rec := serveRedirect(lb, http.MethodPost, "/self", `{"a":1}`)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Location"); got != "http://public.example.com/landing" {
+		t.Errorf("expected rewritten Location, got %q", got)
+	}
+}
+
+func TestRedirectPolicy_FollowStripsCredentialsAcrossOrigins(t *testing.T) {
+	credentials := func(r *http.Request) string {
+		return r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie")
+	}
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(credentials(r)))
+	}))
+	t.Cleanup(peer.Close)
+	var backend *httptest.Server
+	backend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/self":
+			http.Redirect(w, r, backend.URL+"/landing", http.StatusFound)
+		case "/peer":
+			http.Redirect(w, r, peer.URL+"/final", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte(credentials(r)))
+		}
+	}))
+	t.Cleanup(backend.Close)
+
+	peerURL, _ := url.Parse(peer.URL)
+	lb, err := NewLoadBalancer(&config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends: []config.BackendConfig{{
+			Name:                 "redirecting",
+			Address:              backend.URL,
+			RedirectPolicy:       config.RedirectFollow,
+			AllowedRedirectHosts: []string{peerURL.Host},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/self", "Bearer secret|session=1"},
+		{"/peer", "|"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Cookie", "session=1")
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+			t.Errorf("%s: expected credentials %q, got %d %q", tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
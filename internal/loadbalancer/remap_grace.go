@@ -0,0 +1,122 @@
+package loadbalancer
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// remapGraceCapacity bounds the number of client assignments remembered by
+// remapGrace; the least recently seen clients are forgotten first
+const remapGraceCapacity = 10000
+
+// remapGrace remembers recent client IP to backend assignments of a hashing
+// strategy. When the healthy backend set changes and a client's hash moves to
+// another backend, the client keeps its previous backend for the grace period
+// as long as that backend stays healthy, so sessions move gradually instead
+// of all at once.
+type remapGrace struct {
+	grace    time.Duration
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently seen at the front
+}
+
+// remapEntry is a client's current assignment
+type remapEntry struct {
+	ip      string
+	backend *Backend
+	movedAt time.Time // when the hash first pointed elsewhere; zero while it agrees
+}
+
+// newRemapGrace returns a remapGrace for the given period, or nil when the
+// period is zero so callers skip the bookkeeping entirely
+func newRemapGrace(grace time.Duration) *remapGrace {
+	if grace <= 0 {
+		return nil
+	}
+	return &remapGrace{
+		grace:    grace,
+		capacity: remapGraceCapacity,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// pick returns the backend to use for ip, given the backend its hash selects
+// now and the currently healthy backends
+func (rg *remapGrace) pick(ip string, hashed *Backend, healthy []*Backend) *Backend {
+	if rg == nil {
+		return hashed
+	}
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	el, ok := rg.entries[ip]
+	if !ok {
+		rg.entries[ip] = rg.order.PushFront(&remapEntry{ip: ip, backend: hashed})
+		if rg.order.Len() > rg.capacity {
+			oldest := rg.order.Back()
+			rg.order.Remove(oldest)
+			delete(rg.entries, oldest.Value.(*remapEntry).ip)
+		}
+		return hashed
+	}
+	rg.order.MoveToFront(el)
+	entry := el.Value.(*remapEntry)
+
+	if entry.backend == hashed || !containsBackend(healthy, entry.backend) {
+		entry.backend = hashed
+		entry.movedAt = time.Time{}
+		return hashed
+	}
+	now := rg.now()
+	if entry.movedAt.IsZero() {
+		entry.movedAt = now
+	}
+	if now.Sub(entry.movedAt) < rg.grace {
+		return entry.backend
+	}
+	entry.backend = hashed
+	entry.movedAt = time.Time{}
+	return hashed
+}
+
+// containsBackend reports whether b is one of backends
+func containsBackend(backends []*Backend, b *Backend) bool {
+	for _, candidate := range backends {
+		if candidate == b {
+			return true
+		}
+	}
+	return false
+}
+
+// withRemapGrace adapts a constructor for an IP hashing strategy accepting
+// the remap_grace_seconds option
+func withRemapGrace(newStrategy func(remap *remapGrace, env StrategyEnv) Strategy) StrategyFactory {
+	return func(options map[string]interface{}, env StrategyEnv) (Strategy, error) {
+		if err := checkStrategyOptions(options, "remap_grace_seconds"); err != nil {
+			return nil, err
+		}
+		var grace time.Duration
+		switch v := options["remap_grace_seconds"].(type) {
+		case nil:
+		case int:
+			grace = time.Duration(v) * time.Second
+		case float64:
+			grace = time.Duration(v * float64(time.Second))
+		default:
+			return nil, fmt.Errorf("remap_grace_seconds must be a number (got %T)", v)
+		}
+		if grace < 0 {
+			return nil, fmt.Errorf("remap_grace_seconds must be non-negative (got %v)", options["remap_grace_seconds"])
+		}
+		return newStrategy(newRemapGrace(grace), env), nil
+	}
+}
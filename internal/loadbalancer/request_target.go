@@ -0,0 +1,106 @@
+package loadbalancer
+
+import (
+	"net/http"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+// Reasons the request target guard rejects a request, reported under
+// security_rejections in the metrics
+const (
+	rejectConnect        = "connect"
+	rejectAbsoluteForm   = "absolute_form"
+	rejectMissingHost    = "missing_host"
+	rejectHostNotAllowed = "host_not_allowed"
+)
+
+// allowedHost is a server.allowed_hosts entry; an empty port matches any
+type allowedHost struct {
+	host, port string
+}
+
+// newAllowedHosts normalizes server.allowed_hosts, returning nil when any
+// host is accepted
+func newAllowedHosts(hosts []string) []allowedHost {
+	var allowed []allowedHost
+	for _, h := range hosts {
+		host, port := utils.NormalizeHost(h)
+		allowed = append(allowed, allowedHost{host: host, port: port})
+	}
+	return allowed
+}
+
+// hostAllowed reports whether hostport is one of the allowed hosts. A host
+// without a port is on the default port of the connection's scheme.
+func (lb *LoadBalancer) hostAllowed(hostport string, tls bool) bool {
+	host, port := utils.NormalizeHost(hostport)
+	if port == "" {
+		port = "80"
+		if tls {
+			port = "443"
+		}
+	}
+	for _, a := range lb.allowedHosts {
+		if a.host == host && (a.port == "" || a.port == port) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequestTarget keeps Helios from acting as a forward proxy: CONNECT is
+// refused, absolute-form targets are only accepted for allowed hosts and are
+// reduced to origin form, and with server.allowed_hosts set the Host must be
+// one of them. Userinfo is stripped from the URL. It writes the error
+// response and returns false when the request is rejected.
+func (lb *LoadBalancer) checkRequestTarget(w http.ResponseWriter, r *http.Request) bool {
+	r.URL.User = nil
+
+	if r.Method == http.MethodConnect {
+		lb.rejectRequestTarget(w, r, rejectConnect, http.StatusMethodNotAllowed, "CONNECT is not supported")
+		return false
+	}
+
+	if r.URL.Scheme != "" || r.URL.Host != "" {
+		if len(lb.allowedHosts) == 0 {
+			lb.rejectRequestTarget(w, r, rejectAbsoluteForm, http.StatusBadRequest, "absolute-form request targets are not supported")
+			return false
+		}
+		// The server took Host from the target; the Host check below applies
+		r.URL.Scheme = ""
+		r.URL.Host = ""
+	}
+
+	if len(lb.allowedHosts) == 0 {
+		return true
+	}
+	if r.Host == "" {
+		lb.rejectRequestTarget(w, r, rejectMissingHost, http.StatusBadRequest, "Host header is required")
+		return false
+	}
+	if !lb.hostAllowed(r.Host, r.TLS != nil) {
+		lb.rejectRequestTarget(w, r, rejectHostNotAllowed, http.StatusMisdirectedRequest, "host is not served here")
+		return false
+	}
+	return true
+}
+
+// rejectRequestTarget counts, logs and answers a request refused by
+// checkRequestTarget
+func (lb *LoadBalancer) rejectRequestTarget(w http.ResponseWriter, r *http.Request, reason string, status int, msg string) {
+	lb.metricsCollector.RecordSecurityRejection(reason)
+	logging.WithContext(r.Context()).Warn().
+		Str("reason", reason).
+		Str("method", r.Method).
+		Str("target", r.RequestURI).
+		Str("host", r.Host).
+		Str("remote_addr", r.RemoteAddr).
+		Msg("request target rejected")
+	if status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+	}
+	w.Header().Set("Connection", "close")
+	http.Error(w, msg, status)
+}
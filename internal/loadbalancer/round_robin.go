@@ -30,7 +30,8 @@ func (rr *RoundRobinStrategy) NextBackend(r *http.Request) *Backend {
 		return nil
 	}
 
-	// Get the next index in a thread-safe way
+	// Get the next index in a thread-safe way. The modulo is taken against the
+	// length seen under the read lock, so a shrunk set can never be overrun.
 	idx := atomic.AddUint64(&rr.current, 1) % uint64(len(rr.backends))
 	return rr.backends[idx]
 }
@@ -47,14 +48,7 @@ func (rr *RoundRobinStrategy) RemoveBackend(backend *Backend) {
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
-	for i, b := range rr.backends {
-		if b == backend {
-			// Remove the backend by swapping with the last element and truncating
-			rr.backends[i] = rr.backends[len(rr.backends)-1]
-			rr.backends = rr.backends[:len(rr.backends)-1]
-			return
-		}
-	}
+	rr.backends = withoutBackend(rr.backends, backend)
 }
 
 // GetBackends returns all backends in the pool
@@ -0,0 +1,256 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// shadowEvaluator runs a second strategy alongside the primary one on a
+// sample of requests and records where it would have routed them. It never
+// influences routing and never blocks the request path: when its lock is
+// contended the sample is skipped.
+type shadowEvaluator struct {
+	name      string
+	strategy  Strategy
+	sampleBps uint64 // sampled share of requests in basis points (10000 = all)
+
+	seen    uint64 // requests offered for sampling, accessed atomically
+	skipped uint64 // samples dropped because the evaluator was busy, accessed atomically
+
+	mu    sync.Mutex
+	stats shadowStats
+}
+
+// shadowStats accumulates the divergence report between two resets
+type shadowStats struct {
+	since    time.Time
+	sampled  uint64
+	agreed   uint64
+	backends map[string]*shadowBackendStats
+}
+
+type shadowBackendStats struct {
+	primary    uint64
+	shadow     uint64
+	latencyN   uint64
+	latencySum time.Duration
+}
+
+// ShadowReport compares the primary strategy with the shadow strategy over
+// the sampled requests since the last reset
+type ShadowReport struct {
+	Strategy         string                        `json:"strategy"`
+	ShadowStrategy   string                        `json:"shadow_strategy"`
+	SamplePercent    float64                       `json:"sample_percent"`
+	Since            time.Time                     `json:"since"`
+	Sampled          uint64                        `json:"sampled"`
+	Agreed           uint64                        `json:"agreed"`
+	AgreementPercent float64                       `json:"agreement_percent"`
+	Skipped          uint64                        `json:"skipped"`
+	Backends         map[string]ShadowBackendStats `json:"backends"`
+	// EstimatedLatencyDeltaMs is the shadow choices' expected latency minus the
+	// primary choices', using the latency observed on each backend when it
+	// was actually chosen. Positive means the shadow strategy would be slower.
+	EstimatedLatencyDeltaMs float64 `json:"estimated_latency_delta_ms"`
+}
+
+// ShadowBackendStats counts how often each strategy picked a backend
+type ShadowBackendStats struct {
+	Primary                uint64  `json:"primary"`
+	Shadow                 uint64  `json:"shadow"`
+	ObservedLatencyMs      float64 `json:"observed_latency_ms"`
+	ObservedLatencySamples uint64  `json:"observed_latency_samples"`
+}
+
+// newShadowEvaluator builds the shadow strategy configured under
+// load_balancer.shadow_strategy, or returns nil when shadowing is disabled
+func newShadowEvaluator(lbCfg config.LoadBalancerConfig, env StrategyEnv) (*shadowEvaluator, error) {
+	shadowCfg := lbCfg.ShadowStrategy
+	if shadowCfg.Strategy == "" {
+		return nil, nil
+	}
+	strategy, err := NewStrategy(shadowCfg.Strategy, lbCfg.StrategyConfig[shadowCfg.Strategy], env)
+	if err != nil {
+		return nil, err
+	}
+	percent := shadowCfg.SamplePercent
+	if percent == 0 {
+		percent = 100
+	}
+	s := &shadowEvaluator{
+		name:      shadowCfg.Strategy,
+		strategy:  strategy,
+		sampleBps: uint64(percent * 100),
+	}
+	s.resetLocked()
+	return s, nil
+}
+
+// resetLocked clears the report. Must be called with mu held (or before the
+// evaluator is shared).
+func (s *shadowEvaluator) resetLocked() {
+	s.stats = shadowStats{since: time.Now(), backends: make(map[string]*shadowBackendStats)}
+	atomic.StoreUint64(&s.skipped, 0)
+}
+
+// reset clears the report after a strategy or backend change
+func (s *shadowEvaluator) reset() {
+	s.mu.Lock()
+	s.resetLocked()
+	s.mu.Unlock()
+}
+
+func (s *shadowEvaluator) addBackend(backend *Backend) {
+	s.mu.Lock()
+	s.strategy.AddBackend(backend)
+	s.resetLocked()
+	s.mu.Unlock()
+}
+
+func (s *shadowEvaluator) removeBackend(backend *Backend) {
+	s.mu.Lock()
+	s.strategy.RemoveBackend(backend)
+	s.resetLocked()
+	s.mu.Unlock()
+}
+
+// sampled reports whether the next request falls into the sample. Requests
+// are spread evenly: with 25% every fourth request is sampled.
+func (s *shadowEvaluator) sampled() bool {
+	n := atomic.AddUint64(&s.seen, 1)
+	return n*s.sampleBps/10000 != (n-1)*s.sampleBps/10000
+}
+
+// observe asks the shadow strategy where it would route r and records the
+// comparison with the primary choice. Only backends accepted by available
+// count as choices, as findHealthyBackend filters the primary's picks. It
+// reports whether the request was recorded, in which case its latency should
+// be passed to observeLatency.
+func (s *shadowEvaluator) observe(r *http.Request, primary *Backend, available func(*Backend) bool) bool {
+	if !s.sampled() {
+		return false
+	}
+	if !s.mu.TryLock() {
+		atomic.AddUint64(&s.skipped, 1)
+		return false
+	}
+	defer s.mu.Unlock()
+
+	choice := s.nextAvailableLocked(r, available)
+	s.stats.sampled++
+	s.backendLocked(primary.Name).primary++
+	if choice == nil {
+		return true
+	}
+	if choice.Name == primary.Name {
+		s.stats.agreed++
+	}
+	s.backendLocked(choice.Name).shadow++
+	return true
+}
+
+// nextAvailableLocked mirrors findHealthyBackend for the shadow strategy: up
+// to three picks, then the first available backend it holds
+func (s *shadowEvaluator) nextAvailableLocked(r *http.Request, available func(*Backend) bool) *Backend {
+	for i := 0; i < 3; i++ {
+		backend := s.strategy.NextBackend(r)
+		if backend == nil {
+			return nil
+		}
+		if available(backend) {
+			return backend
+		}
+	}
+	for _, backend := range s.strategy.GetBackends() {
+		if available(backend) {
+			return backend
+		}
+	}
+	return nil
+}
+
+// shadowCandidate reports whether the primary path could have routed to
+// backend: it must take traffic and, with backend_groups, belong to the
+// group currently taking traffic
+func (lb *LoadBalancer) shadowCandidate(backend *Backend) bool {
+	if lb.groups != nil && lb.groups.groupOf(backend.Name).name != lb.groups.activeName() {
+		return false
+	}
+	return lb.isBackendAvailable(backend)
+}
+
+// observeLatency records the latency of a sampled request on the backend
+// that actually served it
+func (s *shadowEvaluator) observeLatency(backend string, d time.Duration) {
+	if !s.mu.TryLock() {
+		return
+	}
+	defer s.mu.Unlock()
+
+	// The report may have been reset while the request was in flight
+	if st, ok := s.stats.backends[backend]; ok {
+		st.latencyN++
+		st.latencySum += d
+	}
+}
+
+func (s *shadowEvaluator) backendLocked(name string) *shadowBackendStats {
+	st, ok := s.stats.backends[name]
+	if !ok {
+		st = &shadowBackendStats{}
+		s.stats.backends[name] = st
+	}
+	return st
+}
+
+// report builds a snapshot of the divergence report
+func (s *shadowEvaluator) report(primary string) ShadowReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rep := ShadowReport{
+		Strategy:       primary,
+		ShadowStrategy: s.name,
+		SamplePercent:  float64(s.sampleBps) / 100,
+		Since:          s.stats.since,
+		Sampled:        s.stats.sampled,
+		Agreed:         s.stats.agreed,
+		Skipped:        atomic.LoadUint64(&s.skipped),
+		Backends:       make(map[string]ShadowBackendStats, len(s.stats.backends)),
+	}
+	if rep.Sampled > 0 {
+		rep.AgreementPercent = float64(rep.Agreed) / float64(rep.Sampled) * 100
+	}
+
+	// Weight each backend's observed latency by how often each strategy chose it,
+	// ignoring backends without a latency observation
+	var primaryMs, primaryN, shadowMs, shadowN float64
+	for name, st := range s.stats.backends {
+		out := ShadowBackendStats{Primary: st.primary, Shadow: st.shadow, ObservedLatencySamples: st.latencyN}
+		if st.latencyN > 0 {
+			out.ObservedLatencyMs = float64(st.latencySum.Microseconds()) / 1000 / float64(st.latencyN)
+			primaryMs += out.ObservedLatencyMs * float64(st.primary)
+			primaryN += float64(st.primary)
+			shadowMs += out.ObservedLatencyMs * float64(st.shadow)
+			shadowN += float64(st.shadow)
+		}
+		rep.Backends[name] = out
+	}
+	if primaryN > 0 && shadowN > 0 {
+		rep.EstimatedLatencyDeltaMs = shadowMs/shadowN - primaryMs/primaryN
+	}
+	return rep
+}
+
+// ShadowReport returns the shadow strategy divergence report, or false when
+// load_balancer.shadow_strategy is not configured
+func (lb *LoadBalancer) ShadowReport() (ShadowReport, bool) {
+	if lb.shadow == nil {
+		return ShadowReport{}, false
+	}
+	return lb.shadow.report(lb.GetStrategy()), true
+}
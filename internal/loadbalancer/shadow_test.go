@@ -0,0 +1,275 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// countingStrategy wraps a strategy and counts NextBackend calls, also
+// into total when set
+type countingStrategy struct {
+	Strategy
+	calls int64
+	total *int64
+}
+
+func (c *countingStrategy) NextBackend(r *http.Request) *Backend {
+	atomic.AddInt64(&c.calls, 1)
+	if c.total != nil {
+		atomic.AddInt64(c.total, 1)
+	}
+	return c.Strategy.NextBackend(r)
+}
+
+func newShadowTestBackends(n int) []*Backend {
+	backends := make([]*Backend, n)
+	for i := range backends {
+		u, _ := url.Parse(fmt.Sprintf("http://localhost:%d", 9001+i))
+		backends[i] = &Backend{Name: fmt.Sprintf("b%d", i+1), URL: u, IsHealthy: true, Weight: 1}
+	}
+	return backends
+}
+
+func TestShadowEvaluator_LeastConnectionsBehindRoundRobin(t *testing.T) {
+	shadow, err := newShadowEvaluator(config.LoadBalancerConfig{
+		ShadowStrategy: config.ShadowStrategyConfig{Strategy: "least_connections"},
+	}, StrategyEnv{})
+	if err != nil {
+		t.Fatalf("failed to create shadow evaluator: %v", err)
+	}
+	primary := NewRoundRobinStrategy()
+	backends := newShadowTestBackends(3)
+	for _, b := range backends {
+		primary.AddBackend(b)
+		shadow.addBackend(b)
+	}
+
+	// Seeded workload: random connection counts, computing the least-loaded
+	// backend independently to know how often the strategies must agree
+	rng := rand.New(rand.NewSource(42))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	const requests = 3000
+	wantAgreed := 0
+	wantShadow := map[string]uint64{}
+	for i := 0; i < requests; i++ {
+		least := backends[0]
+		for _, b := range backends {
+			b.ActiveConnections = int32(rng.Intn(10))
+			if b.ActiveConnections < least.ActiveConnections {
+				least = b
+			}
+		}
+		chosen := primary.NextBackend(req)
+		if chosen == least {
+			wantAgreed++
+		}
+		wantShadow[least.Name]++
+		if !shadow.observe(req, chosen, (*Backend).Available) {
+			t.Fatalf("request %d was not sampled", i)
+		}
+	}
+
+	rep := shadow.report("round_robin")
+	if rep.Sampled != requests || rep.Agreed != uint64(wantAgreed) {
+		t.Fatalf("expected %d/%d agreed, got %d/%d", wantAgreed, requests, rep.Agreed, rep.Sampled)
+	}
+	// Round robin spreads evenly, so it agrees with the least-loaded pick about a third of the time
+	if rep.AgreementPercent < 25 || rep.AgreementPercent > 42 {
+		t.Errorf("implausible agreement percentage %.1f", rep.AgreementPercent)
+	}
+	for _, b := range backends {
+		got := rep.Backends[b.Name]
+		if got.Primary != requests/3 {
+			t.Errorf("%s: expected %d primary picks, got %d", b.Name, requests/3, got.Primary)
+		}
+		if got.Shadow != wantShadow[b.Name] {
+			t.Errorf("%s: expected %d counterfactual picks, got %d", b.Name, wantShadow[b.Name], got.Shadow)
+		}
+	}
+	// Least connections favours the first backend on ties
+	if rep.Backends["b1"].Shadow <= rep.Backends["b3"].Shadow {
+		t.Errorf("expected tie-breaking to favour b1, got %+v", rep.Backends)
+	}
+
+	shadow.removeBackend(backends[2])
+	if rep := shadow.report("round_robin"); rep.Sampled != 0 || len(rep.Backends) != 0 {
+		t.Fatalf("expected backend removal to reset the report, got %+v", rep)
+	}
+}
+
+func TestShadowEvaluator_SamplePercent(t *testing.T) {
+	shadow, err := newShadowEvaluator(config.LoadBalancerConfig{
+		ShadowStrategy: config.ShadowStrategyConfig{Strategy: "round_robin", SamplePercent: 25},
+	}, StrategyEnv{})
+	if err != nil {
+		t.Fatalf("failed to create shadow evaluator: %v", err)
+	}
+	b := newShadowTestBackends(1)[0]
+	shadow.addBackend(b)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 100; i++ {
+		shadow.observe(req, b, (*Backend).Available)
+	}
+	if rep := shadow.report("round_robin"); rep.Sampled != 25 || rep.AgreementPercent != 100 {
+		t.Fatalf("expected 25 sampled requests in full agreement, got %d (%.1f%%)", rep.Sampled, rep.AgreementPercent)
+	}
+}
+
+func TestShadowEvaluator_SkipsUnavailableBackends(t *testing.T) {
+	shadow, err := newShadowEvaluator(config.LoadBalancerConfig{
+		ShadowStrategy: config.ShadowStrategyConfig{Strategy: "round_robin"},
+	}, StrategyEnv{})
+	if err != nil {
+		t.Fatalf("failed to create shadow evaluator: %v", err)
+	}
+	backends := newShadowTestBackends(3)
+	for _, b := range backends {
+		shadow.addBackend(b)
+	}
+	backends[1].IsHealthy = false
+	backends[2].hints.draining = true
+
+	// Round robin would pick each backend in turn; only b1 may be counted
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 30; i++ {
+		shadow.observe(req, backends[0], (*Backend).Available)
+	}
+	rep := shadow.report("round_robin")
+	if rep.Agreed != 30 || rep.Backends["b1"].Shadow != 30 {
+		t.Fatalf("expected every counterfactual pick on b1, got %+v", rep.Backends)
+	}
+	if rep.Backends["b2"].Shadow != 0 || rep.Backends["b3"].Shadow != 0 {
+		t.Fatalf("expected no picks on unhealthy or draining backends, got %+v", rep.Backends)
+	}
+}
+
+// countingShadowFactory registers a strategy counting every NextBackend call
+// across the instances it builds, removing it again when the test ends
+func countingShadowFactory(t *testing.T, name string) *int64 {
+	t.Helper()
+	var calls int64
+	RegisterStrategy(name, func(options map[string]interface{}, env StrategyEnv) (Strategy, error) {
+		return &countingStrategy{Strategy: NewLeastConnectionsStrategy(), total: &calls}, nil
+	})
+	t.Cleanup(func() { delete(strategyFactories, name) })
+	return &calls
+}
+
+func TestShadowStrategy_ThroughLoadBalancer(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	newLB := func(shadow config.ShadowStrategyConfig) *LoadBalancer {
+		cfg := &config.Config{
+			LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin", ShadowStrategy: shadow},
+			Backends: []config.BackendConfig{
+				{Name: "a", Address: backend.URL},
+				{Name: "b", Address: backend.URL},
+			},
+		}
+		lb, err := NewLoadBalancer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create lb: %v", err)
+		}
+		t.Cleanup(lb.Stop)
+		return lb
+	}
+	serve := func(lb *LoadBalancer, n int) {
+		for i := 0; i < n; i++ {
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+		}
+	}
+
+	calls := countingShadowFactory(t, "test_counting_shadow")
+
+	// Disabled: no shadow strategy exists, so the request path only sees a nil check
+	lb := newLB(config.ShadowStrategyConfig{})
+	serve(lb, 10)
+	if got := atomic.LoadInt64(calls); got != 0 {
+		t.Fatalf("expected no shadow calls while disabled, got %d", got)
+	}
+	if lb.shadow != nil {
+		t.Fatal("expected no shadow evaluator without shadow_strategy")
+	}
+	if _, ok := lb.ShadowReport(); ok {
+		t.Fatal("expected no shadow report without shadow_strategy")
+	}
+
+	// Enabled: every sampled request consults the shadow strategy once
+	lb = newLB(config.ShadowStrategyConfig{Strategy: "test_counting_shadow", SamplePercent: 50})
+	serve(lb, 10)
+	if got := atomic.LoadInt64(calls); got != 5 {
+		t.Fatalf("expected 5 shadow calls, got %d", got)
+	}
+	rep, ok := lb.ShadowReport()
+	if !ok || rep.Sampled != 5 || rep.ShadowStrategy != "test_counting_shadow" || rep.Strategy != "round_robin" {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if rep.Backends["a"].ObservedLatencySamples+rep.Backends["b"].ObservedLatencySamples != 5 {
+		t.Errorf("expected latency of every sampled request to be observed, got %+v", rep.Backends)
+	}
+
+	// Strategy changes reset the report
+	if err := lb.SetStrategy("least_connections"); err != nil {
+		t.Fatalf("failed to switch strategy: %v", err)
+	}
+	if rep, _ := lb.ShadowReport(); rep.Sampled != 0 {
+		t.Fatalf("expected strategy switch to reset the report, got %d samples", rep.Sampled)
+	}
+}
+
+func TestShadowStrategy_FollowsActiveBackendGroup(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	cfg := &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{
+			Strategy:       "round_robin",
+			ShadowStrategy: config.ShadowStrategyConfig{Strategy: "round_robin"},
+		},
+		Backends: []config.BackendConfig{
+			{Name: "primary", Address: backend.URL},
+			{Name: "standby", Address: backend.URL},
+		},
+		BackendGroups: []config.BackendGroupConfig{
+			{Name: "main", Priority: 1, Members: []string{"primary"}},
+			{Name: "backup", Priority: 2, Members: []string{"standby"}},
+		},
+	}
+	lb, err := NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	for i := 0; i < 10; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	}
+
+	// The shadow strategy holds both backends, but only the active group's
+	// member is a choice the primary path could have made
+	rep, _ := lb.ShadowReport()
+	if rep.Agreed != 10 || rep.Backends["standby"].Shadow != 0 {
+		t.Fatalf("expected the shadow strategy to stay within the active group, got %+v", rep)
+	}
+}
@@ -0,0 +1,297 @@
+package loadbalancer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// Shutdown phases reported by ShutdownStatus
+const (
+	PhaseRunning           = "running"
+	PhaseDraining          = "draining"
+	PhaseWaitingOnRequests = "waiting_on_requests"
+	PhaseClosingTunnels    = "closing_tunnels"
+	PhaseDone              = "done"
+)
+
+// shutdownPollInterval is how often the coordinator re-checks the in-flight counts
+var shutdownPollInterval = 25 * time.Millisecond
+
+// ShutdownStatus describes the progress of a graceful shutdown
+type ShutdownStatus struct {
+	Phase            string `json:"phase"`
+	InFlightRequests int64  `json:"in_flight_requests"`
+	OpenTunnels      int    `json:"open_tunnels"`
+	ElapsedMs        int64  `json:"elapsed_ms"`
+	TimeoutMs        int64  `json:"timeout_ms"`
+	Forced           bool   `json:"forced"`
+	// BackendsWithConnections names the backends still holding active
+	// connections, sorted
+	BackendsWithConnections []string `json:"backends_with_connections"`
+}
+
+// shutdownCoordinator tracks in-flight requests and hijacked (WebSocket)
+// tunnels on the main listener and drives the shutdown phases.
+//
+// Hijacked connections are invisible to http.Server.Shutdown, so tunnels are
+// registered here when the proxy hijacks the client connection and are
+// closed explicitly once the shutdown budget runs out.
+type shutdownCoordinator struct {
+	inFlight int64 // requests inside ServeHTTP, tunnels included; accessed atomically
+	draining int32 // set once shutdown begins; accessed atomically
+
+	mu      sync.Mutex
+	phase   string
+	started time.Time
+	timeout time.Duration
+	forced  bool
+	force   chan struct{}
+	tunnels map[*tunnelConn]string // open tunnel -> backend name
+}
+
+func newShutdownCoordinator() *shutdownCoordinator {
+	return &shutdownCoordinator{
+		phase:   PhaseRunning,
+		force:   make(chan struct{}),
+		tunnels: make(map[*tunnelConn]string),
+	}
+}
+
+// enter counts a request on the main listener; the returned func must be
+// called when it finishes. Safe on a nil coordinator.
+func (c *shutdownCoordinator) enter() func() {
+	if c == nil {
+		return func() {}
+	}
+	atomic.AddInt64(&c.inFlight, 1)
+	return func() { atomic.AddInt64(&c.inFlight, -1) }
+}
+
+func (c *shutdownCoordinator) isDraining() bool {
+	return c != nil && atomic.LoadInt32(&c.draining) == 1
+}
+
+// counts returns the in-flight requests (tunnels excluded) and open tunnels
+func (c *shutdownCoordinator) counts() (int64, int) {
+	c.mu.Lock()
+	tunnels := len(c.tunnels)
+	c.mu.Unlock()
+	requests := atomic.LoadInt64(&c.inFlight) - int64(tunnels)
+	if requests < 0 {
+		requests = 0
+	}
+	return requests, tunnels
+}
+
+// trackTunnel registers a hijacked client connection. Safe on a nil coordinator.
+func (c *shutdownCoordinator) trackTunnel(conn net.Conn, backend string) net.Conn {
+	if c == nil {
+		return conn
+	}
+	tc := &tunnelConn{Conn: conn, owner: c}
+	c.mu.Lock()
+	c.tunnels[tc] = backend
+	c.mu.Unlock()
+	return tc
+}
+
+// closeTunnels closes every open tunnel and returns how many were closed
+func (c *shutdownCoordinator) closeTunnels() int {
+	c.mu.Lock()
+	open := make([]*tunnelConn, 0, len(c.tunnels))
+	for tc := range c.tunnels {
+		open = append(open, tc)
+	}
+	c.mu.Unlock()
+
+	for _, tc := range open {
+		_ = tc.Close()
+	}
+	return len(open)
+}
+
+// begin moves the coordinator out of the running phase. It returns false if
+// shutdown was already started.
+func (c *shutdownCoordinator) begin(timeout time.Duration) bool {
+	c.mu.Lock()
+	if c.phase != PhaseRunning {
+		c.mu.Unlock()
+		return false
+	}
+	c.started = time.Now()
+	c.timeout = timeout
+	c.mu.Unlock()
+
+	atomic.StoreInt32(&c.draining, 1)
+	c.setPhase(PhaseDraining)
+	return true
+}
+
+// setPhase records a phase transition and logs it
+func (c *shutdownCoordinator) setPhase(phase string) {
+	c.mu.Lock()
+	c.phase = phase
+	elapsed := time.Since(c.started)
+	forced := c.forced
+	c.mu.Unlock()
+
+	requests, tunnels := c.counts()
+	logging.L().Info().
+		Str("phase", phase).
+		Int64("in_flight_requests", requests).
+		Int("open_tunnels", tunnels).
+		Dur("elapsed", elapsed).
+		Bool("forced", forced).
+		Msg("shutdown phase changed")
+}
+
+// waitFor polls until done reports true. It returns false if the context
+// expires or the shutdown is forced first.
+func (c *shutdownCoordinator) waitFor(ctx context.Context, done func() bool) bool {
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.force:
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// GracefulShutdown stops the main listener and drains it in phases:
+// draining stops accepting connections and answers with Connection: close,
+// waiting_on_requests waits for in-flight HTTP requests, closing_tunnels
+// gives WebSocket tunnels the rest of the timeout before closing them. It
+// returns once the phase is done. ForceShutdown skips the remaining wait.
+func (lb *LoadBalancer) GracefulShutdown(server *http.Server, timeout time.Duration) {
+	c := lb.shutdown
+	if c == nil || !c.begin(timeout) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Shutdown closes the listener and idle keep-alive connections, then
+	// waits for the remaining (non-hijacked) connections to go idle
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		if err := server.Shutdown(ctx); err != nil && err != context.DeadlineExceeded && err != context.Canceled {
+			logging.L().Error().Err(err).Msg("error during server shutdown")
+		}
+	}()
+
+	c.setPhase(PhaseWaitingOnRequests)
+	requestsDone := c.waitFor(ctx, func() bool {
+		select {
+		case <-serverDone:
+		default:
+			return false
+		}
+		requests, _ := c.counts()
+		return requests == 0
+	})
+	if !requestsDone {
+		// Out of time or forced: cut the remaining requests
+		if err := server.Close(); err != nil {
+			logging.L().Error().Err(err).Msg("error closing server")
+		}
+	}
+
+	c.setPhase(PhaseClosingTunnels)
+	if !c.waitFor(ctx, func() bool { _, tunnels := c.counts(); return tunnels == 0 }) {
+		if closed := c.closeTunnels(); closed > 0 {
+			logging.L().Warn().Int("tunnels", closed).Msg("closed websocket tunnels at shutdown")
+		}
+	}
+
+	c.setPhase(PhaseDone)
+}
+
+// ForceShutdown skips the remaining wait of a graceful shutdown: in-flight
+// requests and tunnels are closed immediately. It returns false if shutdown
+// has not started.
+func (lb *LoadBalancer) ForceShutdown() bool {
+	c := lb.shutdown
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.phase == PhaseRunning {
+		return false
+	}
+	if !c.forced {
+		c.forced = true
+		close(c.force)
+		logging.L().Warn().Str("phase", c.phase).Msg("shutdown forced")
+	}
+	return true
+}
+
+// ShutdownStatus reports the progress of the graceful shutdown, or the
+// running phase with the current counts before it has started
+func (lb *LoadBalancer) ShutdownStatus() ShutdownStatus {
+	status := ShutdownStatus{Phase: PhaseRunning, BackendsWithConnections: []string{}}
+	c := lb.shutdown
+	if c == nil {
+		return status
+	}
+
+	c.mu.Lock()
+	status.Phase = c.phase
+	status.Forced = c.forced
+	if c.phase != PhaseRunning {
+		status.ElapsedMs = time.Since(c.started).Milliseconds()
+		status.TimeoutMs = c.timeout.Milliseconds()
+	}
+	c.mu.Unlock()
+	status.InFlightRequests, status.OpenTunnels = c.counts()
+
+	for _, info := range lb.ListBackends() {
+		if info.ActiveConnections > 0 {
+			status.BackendsWithConnections = append(status.BackendsWithConnections, info.Name)
+		}
+	}
+	sort.Strings(status.BackendsWithConnections)
+	return status
+}
+
+// CloseOnDrain wraps the main listener's handler so responses carry
+// Connection: close once shutdown begins, prompting clients to reconnect
+// elsewhere instead of reusing the connection
+func (lb *LoadBalancer) CloseOnDrain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lb.shutdown.isDraining() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tunnelConn is a hijacked client connection that unregisters itself on Close
+type tunnelConn struct {
+	net.Conn
+	owner *shutdownCoordinator
+	once  sync.Once
+}
+
+func (tc *tunnelConn) Close() error {
+	tc.once.Do(func() {
+		tc.owner.mu.Lock()
+		delete(tc.owner.tunnels, tc)
+		tc.owner.mu.Unlock()
+	})
+	return tc.Conn.Close()
+}
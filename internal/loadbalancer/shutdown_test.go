@@ -0,0 +1,213 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+// newShutdownTestProxy starts a backend serving a WebSocket echo on /ws and a
+// request on /slow that blocks until release is closed, and a proxy in front of it
+func newShutdownTestProxy(t *testing.T, release chan struct{}) (*LoadBalancer, *httptest.Server) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			for {
+				mt, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if err := conn.WriteMessage(mt, msg); err != nil {
+					return
+				}
+			}
+		case "/slow":
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(backend.Close)
+
+	lb, err := NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "app", Address: backend.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	proxy := httptest.NewServer(lb.CloseOnDrain(lb))
+	t.Cleanup(proxy.Close)
+	return lb, proxy
+}
+
+func dialShutdownTunnel(t *testing.T, proxy *httptest.Server) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(proxy.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// waitForStatus polls ShutdownStatus until cond holds
+func waitForStatus(t *testing.T, lb *LoadBalancer, what string, cond func(ShutdownStatus) bool) ShutdownStatus {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		status := lb.ShutdownStatus()
+		if cond(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, last status %+v", what, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGracefulShutdownDrainsRequestsThenTunnels(t *testing.T) {
+	release := make(chan struct{})
+	lb, proxy := newShutdownTestProxy(t, release)
+
+	ws := dialShutdownTunnel(t, proxy)
+	slowDone := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(proxy.URL + "/slow")
+		if err != nil {
+			slowDone <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		slowDone <- resp.StatusCode
+	}()
+
+	status := waitForStatus(t, lb, "request and tunnel in flight", func(s ShutdownStatus) bool {
+		return s.InFlightRequests == 1 && s.OpenTunnels == 1
+	})
+	if status.Phase != PhaseRunning {
+		t.Fatalf("expected phase %s before shutdown, got %s", PhaseRunning, status.Phase)
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		lb.GracefulShutdown(proxy.Config, 10*time.Second)
+		close(shutdownDone)
+	}()
+
+	status = waitForStatus(t, lb, "waiting_on_requests", func(s ShutdownStatus) bool {
+		return s.Phase == PhaseWaitingOnRequests
+	})
+	if status.InFlightRequests != 1 || status.OpenTunnels != 1 {
+		t.Errorf("expected 1 request and 1 tunnel, got %d and %d", status.InFlightRequests, status.OpenTunnels)
+	}
+	if !reflect.DeepEqual(status.BackendsWithConnections, []string{"app"}) {
+		t.Errorf("expected backend app to hold connections, got %v", status.BackendsWithConnections)
+	}
+	if status.TimeoutMs != 10000 {
+		t.Errorf("expected timeout 10000ms, got %d", status.TimeoutMs)
+	}
+
+	// Finishing the slow request moves on to the tunnels, which stay open
+	close(release)
+	if code := <-slowDone; code != http.StatusOK {
+		t.Fatalf("expected in-flight request to complete with 200, got %d", code)
+	}
+	status = waitForStatus(t, lb, "closing_tunnels", func(s ShutdownStatus) bool {
+		return s.Phase == PhaseClosingTunnels
+	})
+	if status.InFlightRequests != 0 || status.OpenTunnels != 1 {
+		t.Errorf("expected 0 requests and 1 tunnel, got %d and %d", status.InFlightRequests, status.OpenTunnels)
+	}
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("still open")); err != nil {
+		t.Fatalf("tunnel should stay usable while draining: %v", err)
+	}
+	if _, msg, err := ws.ReadMessage(); err != nil || string(msg) != "still open" {
+		t.Fatalf("expected echo through the tunnel, got %q (%v)", msg, err)
+	}
+
+	// The client closing its tunnel completes the shutdown
+	_ = ws.Close()
+	select {
+	case <-shutdownDone:
+	case <-time.After(3 * time.Second):
+		t.Fatal("shutdown did not finish after the last tunnel closed")
+	}
+	status = lb.ShutdownStatus()
+	if status.Phase != PhaseDone || status.OpenTunnels != 0 || status.Forced {
+		t.Errorf("expected unforced done with no tunnels, got %+v", status)
+	}
+}
+
+func TestForceShutdownClosesTunnels(t *testing.T) {
+	lb, proxy := newShutdownTestProxy(t, make(chan struct{}))
+
+	if lb.ForceShutdown() {
+		t.Fatal("expected force to be rejected before shutdown starts")
+	}
+
+	ws := dialShutdownTunnel(t, proxy)
+	waitForStatus(t, lb, "tunnel open", func(s ShutdownStatus) bool { return s.OpenTunnels == 1 })
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		lb.GracefulShutdown(proxy.Config, time.Minute)
+		close(shutdownDone)
+	}()
+	waitForStatus(t, lb, "closing_tunnels", func(s ShutdownStatus) bool { return s.Phase == PhaseClosingTunnels })
+
+	start := time.Now()
+	if !lb.ForceShutdown() {
+		t.Fatal("expected force to be accepted during shutdown")
+	}
+	select {
+	case <-shutdownDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("forced shutdown did not finish promptly")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("forced shutdown took %v", elapsed)
+	}
+
+	status := lb.ShutdownStatus()
+	if status.Phase != PhaseDone || !status.Forced || status.OpenTunnels != 0 {
+		t.Errorf("expected forced done with no tunnels, got %+v", status)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := ws.ReadMessage(); err == nil {
+		t.Error("expected the tunnel to be closed")
+	}
+}
+
+func TestCloseOnDrain(t *testing.T) {
+	lb := &LoadBalancer{shutdown: newShutdownCoordinator()}
+	handler := lb.CloseOnDrain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Connection"); got != "" {
+		t.Errorf("expected no Connection header while running, got %q", got)
+	}
+
+	lb.shutdown.begin(time.Second)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Connection"); got != "close" {
+		t.Errorf("expected Connection: close while draining, got %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package loadbalancer
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/logging"
+)
+
+// headerTracker follows connections from accept until their first request
+// headers arrive. ReadHeaderTimeout closes connections that dribble headers;
+// the tracker counts them and, with server.slowloris_protection.max_pending,
+// refuses new connections while too many are still waiting.
+type headerTracker struct {
+	timeout    time.Duration
+	maxPending int
+	lb         *LoadBalancer
+
+	mu      sync.Mutex
+	pending map[net.Conn]time.Time
+}
+
+// TrackHeaderReads returns an http.Server ConnState hook tracking connections
+// that have not yet sent request headers. timeout should match the server's
+// ReadHeaderTimeout: connections closed after waiting that long count as slow.
+func (lb *LoadBalancer) TrackHeaderReads(timeout time.Duration) func(net.Conn, http.ConnState) {
+	ht := &headerTracker{
+		timeout: timeout,
+		lb:      lb,
+		pending: make(map[net.Conn]time.Time),
+	}
+	if lb.config != nil {
+		ht.maxPending = lb.config.Server.SlowlorisProtection.MaxPending
+	}
+	return ht.connState
+}
+
+func (ht *headerTracker) connState(c net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		ht.accept(c)
+	case http.StateActive, http.StateClosed:
+		// net/http marks a connection active once it has read any bytes,
+		// even when the headers then time out, so both may end a slow read
+		ht.finish(c, true)
+	case http.StateHijacked:
+		ht.finish(c, false)
+	}
+}
+
+// accept starts tracking a new connection, or closes it when too many are
+// already waiting for headers
+func (ht *headerTracker) accept(c net.Conn) {
+	ht.mu.Lock()
+	if ht.maxPending > 0 && len(ht.pending) >= ht.maxPending {
+		ht.mu.Unlock()
+		logging.L().Debug().Str("remote_addr", c.RemoteAddr().String()).Int("max_pending", ht.maxPending).Msg("too many connections awaiting headers, refusing connection")
+		if ht.lb.metricsCollector != nil {
+			ht.lb.metricsCollector.RecordRefusedHeaderConnection()
+		}
+		_ = c.Close()
+		return
+	}
+	ht.pending[c] = time.Now()
+	n := len(ht.pending)
+	ht.mu.Unlock()
+
+	if ht.lb.metricsCollector != nil {
+		ht.lb.metricsCollector.SetPendingHeaderConnections(n)
+	}
+}
+
+// finish stops tracking a connection, counting it as slow if it waited out
+// the whole read header timeout
+func (ht *headerTracker) finish(c net.Conn, checkSlow bool) {
+	ht.mu.Lock()
+	since, ok := ht.pending[c]
+	if !ok {
+		ht.mu.Unlock()
+		return
+	}
+	delete(ht.pending, c)
+	n := len(ht.pending)
+	ht.mu.Unlock()
+
+	mc := ht.lb.metricsCollector
+	if mc != nil {
+		mc.SetPendingHeaderConnections(n)
+	}
+	// Headers that arrive in time are read well within the timeout
+	if waited := time.Since(since); checkSlow && ht.timeout > 0 && waited >= ht.timeout {
+		logging.L().Debug().Str("remote_addr", c.RemoteAddr().String()).Dur("waited", waited).Msg("connection timed out sending request headers")
+		if mc != nil {
+			mc.RecordSlowHeaderConnection()
+		}
+	}
+}
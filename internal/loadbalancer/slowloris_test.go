@@ -0,0 +1,156 @@
+package loadbalancer
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// newHeaderTrackedServer serves a trivial handler with a short
+// ReadHeaderTimeout and a much longer ReadTimeout
+func newHeaderTrackedServer(t *testing.T, headerTimeout time.Duration, maxPending int) (*LoadBalancer, *httptest.Server) {
+	t.Helper()
+	lb, err := NewLoadBalancer(&config.Config{
+		Server:       config.ServerConfig{SlowlorisProtection: config.SlowlorisConfig{MaxPending: maxPending}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	server.Config.ReadHeaderTimeout = headerTimeout
+	server.Config.ReadTimeout = 30 * time.Second
+	server.Config.ConnState = lb.TrackHeaderReads(headerTimeout)
+	server.Start()
+	t.Cleanup(server.Close)
+	return lb, server
+}
+
+func expectGoodRequest(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("well-behaved request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for a well-behaved request, got %d", resp.StatusCode)
+	}
+}
+
+// expectConnDropped reads from conn until the server closes it. Unread request
+// bytes make the close a reset rather than EOF; either counts.
+func expectConnDropped(t *testing.T, conn net.Conn, within time.Duration) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(within))
+	_, err := io.Copy(io.Discard, conn)
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatalf("connection still open after %v", within)
+	}
+}
+
+// waitForMetric polls cond until it holds or a second passes
+func waitForMetric(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSlowHeaderConnectionClosedAfterReadHeaderTimeout(t *testing.T) {
+	lb, server := newHeaderTrackedServer(t, time.Second, 0)
+	mc := lb.GetMetricsCollector()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Dribble one header byte per second
+	start := time.Now()
+	go func() {
+		for _, b := range []byte("GET / HTTP/1.1\r\nHost: example.com\r\n") {
+			if _, err := conn.Write([]byte{b}); err != nil {
+				return
+			}
+			time.Sleep(time.Second)
+		}
+	}()
+
+	waitForMetric(t, "a pending header connection", func() bool {
+		return mc.GetMetrics().PendingHeaderConnections == 1
+	})
+
+	// Other clients are served while the attack is in progress
+	expectGoodRequest(t, server)
+
+	expectConnDropped(t, conn, 10*time.Second)
+	elapsed := time.Since(start)
+	if elapsed < 900*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("expected the connection closed after about 1s, took %v", elapsed)
+	}
+
+	waitForMetric(t, "the slow connection to be counted", func() bool {
+		m := mc.GetMetrics()
+		return m.SlowHeaderConnections == 1 && m.PendingHeaderConnections == 0
+	})
+	expectGoodRequest(t, server)
+	if got := mc.GetMetrics().SlowHeaderConnections; got != 1 {
+		t.Errorf("expected well-behaved requests not to count as slow, got %d", got)
+	}
+}
+
+func TestMaxPendingHeaderConnectionsRefusesNewConnections(t *testing.T) {
+	lb, server := newHeaderTrackedServer(t, 10*time.Second, 2)
+	mc := lb.GetMetricsCollector()
+	addr := server.Listener.Addr().String()
+
+	var idle []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		defer conn.Close()
+		idle = append(idle, conn)
+	}
+	waitForMetric(t, "two pending header connections", func() bool {
+		return mc.GetMetrics().PendingHeaderConnections == 2
+	})
+
+	refused, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer refused.Close()
+	expectConnDropped(t, refused, 2*time.Second)
+	if got := mc.GetMetrics().RefusedHeaderConnections; got != 1 {
+		t.Errorf("expected 1 refused connection, got %d", got)
+	}
+
+	// Freeing the pending slots lets clients in again
+	for _, conn := range idle {
+		_ = conn.Close()
+	}
+	waitForMetric(t, "pending connections to drain", func() bool {
+		return mc.GetMetrics().PendingHeaderConnections == 0
+	})
+	expectGoodRequest(t, server)
+	if got := mc.GetMetrics().SlowHeaderConnections; got != 0 {
+		t.Errorf("expected no slow connections, got %d", got)
+	}
+}
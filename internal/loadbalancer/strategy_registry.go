@@ -0,0 +1,144 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+// defaultStrategy is used when load_balancer.strategy is empty
+const defaultStrategy = "round_robin"
+
+// StrategyEnv carries the settings from outside load_balancer.strategy_config
+// that strategies may depend on
+type StrategyEnv struct {
+	// TrustedProxies decides whose forwarding headers name the client
+	// (server.trusted_proxies); the zero value hashes the peer address
+	TrustedProxies utils.TrustedProxies
+}
+
+// StrategyFactory constructs a strategy from its load_balancer.strategy_config
+// block. Factories must reject option keys they do not understand.
+type StrategyFactory func(options map[string]interface{}, env StrategyEnv) (Strategy, error)
+
+// strategyFactories holds registered strategy factories by name
+var strategyFactories = map[string]StrategyFactory{}
+
+// RegisterStrategy registers a strategy factory under name
+func RegisterStrategy(name string, f StrategyFactory) {
+	if name == "" || f == nil {
+		return
+	}
+	strategyFactories[name] = f
+}
+
+// NewStrategy builds the named strategy with the given options.
+// An empty name selects round_robin.
+func NewStrategy(name string, options map[string]interface{}, env StrategyEnv) (Strategy, error) {
+	if name == "" {
+		name = defaultStrategy
+	}
+	f, ok := strategyFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s (valid: %s)", name, strings.Join(StrategyNames(), ", "))
+	}
+	s, err := f(options, env)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// StrategyNames returns the registered strategy names in sorted order
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategyFactories))
+	for name := range strategyFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkStrategyOptions returns an error naming every option key not listed in allowed
+func checkStrategyOptions(options map[string]interface{}, allowed ...string) error {
+	var unknown []string
+	for key := range options {
+		known := false
+		for _, a := range allowed {
+			if key == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown option(s): %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// copyOptions returns a shallow copy of a strategy options map (nil stays nil)
+func copyOptions(options map[string]interface{}) map[string]interface{} {
+	if options == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(options))
+	for k, v := range options {
+		out[k] = v
+	}
+	return out
+}
+
+// withoutOptions adapts a constructor for a strategy that takes no options
+func withoutOptions(newStrategy func() Strategy) StrategyFactory {
+	return func(options map[string]interface{}, _ StrategyEnv) (Strategy, error) {
+		if err := checkStrategyOptions(options); err != nil {
+			return nil, err
+		}
+		return newStrategy(), nil
+	}
+}
+
+// ValidateStrategies builds the selected and shadow strategies with their
+// strategy_config blocks, rejecting the unknown options Config.Validate
+// cannot see. Blocks for other strategies are ignored.
+func ValidateStrategies(lbCfg config.LoadBalancerConfig) error {
+	name := lbCfg.Strategy
+	if name == "" {
+		name = defaultStrategy
+	}
+	if _, err := NewStrategy(name, lbCfg.StrategyConfig[name], StrategyEnv{}); err != nil {
+		return fmt.Errorf("load_balancer.strategy: %w", err)
+	}
+	if shadow := lbCfg.ShadowStrategy.Strategy; shadow != "" {
+		if _, err := NewStrategy(shadow, lbCfg.StrategyConfig[shadow], StrategyEnv{}); err != nil {
+			return fmt.Errorf("shadow_strategy: %w", err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterStrategy("round_robin", withoutOptions(func() Strategy { return NewRoundRobinStrategy() }))
+	RegisterStrategy("least_connections", withoutOptions(func() Strategy { return NewLeastConnectionsStrategy() }))
+	RegisterStrategy("weighted_round_robin", withoutOptions(func() Strategy { return NewWeightedRoundRobinStrategy() }))
+	RegisterStrategy("ip_hash", withRemapGrace(func(remap *remapGrace, env StrategyEnv) Strategy {
+		s := NewIPHashStrategy()
+		s.remap = remap
+		s.proxies = env.TrustedProxies
+		return s
+	}))
+	RegisterStrategy("ip_hash_consistent", withRemapGrace(func(remap *remapGrace, env StrategyEnv) Strategy {
+		s := NewIPHashConsistentStrategy()
+		s.remap = remap
+		s.proxies = env.TrustedProxies
+		return s
+	}))
+}
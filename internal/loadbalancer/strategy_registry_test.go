@@ -0,0 +1,189 @@
+package loadbalancer
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func TestNewStrategy_RejectsUnknownStrategy(t *testing.T) {
+	_, err := NewStrategy("random", nil, StrategyEnv{})
+	if err == nil || !strings.Contains(err.Error(), "unknown strategy: random") {
+		t.Fatalf("expected unknown strategy error, got %v", err)
+	}
+}
+
+func TestNewStrategy_DefaultsToRoundRobin(t *testing.T) {
+	s, err := NewStrategy("", nil, StrategyEnv{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.(*RoundRobinStrategy); !ok {
+		t.Fatalf("expected round robin strategy, got %T", s)
+	}
+}
+
+func TestNewStrategy_Options(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		options  map[string]interface{}
+		wantErr  string
+	}{
+		{"ip_hash empty block", "ip_hash", map[string]interface{}{}, ""},
+		{"ip_hash no block", "ip_hash", nil, ""},
+		{"unknown key", "ip_hash", map[string]interface{}{"virtual_nodes": 100}, "unknown option(s): virtual_nodes"},
+		{"ip_hash remap grace", "ip_hash", map[string]interface{}{"remap_grace_seconds": 30}, ""},
+		{"ip_hash_consistent fractional remap grace", "ip_hash_consistent", map[string]interface{}{"remap_grace_seconds": 1.5}, ""},
+		{"negative remap grace", "ip_hash", map[string]interface{}{"remap_grace_seconds": -1}, "remap_grace_seconds must be non-negative"},
+		{"non-numeric remap grace", "ip_hash", map[string]interface{}{"remap_grace_seconds": "30s"}, "remap_grace_seconds must be a number"},
+		{"unknown keys sorted", "round_robin", map[string]interface{}{"b": 1, "a": 2}, "unknown option(s): a, b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStrategy(tt.strategy, tt.options, StrategyEnv{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// Config validation cannot import this package, so guard against the two
+// lists of strategy names drifting apart
+func TestStrategyRegistryMatchesConfigValidation(t *testing.T) {
+	for _, name := range StrategyNames() {
+		cfg := &config.Config{
+			Server:       config.ServerConfig{Port: 8080},
+			Backends:     []config.BackendConfig{{Name: "test", Address: "http://localhost:8081"}},
+			LoadBalancer: config.LoadBalancerConfig{Strategy: name},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("registered strategy %s rejected by config validation: %v", name, err)
+		}
+	}
+}
+
+func TestValidateStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		lb      config.LoadBalancerConfig
+		wantErr string
+	}{
+		{
+			name: "registered strategy with options",
+			lb: config.LoadBalancerConfig{
+				Strategy:       "ip_hash",
+				StrategyConfig: map[string]map[string]interface{}{"ip_hash": {"remap_grace_seconds": 5}},
+			},
+		},
+		{
+			name: "unselected block is ignored",
+			lb: config.LoadBalancerConfig{
+				Strategy:       "round_robin",
+				StrategyConfig: map[string]map[string]interface{}{"least_connections": {"not_an_option": true}},
+			},
+		},
+		{
+			name:    "unknown strategy",
+			lb:      config.LoadBalancerConfig{Strategy: "random"},
+			wantErr: "unknown strategy: random",
+		},
+		{
+			name: "unknown option",
+			lb: config.LoadBalancerConfig{
+				Strategy:       "least_connections",
+				StrategyConfig: map[string]map[string]interface{}{"least_connections": {"not_an_option": true}},
+			},
+			wantErr: "not_an_option",
+		},
+		{
+			name: "unknown shadow option",
+			lb: config.LoadBalancerConfig{
+				Strategy:       "round_robin",
+				ShadowStrategy: config.ShadowStrategyConfig{Strategy: "ip_hash"},
+				StrategyConfig: map[string]map[string]interface{}{"ip_hash": {"not_an_option": true}},
+			},
+			wantErr: "shadow_strategy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStrategies(tt.lb)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewLoadBalancer_StrategyConfig(t *testing.T) {
+	var lbCfg config.LoadBalancerConfig
+	doc := `
+strategy: ip_hash
+strategy_config:
+  ip_hash: {}
+  least_connections:
+    not_an_option: true
+`
+	if err := yaml.Unmarshal([]byte(doc), &lbCfg); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	// Options for the unselected strategy are ignored
+	lb, err := NewLoadBalancer(&config.Config{LoadBalancer: lbCfg})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+	if _, ok := lb.strategy.(*IPHashStrategy); !ok {
+		t.Fatalf("expected ip_hash strategy, got %T", lb.strategy)
+	}
+
+	// The same block is rejected once its strategy is selected
+	lbCfg.Strategy = "least_connections"
+	if _, err := NewLoadBalancer(&config.Config{LoadBalancer: lbCfg}); err == nil || !strings.Contains(err.Error(), "not_an_option") {
+		t.Fatalf("expected error naming the unknown option, got %v", err)
+	}
+}
+
+func TestSetStrategyWithOptions(t *testing.T) {
+	lb, err := NewLoadBalancer(&config.Config{LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"}})
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	defer lb.Stop()
+
+	if err := lb.SetStrategyWithOptions("ip_hash", map[string]interface{}{"bogus": 1}); err == nil {
+		t.Fatal("expected unknown option to be rejected")
+	}
+	if got := lb.GetStrategy(); got != "round_robin" {
+		t.Fatalf("failed switch must keep the previous strategy, got %s", got)
+	}
+
+	if err := lb.SetStrategyWithOptions("ip_hash", map[string]interface{}{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := lb.GetStrategy(); got != "ip_hash" {
+		t.Fatalf("expected ip_hash, got %s", got)
+	}
+	if opts := lb.GetStrategyOptions(); opts == nil || len(opts) != 0 {
+		t.Fatalf("expected empty options block to be stored, got %v", opts)
+	}
+}
@@ -0,0 +1,167 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const stressWorkers = 8
+
+func newStressBackend(name string) *Backend {
+	return &Backend{Name: name, IsHealthy: true, Weight: 1}
+}
+
+// stressResult holds selection counts gathered by the stress workers
+type stressResult struct {
+	counts map[string]int
+	nils   int64
+}
+
+// runStrategyStress hammers NextBackend from several goroutines while another
+// goroutine keeps adding, flipping and removing extra backends. Two stable
+// backends stay healthy throughout, so NextBackend must never return nil.
+func runStrategyStress(t *testing.T, s Strategy) stressResult {
+	t.Helper()
+
+	for _, name := range []string{"stable-0", "stable-1"} {
+		s.AddBackend(newStressBackend(name))
+	}
+
+	duration := 2 * time.Second
+	if testing.Short() {
+		duration = 300 * time.Millisecond
+	}
+
+	stop := make(chan struct{})
+	var nils int64
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < stressWorkers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			local := make(map[string]int)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					mu.Lock()
+					for k, v := range local {
+						counts[k] += v
+					}
+					mu.Unlock()
+					return
+				default:
+				}
+				req.RemoteAddr = fmt.Sprintf("10.%d.%d.%d:1234", worker, (i/256)%256, i%256)
+				b := s.NextBackend(req)
+				if b == nil {
+					atomic.AddInt64(&nils, 1)
+					continue
+				}
+				b.IncrementConnections()
+				local[b.Name]++
+				b.DecrementConnections()
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var churn []*Backend
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			b := newStressBackend(fmt.Sprintf("churn-%d", i))
+			s.AddBackend(b)
+			churn = append(churn, b)
+
+			// Flip health and weight the way the load balancer does
+			b.Mutex.Lock()
+			b.IsHealthy = i%2 == 0
+			b.Weight = 1 + i%3
+			b.Mutex.Unlock()
+
+			if len(churn) > 3 {
+				s.RemoveBackend(churn[0])
+				churn = churn[1:]
+			}
+			_ = s.GetBackends()
+		}
+	}()
+
+	time.Sleep(duration)
+	close(stop)
+	wg.Wait()
+
+	if nils > 0 {
+		t.Errorf("NextBackend returned nil %d times while healthy backends existed", nils)
+	}
+	if counts["stable-0"]+counts["stable-1"] == 0 {
+		t.Fatal("stable backends were never selected")
+	}
+	return stressResult{counts: counts, nils: nils}
+}
+
+// assertBalanced checks that both stable backends received a comparable share
+func assertBalanced(t *testing.T, res stressResult, maxRatio float64) {
+	t.Helper()
+	a, b := float64(res.counts["stable-0"]), float64(res.counts["stable-1"])
+	if a == 0 || b == 0 {
+		t.Fatalf("a stable backend was starved: stable-0=%v stable-1=%v", a, b)
+	}
+	if a/b > maxRatio || b/a > maxRatio {
+		t.Errorf("unbalanced distribution: stable-0=%v stable-1=%v", a, b)
+	}
+}
+
+func TestStress_RoundRobin(t *testing.T) {
+	assertBalanced(t, runStrategyStress(t, NewRoundRobinStrategy()), 1.5)
+}
+
+func TestStress_WeightedRoundRobin(t *testing.T) {
+	assertBalanced(t, runStrategyStress(t, NewWeightedRoundRobinStrategy()), 1.5)
+}
+
+func TestStress_LeastConnections(t *testing.T) {
+	// Selection depends on in-flight counts, so only require both to serve traffic
+	res := runStrategyStress(t, NewLeastConnectionsStrategy())
+	if res.counts["stable-0"] == 0 && res.counts["stable-1"] == 0 {
+		t.Fatal("least connections never picked a stable backend")
+	}
+}
+
+func TestStress_IPHash(t *testing.T) {
+	assertBalanced(t, runStrategyStress(t, NewIPHashStrategy()), 4)
+}
+
+func TestStress_IPHashConsistent(t *testing.T) {
+	assertBalanced(t, runStrategyStress(t, NewIPHashConsistentStrategy()), 4)
+}
+
+func TestWithoutBackendPreservesInput(t *testing.T) {
+	a, b, c := newStressBackend("a"), newStressBackend("b"), newStressBackend("c")
+	snapshot := []*Backend{a, b, c}
+
+	got := withoutBackend(snapshot, a)
+	if len(got) != 2 || got[0] != b || got[1] != c {
+		t.Fatalf("unexpected result order: %v", got)
+	}
+	if snapshot[0] != a || snapshot[1] != b || snapshot[2] != c {
+		t.Fatal("input slice was mutated")
+	}
+	if same := withoutBackend(got, a); len(same) != 2 {
+		t.Fatalf("removing a missing backend changed the slice: %v", same)
+	}
+}
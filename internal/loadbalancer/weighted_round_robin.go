@@ -39,9 +39,10 @@ func (wrr *WeightedRoundRobinStrategy) NextBackend(r *http.Request) *Backend {
 
 	for _, wb := range wrr.backends {
 		// Only consider healthy backends
-		if wb.backend.IsHealthy {
-			totalWeight += wb.backend.Weight
-			wb.currentWeight += wb.backend.Weight
+		if wb.backend.Available() {
+			weight := wb.backend.GetWeight()
+			totalWeight += weight
+			wb.currentWeight += weight
 
 			if best == nil || wb.currentWeight > best.currentWeight {
 				best = wb
@@ -74,11 +75,12 @@ func (wrr *WeightedRoundRobinStrategy) RemoveBackend(backend *Backend) {
 	wrr.mutex.Lock()
 	defer wrr.mutex.Unlock()
 
+	// Rebuild rather than swap-delete so the rotation order is preserved
 	for i, wb := range wrr.backends {
 		if wb.backend == backend {
-			// Remove the backend by swapping with the last element and truncating.
-			wrr.backends[i] = wrr.backends[len(wrr.backends)-1]
-			wrr.backends = wrr.backends[:len(wrr.backends)-1]
+			next := make([]*weightedBackend, 0, len(wrr.backends)-1)
+			next = append(next, wrr.backends[:i]...)
+			wrr.backends = append(next, wrr.backends[i+1:]...)
 			return
 		}
 	}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// callbackWarnAfter is how long a callback may run before SafeCall logs a warning
+var callbackWarnAfter = 100 * time.Millisecond
+
+// SafeCall runs a user-facing callback synchronously. A panic is logged with its
+// stack instead of being propagated to the caller, and a watchdog logs a warning
+// if the callback is still running after callbackWarnAfter. It reports whether
+// fn returned normally.
+func SafeCall(name string, fn func()) (ok bool) {
+	start := time.Now()
+	watchdog := time.AfterFunc(callbackWarnAfter, func() {
+		L().Warn().Str("callback", name).Dur("threshold", callbackWarnAfter).Msg("callback is running longer than expected")
+	})
+	defer func() {
+		watchdog.Stop()
+		if r := recover(); r != nil {
+			L().Error().
+				Str("callback", name).
+				Interface("panic", r).
+				Str("stack", string(debug.Stack())).
+				Msg("callback panicked")
+			ok = false
+			return
+		}
+		if elapsed := time.Since(start); elapsed > callbackWarnAfter {
+			L().Warn().Str("callback", name).Dur("duration", elapsed).Msg("slow callback finished")
+		}
+	}()
+	fn()
+	return true
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSafeCall(t *testing.T) {
+	called := false
+	if !SafeCall("test", func() { called = true }) || !called {
+		t.Fatal("expected callback to run and report success")
+	}
+
+	if SafeCall("test", func() { panic("boom") }) {
+		t.Fatal("expected a panicking callback to report failure")
+	}
+}
+
+func TestSafeCall_SlowCallbackRunsToCompletion(t *testing.T) {
+	prev := callbackWarnAfter
+	callbackWarnAfter = 10 * time.Millisecond
+	t.Cleanup(func() { callbackWarnAfter = prev })
+
+	finished := false
+	SafeCall("slow", func() {
+		time.Sleep(30 * time.Millisecond)
+		finished = true
+	})
+	if !finished {
+		t.Fatal("the watchdog must only log, not abandon the callback")
+	}
+}
@@ -34,6 +34,10 @@ const (
 var (
 	baseLogger   *zerolog.Logger
 	baseLoggerMu sync.RWMutex
+
+	// outputClosers holds resources (files, syslog connections) owned by the
+	// current base logger; guarded by baseLoggerMu
+	outputClosers []io.Closer
 )
 
 func init() {
@@ -43,10 +47,53 @@ func init() {
 }
 
 // Init configures the global logger based on configuration values.
-func Init(cfg config.LoggingConfig) {
+// It may be called repeatedly; outputs owned by the previous logger are
+// closed only after the new logger has been swapped in, and events still
+// written through a copy of the previous logger are dropped.
+func Init(cfg config.LoggingConfig) error {
+	return initWithStreams(cfg, defaultStreams())
+}
+
+func initWithStreams(cfg config.LoggingConfig, streams stdStreams) error {
 	level := parseLevel(cfg.Level)
 	format := parseFormat(cfg.Format)
-	setBaseLogger(newLogger(os.Stdout, level, format, cfg.IncludeCaller))
+
+	// Outputs without an explicit level inherit the global level; the logger
+	// itself must admit the most verbose level any output asks for
+	outputs := make([]config.LogOutputConfig, len(cfg.Outputs))
+	copy(outputs, cfg.Outputs)
+	loggerLevel := level
+	for i := range outputs {
+		if outputs[i].Level == "" {
+			outputs[i].Level = level.String()
+		}
+		if l := parseLevel(outputs[i].Level); l < loggerLevel {
+			loggerLevel = l
+		}
+	}
+	if len(outputs) == 0 {
+		outputs = []config.LogOutputConfig{{Type: "stdout", Level: level.String()}}
+	}
+
+	writer, closers, err := buildOutputs(outputs, format, streams)
+	if err != nil {
+		return err
+	}
+
+	builder := zerolog.New(writer).Level(loggerLevel).With().Timestamp()
+	if cfg.IncludeCaller {
+		builder = builder.CallerWithSkipFrameCount(1)
+	}
+	logger := builder.Logger()
+
+	baseLoggerMu.Lock()
+	baseLogger = &logger
+	previous := outputClosers
+	outputClosers = closers
+	baseLoggerMu.Unlock()
+
+	closeAll(previous)
+	return nil
 }
 
 func parseLevel(value string) zerolog.Level {
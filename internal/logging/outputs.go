@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+const (
+	defaultFileMaxSizeMB  = 100
+	defaultFileMaxBackups = 5
+)
+
+// sink is a single log destination with its own minimum level
+type sink struct {
+	name   string
+	writer zerolog.LevelWriter
+	level  zerolog.Level
+
+	// failing is set after a write error so the failure is reported once
+	// rather than on every event until the sink recovers
+	mu      sync.Mutex
+	failing bool
+}
+
+// retiringWriter guards an output that owns a closable resource. Goroutines
+// holding a copy of a logger replaced by Init may still write to its outputs;
+// Close waits for writes in flight, and later writes are dropped rather than
+// failing on the closed resource.
+type retiringWriter struct {
+	zerolog.LevelWriter
+	closer io.Closer
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Write implements io.Writer
+func (r *retiringWriter) Write(p []byte) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return len(p), nil
+	}
+	return r.LevelWriter.Write(p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (r *retiringWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return len(p), nil
+	}
+	return r.LevelWriter.WriteLevel(level, p)
+}
+
+// Close retires the writer and closes the underlying resource
+func (r *retiringWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.closer.Close()
+}
+
+// fanoutWriter writes every event to all sinks that accept its level.
+// A failing sink never blocks or fails the others.
+type fanoutWriter struct {
+	sinks  []*sink
+	errOut io.Writer
+}
+
+// Write implements io.Writer for events without level information
+func (f *fanoutWriter) Write(p []byte) (int, error) {
+	return f.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (f *fanoutWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	for _, s := range f.sinks {
+		if level != zerolog.NoLevel && level < s.level {
+			continue
+		}
+		_, err := s.writer.WriteLevel(level, p)
+		f.reportSinkError(s, err)
+	}
+	return len(p), nil
+}
+
+// reportSinkError prints a sink failure to the error output on the first
+// failure and again once the sink recovers
+func (f *fanoutWriter) reportSinkError(s *sink, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil && !s.failing {
+		s.failing = true
+		_, _ = fmt.Fprintf(f.errOut, "helios: log output %s write failed: %v\n", s.name, err)
+	} else if err == nil && s.failing {
+		s.failing = false
+		_, _ = fmt.Fprintf(f.errOut, "helios: log output %s recovered\n", s.name)
+	}
+}
+
+// stdStreams holds the standard streams used for stdout/stderr sinks
+type stdStreams struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// buildOutputs opens all configured sinks and returns the fan-out writer
+// together with the resources that must be closed when it is replaced.
+// When no outputs are configured everything goes to stdout.
+func buildOutputs(outputs []config.LogOutputConfig, format logFormat, streams stdStreams) (zerolog.LevelWriter, []io.Closer, error) {
+	if len(outputs) == 0 {
+		outputs = []config.LogOutputConfig{{Type: "stdout"}}
+	}
+
+	fanout := &fanoutWriter{errOut: streams.stderr}
+	var closers []io.Closer
+
+	for i, out := range outputs {
+		w, closer, err := openOutput(out, format, streams)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, fmt.Errorf("logging output %d (%s): %w", i, out.Type, err)
+		}
+		if closer != nil {
+			retiring := &retiringWriter{LevelWriter: w, closer: closer}
+			w = retiring
+			closers = append(closers, retiring)
+		}
+
+		level := zerolog.TraceLevel
+		if out.Level != "" {
+			level = parseLevel(out.Level)
+		}
+		fanout.sinks = append(fanout.sinks, &sink{
+			name:   sinkName(out),
+			writer: w,
+			level:  level,
+		})
+	}
+
+	return fanout, closers, nil
+}
+
+// openOutput creates the writer for a single output definition
+func openOutput(out config.LogOutputConfig, format logFormat, streams stdStreams) (zerolog.LevelWriter, io.Closer, error) {
+	switch strings.ToLower(out.Type) {
+	case "", "stdout":
+		return formatWriter(streams.stdout, format), nil, nil
+	case "stderr":
+		return formatWriter(streams.stderr, format), nil, nil
+	case "file":
+		if out.Path == "" {
+			return nil, nil, fmt.Errorf("file output requires a path")
+		}
+		maxSizeMB := out.MaxSizeMB
+		if maxSizeMB <= 0 {
+			maxSizeMB = defaultFileMaxSizeMB
+		}
+		maxBackups := out.MaxBackups
+		if maxBackups <= 0 {
+			maxBackups = defaultFileMaxBackups
+		}
+		rf, err := NewRotatingFile(out.Path, int64(maxSizeMB)*1024*1024, maxBackups, out.Compress)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file %s: %w", out.Path, err)
+		}
+		return formatWriter(rf, format), rf, nil
+	case "syslog":
+		return openSyslog(out)
+	default:
+		return nil, nil, fmt.Errorf("unknown output type %q", out.Type)
+	}
+}
+
+// formatWriter applies the configured log format to a raw destination
+func formatWriter(w io.Writer, format logFormat) zerolog.LevelWriter {
+	if format == formatText {
+		return zerolog.LevelWriterAdapter{Writer: zerolog.ConsoleWriter{
+			Out:        w,
+			TimeFormat: time.RFC3339Nano,
+			NoColor:    true,
+		}}
+	}
+	return zerolog.LevelWriterAdapter{Writer: w}
+}
+
+// sinkName returns a human-readable identifier for an output
+func sinkName(out config.LogOutputConfig) string {
+	switch out.Type {
+	case "file":
+		return "file:" + out.Path
+	case "syslog":
+		if out.Address != "" {
+			return "syslog:" + out.Address
+		}
+		return "syslog"
+	case "":
+		return "stdout"
+	default:
+		return out.Type
+	}
+}
+
+// closeAll closes every closer, ignoring errors
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
+
+// defaultStreams returns the process standard streams
+func defaultStreams() stdStreams {
+	return stdStreams{stdout: os.Stdout, stderr: os.Stderr}
+}
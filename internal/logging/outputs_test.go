@@ -0,0 +1,275 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// restoreBaseLogger snapshots the global logger and its outputs so tests
+// calling initWithStreams do not leak state into other tests
+func restoreBaseLogger(t *testing.T) {
+	t.Helper()
+	baseLoggerMu.Lock()
+	previous := baseLogger
+	previousClosers := outputClosers
+	outputClosers = nil
+	baseLoggerMu.Unlock()
+
+	t.Cleanup(func() {
+		baseLoggerMu.Lock()
+		current := outputClosers
+		baseLogger = previous
+		outputClosers = previousClosers
+		baseLoggerMu.Unlock()
+		closeAll(current)
+	})
+}
+
+func TestRotatingFile_RotatesAtThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helios.log")
+	rf, err := NewRotatingFile(path, 100, 2, false)
+	if err != nil {
+		t.Fatalf("failed to open rotating file: %v", err)
+	}
+	defer func() { _ = rf.Close() }()
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 4; i++ {
+		if _, err := rf.Write(line); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+
+	// Every second write overflows the 100 byte threshold
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s exceeds threshold: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helios.log")
+	rf, err := NewRotatingFile(path, 10, 1, true)
+	if err != nil {
+		t.Fatalf("failed to open rotating file: %v", err)
+	}
+	defer func() { _ = rf.Close() }()
+
+	_, _ = rf.Write([]byte("first line\n"))
+	_, _ = rf.Write([]byte("second line\n"))
+
+	// Close waits for the background compression
+	if err := rf.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".1.gz"); err != nil {
+		t.Fatalf("expected compressed backup: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("expected uncompressed backup to be removed")
+	}
+}
+
+func TestRotatingFile_CompressKeepsBackupOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helios.log")
+	rf, err := NewRotatingFile(path, 10, 2, true)
+	if err != nil {
+		t.Fatalf("failed to open rotating file: %v", err)
+	}
+
+	// Rotate faster than compression may keep up with
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	for name, want := range map[string]string{path + ".1.gz": "third line\n", path + ".2.gz": "second line\n"} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("expected backup %s: %v", name, err)
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		data, _ := io.ReadAll(gz)
+		_ = f.Close()
+		if string(data) != want {
+			t.Errorf("expected %s to hold %q, got %q", name, want, data)
+		}
+	}
+	if _, err := os.Stat(path + ".3.gz"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+	if staged, _ := filepath.Glob(path + ".*.rotating"); len(staged) != 0 {
+		t.Errorf("expected no rotated files left uncompressed, got %v", staged)
+	}
+}
+
+func TestRotatingFile_KeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helios.log")
+	rf, err := NewRotatingFile(path, 10, 1, false)
+	if err != nil {
+		t.Fatalf("failed to open rotating file: %v", err)
+	}
+	defer func() { _ = rf.Close() }()
+
+	// A non-empty directory where the backup goes makes the rename fail,
+	// even for root, which a read-only directory wouldn't
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocker"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = rf.Write([]byte("first line\n"))
+	if n, err := rf.Write([]byte("second line\n")); err == nil || n != 12 {
+		t.Fatalf("expected the line written and the rotation error reported, got %d, %v", n, err)
+	}
+	if _, err := rf.Write([]byte("third line\n")); err == nil {
+		t.Error("expected rotation to be retried and fail again")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "first line\nsecond line\nthird line\n" {
+		t.Fatalf("expected logging to continue in the active file, got %q (%v)", data, err)
+	}
+
+	// Once the backup path is free, the next write rotates
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rf.Write([]byte("fourth line\n")); err != nil {
+		t.Fatalf("expected rotation to succeed, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "fourth line\n" {
+		t.Errorf("expected a fresh active file, got %q", data)
+	}
+	if data, _ := os.ReadFile(path + ".1"); !strings.HasPrefix(string(data), "first line") {
+		t.Errorf("expected the old file as the backup, got %q", data)
+	}
+}
+
+func TestInit_FileSinkLevelFilter(t *testing.T) {
+	restoreBaseLogger(t)
+
+	path := filepath.Join(t.TempDir(), "helios.log")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cfg := config.LoggingConfig{
+		Level:  "debug",
+		Format: "json",
+		Outputs: []config.LogOutputConfig{
+			{Type: "stdout"},
+			{Type: "file", Level: "warn", Path: path, MaxSizeMB: 1, MaxBackups: 1},
+		},
+	}
+	if err := initWithStreams(cfg, stdStreams{stdout: stdout, stderr: stderr}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	L().Debug().Msg("debug-event")
+	L().Info().Msg("info-event")
+	L().Warn().Msg("warn-event")
+
+	for _, msg := range []string{"debug-event", "info-event", "warn-event"} {
+		if !strings.Contains(stdout.String(), msg) {
+			t.Errorf("expected stdout to contain %s", msg)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if strings.Contains(string(data), "debug-event") || strings.Contains(string(data), "info-event") {
+		t.Errorf("file sink should only contain warn+ events, got %s", data)
+	}
+	if !strings.Contains(string(data), "warn-event") {
+		t.Errorf("expected file sink to contain warn event")
+	}
+
+	// Push the file past 1MB to trigger rotation
+	padding := strings.Repeat("p", 1024)
+	for i := 0; i < 1100; i++ {
+		L().Warn().Str("padding", padding).Msg("fill")
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected file sink to rotate: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected active log file: %v", err)
+	}
+	if info.Size() > 1024*1024 {
+		t.Errorf("active log file exceeds threshold: %d bytes", info.Size())
+	}
+}
+
+func TestInit_IsIdempotentAndClosesPreviousOutputs(t *testing.T) {
+	restoreBaseLogger(t)
+
+	path := filepath.Join(t.TempDir(), "helios.log")
+	streams := stdStreams{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	cfg := config.LoggingConfig{Format: "json", Outputs: []config.LogOutputConfig{{Type: "file", Path: path}}}
+
+	if err := initWithStreams(cfg, streams); err != nil {
+		t.Fatalf("first init failed: %v", err)
+	}
+	baseLoggerMu.RLock()
+	first := outputClosers[0].(*retiringWriter)
+	baseLoggerMu.RUnlock()
+	previous := L()
+
+	if err := initWithStreams(cfg, streams); err != nil {
+		t.Fatalf("second init failed: %v", err)
+	}
+	if _, err := first.closer.(*RotatingFile).Write([]byte("late")); err != os.ErrClosed {
+		t.Errorf("expected previous output to be closed, got %v", err)
+	}
+	// A copy of the previous logger keeps working; its events are dropped
+	if n, err := first.Write([]byte("late")); err != nil || n != 4 {
+		t.Errorf("expected writes to a retired output to be dropped, got %d, %v", n, err)
+	}
+	previous.Info().Msg("before-reinit-copy")
+
+	L().Info().Msg("after-reinit")
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "after-reinit") {
+		t.Errorf("expected new logger to write to file")
+	}
+	if strings.Contains(string(data), "before-reinit-copy") {
+		t.Errorf("expected the retired logger's event to be dropped")
+	}
+	if s := streams.stderr.(*bytes.Buffer).String(); s != "" {
+		t.Errorf("expected no write failures reported, got %q", s)
+	}
+}
+
+func TestInit_UnopenableFileFails(t *testing.T) {
+	restoreBaseLogger(t)
+
+	cfg := config.LoggingConfig{Outputs: []config.LogOutputConfig{
+		{Type: "file", Path: filepath.Join(t.TempDir(), "missing", "helios.log")},
+	}}
+	err := initWithStreams(cfg, stdStreams{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "failed to open log file") {
+		t.Fatalf("expected open failure, got %v", err)
+	}
+}
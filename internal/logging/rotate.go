@@ -0,0 +1,258 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.WriteCloser that rotates the underlying file once it
+// reaches a size threshold. Rotated files are named path.1, path.2, ... with
+// path.1 being the most recent; when compression is enabled they are gzipped
+// to path.N.gz in the background. It is safe for concurrent use and shared by
+// any feature that writes to a size-bounded file.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+
+	mu     sync.Mutex
+	file   *os.File // nil after Close, or while reopening after a rotation keeps failing
+	size   int64
+	closed bool
+
+	// Rotated files waiting to be compressed, oldest first, and the state of
+	// the single worker compressing them; guarded by mu
+	pending    []string
+	archiving  bool
+	archiveErr error // Last compression failure, reported by the next Write or Close
+	archived   sync.WaitGroup
+}
+
+// NewRotatingFile opens (or creates) path for appending and rotates it once
+// writing would exceed maxSize bytes, keeping at most maxBackups old files.
+func NewRotatingFile(path string, maxSize int64, maxBackups int, compress bool) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("max size must be positive (got %d)", maxSize)
+	}
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// open opens the active file in append mode and records its current size
+func (rf *RotatingFile) open() error {
+	// #nosec G302 G304 - log path is provided by trusted admin configuration
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	return nil
+}
+
+// Write appends p to the active file, rotating first if p would overflow it
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	if rf.file == nil {
+		// A previous rotation couldn't reopen the file; try again
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			if rf.file == nil {
+				return 0, err
+			}
+			// Keep logging to the file that couldn't be rotated; rotation
+			// is retried on the next write
+			n, _ := rf.file.Write(p)
+			rf.size += int64(n)
+			return n, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	if err == nil && rf.archiveErr != nil {
+		err, rf.archiveErr = rf.archiveErr, nil
+	}
+	return n, err
+}
+
+// Close closes the active file and waits for pending compressions to finish
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	rf.closed = true
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	rf.mu.Unlock()
+
+	rf.archived.Wait()
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	err = errors.Join(err, rf.archiveErr)
+	rf.archiveErr = nil
+	return err
+}
+
+// rotate shifts existing backups, moves the active file to path.1 and reopens
+// a fresh file. If a step fails, path is reopened as it is so logging
+// continues, and the error is returned. Must be called with rf.mu held.
+func (rf *RotatingFile) rotate() error {
+	closeErr := rf.file.Close()
+	rf.file = nil
+
+	err := closeErr
+	if err == nil {
+		err = rf.shiftFiles()
+	}
+	if err != nil {
+		err = fmt.Errorf("rotate %s: %w", rf.path, err)
+	}
+	if openErr := rf.open(); openErr != nil {
+		if err != nil {
+			return fmt.Errorf("%v; reopen: %w", err, openErr)
+		}
+		return openErr
+	}
+	return err
+}
+
+// shiftFiles moves the active file to path.1, shifting older backups up and
+// dropping the oldest. With compression the file is only renamed aside here
+// and the archive worker does the rest. Must be called with rf.mu held and
+// the file closed.
+func (rf *RotatingFile) shiftFiles() error {
+	if rf.maxBackups <= 0 {
+		if err := os.Remove(rf.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if !rf.compress {
+		return rf.shiftBackups(rf.path)
+	}
+
+	staged := fmt.Sprintf("%s.%d.rotating", rf.path, time.Now().UnixNano())
+	if err := os.Rename(rf.path, staged); err != nil {
+		return err
+	}
+	rf.pending = append(rf.pending, staged)
+	if !rf.archiving {
+		rf.archiving = true
+		rf.archived.Add(1)
+		go rf.archive()
+	}
+	return nil
+}
+
+// shiftBackups drops the oldest backup, shifts the rest up by one and moves
+// src into the first slot
+func (rf *RotatingFile) shiftBackups(src string) error {
+	_ = os.Remove(rf.backupName(rf.maxBackups))
+	for i := rf.maxBackups - 1; i >= 1; i-- {
+		if _, err := os.Stat(rf.backupName(i)); err == nil {
+			if err := os.Rename(rf.backupName(i), rf.backupName(i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(src, rf.backupName(1))
+}
+
+// archive compresses pending rotated files one at a time, oldest first, so
+// the backups keep their order. It exits once the queue is empty.
+func (rf *RotatingFile) archive() {
+	defer rf.archived.Done()
+	for {
+		rf.mu.Lock()
+		if len(rf.pending) == 0 {
+			rf.archiving = false
+			rf.mu.Unlock()
+			return
+		}
+		staged := rf.pending[0]
+		rf.pending = rf.pending[1:]
+		rf.mu.Unlock()
+
+		err := compressFile(staged, staged+".gz")
+		if err == nil {
+			err = rf.shiftBackups(staged + ".gz")
+		}
+		if err != nil {
+			rf.mu.Lock()
+			rf.archiveErr = fmt.Errorf("archive %s: %w", staged, err)
+			rf.mu.Unlock()
+		}
+	}
+}
+
+// backupName returns the file name of the n-th backup
+func (rf *RotatingFile) backupName(n int) string {
+	name := fmt.Sprintf("%s.%d", rf.path, n)
+	if rf.compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// compressFile gzips src into dst and removes src
+func compressFile(src, dst string) error {
+	// #nosec G304 - paths derive from trusted log configuration
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	// #nosec G302 G304 - paths derive from trusted log configuration
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		_ = gz.Close()
+		_ = out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
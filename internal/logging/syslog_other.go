@@ -0,0 +1,17 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"io"
+
+	"github.com/rs/zerolog"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// openSyslog reports that syslog is unavailable on this platform
+func openSyslog(out config.LogOutputConfig) (zerolog.LevelWriter, io.Closer, error) {
+	return nil, nil, errors.New("syslog output is not supported on this platform")
+}
@@ -0,0 +1,57 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"io"
+	"log/syslog"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// openSyslog connects to the configured syslog daemon. Events are always sent
+// as JSON and mapped to the matching syslog severity.
+func openSyslog(out config.LogOutputConfig) (zerolog.LevelWriter, io.Closer, error) {
+	facility := syslog.LOG_DAEMON
+	if out.Facility != "" {
+		if f, ok := syslogFacilities[strings.ToLower(out.Facility)]; ok {
+			facility = f
+		}
+	}
+	tag := out.Tag
+	if tag == "" {
+		tag = "helios"
+	}
+
+	w, err := syslog.Dial(out.Network, out.Address, facility|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zerolog.SyslogLevelWriter(w), w, nil
+}
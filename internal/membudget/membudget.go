@@ -0,0 +1,113 @@
+// Package membudget bounds the memory that request and response buffering
+// features use collectively. Each feature acquires bytes before buffering
+// and releases them when done; when the budget is exhausted it falls back
+// to its non-buffering behavior instead of failing the request.
+package membudget
+
+import "sync/atomic"
+
+// Budget is a byte accountant shared by all buffering features. A nil
+// Budget grants every request, so callers need no special casing.
+type Budget struct {
+	limit int64 // 0 means unlimited; usage is still tracked
+	used  atomic.Int64
+	peak  atomic.Int64
+}
+
+// New creates a budget of limit bytes; limit <= 0 tracks usage without a cap
+func New(limit int64) *Budget {
+	if limit < 0 {
+		limit = 0
+	}
+	return &Budget{limit: limit}
+}
+
+// Acquire reserves n bytes and reports whether they were granted
+func (b *Budget) Acquire(n int64) bool {
+	if b == nil || n <= 0 {
+		return true
+	}
+	for {
+		cur := b.used.Load()
+		next := cur + n
+		if b.limit > 0 && next > b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(cur, next) {
+			b.raisePeak(next)
+			return true
+		}
+	}
+}
+
+// Release returns n previously acquired bytes
+func (b *Budget) Release(n int64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	b.used.Add(-n)
+}
+
+// raisePeak records a new high-water mark
+func (b *Budget) raisePeak(used int64) {
+	for {
+		peak := b.peak.Load()
+		if used <= peak || b.peak.CompareAndSwap(peak, used) {
+			return
+		}
+	}
+}
+
+// Limit returns the budget in bytes, 0 when unlimited
+func (b *Budget) Limit() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.limit
+}
+
+// Used returns the bytes currently reserved
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.used.Load()
+}
+
+// Peak returns the highest number of bytes reserved at once
+func (b *Budget) Peak() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.peak.Load()
+}
+
+// Reservation tracks the bytes one buffering site holds so they can be
+// released in one call, typically deferred
+type Reservation struct {
+	budget *Budget
+	held   int64
+}
+
+// Reserve starts a reservation against b
+func (b *Budget) Reserve() *Reservation {
+	return &Reservation{budget: b}
+}
+
+// Grow acquires n more bytes for the reservation
+func (r *Reservation) Grow(n int) bool {
+	if !r.budget.Acquire(int64(n)) {
+		return false
+	}
+	if r.budget != nil {
+		r.held += int64(n)
+	}
+	return true
+}
+
+// Release returns everything the reservation holds. It is safe to call
+// more than once.
+func (r *Reservation) Release() {
+	r.budget.Release(r.held)
+	r.held = 0
+}
@@ -0,0 +1,93 @@
+package membudget
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	b := New(100)
+
+	if !b.Acquire(60) {
+		t.Fatal("expected 60 of 100 bytes to be granted")
+	}
+	if b.Acquire(50) {
+		t.Fatal("expected acquire beyond the limit to be denied")
+	}
+	if !b.Acquire(40) {
+		t.Fatal("expected acquire up to the limit to be granted")
+	}
+	b.Release(60)
+	if got := b.Used(); got != 40 {
+		t.Errorf("expected 40 bytes used, got %d", got)
+	}
+	if got := b.Peak(); got != 100 {
+		t.Errorf("expected peak of 100, got %d", got)
+	}
+}
+
+func TestUnlimitedAndNil(t *testing.T) {
+	b := New(0)
+	if !b.Acquire(1 << 40) {
+		t.Fatal("expected an unlimited budget to grant everything")
+	}
+	if got := b.Used(); got != 1<<40 {
+		t.Errorf("expected usage to be tracked without a limit, got %d", got)
+	}
+
+	var none *Budget
+	if !none.Acquire(10) {
+		t.Fatal("expected a nil budget to grant everything")
+	}
+	none.Release(10)
+	r := none.Reserve()
+	if !r.Grow(10) {
+		t.Fatal("expected a nil budget reservation to grow")
+	}
+	r.Release()
+}
+
+func TestReservation(t *testing.T) {
+	b := New(100)
+	r := b.Reserve()
+	if !r.Grow(30) || !r.Grow(30) {
+		t.Fatal("expected reservation to grow within the limit")
+	}
+	if r.Grow(50) {
+		t.Fatal("expected reservation growth beyond the limit to be denied")
+	}
+	r.Release()
+	r.Release()
+	if got := b.Used(); got != 0 {
+		t.Errorf("expected all bytes released, got %d used", got)
+	}
+}
+
+func TestConcurrentAcquireNeverExceedsLimit(t *testing.T) {
+	const limit = 1000
+	b := New(limit)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if b.Acquire(64) {
+					if used := b.Used(); used > limit {
+						t.Errorf("usage %d exceeded limit %d", used, limit)
+					}
+					b.Release(64)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if b.Used() != 0 {
+		t.Errorf("expected all bytes released, got %d used", b.Used())
+	}
+	if b.Peak() > limit {
+		t.Errorf("peak %d exceeded limit %d", b.Peak(), limit)
+	}
+}
@@ -1,12 +1,19 @@
 package metrics
 
 import (
-	"encoding/json"
+	"encoding/binary"
+	"hash/fnv"
 	"math"
 	"net/http"
+	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/0xReLogic/Helios/internal/membudget"
+	"github.com/0xReLogic/Helios/internal/store"
+	"github.com/0xReLogic/Helios/internal/utils"
 )
 
 const (
@@ -36,10 +43,43 @@ type Metrics struct {
 
 	// Rate limiting metrics
 	RateLimitedRequests uint64 `json:"rate_limited_requests"`
+	// Rejections by rate limit rule name
+	RateLimitRuleRejections map[string]uint64 `json:"rate_limit_rule_rejections"`
+
+	// Requests from HTTP/1.0 clients
+	HTTP10Requests uint64 `json:"http10_requests"`
+
+	// Requests rejected by the request target guard, by reason
+	SecurityRejections map[string]uint64 `json:"security_rejections"`
+
+	// Connections still sending their first request headers, those closed
+	// for exceeding the read header timeout, and those refused because
+	// server.slowloris_protection.max_pending was reached
+	PendingHeaderConnections int64  `json:"pending_header_connections"`
+	SlowHeaderConnections    uint64 `json:"slow_header_connections"`
+	RefusedHeaderConnections uint64 `json:"refused_header_connections"`
 
 	// Circuit breaker metrics
 	CircuitBreakerMetrics map[string]*CircuitBreakerMetrics `json:"circuit_breaker_metrics"`
 
+	// Synthetic monitoring metrics
+	SyntheticMetrics map[string]*SyntheticMetrics `json:"synthetic_metrics"`
+
+	// Named counters reported by plugins, keyed by plugin then counter
+	PluginMetrics map[string]map[string]uint64 `json:"plugin_metrics"`
+
+	// Backend group taking traffic, when backend_groups is configured
+	ActiveBackendGroup string `json:"active_backend_group,omitempty"`
+
+	// Memory shared by buffering features (limits.max_buffer_bytes_total)
+	BufferBudget BufferBudgetMetrics `json:"buffer_budget"`
+
+	// Usage by cost_attribution label
+	CostMetrics map[string]*CostMetrics `json:"cost_metrics"`
+
+	// Size and counters of the stores holding dynamic state, by store name
+	Stores map[string]store.Stats `json:"stores"`
+
 	// System metrics
 	StartTime time.Time `json:"start_time"`
 	Uptime    string    `json:"uptime"`
@@ -47,6 +87,24 @@ type Metrics struct {
 	mutex sync.RWMutex
 }
 
+// BufferBudgetMetrics reports the global buffering budget
+type BufferBudgetMetrics struct {
+	LimitBytes int64 `json:"limit_bytes"` // 0 when unlimited
+	UsedBytes  int64 `json:"used_bytes"`
+	PeakBytes  int64 `json:"peak_bytes"`
+	// Times each feature fell back to not buffering because the budget was exhausted
+	Denials map[string]uint64 `json:"denials"`
+}
+
+// CostMetrics is the usage attributed to one cost_attribution label
+type CostMetrics struct {
+	Requests         uint64  `json:"requests"`
+	BytesIn          uint64  `json:"bytes_in"`           // Request body bytes read
+	BytesOut         uint64  `json:"bytes_out"`          // Response body bytes written
+	BackendLatencyMs float64 `json:"backend_latency_ms"` // Total time backends spent responding, a proxy for compute
+	Errors           uint64  `json:"errors"`             // Responses with a 5xx status
+}
+
 // BackendMetrics holds metrics for individual backends
 type BackendMetrics struct {
 	Name                string    `json:"name"`
@@ -58,8 +116,28 @@ type BackendMetrics struct {
 	alpha               float64   // EMA smoothing factor (not exported)
 	IsHealthy           bool      `json:"is_healthy"`
 	LastHealthCheck     time.Time `json:"last_health_check"`
+	HeaderOverflows     uint64    `json:"header_overflows"`
+	BodyStalls          uint64    `json:"backend_body_stall"`
+	RedirectsFollowed   uint64    `json:"redirects_followed"`
+	RedirectsRewritten  uint64    `json:"redirects_rewritten"`
+	ConnectionsRecycled uint64    `json:"connections_recycled"` // Closed by connection_recycling
+
+	// Active health check streaks, compared against health_checks.active.fall/rise
+	ConsecutiveFailures  int `json:"consecutive_failures"`
+	ConsecutiveSuccesses int `json:"consecutive_successes"`
+
+	// Connection pool wait metrics
+	ConnWaitMs       float64 `json:"conn_wait_ms"`     // EMA of connection acquisition wait
+	ConnWaitP95Ms    float64 `json:"conn_wait_p95_ms"` // p95 over the recent sample window
+	ConnWaitWarnings uint64  `json:"conn_wait_warnings"`
+	PoolExhaustions  uint64  `json:"pool_exhaustions"`
+	connWaitSamples  [connWaitWindow]float64
+	connWaitCount    int
 }
 
+// connWaitWindow is the number of recent connection waits kept for the p95
+const connWaitWindow = 128
+
 // CircuitBreakerMetrics holds metrics for circuit breakers
 type CircuitBreakerMetrics struct {
 	Name            string    `json:"name"`
@@ -70,29 +148,62 @@ type CircuitBreakerMetrics struct {
 	LastStateChange time.Time `json:"last_state_change"`
 }
 
+// SyntheticMetrics holds results for a synthetic monitoring check
+type SyntheticMetrics struct {
+	Name              string    `json:"name"`
+	Runs              uint64    `json:"runs"`
+	Passes            uint64    `json:"passes"`
+	Failures          uint64    `json:"failures"`
+	LastPassed        bool      `json:"last_passed"`
+	LastLatency       float64   `json:"last_latency_ms"`
+	AverageLatency    float64   `json:"average_latency_ms"`
+	LastFailureReason string    `json:"last_failure_reason,omitempty"`
+	LastRun           time.Time `json:"last_run"`
+}
+
 // MetricsCollector manages metrics collection
 type MetricsCollector struct {
 	metrics     *Metrics
 	metricsPool sync.Pool // Pool for Metrics copies to reduce GC pressure
 	backendPool sync.Pool // Pool for BackendMetrics copies
+
+	bufferBudget *membudget.Budget         // Reported under buffer_budget; guarded by metrics.mutex
+	stores       map[string]store.Reporter // Reported under stores; guarded by metrics.mutex
+
+	version atomic.Uint64 // Bumped after every change, for ETags
 }
 
 // NewMetricsCollector creates a new metrics collector
 func NewMetricsCollector() *MetricsCollector {
 	mc := &MetricsCollector{
 		metrics: &Metrics{
-			BackendMetrics:        make(map[string]*BackendMetrics),
-			CircuitBreakerMetrics: make(map[string]*CircuitBreakerMetrics),
-			StartTime:             time.Now(),
-			alpha:                 DefaultAlpha,
+			BackendMetrics:          make(map[string]*BackendMetrics),
+			CircuitBreakerMetrics:   make(map[string]*CircuitBreakerMetrics),
+			SyntheticMetrics:        make(map[string]*SyntheticMetrics),
+			PluginMetrics:           make(map[string]map[string]uint64),
+			RateLimitRuleRejections: make(map[string]uint64),
+			SecurityRejections:      make(map[string]uint64),
+			BufferBudget:            BufferBudgetMetrics{Denials: make(map[string]uint64)},
+			CostMetrics:             make(map[string]*CostMetrics),
+			Stores:                  make(map[string]store.Stats),
+			StartTime:               time.Now(),
+			alpha:                   DefaultAlpha,
 		},
+		stores: make(map[string]store.Reporter),
 	}
 
 	// Initialize object pools for zero-allocation copies
 	mc.metricsPool.New = func() interface{} {
 		return &Metrics{
-			BackendMetrics:        make(map[string]*BackendMetrics),
-			CircuitBreakerMetrics: make(map[string]*CircuitBreakerMetrics),
+			BackendMetrics:          make(map[string]*BackendMetrics),
+			CircuitBreakerMetrics:   make(map[string]*CircuitBreakerMetrics),
+			SyntheticMetrics:        make(map[string]*SyntheticMetrics),
+			PluginMetrics:           make(map[string]map[string]uint64),
+			RateLimitRuleRejections: make(map[string]uint64),
+			SecurityRejections:      make(map[string]uint64),
+			BufferBudget:            BufferBudgetMetrics{Denials: make(map[string]uint64)},
+			CostMetrics:             make(map[string]*CostMetrics),
+			Stores:                  make(map[string]store.Stats),
 		}
 	}
 
@@ -103,13 +214,21 @@ func NewMetricsCollector() *MetricsCollector {
 	return mc
 }
 
+// changed marks the metrics as modified so pollers' ETags go stale. It is
+// deferred by every mutator so it runs after the change is visible.
+func (mc *MetricsCollector) changed() {
+	mc.version.Add(1)
+}
+
 // RecordRequest records a new request
 func (mc *MetricsCollector) RecordRequest() {
+	defer mc.changed()
 	atomic.AddUint64(&mc.metrics.TotalRequests, 1)
 }
 
 // RecordResponse records a response with its status and duration
 func (mc *MetricsCollector) RecordResponse(success bool, responseTime time.Duration) {
+	defer mc.changed()
 	responseTimeMs := responseTime.Milliseconds()
 
 	if success {
@@ -125,6 +244,7 @@ func (mc *MetricsCollector) RecordResponse(success bool, responseTime time.Durat
 
 // RecordBackendRequest records a request to a specific backend
 func (mc *MetricsCollector) RecordBackendRequest(backendName string, success bool, responseTime time.Duration) {
+	defer mc.changed()
 	mc.metrics.mutex.Lock()
 
 	// Check if we're exceeding max backends limit
@@ -169,6 +289,7 @@ func boolToInt(b bool) int {
 
 // UpdateBackendHealth updates the health status of a backend
 func (mc *MetricsCollector) UpdateBackendHealth(backendName string, isHealthy bool) {
+	defer mc.changed()
 	mc.metrics.mutex.Lock()
 	defer mc.metrics.mutex.Unlock()
 
@@ -184,8 +305,22 @@ func (mc *MetricsCollector) UpdateBackendHealth(backendName string, isHealthy bo
 	backend.LastHealthCheck = time.Now()
 }
 
+// BackendHealth returns the health status recorded for a backend and whether
+// one has been recorded
+func (mc *MetricsCollector) BackendHealth(backendName string) (isHealthy, ok bool) {
+	mc.metrics.mutex.RLock()
+	defer mc.metrics.mutex.RUnlock()
+
+	backend, exists := mc.metrics.BackendMetrics[backendName]
+	if !exists {
+		return false, false
+	}
+	return backend.IsHealthy, true
+}
+
 // UpdateBackendConnections updates the active connections count for a backend
 func (mc *MetricsCollector) UpdateBackendConnections(backendName string, connections int32) {
+	defer mc.changed()
 	mc.metrics.mutex.Lock()
 	defer mc.metrics.mutex.Unlock()
 
@@ -200,9 +335,246 @@ func (mc *MetricsCollector) UpdateBackendConnections(backendName string, connect
 	backend.ActiveConnections = connections
 }
 
+// RecordBackendHeaderOverflow counts a response rejected for exceeding the header size limit
+func (mc *MetricsCollector) RecordBackendHeaderOverflow(backendName string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	backend, exists := mc.metrics.BackendMetrics[backendName]
+	if !exists {
+		if len(mc.metrics.BackendMetrics) >= MaxBackendMetrics {
+			return
+		}
+		backend = &BackendMetrics{
+			Name:  backendName,
+			alpha: DefaultAlpha,
+		}
+		mc.metrics.BackendMetrics[backendName] = backend
+	}
+
+	backend.HeaderOverflows++
+}
+
+// RecordBackendConnWait records how long a request waited for a pooled connection
+func (mc *MetricsCollector) RecordBackendConnWait(backendName string, wait time.Duration, exceededWarn bool) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	backend := mc.backendLocked(backendName)
+	if backend == nil {
+		return
+	}
+
+	waitMs := float64(wait) / float64(time.Millisecond)
+	if backend.connWaitCount == 0 {
+		backend.ConnWaitMs = waitMs
+	} else {
+		backend.ConnWaitMs = backend.alpha*waitMs + (1-backend.alpha)*backend.ConnWaitMs
+	}
+	backend.connWaitSamples[backend.connWaitCount%connWaitWindow] = waitMs
+	backend.connWaitCount++
+
+	if exceededWarn {
+		backend.ConnWaitWarnings++
+	}
+}
+
+// RecordBackendPoolExhausted counts an attempt abandoned because no pooled connection became available in time
+func (mc *MetricsCollector) RecordBackendPoolExhausted(backendName string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.PoolExhaustions++
+	}
+}
+
+// RecordBackendBodyStall counts a response aborted because the backend stopped sending its body
+func (mc *MetricsCollector) RecordBackendBodyStall(backendName string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.BodyStalls++
+	}
+}
+
+// RecordBackendConnectionsRecycled counts backend connections closed by connection_recycling
+func (mc *MetricsCollector) RecordBackendConnectionsRecycled(backendName string, n uint64) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.ConnectionsRecycled += n
+	}
+}
+
+// RecordBackendRedirectFollowed counts a backend redirect followed internally by the proxy
+func (mc *MetricsCollector) RecordBackendRedirectFollowed(backendName string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.RedirectsFollowed++
+	}
+}
+
+// RecordBackendRedirectRewritten counts a backend redirect whose Location was rewritten to the public host
+func (mc *MetricsCollector) RecordBackendRedirectRewritten(backendName string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.RedirectsRewritten++
+	}
+}
+
+// UpdateBackendHealthStreak records a backend's consecutive active health check results
+func (mc *MetricsCollector) UpdateBackendHealthStreak(backendName string, failures, successes int) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	if backend := mc.backendLocked(backendName); backend != nil {
+		backend.ConsecutiveFailures = failures
+		backend.ConsecutiveSuccesses = successes
+	}
+}
+
+// backendLocked returns the metrics entry for a backend, creating it if needed.
+// Returns nil when the backend cap is reached. Caller must hold the write lock.
+func (mc *MetricsCollector) backendLocked(backendName string) *BackendMetrics {
+	backend, exists := mc.metrics.BackendMetrics[backendName]
+	if !exists {
+		if len(mc.metrics.BackendMetrics) >= MaxBackendMetrics {
+			return nil
+		}
+		backend = &BackendMetrics{
+			Name:  backendName,
+			alpha: DefaultAlpha,
+		}
+		mc.metrics.BackendMetrics[backendName] = backend
+	}
+	if backend.alpha == 0 {
+		backend.alpha = DefaultAlpha
+	}
+	return backend
+}
+
+// connWaitP95 returns the 95th percentile of the recorded connection waits
+func (b *BackendMetrics) connWaitP95() float64 {
+	n := b.connWaitCount
+	if n > connWaitWindow {
+		n = connWaitWindow
+	}
+	if n == 0 {
+		return 0
+	}
+	samples := make([]float64, n)
+	copy(samples, b.connWaitSamples[:n])
+	sort.Float64s(samples)
+	idx := int(math.Ceil(0.95*float64(n))) - 1
+	return samples[idx]
+}
+
 // RecordRateLimitedRequest records a rate-limited request
 func (mc *MetricsCollector) RecordRateLimitedRequest() {
+	defer mc.changed()
+	atomic.AddUint64(&mc.metrics.RateLimitedRequests, 1)
+}
+
+// RecordHTTP10Request records a request made by an HTTP/1.0 client
+func (mc *MetricsCollector) RecordHTTP10Request() {
+	defer mc.changed()
+	atomic.AddUint64(&mc.metrics.HTTP10Requests, 1)
+}
+
+// SetPendingHeaderConnections sets the number of connections awaiting request headers
+func (mc *MetricsCollector) SetPendingHeaderConnections(n int) {
+	defer mc.changed()
+	atomic.StoreInt64(&mc.metrics.PendingHeaderConnections, int64(n))
+}
+
+// RecordSlowHeaderConnection records a connection closed before it finished sending headers
+func (mc *MetricsCollector) RecordSlowHeaderConnection() {
+	defer mc.changed()
+	atomic.AddUint64(&mc.metrics.SlowHeaderConnections, 1)
+}
+
+// RecordRefusedHeaderConnection records a connection refused because too many were awaiting headers
+func (mc *MetricsCollector) RecordRefusedHeaderConnection() {
+	defer mc.changed()
+	atomic.AddUint64(&mc.metrics.RefusedHeaderConnections, 1)
+}
+
+// RecordRateLimitRuleRejection records a request rejected by a named rate limit rule
+func (mc *MetricsCollector) RecordRateLimitRuleRejection(rule string) {
+	defer mc.changed()
 	atomic.AddUint64(&mc.metrics.RateLimitedRequests, 1)
+
+	mc.metrics.mutex.Lock()
+	mc.metrics.RateLimitRuleRejections[rule]++
+	mc.metrics.mutex.Unlock()
+}
+
+// RecordSecurityRejection records a request rejected by the request target
+// guard for the given reason
+func (mc *MetricsCollector) RecordSecurityRejection(reason string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	mc.metrics.SecurityRejections[reason]++
+	mc.metrics.mutex.Unlock()
+}
+
+// InitCostLabels lists every cost attribution label at zero so reports
+// include labels that have seen no traffic yet
+func (mc *MetricsCollector) InitCostLabels(labels []string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+	for _, label := range labels {
+		if _, ok := mc.metrics.CostMetrics[label]; !ok {
+			mc.metrics.CostMetrics[label] = &CostMetrics{}
+		}
+	}
+}
+
+// RecordCost adds a request's usage to its cost attribution label. Labels
+// come from the configured rules, which keeps their number bounded.
+func (mc *MetricsCollector) RecordCost(label string, bytesIn, bytesOut int64, backendLatency time.Duration, failed bool) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+	cm, ok := mc.metrics.CostMetrics[label]
+	if !ok {
+		cm = &CostMetrics{}
+		mc.metrics.CostMetrics[label] = cm
+	}
+	cm.Requests++
+	cm.BytesIn += uint64(bytesIn)
+	cm.BytesOut += uint64(bytesOut)
+	cm.BackendLatencyMs += float64(backendLatency) / float64(time.Millisecond)
+	if failed {
+		cm.Errors++
+	}
+}
+
+// GetCostMetrics returns a copy of the usage by cost attribution label
+func (mc *MetricsCollector) GetCostMetrics() map[string]CostMetrics {
+	mc.metrics.mutex.RLock()
+	defer mc.metrics.mutex.RUnlock()
+	costs := make(map[string]CostMetrics, len(mc.metrics.CostMetrics))
+	for label, cm := range mc.metrics.CostMetrics {
+		costs[label] = *cm
+	}
+	return costs
 }
 
 // CircuitBreakerCounts holds the count values for circuit breaker updates
@@ -214,6 +586,7 @@ type CircuitBreakerCounts struct {
 
 // UpdateCircuitBreakerState updates the state of a circuit breaker
 func (mc *MetricsCollector) UpdateCircuitBreakerState(name, state string, counts CircuitBreakerCounts) {
+	defer mc.changed()
 	mc.metrics.mutex.Lock()
 
 	// Prevent unbounded growth of circuit breaker metrics
@@ -239,6 +612,95 @@ func (mc *MetricsCollector) UpdateCircuitBreakerState(name, state string, counts
 	mc.metrics.mutex.Unlock()
 }
 
+// RecordSyntheticResult records the outcome of a synthetic check run
+func (mc *MetricsCollector) RecordSyntheticResult(name string, passed bool, latency time.Duration, reason string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	sm, exists := mc.metrics.SyntheticMetrics[name]
+	if !exists {
+		sm = &SyntheticMetrics{Name: name}
+		mc.metrics.SyntheticMetrics[name] = sm
+	}
+
+	latencyMs := float64(latency) / float64(time.Millisecond)
+	sm.Runs++
+	if passed {
+		sm.Passes++
+		sm.LastFailureReason = ""
+	} else {
+		sm.Failures++
+		sm.LastFailureReason = reason
+	}
+	sm.LastPassed = passed
+	sm.LastLatency = latencyMs
+	if sm.AverageLatency == 0 {
+		sm.AverageLatency = latencyMs
+	} else {
+		sm.AverageLatency = DefaultAlpha*latencyMs + (1-DefaultAlpha)*sm.AverageLatency
+	}
+	sm.LastRun = time.Now()
+}
+
+// SetActiveBackendGroup records the backend group currently taking traffic
+func (mc *MetricsCollector) SetActiveBackendGroup(name string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	mc.metrics.ActiveBackendGroup = name
+	mc.metrics.mutex.Unlock()
+}
+
+// SetBufferBudget sets the buffering budget whose usage is reported
+func (mc *MetricsCollector) SetBufferBudget(b *membudget.Budget) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	mc.bufferBudget = b
+	mc.metrics.mutex.Unlock()
+}
+
+// RegisterStore reports a store's size and counters under stores, replacing
+// any store registered under the same name
+func (mc *MetricsCollector) RegisterStore(name string, s store.Reporter) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	mc.stores[name] = s
+	mc.metrics.mutex.Unlock()
+}
+
+// UnregisterStore stops reporting the store registered under name, provided
+// it is still s; a store that replaced it keeps being reported
+func (mc *MetricsCollector) UnregisterStore(name string, s store.Reporter) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	if mc.stores[name] == s {
+		delete(mc.stores, name)
+	}
+	mc.metrics.mutex.Unlock()
+}
+
+// RecordBufferBudgetDenial records a buffering feature denied memory by the budget
+func (mc *MetricsCollector) RecordBufferBudgetDenial(feature string) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	mc.metrics.BufferBudget.Denials[feature]++
+	mc.metrics.mutex.Unlock()
+}
+
+// AddPluginCounter adds delta to a named counter reported by a plugin
+func (mc *MetricsCollector) AddPluginCounter(plugin, counter string, delta uint64) {
+	defer mc.changed()
+	mc.metrics.mutex.Lock()
+	defer mc.metrics.mutex.Unlock()
+
+	counters, exists := mc.metrics.PluginMetrics[plugin]
+	if !exists {
+		counters = make(map[string]uint64)
+		mc.metrics.PluginMetrics[plugin] = counters
+	}
+	counters[counter] += delta
+}
+
 // updateAverageResponseTime calculates the average response time
 func (mc *MetricsCollector) updateAverageResponseTime(newResponseTime float64) {
 	// Lock-free atomic update using CAS loop
@@ -280,12 +742,37 @@ func (mc *MetricsCollector) GetMetrics() *Metrics {
 	for k := range metricsCopy.CircuitBreakerMetrics {
 		delete(metricsCopy.CircuitBreakerMetrics, k)
 	}
+	for k := range metricsCopy.SyntheticMetrics {
+		delete(metricsCopy.SyntheticMetrics, k)
+	}
+	for k := range metricsCopy.PluginMetrics {
+		delete(metricsCopy.PluginMetrics, k)
+	}
+	for k := range metricsCopy.RateLimitRuleRejections {
+		delete(metricsCopy.RateLimitRuleRejections, k)
+	}
+	for k := range metricsCopy.SecurityRejections {
+		delete(metricsCopy.SecurityRejections, k)
+	}
+	for k := range metricsCopy.BufferBudget.Denials {
+		delete(metricsCopy.BufferBudget.Denials, k)
+	}
+	for k := range metricsCopy.CostMetrics {
+		delete(metricsCopy.CostMetrics, k)
+	}
+	for k := range metricsCopy.Stores {
+		delete(metricsCopy.Stores, k)
+	}
 
 	// Copy atomic counters (lock-free reads)
 	metricsCopy.TotalRequests = atomic.LoadUint64(&mc.metrics.TotalRequests)
 	metricsCopy.SuccessfulRequests = atomic.LoadUint64(&mc.metrics.SuccessfulRequests)
 	metricsCopy.FailedRequests = atomic.LoadUint64(&mc.metrics.FailedRequests)
 	metricsCopy.RateLimitedRequests = atomic.LoadUint64(&mc.metrics.RateLimitedRequests)
+	metricsCopy.HTTP10Requests = atomic.LoadUint64(&mc.metrics.HTTP10Requests)
+	metricsCopy.PendingHeaderConnections = atomic.LoadInt64(&mc.metrics.PendingHeaderConnections)
+	metricsCopy.SlowHeaderConnections = atomic.LoadUint64(&mc.metrics.SlowHeaderConnections)
+	metricsCopy.RefusedHeaderConnections = atomic.LoadUint64(&mc.metrics.RefusedHeaderConnections)
 
 	// Copy average response time atomically
 	avgBits := atomic.LoadUint64(&mc.metrics.avgResponseTimeBits)
@@ -306,6 +793,17 @@ func (mc *MetricsCollector) GetMetrics() *Metrics {
 		backendCopy.AverageResponseTime = backend.AverageResponseTime
 		backendCopy.IsHealthy = backend.IsHealthy
 		backendCopy.LastHealthCheck = backend.LastHealthCheck
+		backendCopy.HeaderOverflows = backend.HeaderOverflows
+		backendCopy.BodyStalls = backend.BodyStalls
+		backendCopy.RedirectsFollowed = backend.RedirectsFollowed
+		backendCopy.RedirectsRewritten = backend.RedirectsRewritten
+		backendCopy.ConnectionsRecycled = backend.ConnectionsRecycled
+		backendCopy.ConsecutiveFailures = backend.ConsecutiveFailures
+		backendCopy.ConsecutiveSuccesses = backend.ConsecutiveSuccesses
+		backendCopy.ConnWaitMs = backend.ConnWaitMs
+		backendCopy.ConnWaitP95Ms = backend.connWaitP95()
+		backendCopy.ConnWaitWarnings = backend.ConnWaitWarnings
+		backendCopy.PoolExhaustions = backend.PoolExhaustions
 		metricsCopy.BackendMetrics[name] = backendCopy
 	}
 
@@ -321,57 +819,121 @@ func (mc *MetricsCollector) GetMetrics() *Metrics {
 		}
 	}
 
+	// Copy synthetic check metrics
+	for name, sm := range mc.metrics.SyntheticMetrics {
+		smCopy := *sm
+		metricsCopy.SyntheticMetrics[name] = &smCopy
+	}
+
+	// Copy plugin counters
+	for plugin, counters := range mc.metrics.PluginMetrics {
+		countersCopy := make(map[string]uint64, len(counters))
+		for k, v := range counters {
+			countersCopy[k] = v
+		}
+		metricsCopy.PluginMetrics[plugin] = countersCopy
+	}
+	for rule, n := range mc.metrics.RateLimitRuleRejections {
+		metricsCopy.RateLimitRuleRejections[rule] = n
+	}
+	for reason, n := range mc.metrics.SecurityRejections {
+		metricsCopy.SecurityRejections[reason] = n
+	}
+
+	metricsCopy.ActiveBackendGroup = mc.metrics.ActiveBackendGroup
+
+	// Copy buffer budget usage
+	metricsCopy.BufferBudget.LimitBytes = mc.bufferBudget.Limit()
+	metricsCopy.BufferBudget.UsedBytes = mc.bufferBudget.Used()
+	metricsCopy.BufferBudget.PeakBytes = mc.bufferBudget.Peak()
+	for feature, n := range mc.metrics.BufferBudget.Denials {
+		metricsCopy.BufferBudget.Denials[feature] = n
+	}
+	for label, cm := range mc.metrics.CostMetrics {
+		costCopy := *cm
+		metricsCopy.CostMetrics[label] = &costCopy
+	}
+	for name, s := range mc.stores {
+		metricsCopy.Stores[name] = s.Stats()
+	}
+
 	mc.metrics.mutex.RUnlock()
 
 	return metricsCopy
 }
 
+// pollerResponse serves the metrics and health endpoints with ETags and
+// ?fields= selection
+var pollerResponse = utils.JSONResponse{Indent: "  "}
+
+// snapshotVersion identifies the current metrics for ETags without copying
+// them. Besides the change counter it covers the start time, so a restarted
+// process doesn't reuse versions, and the buffer budget and stores, whose
+// figures change without going through the collector. Uptime advances on
+// every call, so it isn't covered.
+func (mc *MetricsCollector) snapshotVersion() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	put := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		_, _ = h.Write(buf[:])
+	}
+	put(uint64(mc.metrics.StartTime.UnixNano()))
+	put(mc.version.Load())
+
+	mc.metrics.mutex.RLock()
+	defer mc.metrics.mutex.RUnlock()
+	put(uint64(mc.bufferBudget.Limit()))
+	put(uint64(mc.bufferBudget.Used()))
+	put(uint64(mc.bufferBudget.Peak()))
+	names := make([]string, 0, len(mc.stores))
+	for name := range mc.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		st := mc.stores[name].Stats()
+		_, _ = h.Write([]byte(name))
+		put(uint64(st.Size))
+		put(st.Hits)
+		put(st.Misses)
+		put(st.Evictions)
+		put(st.Expirations)
+	}
+	return h.Sum64()
+}
+
 // MetricsHandler returns an HTTP handler for the metrics endpoint
 func (mc *MetricsCollector) MetricsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		metrics := mc.GetMetrics()
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		encoder := json.NewEncoder(w)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(metrics); err != nil {
-			http.Error(w, "Failed to encode metrics", http.StatusInternalServerError)
-			return
-		}
+		pollerResponse.ServeVersioned(w, r, mc.snapshotVersion(), reflect.TypeOf(&Metrics{}), func() interface{} {
+			return mc.GetMetrics()
+		})
 	}
 }
 
 // HealthHandler returns an HTTP handler for the health endpoint
 func (mc *MetricsCollector) HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		metrics := mc.GetMetrics()
-
-		health := map[string]interface{}{
-			"status":         "healthy",
-			"uptime":         metrics.Uptime,
-			"total_requests": metrics.TotalRequests,
-			"backends":       make(map[string]interface{}),
-		}
-
-		// Add backend health status
-		for name, backend := range metrics.BackendMetrics {
-			health["backends"].(map[string]interface{})[name] = map[string]interface{}{
-				"healthy":            backend.IsHealthy,
-				"active_connections": backend.ActiveConnections,
-				"last_health_check":  backend.LastHealthCheck,
+		pollerResponse.ServeVersioned(w, r, mc.snapshotVersion(), reflect.TypeOf(map[string]interface{}{}), func() interface{} {
+			metrics := mc.GetMetrics()
+
+			health := map[string]interface{}{
+				"status":         "healthy",
+				"uptime":         metrics.Uptime,
+				"total_requests": metrics.TotalRequests,
+				"backends":       make(map[string]interface{}),
 			}
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
 
-		encoder := json.NewEncoder(w)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(health); err != nil {
-			http.Error(w, "Failed to encode health status", http.StatusInternalServerError)
-			return
-		}
+			// Add backend health status
+			for name, backend := range metrics.BackendMetrics {
+				health["backends"].(map[string]interface{})[name] = map[string]interface{}{
+					"healthy":            backend.IsHealthy,
+					"active_connections": backend.ActiveConnections,
+					"last_health_check":  backend.LastHealthCheck,
+				}
+			}
+			return health
+		})
 	}
 }
@@ -3,8 +3,11 @@ package metrics
 import (
 	"encoding/json"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/0xReLogic/Helios/internal/store"
 )
 
 func TestMetricsCollector(t *testing.T) {
@@ -242,6 +245,50 @@ func TestRateLimitMetrics(t *testing.T) {
 	}
 }
 
+func TestPluginCounters(t *testing.T) {
+	mc := NewMetricsCollector()
+
+	mc.AddPluginCounter("idempotency", "replays", 1)
+	mc.AddPluginCounter("idempotency", "replays", 2)
+	mc.AddPluginCounter("idempotency", "evictions", 1)
+
+	metrics := mc.GetMetrics()
+	if got := metrics.PluginMetrics["idempotency"]["replays"]; got != 3 {
+		t.Errorf("Expected 3 replays, got %d", got)
+	}
+	if got := metrics.PluginMetrics["idempotency"]["evictions"]; got != 1 {
+		t.Errorf("Expected 1 eviction, got %d", got)
+	}
+
+	// The snapshot must not alias the live counters
+	metrics.PluginMetrics["idempotency"]["replays"] = 100
+	if got := mc.GetMetrics().PluginMetrics["idempotency"]["replays"]; got != 3 {
+		t.Errorf("Expected snapshot to be a copy, got %d", got)
+	}
+}
+
+func TestStoreMetrics(t *testing.T) {
+	mc := NewMetricsCollector()
+	s := store.NewMemory(store.Options{})
+	defer s.Close()
+	mc.RegisterStore("rate_limit:default", s)
+
+	s.Set("10.0.0.1", 1, 0)
+	s.Get("10.0.0.1")
+	s.Get("10.0.0.2")
+
+	got := mc.GetMetrics().Stores["rate_limit:default"]
+	if got.Size != 1 || got.Hits != 1 || got.Misses != 1 {
+		t.Errorf("Expected size 1, 1 hit and 1 miss, got %+v", got)
+	}
+
+	// Snapshots are read when the metrics are
+	s.Set("10.0.0.2", 2, 0)
+	if got := mc.GetMetrics().Stores["rate_limit:default"].Size; got != 2 {
+		t.Errorf("Expected the size to follow the store, got %d", got)
+	}
+}
+
 func TestMetricsHandler(t *testing.T) {
 	mc := NewMetricsCollector()
 
@@ -281,6 +328,96 @@ func TestMetricsHandler(t *testing.T) {
 	}
 }
 
+func TestMetricsHandler_ConditionalGET(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.RecordBackendRequest("server1", true, 10*time.Millisecond)
+	handler := mc.MetricsHandler()
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != 200 || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expected 200 with a weak ETag, got %d %q", first.Code, etag)
+	}
+
+	// Nothing changed but uptime
+	time.Sleep(2 * time.Millisecond)
+	second := get(etag)
+	if second.Code != 304 || second.Body.Len() != 0 {
+		t.Fatalf("expected 304 with no body, got %d with %d bytes", second.Code, second.Body.Len())
+	}
+	if second.Header().Get("ETag") != etag {
+		t.Errorf("expected the 304 to repeat the ETag")
+	}
+
+	// Traffic in between
+	mc.RecordRequest()
+	third := get(etag)
+	if third.Code != 200 {
+		t.Fatalf("expected 200 after traffic, got %d", third.Code)
+	}
+	if newTag := third.Header().Get("ETag"); newTag == "" || newTag == etag {
+		t.Errorf("expected a new ETag after traffic, got %q", newTag)
+	}
+	var metrics Metrics
+	if err := json.Unmarshal(third.Body.Bytes(), &metrics); err != nil || metrics.TotalRequests != 1 {
+		t.Errorf("expected the full metrics after traffic, got %v (%v)", metrics.TotalRequests, err)
+	}
+
+	// Stores change without going through the collector
+	s := store.NewMemory(store.Options{})
+	defer s.Close()
+	mc.RegisterStore("test", s)
+	etag = get("").Header().Get("ETag")
+	s.Set("k", 1, 0)
+	if rec := get(etag); rec.Code != 200 {
+		t.Errorf("expected 200 after a store changed, got %d", rec.Code)
+	}
+}
+
+func TestMetricsHandler_Fields(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.RecordRequest()
+	mc.RecordBackendRequest("server1", true, 10*time.Millisecond)
+	mc.UpdateBackendHealth("server1", true)
+	handler := mc.MetricsHandler()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics?fields=total_requests,backend_metrics.is_healthy,backend_metrics.total_requests", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got) != 2 || got["total_requests"] != float64(1) {
+		t.Errorf("expected only total_requests and backend_metrics, got %v", got)
+	}
+	backends, _ := got["backend_metrics"].(map[string]interface{})
+	server1, _ := backends["server1"].(map[string]interface{})
+	if len(server1) != 2 || server1["is_healthy"] != true || server1["total_requests"] != float64(1) {
+		t.Errorf("expected is_healthy and total_requests of server1, got %v", backends)
+	}
+
+	for _, fields := range []string{"total_requestz", "backend_metrics.bogus", "total_requests.count"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/metrics?fields="+fields, nil))
+		if w.Code != 400 {
+			t.Errorf("fields=%s: expected 400, got %d", fields, w.Code)
+		}
+	}
+}
+
 func TestHealthHandler(t *testing.T) {
 	mc := NewMetricsCollector()
 
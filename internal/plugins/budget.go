@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"net/http"
+	"sync"
+
+	logging "github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/membudget"
+)
+
+var (
+	budgetMu     sync.RWMutex
+	bufferBudget *membudget.Budget
+)
+
+// SetBufferBudget sets the memory budget shared by buffering plugins.
+// With no budget set, buffering is bounded only by each plugin's own cap.
+func SetBufferBudget(b *membudget.Budget) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	bufferBudget = b
+}
+
+// reserveBuffer starts a reservation against the shared buffering budget
+func reserveBuffer() *membudget.Reservation {
+	budgetMu.RLock()
+	b := bufferBudget
+	budgetMu.RUnlock()
+	return b.Reserve()
+}
+
+// growBuffer acquires n more bytes for a buffering feature. On denial it
+// logs and counts it; the caller then stops buffering.
+func growBuffer(r *http.Request, res *membudget.Reservation, feature string, n int) bool {
+	if res.Grow(n) {
+		return true
+	}
+	logging.WithContext(r.Context()).Debug().Str("feature", feature).Int("bytes", n).Msg("buffer budget exhausted, not buffering")
+
+	metricsMu.RLock()
+	mc := metricsCollector
+	metricsMu.RUnlock()
+	if mc != nil {
+		mc.RecordBufferBudgetDenial(feature)
+	}
+	return false
+}
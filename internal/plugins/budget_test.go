@@ -0,0 +1,173 @@
+package plugins
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/membudget"
+	"github.com/0xReLogic/Helios/internal/metrics"
+)
+
+// useBufferBudget installs a budget and metrics collector for the test
+func useBufferBudget(t *testing.T, limit int64) (*membudget.Budget, *metrics.MetricsCollector) {
+	t.Helper()
+	budget := membudget.New(limit)
+	mc := metrics.NewMetricsCollector()
+	mc.SetBufferBudget(budget)
+	SetBufferBudget(budget)
+	SetMetricsCollector(mc)
+	t.Cleanup(func() {
+		SetBufferBudget(nil)
+		SetMetricsCollector(nil)
+	})
+	return budget, mc
+}
+
+func TestBufferBudgetGzipFallsBackToStreaming(t *testing.T) {
+	const limit = 48 << 10
+	budget, mc := useBufferBudget(t, limit)
+
+	var sb strings.Builder
+	for sb.Len() < 32<<10 {
+		sb.WriteString(`{"id": ` + strconv.Itoa(sb.Len()) + `, "status": "ok"}`)
+	}
+	body := sb.String()
+
+	// Write in chunks so concurrent responses hold the budget at the same time
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		for i := 0; i < len(body); i += 4 << 10 {
+			end := i + 4<<10
+			if end > len(body) {
+				end = len(body)
+			}
+			_, _ = io.WriteString(w, body[i:end])
+			time.Sleep(time.Millisecond)
+		}
+	})
+	chain := newGzipMiddleware(t, 6, 0, []string{ContentTypeJSON})(handler)
+
+	// Watch usage while the requests run
+	var exceeded atomic.Int64
+	stop := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if used := budget.Used(); used > limit {
+				exceeded.Store(used)
+			}
+		}
+	}()
+
+	const requests = 40
+	var compressed, uncompressed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, TestPath, nil)
+			req.Header.Set(AcceptEncodingHeader, "gzip")
+			rec := httptest.NewRecorder()
+			chain.ServeHTTP(rec, req)
+
+			got := rec.Body.String()
+			if rec.Header().Get(ContentEncodingHeader) == "gzip" {
+				compressed.Add(1)
+				got = decompressBody(t, rec.Body.Bytes())
+			} else {
+				uncompressed.Add(1)
+			}
+			if got != body {
+				t.Errorf("response body mismatch: got %d bytes, want %d", len(got), len(body))
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-watched
+
+	if used := exceeded.Load(); used != 0 {
+		t.Errorf("tracked usage %d exceeded the budget of %d", used, limit)
+	}
+	if budget.Peak() > limit {
+		t.Errorf("peak usage %d exceeded the budget of %d", budget.Peak(), limit)
+	}
+	if budget.Used() != 0 {
+		t.Errorf("expected all buffer memory released, %d bytes still held", budget.Used())
+	}
+	if compressed.Load() == 0 || uncompressed.Load() == 0 {
+		t.Errorf("expected a mix of compressed and uncompressed responses, got %d/%d", compressed.Load(), uncompressed.Load())
+	}
+
+	m := mc.GetMetrics()
+	if m.BufferBudget.Denials["gzip"] == 0 {
+		t.Error("expected gzip budget denials to be counted")
+	}
+	if m.BufferBudget.LimitBytes != limit || m.BufferBudget.PeakBytes == 0 || m.BufferBudget.UsedBytes != 0 {
+		t.Errorf("unexpected buffer budget metrics: %+v", m.BufferBudget)
+	}
+}
+
+func TestBufferBudgetEtagAndIdempotencyFallBack(t *testing.T) {
+	_, mc := useBufferBudget(t, 1)
+
+	body := strings.Repeat("a", 1024)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = io.WriteString(w, body)
+	})
+
+	etagMW, err := builtins["etag"]("etag", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("failed to create etag plugin: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	etagMW(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app.css", nil))
+	if rec.Body.String() != body {
+		t.Fatalf("etag: unexpected body of %d bytes", rec.Body.Len())
+	}
+	if rec.Header().Get("ETag") != "" {
+		t.Error("etag: expected no ETag when the budget denies buffering")
+	}
+
+	idemMW, err := builtins["idempotency"]("idempotency", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("failed to create idempotency plugin: %v", err)
+	}
+	var calls atomic.Int64
+	counted := idemMW(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set(DefaultIdempotencyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		counted.ServeHTTP(rec, req)
+		if rec.Body.String() != body {
+			t.Fatalf("idempotency: unexpected body of %d bytes", rec.Body.Len())
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("idempotency: expected both requests forwarded when the response can't be stored, got %d", calls.Load())
+	}
+
+	denials := mc.GetMetrics().BufferBudget.Denials
+	if denials["etag"] != 1 || denials["idempotency"] != 2 {
+		t.Errorf("unexpected budget denials: %v", denials)
+	}
+}
@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+var (
+	proxiesMu      sync.RWMutex
+	trustedProxies utils.TrustedProxies
+)
+
+// SetTrustedProxies sets the proxies whose forwarding headers plugins believe
+// when they need a client address that can't be forged. With none set only
+// the peer address is trusted.
+func SetTrustedProxies(tp utils.TrustedProxies) {
+	proxiesMu.Lock()
+	defer proxiesMu.Unlock()
+	trustedProxies = tp
+}
+
+// scopedClientIP identifies the client of r for per-client state. Through
+// trusted proxies it is the resolved client. With none, it is the peer
+// address plus the forwarded address when one is sent: a forged header can't
+// reach another peer's state, and clients behind an untrusted load balancer
+// still don't share one scope.
+func scopedClientIP(r *http.Request) string {
+	proxiesMu.RLock()
+	tp := trustedProxies
+	proxiesMu.RUnlock()
+
+	peer := tp.ClientIP(r)
+	if !tp.IsEmpty() {
+		return peer
+	}
+	if forwarded := utils.GetClientIP(r); forwarded != peer {
+		return peer + "/" + forwarded
+	}
+	return peer
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	logging "github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/membudget"
 )
 
 const (
@@ -21,6 +22,7 @@ const (
 
 type gzipResponseWriter struct {
 	http.ResponseWriter
+	r            *http.Request
 	statusCode   int
 	wroteHeader  bool
 	minSize      int
@@ -28,9 +30,14 @@ type gzipResponseWriter struct {
 	contentTypes []string
 
 	buf            bytes.Buffer
-	bufferExceeded bool // Track if we exceeded max buffer size
+	bufferExceeded bool                   // Streaming uncompressed: over the buffer cap or budget, or flushed
+	budget         *membudget.Reservation // Share of limits.max_buffer_bytes_total held by buf
+	headerSent     bool                   // Status line forwarded to the underlying writer
+	http10         bool                   // Client can't parse chunked encoding; send Content-Length
 }
 
+// WriteHeader records the status code; it is forwarded once the encoding
+// is decided so Content-Encoding, ETag and Vary can still be adjusted
 func (g *gzipResponseWriter) WriteHeader(code int) {
 	if g.wroteHeader {
 		return
@@ -38,28 +45,51 @@ func (g *gzipResponseWriter) WriteHeader(code int) {
 
 	g.statusCode = code
 	g.wroteHeader = true
-	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) sendHeader() {
+	if g.headerSent {
+		return
+	}
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	g.headerSent = true
+	g.ResponseWriter.WriteHeader(g.statusCode)
 }
 
 func (g *gzipResponseWriter) Write(b []byte) (int, error) {
-	// Check if adding this data would exceed max buffer size
-	if g.buf.Len()+len(b) > MaxCompressionBufferSize {
-		// Mark as exceeded and fall back to streaming uncompressed
-		if !g.bufferExceeded {
-			g.bufferExceeded = true
-			// Flush existing buffer uncompressed
-			if g.buf.Len() > 0 {
-				_, _ = g.ResponseWriter.Write(g.buf.Bytes())
-				g.buf.Reset()
-			}
+	if !g.bufferExceeded {
+		// Buffer while under the per-response cap and the shared budget allows it
+		if g.buf.Len()+len(b) <= MaxCompressionBufferSize && growBuffer(g.r, g.budget, "gzip", len(b)) {
+			return g.buf.Write(b)
 		}
-		// Stream directly without compression
-		return g.ResponseWriter.Write(b)
+		// Fall back to streaming uncompressed
+		g.passThrough()
+	}
+	// Stream directly without compression
+	return g.ResponseWriter.Write(b)
+}
+
+// passThrough gives up on compression: the headers are sent unchanged and
+// whatever was buffered is written out as is
+func (g *gzipResponseWriter) passThrough() {
+	g.bufferExceeded = true
+	g.sendHeader()
+	if g.buf.Len() > 0 {
+		_, _ = g.ResponseWriter.Write(g.buf.Bytes())
+		g.buf.Reset()
 	}
-	return g.buf.Write(b)
+	g.budget.Release()
 }
 
+// Flush commits to passing the body through uncompressed: a flushed
+// response is being streamed, and the headers sent now can't announce gzip
+// for bytes that haven't been seen yet
 func (g *gzipResponseWriter) Flush() {
+	if !g.bufferExceeded {
+		g.passThrough()
+	}
 	if f, ok := g.ResponseWriter.(http.Flusher); ok {
 		f.Flush()
 	}
@@ -84,11 +114,23 @@ func (g *gzipResponseWriter) Finish() error {
 
 	body := g.buf.Bytes()
 
+	// Bodiless responses are never compressed, but a 304 must carry the
+	// same validator the compressed 200 would have
+	if g.statusCode == http.StatusNotModified {
+		weakenETag(g.Header())
+	}
+	if len(body) == 0 || g.statusCode == http.StatusNotModified || g.statusCode == http.StatusNoContent {
+		g.sendHeader()
+		_, err := g.ResponseWriter.Write(body)
+		return err
+	}
+
 	clHeader := g.Header().Get("Content-Length")
 	if clHeader != "" {
 		cl, err := strconv.Atoi(clHeader)
 		// if Content-Length header found and is less than the minSize then return the body as is.
 		if err == nil && cl < g.minSize {
+			g.sendHeader()
 			_, err := g.ResponseWriter.Write(body)
 			return err
 		}
@@ -96,6 +138,7 @@ func (g *gzipResponseWriter) Finish() error {
 
 	// acts as a fallback when Content-Length is not available.
 	if len(body) < g.minSize {
+		g.sendHeader()
 		_, err := g.ResponseWriter.Write(body)
 		return err
 	}
@@ -103,6 +146,7 @@ func (g *gzipResponseWriter) Finish() error {
 	// return body as is when Content-Type doesn't match specified in Config
 	ct := g.Header().Get("Content-Type")
 	if !matchesContentType(ct, g.contentTypes) {
+		g.sendHeader()
 		_, err := g.ResponseWriter.Write(body)
 		return err
 	}
@@ -110,6 +154,15 @@ func (g *gzipResponseWriter) Finish() error {
 	g.Header().Set("Content-Encoding", "gzip")
 	// Remove Content-Length since compressed size differs from original
 	g.Header().Del("Content-Length")
+	// The compressed bytes differ from the representation a strong ETag
+	// was computed on, so downgrade it and vary on the negotiated encoding
+	weakenETag(g.Header())
+	addVary(g.Header(), "Accept-Encoding")
+
+	if g.http10 {
+		return g.finishHTTP10(body)
+	}
+	g.sendHeader()
 
 	gz, err := gzip.NewWriterLevel(g.ResponseWriter, g.level)
 	if err != nil {
@@ -130,6 +183,46 @@ func (g *gzipResponseWriter) Finish() error {
 	return gz.Close()
 }
 
+// finishHTTP10 compresses body up front so the response carries a
+// Content-Length; HTTP/1.0 clients can't parse chunked encoding
+func (g *gzipResponseWriter) finishHTTP10(body []byte) error {
+	var compressed bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&compressed, g.level)
+	if err != nil {
+		return err
+	}
+	if _, err := gz.Write(body); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	g.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+	g.sendHeader()
+	_, err = g.ResponseWriter.Write(compressed.Bytes())
+	return err
+}
+
+// weakenETag converts a strong ETag into a weak one
+func weakenETag(h http.Header) {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+}
+
+// addVary appends a field to the Vary header unless it is already listed
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range splitAndTrim(v, ",") {
+			if strings.EqualFold(f, field) || f == "*" {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
 // matchesContentType checks if content type matches any allowed prefix
 // OPTIMIZED: Use strings.HasPrefix instead of manual slicing
 // - Safer (no bounds checking needed)
@@ -145,37 +238,37 @@ func matchesContentType(ct string, allowed []string) bool {
 }
 
 func parseGzipConfig(cfg map[string]interface{}) (int, int, []string, error) {
-	// numbers are unmarshalled into float64 by default
-	levelFloat, ok := cfg["level"].(float64)
-	if !ok {
-		return 0, 0, nil, fmt.Errorf("expected level for gzip config")
+	if err := rejectUnknownKeys(cfg, "level", "min_size", "content_types"); err != nil {
+		return 0, 0, nil, err
+	}
+	for _, key := range []string{"level", "min_size", "content_types"} {
+		if _, ok := cfg[key]; !ok {
+			return 0, 0, nil, fmt.Errorf("%s is required", key)
+		}
+	}
+
+	level, err := parseInt("level", cfg["level"])
+	if err != nil {
+		return 0, 0, nil, err
 	}
-	level := int(levelFloat)
 	// Allow -1 (DefaultCompression), 0 (NoCompression), or 1-9
 	if level < -1 || level > 9 {
 		return 0, 0, nil, fmt.Errorf("compression level must be between -1 and 9, got %d", level)
 	}
 
-	minSizeFloat, ok := cfg["min_size"].(float64)
-	if !ok {
-		return 0, 0, nil, fmt.Errorf("expected min_size for gzip config")
+	minSize, err := parseInt("min_size", cfg["min_size"])
+	if err != nil {
+		return 0, 0, nil, err
 	}
-	minSize := int(minSizeFloat)
-
-	rawTypes, ok := cfg["content_types"].([]interface{})
-	if !ok {
-		return 0, 0, nil, fmt.Errorf("expected content_types to be a list of strings")
+	if minSize < 0 {
+		return 0, 0, nil, fmt.Errorf("min_size must be non-negative, got %d", minSize)
 	}
 
-	contentTypes := make([]string, 0, len(rawTypes))
-	for _, v := range rawTypes {
-		s, ok := v.(string)
-		if !ok {
-			return 0, 0, nil, fmt.Errorf("all content_types must be string")
-		}
-		contentTypes = append(contentTypes, s)
+	contentTypes, err := parseStringList(cfg, "content_types", nil)
+	if err != nil {
+		return 0, 0, nil, err
 	}
-	return level, minSize, contentTypes, nil
+	return int(level), int(minSize), contentTypes, nil
 }
 
 // Config example :
@@ -193,6 +286,9 @@ func parseGzipConfig(cfg map[string]interface{}) (int, int, []string, error) {
 //	        - "application/json"
 //	        - "application/javascript"
 func init() {
+	// Plugins that inspect or limit response bodies must sit inside gzip so
+	// they see the uncompressed bytes
+	RegisterOrdering("gzip", Ordering{MustRunBefore: []string{"etag", "size_limit", "mime_guard"}})
 	RegisterBuiltin("gzip", func(name string, cfg map[string]interface{}) (Middleware, error) {
 		level, minSize, contentTypes, err := parseGzipConfig(cfg)
 		if err != nil {
@@ -207,11 +303,15 @@ func init() {
 
 				grw := &gzipResponseWriter{
 					ResponseWriter: w,
+					r:              r,
+					budget:         reserveBuffer(),
 					level:          level,
 					minSize:        minSize,
 					contentTypes:   contentTypes,
+					http10:         !r.ProtoAtLeast(1, 1),
 				}
 
+				defer grw.budget.Release()
 				next.ServeHTTP(grw, r)
 
 				err := grw.Finish()
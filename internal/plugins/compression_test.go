@@ -1,12 +1,17 @@
 package plugins
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -332,3 +337,85 @@ func convertStringsToInterfaces(s []string) []interface{} {
 	}
 	return interfaces
 }
+
+func TestGzipHTTP10ContentLength(t *testing.T) {
+	// Large and varied enough that the compressed body exceeds net/http's
+	// own buffering, so an unsized HTTP/1.1 response would be chunked
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		sb.WriteString(strconv.Itoa(i * 7919))
+	}
+	body := sb.String()
+
+	mw := newGzipMiddleware(t, 6, 0, []string{"text/plain"})
+	server := httptest.NewServer(mw(newMockHandler(t, "text/plain", body)))
+	defer server.Close()
+
+	tests := []struct {
+		proto       string
+		wantChunked bool
+	}{
+		{"HTTP/1.0", false},
+		{"HTTP/1.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto, func(t *testing.T) {
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			req := "GET / " + tt.proto + "\r\nHost: example.com\r\nAccept-Encoding: gzip\r\n\r\n"
+			if _, err := io.WriteString(conn, req); err != nil {
+				t.Fatalf("write request: %v", err)
+			}
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("read response: %v", err)
+			}
+			compressed, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+
+			assertContentEncoding(t, resp.Header.Get(ContentEncodingHeader), true)
+			chunked := len(resp.TransferEncoding) > 0 && resp.TransferEncoding[0] == "chunked"
+			if chunked != tt.wantChunked {
+				t.Errorf("chunked = %v, want %v", chunked, tt.wantChunked)
+			}
+			if !tt.wantChunked && resp.ContentLength != int64(len(compressed)) {
+				t.Errorf("expected Content-Length %d, got %d", len(compressed), resp.ContentLength)
+			}
+			if got := decompressBody(t, compressed); got != body {
+				t.Errorf("decompressed body mismatch (%d bytes, want %d)", len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestGzipFlushBeforeWritePassesThrough(t *testing.T) {
+	body := strings.Repeat("streamed text ", 200)
+	mw := newGzipMiddleware(t, 6, 0, []string{"text/plain"})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		_, _ = io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, TestPath, nil)
+	req.Header.Set(AcceptEncodingHeader, "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	// The headers went out on the flush, so the body must match them
+	assertContentEncoding(t, rec.Header().Get(ContentEncodingHeader), false)
+	if !rec.Flushed {
+		t.Error("expected the flush to reach the client")
+	}
+	if got := rec.Body.String(); got != body {
+		t.Errorf("expected the body uncompressed, got %d bytes starting %q", len(got), got[:8])
+	}
+}
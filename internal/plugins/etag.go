@@ -0,0 +1,288 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	logging "github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/membudget"
+)
+
+const (
+	// DefaultETagMaxSize is the largest body the etag plugin buffers to hash (1MB)
+	DefaultETagMaxSize = 1 * 1024 * 1024
+)
+
+// defaultETagContentTypes covers typical static assets
+var defaultETagContentTypes = []string{
+	"text/css",
+	"text/javascript",
+	"application/javascript",
+	"application/wasm",
+	"image/",
+	"font/",
+}
+
+type etagMode int
+
+const (
+	etagUndecided etagMode = iota
+	etagPassthrough
+	etagBuffer
+	etagNotModified
+)
+
+// etagResponseWriter decides per response whether to pass it through, answer
+// 304 from existing validators, or buffer the body to compute an ETag
+type etagResponseWriter struct {
+	http.ResponseWriter
+	r            *http.Request
+	maxSize      int64
+	contentTypes []string
+
+	mode       etagMode
+	statusCode int
+	buf        bytes.Buffer
+	budget     *membudget.Reservation // Share of limits.max_buffer_bytes_total held by buf
+}
+
+func (e *etagResponseWriter) WriteHeader(code int) {
+	if e.mode != etagUndecided {
+		return
+	}
+	e.statusCode = code
+	e.decide()
+}
+
+// decide picks the handling mode once the status and headers are known
+func (e *etagResponseWriter) decide() {
+	h := e.Header()
+
+	if e.statusCode != http.StatusOK || h.Get("Content-Encoding") != "" {
+		e.passthrough()
+		return
+	}
+
+	etag, lastModified := h.Get("ETag"), h.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		// Backend already supplies validators: only evaluate the conditional
+		if notModified(e.r, etag, lastModified) {
+			e.writeNotModified()
+			return
+		}
+		e.passthrough()
+		return
+	}
+
+	// A HEAD response has no body to hash
+	if e.r.Method == http.MethodHead || !matchesContentType(h.Get("Content-Type"), e.contentTypes) {
+		e.passthrough()
+		return
+	}
+	if cl, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil && cl > e.maxSize {
+		e.passthrough()
+		return
+	}
+	e.mode = etagBuffer
+}
+
+func (e *etagResponseWriter) passthrough() {
+	e.mode = etagPassthrough
+	e.ResponseWriter.WriteHeader(e.statusCode)
+}
+
+func (e *etagResponseWriter) writeNotModified() {
+	e.mode = etagNotModified
+	h := e.Header()
+	// Keep ETag, Cache-Control and Vary; drop headers describing the suppressed body
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Transfer-Encoding")
+	e.ResponseWriter.WriteHeader(http.StatusNotModified)
+}
+
+func (e *etagResponseWriter) Write(b []byte) (int, error) {
+	if e.mode == etagUndecided {
+		e.WriteHeader(http.StatusOK)
+	}
+
+	switch e.mode {
+	case etagNotModified:
+		return len(b), nil
+	case etagBuffer:
+		if int64(e.buf.Len()+len(b)) <= e.maxSize && growBuffer(e.r, e.budget, "etag", len(b)) {
+			return e.buf.Write(b)
+		}
+		// Over the cap or the shared budget: flush what we have and stream the rest untouched
+		if err := e.flushBuffered(); err != nil {
+			return 0, err
+		}
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// flushBuffered switches a buffering writer to passthrough
+func (e *etagResponseWriter) flushBuffered() error {
+	e.passthrough()
+	if e.buf.Len() == 0 {
+		return nil
+	}
+	_, err := e.ResponseWriter.Write(e.buf.Bytes())
+	e.buf.Reset()
+	e.budget.Release()
+	return err
+}
+
+func (e *etagResponseWriter) Flush() {
+	// An explicit flush means the handler is streaming; stop buffering
+	if e.mode == etagBuffer {
+		if err := e.flushBuffered(); err != nil {
+			return
+		}
+	}
+	if f, ok := e.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (e *etagResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := e.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+}
+
+// Finish computes the ETag for a buffered body and writes the response
+func (e *etagResponseWriter) Finish() error {
+	if e.mode == etagUndecided {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.mode != etagBuffer {
+		return nil
+	}
+
+	body := e.buf.Bytes()
+	etag := computeETag(body)
+	e.Header().Set("ETag", etag)
+
+	if notModified(e.r, etag, "") {
+		e.writeNotModified()
+		return nil
+	}
+
+	if e.Header().Get("Content-Length") == "" {
+		e.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	e.mode = etagPassthrough
+	e.ResponseWriter.WriteHeader(e.statusCode)
+	_, err := e.ResponseWriter.Write(body)
+	return err
+}
+
+// computeETag returns a strong ETag derived from the FNV-1a hash of body
+func computeETag(body []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(body)
+	return fmt.Sprintf(`"%016x"`, h.Sum64())
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// only when no If-None-Match is present (RFC 9110 section 13.2.2)
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagListMatches(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// etagListMatches applies the weak comparison required for If-None-Match
+func etagListMatches(list, etag string) bool {
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range splitAndTrim(list, ",") {
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
+
+func parseETagConfig(cfg map[string]interface{}) (int64, []string, error) {
+	if err := rejectUnknownKeys(cfg, "max_size", "content_types"); err != nil {
+		return 0, nil, err
+	}
+	maxSize, err := parseByteLimit(cfg, "max_size", DefaultETagMaxSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	contentTypes, err := parseStringList(cfg, "content_types", defaultETagContentTypes)
+	if err != nil {
+		return 0, nil, err
+	}
+	return maxSize, contentTypes, nil
+}
+
+// Config example :
+// plugins:
+//
+//	enabled: true
+//	chain:
+//	  - name: gzip   # list gzip before etag so the ETag covers the uncompressed body
+//	    config: {...}
+//	  - name: etag
+//	    config:
+//	      max_size: 1048576  # Only hash bodies up to 1MB
+//	      content_types:
+//	        - "text/css"
+//	        - "application/javascript"
+//	        - "image/"
+func init() {
+	RegisterBuiltin("etag", func(name string, cfg map[string]interface{}) (Middleware, error) {
+		maxSize, contentTypes, err := parseETagConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Only plain GET/HEAD; Range requests are left to the backend
+				if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Range") != "" {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				erw := &etagResponseWriter{
+					ResponseWriter: w,
+					r:              r,
+					maxSize:        maxSize,
+					contentTypes:   contentTypes,
+					budget:         reserveBuffer(),
+				}
+
+				defer erw.budget.Release()
+				next.ServeHTTP(erw, r)
+
+				if err := erw.Finish(); err != nil {
+					logging.WithContext(r.Context()).Error().Err(err).Msg("etag middleware: failed to write response")
+				}
+			})
+		}, nil
+	})
+}
@@ -0,0 +1,226 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+const (
+	cssBody        = "body { color: #333; margin: 0; padding: 0; }"
+	contentTypeCSS = "text/css; charset=utf-8"
+)
+
+func newETagMiddleware(t *testing.T, cfg map[string]interface{}) Middleware {
+	t.Helper()
+	factory := builtins["etag"]
+	if factory == nil {
+		t.Fatal("etag plugin not registered")
+	}
+	mw, err := factory("etag", cfg)
+	if err != nil {
+		t.Fatalf("failed to create etag middleware: %v", err)
+	}
+	return mw
+}
+
+// staticHandler serves a body with the given content type and optional extra headers
+func staticHandler(contentType, body string, extra map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("Vary", "Origin")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		for k, v := range extra {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodHead {
+			_, _ = w.Write([]byte(body))
+		}
+	})
+}
+
+func serve(h http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/app.css", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETag_GenerateAndRevalidate(t *testing.T) {
+	h := newETagMiddleware(t, nil)(staticHandler(contentTypeCSS, cssBody, nil))
+
+	first := serve(h, nil)
+	if first.Code != http.StatusOK {
+		t.Fatalf(ExpectedStatusError, http.StatusOK, first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("expected strong ETag, got %q", etag)
+	}
+	if first.Body.String() != cssBody {
+		t.Fatalf("body mismatch: %q", first.Body.String())
+	}
+
+	second := serve(h, map[string]string{"If-None-Match": etag})
+	if second.Code != http.StatusNotModified {
+		t.Fatalf(ExpectedStatusError, http.StatusNotModified, second.Code)
+	}
+	if second.Body.Len() != 0 {
+		t.Errorf("expected empty 304 body, got %q", second.Body.String())
+	}
+	if got := second.Header().Get("Content-Length"); got != "" {
+		t.Errorf("304 must not carry Content-Length, got %q", got)
+	}
+	for _, name := range []string{"ETag", "Cache-Control", "Vary"} {
+		if second.Header().Get(name) == "" {
+			t.Errorf("304 must retain %s", name)
+		}
+	}
+
+	mismatch := serve(h, map[string]string{"If-None-Match": `"deadbeef"`})
+	if mismatch.Code != http.StatusOK || mismatch.Body.String() != cssBody {
+		t.Fatalf("expected full 200 for mismatched validator, got %d %q", mismatch.Code, mismatch.Body.String())
+	}
+}
+
+func TestETag_OverCapPassesThrough(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	h := newETagMiddleware(t, map[string]interface{}{"max_size": float64(1024)})(staticHandler(contentTypeCSS, body, nil))
+
+	rec := serve(h, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf(ExpectedStatusError, http.StatusOK, rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got != "" {
+		t.Errorf("expected no ETag for over-cap body, got %q", got)
+	}
+	if rec.Body.String() != body {
+		t.Error("over-cap body was altered")
+	}
+}
+
+func TestETag_OverCapWithoutContentLength(t *testing.T) {
+	body := strings.Repeat("b", 2048)
+	h := newETagMiddleware(t, map[string]interface{}{"max_size": float64(1024)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeCSS)
+		for i := 0; i < len(body); i += 512 {
+			_, _ = w.Write([]byte(body[i : i+512]))
+		}
+	}))
+
+	rec := serve(h, nil)
+	if got := rec.Header().Get("ETag"); got != "" {
+		t.Errorf("expected no ETag for streamed over-cap body, got %q", got)
+	}
+	if rec.Body.String() != body {
+		t.Error("streamed over-cap body was altered")
+	}
+}
+
+func TestETag_BackendValidators(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		extra   map[string]string
+		request map[string]string
+		want    int
+	}{
+		{"backend etag match", map[string]string{"ETag": `"v1"`}, map[string]string{"If-None-Match": `W/"v1"`}, http.StatusNotModified},
+		{"backend etag mismatch", map[string]string{"ETag": `"v1"`}, map[string]string{"If-None-Match": `"v2"`}, http.StatusOK},
+		{"not modified since", map[string]string{"Last-Modified": lastModified}, map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{"Last-Modified": lastModified}, map[string]string{"If-Modified-Since": time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newETagMiddleware(t, nil)(staticHandler(contentTypeCSS, cssBody, tt.extra))
+			rec := serve(h, tt.request)
+			if rec.Code != tt.want {
+				t.Fatalf(ExpectedStatusError, tt.want, rec.Code)
+			}
+			if etag, ok := tt.extra["ETag"]; ok && rec.Header().Get("ETag") != etag {
+				t.Errorf("backend ETag changed to %q", rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestETag_SkipsRangeAndIneligible(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		request     map[string]string
+	}{
+		{"range request", contentTypeCSS, map[string]string{"Range": "bytes=0-3"}},
+		{"content type not listed", ContentTypeJSON, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newETagMiddleware(t, nil)(staticHandler(tt.contentType, cssBody, nil))
+			rec := serve(h, tt.request)
+			if got := rec.Header().Get("ETag"); got != "" {
+				t.Errorf("expected no ETag, got %q", got)
+			}
+			if rec.Body.String() != cssBody {
+				t.Error("body was altered")
+			}
+		})
+	}
+}
+
+// gzip listed first wraps etag, so the ETag is computed on the uncompressed
+// body and downgraded to weak once the response is compressed
+func TestETag_ComposesWithGzip(t *testing.T) {
+	body := strings.Repeat(cssBody, 50)
+	chain := config.PluginsConfig{
+		Enabled: true,
+		Chain: []config.PluginConfig{
+			{Name: "gzip", Config: map[string]interface{}{
+				"level":         float64(6),
+				"min_size":      float64(100),
+				"content_types": []interface{}{"text/css"},
+			}},
+			{Name: "etag"},
+		},
+	}
+	h, err := BuildChain(chain, staticHandler(contentTypeCSS, body, nil))
+	if err != nil {
+		t.Fatalf("failed to build chain: %v", err)
+	}
+
+	identity := serve(h, nil)
+	strong := identity.Header().Get("ETag")
+	if strong == "" || strings.HasPrefix(strong, "W/") {
+		t.Fatalf("expected strong ETag for identity response, got %q", strong)
+	}
+
+	compressed := serve(h, map[string]string{AcceptEncodingHeader: "gzip"})
+	assertCompressed(t, compressed, body)
+	weak := compressed.Header().Get("ETag")
+	if weak != "W/"+strong {
+		t.Fatalf("expected weak ETag %q for compressed response, got %q", "W/"+strong, weak)
+	}
+	if !strings.Contains(strings.Join(compressed.Header().Values("Vary"), ","), "Accept-Encoding") {
+		t.Errorf("expected Vary to include Accept-Encoding, got %v", compressed.Header().Values("Vary"))
+	}
+
+	revalidated := serve(h, map[string]string{AcceptEncodingHeader: "gzip", "If-None-Match": weak})
+	if revalidated.Code != http.StatusNotModified {
+		t.Fatalf(ExpectedStatusError, http.StatusNotModified, revalidated.Code)
+	}
+	if got := revalidated.Header().Get("ETag"); got != weak {
+		t.Errorf("304 should carry the compressed representation's ETag %q, got %q", weak, got)
+	}
+	if revalidated.Header().Get(ContentEncodingHeader) != "" || revalidated.Body.Len() != 0 {
+		t.Error("304 must not carry an encoded body")
+	}
+}
@@ -1,16 +1,30 @@
 package plugins
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 )
 
 func init() {
+	// Reject unauthenticated requests before buffering or storing anything
+	RegisterOrdering("custom-auth", Ordering{MustRunBefore: []string{"gzip", "etag"}})
 	RegisterBuiltin("custom-auth", func(name string, cfg map[string]interface{}) (Middleware, error) {
-		apiKey, ok := cfg["apiKey"].(string)
-		if !ok || apiKey == "" {
+		if err := rejectUnknownKeys(cfg, "apiKey"); err != nil {
+			return nil, err
+		}
+		if _, ok := cfg["apiKey"]; !ok {
 			return nil, fmt.Errorf("apiKey is required in config for %s plugin", name)
 		}
+		apiKey, err := parseString(cfg, "apiKey", "")
+		if err != nil {
+			return nil, err
+		}
+
+		// Identify callers by a digest so the key itself never leaves this plugin
+		sum := sha256.Sum256([]byte(apiKey))
+		identity := "api_key:" + hex.EncodeToString(sum[:8])
 
 		return func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -18,7 +32,7 @@ func init() {
 					http.Error(w, "Unauthorized", http.StatusUnauthorized)
 					return
 				}
-				next.ServeHTTP(w, r)
+				next.ServeHTTP(w, withClientIdentity(r, identity))
 			})
 		}, nil
 	})
@@ -9,7 +9,12 @@ import (
 )
 
 func init() {
+	// The logging plugin should see the request with its ID set
+	RegisterOrdering("request-id", Ordering{MustRunBefore: []string{"logging"}})
 	RegisterBuiltin("request-id", func(name string, cfg map[string]interface{}) (Middleware, error) {
+		if err := rejectUnknownKeys(cfg); err != nil {
+			return nil, err
+		}
 		return func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				b := make([]byte, 16)
@@ -5,20 +5,20 @@ import (
 	"net/http"
 )
 
-// toStringMap converts a generic map to map[string]string if possible
-func toStringMap(v interface{}) (map[string]string, error) {
+// toStringMap converts the header map under key to map[string]string
+func toStringMap(key string, v interface{}) (map[string]string, error) {
 	res := map[string]string{}
 	if v == nil {
 		return res, nil
 	}
 	m, ok := v.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("expected object for headers config")
+		return nil, fmt.Errorf("%s must be a map of header names to strings, got %s", key, typeName(v))
 	}
 	for k, val := range m {
 		s, ok := val.(string)
 		if !ok {
-			return nil, fmt.Errorf("header %s must be a string", k)
+			return nil, fmt.Errorf("%s.%s must be a string, got %s", key, k, typeName(val))
 		}
 		res[k] = s
 	}
@@ -39,11 +39,14 @@ func toStringMap(v interface{}) (map[string]string, error) {
 //	        X-From: LB
 func init() {
 	RegisterBuiltin("headers", func(name string, cfg map[string]interface{}) (Middleware, error) {
-		setMap, err := toStringMap(cfg["set"])
+		if err := rejectUnknownKeys(cfg, "set", "request_set"); err != nil {
+			return nil, err
+		}
+		setMap, err := toStringMap("set", cfg["set"])
 		if err != nil {
 			return nil, err
 		}
-		reqSetMap, err := toStringMap(cfg["request_set"])
+		reqSetMap, err := toStringMap("request_set", cfg["request_set"])
 		if err != nil {
 			return nil, err
 		}
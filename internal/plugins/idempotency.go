@@ -0,0 +1,423 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/membudget"
+	"github.com/0xReLogic/Helios/internal/store"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+const (
+	// DefaultIdempotencyHeader is the request header carrying the idempotency key
+	DefaultIdempotencyHeader = "Idempotency-Key"
+	// DefaultIdempotencyTTLSeconds is how long a completed response is replayed (24h)
+	DefaultIdempotencyTTLSeconds = 24 * 60 * 60
+	// DefaultIdempotencyWaitTimeoutMs bounds how long a duplicate waits for the first request
+	DefaultIdempotencyWaitTimeoutMs = 5000
+	// DefaultIdempotencyMaxBodyBytes is the largest response body stored for replay (1MB)
+	DefaultIdempotencyMaxBodyBytes = 1 * 1024 * 1024
+	// DefaultIdempotencyMaxMemoryBytes bounds the memory used by stored responses (64MB)
+	DefaultIdempotencyMaxMemoryBytes = 64 * 1024 * 1024
+
+	// idempotencyReplayedHeader marks a response served from the store
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+	// errCodeRequestInFlight is returned when a duplicate outwaits wait_timeout_ms
+	errCodeRequestInFlight = "request_in_flight"
+	// idempotencyEntryOverhead approximates the bookkeeping cost of one stored entry
+	idempotencyEntryOverhead = 256
+)
+
+var (
+	defaultIdempotencyMethods = []string{http.MethodPost, http.MethodPatch}
+
+	// defaultIdempotencyReplayHeaders are the response headers replayed to duplicates
+	defaultIdempotencyReplayHeaders = []string{
+		"Content-Type",
+		"Content-Encoding",
+		"Content-Language",
+		"Location",
+		"Cache-Control",
+		"ETag",
+		"Last-Modified",
+	}
+)
+
+// storedResponse is a completed response kept for replay
+type storedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// idempotencyEntry tracks one key from the first request until it expires
+type idempotencyEntry struct {
+	key  string
+	done chan struct{}   // closed once the first request completes
+	resp *storedResponse // set before done is closed; nil if nothing was stored
+	size int64           // counted against max_memory_bytes once completed
+}
+
+// idempotencyStore tracks in-flight keys and keeps completed responses in a
+// memory-bounded LRU. In-flight entries are never evicted; they are tiny and
+// bounded by concurrency.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	inFlight  map[string]*idempotencyEntry
+	completed *store.Memory // *idempotencyEntry by key, expiring after ttl
+	ttl       time.Duration
+}
+
+func newIdempotencyStore(maxMemory int64, ttl time.Duration, onEvict func(n int)) *idempotencyStore {
+	return &idempotencyStore{
+		inFlight: make(map[string]*idempotencyEntry),
+		completed: store.NewMemory(store.Options{
+			// One shard keeps the memory bound and LRU order exact across keys
+			Shards:  1,
+			MaxCost: maxMemory,
+			Cost:    func(v interface{}) int64 { return v.(*idempotencyEntry).size },
+			OnEvict: func(_ string, _ interface{}, reason store.EvictReason) {
+				if reason == store.EvictedCapacity && onEvict != nil {
+					onEvict(1)
+				}
+			},
+		}),
+		ttl: ttl,
+	}
+}
+
+// begin returns the entry for key and whether the caller is the first request for it
+func (s *idempotencyStore) begin(key string) (*idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.inFlight[key]; ok {
+		return e, false
+	}
+	if v, ok := s.completed.Get(key); ok {
+		return v.(*idempotencyEntry), false
+	}
+
+	e := &idempotencyEntry{key: key, done: make(chan struct{})}
+	s.inFlight[key] = e
+	return e, true
+}
+
+// complete records the first request's response and releases any waiters.
+// A nil resp forgets the key so the next duplicate is forwarded again.
+func (s *idempotencyStore) complete(e *idempotencyEntry, resp *storedResponse) {
+	s.mu.Lock()
+	delete(s.inFlight, e.key)
+	if resp != nil {
+		e.resp = resp
+		e.size = resp.size() + int64(len(e.key)) + idempotencyEntryOverhead
+		s.completed.Set(e.key, e, s.ttl)
+	}
+	s.mu.Unlock()
+
+	close(e.done)
+}
+
+func (r *storedResponse) size() int64 {
+	n := int64(len(r.body))
+	for k, vs := range r.header {
+		n += int64(len(k))
+		for _, v := range vs {
+			n += int64(len(v))
+		}
+	}
+	return n
+}
+
+// idempotencyRecorder passes the first request's response through to the
+// client while keeping a copy of it for replay
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	r             *http.Request
+	replayHeaders []string
+	maxBody       int64
+	budget        *membudget.Reservation // Share of limits.max_buffer_bytes_total held by body
+
+	status      int
+	header      http.Header
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool // Body over max_body_bytes or the shared budget; not stored
+	denied      bool // Overflow caused by the shared budget
+	hijacked    bool
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.wroteHeader = true
+		rec.status = code
+		rec.header = make(http.Header)
+		for _, name := range rec.replayHeaders {
+			if vs := rec.Header().Values(name); len(vs) > 0 {
+				rec.header[http.CanonicalHeaderKey(name)] = append([]string(nil), vs...)
+			}
+		}
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		switch {
+		case int64(rec.body.Len()+len(b)) > rec.maxBody:
+			rec.overflow = true
+		case !growBuffer(rec.r, rec.budget, "idempotency", len(b)):
+			rec.overflow, rec.denied = true, true
+		default:
+			rec.body.Write(b)
+		}
+		if rec.overflow {
+			rec.body = bytes.Buffer{}
+			rec.budget.Release()
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *idempotencyRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *idempotencyRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+	}
+	rec.hijacked = true
+	return h.Hijack()
+}
+
+// stored returns the response to keep for replay, or nil if it must not be stored.
+// Server errors are not stored so that a retry can reach the backend again.
+func (rec *idempotencyRecorder) stored() *storedResponse {
+	if rec.overflow || rec.hijacked {
+		return nil
+	}
+	if !rec.wroteHeader {
+		rec.status = http.StatusOK
+		rec.header = make(http.Header)
+	}
+	if rec.status >= http.StatusInternalServerError {
+		return nil
+	}
+	return &storedResponse{
+		status: rec.status,
+		header: rec.header,
+		body:   append([]byte(nil), rec.body.Bytes()...),
+	}
+}
+
+// replay writes a stored response to a duplicate request
+func (resp *storedResponse) replay(w http.ResponseWriter) {
+	h := w.Header()
+	for k, vs := range resp.header {
+		h[k] = append([]string(nil), vs...)
+	}
+	h.Set("Content-Length", strconv.Itoa(len(resp.body)))
+	h.Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+type idempotencyConfig struct {
+	header        string
+	methods       map[string]bool
+	replayHeaders []string
+	ttl           time.Duration
+	waitTimeout   time.Duration
+	maxBody       int64
+	maxMemory     int64
+}
+
+func parseIdempotencyConfig(cfg map[string]interface{}) (*idempotencyConfig, error) {
+	if err := rejectUnknownKeys(cfg, "header", "methods", "replay_headers", "ttl_seconds",
+		"wait_timeout_ms", "max_body_bytes", "max_memory_bytes"); err != nil {
+		return nil, err
+	}
+	header, err := parseString(cfg, "header", DefaultIdempotencyHeader)
+	if err != nil {
+		return nil, err
+	}
+	c := &idempotencyConfig{header: header}
+
+	methods, err := parseStringList(cfg, "methods", defaultIdempotencyMethods)
+	if err != nil {
+		return nil, err
+	}
+	c.methods = make(map[string]bool, len(methods))
+	for _, m := range methods {
+		c.methods[strings.ToUpper(m)] = true
+	}
+
+	if c.replayHeaders, err = parseStringList(cfg, "replay_headers", defaultIdempotencyReplayHeaders); err != nil {
+		return nil, err
+	}
+
+	if c.ttl, err = parseDuration(cfg, "ttl_seconds", DefaultIdempotencyTTLSeconds, time.Second); err != nil {
+		return nil, err
+	}
+	if c.waitTimeout, err = parseDuration(cfg, "wait_timeout_ms", DefaultIdempotencyWaitTimeoutMs, time.Millisecond); err != nil {
+		return nil, err
+	}
+	if c.maxBody, err = parseByteLimit(cfg, "max_body_bytes", DefaultIdempotencyMaxBodyBytes); err != nil {
+		return nil, err
+	}
+	if c.maxMemory, err = parseByteLimit(cfg, "max_memory_bytes", DefaultIdempotencyMaxMemoryBytes); err != nil {
+		return nil, err
+	}
+	if c.maxBody > c.maxMemory {
+		return nil, fmt.Errorf("max_body_bytes (%d) must not exceed max_memory_bytes (%d)", c.maxBody, c.maxMemory)
+	}
+	return c, nil
+}
+
+// idempotencyScope isolates keys per caller: the identity set by an
+// authentication plugin listed earlier in the chain, else the client IP as
+// scopedClientIP sees it, so a client can't claim another's address to have
+// its responses replayed.
+func idempotencyScope(r *http.Request) string {
+	if id := clientIdentity(r); id != "" {
+		return id
+	}
+	return "ip:" + scopedClientIP(r)
+}
+
+// idempotencyHandler is one idempotency plugin applied to a chain. It owns
+// the store of completed responses; BuildChain closes it on Chain.Close.
+type idempotencyHandler struct {
+	name    string
+	c       *idempotencyConfig
+	records *idempotencyStore
+	next    http.Handler
+}
+
+// newIdempotencyMiddleware parses the config up front so Validate catches
+// mistakes, but creates the store only when the middleware is applied:
+// validating a config must not leave a store registered with the janitor
+func newIdempotencyMiddleware(name string, cfg map[string]interface{}) (Middleware, error) {
+	c, err := parseIdempotencyConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		records := newIdempotencyStore(c.maxMemory, c.ttl, func(n int) {
+			addCounter(name, "evictions", uint64(n))
+		})
+		registerStore("idempotency:"+name, records.completed)
+		return &idempotencyHandler{name: name, c: c, records: records, next: next}
+	}, nil
+}
+
+func (h *idempotencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	idemKey := r.Header.Get(h.c.header)
+	if idemKey == "" || !h.c.methods[r.Method] {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	key := idempotencyScope(r) + "|" + r.Method + " " + r.URL.Path + "|" + idemKey
+
+	timer := time.NewTimer(h.c.waitTimeout)
+	defer timer.Stop()
+	for {
+		entry, first := h.records.begin(key)
+		if first {
+			forwardFirst(h.name, h.c, h.records, entry, h.next, w, r)
+			return
+		}
+
+		select {
+		case <-entry.done:
+			if entry.resp != nil {
+				addCounter(h.name, "replays", 1)
+				entry.resp.replay(w)
+				return
+			}
+			// The first response was not stored; forward this request instead
+		case <-timer.C:
+			addCounter(h.name, "in_flight_conflicts", 1)
+			utils.WriteError(w, http.StatusConflict, errCodeRequestInFlight, "A request with this idempotency key is still in flight")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Close stops sweeping the store and drops it from the metrics
+func (h *idempotencyHandler) Close() error {
+	unregisterStore("idempotency:"+h.name, h.records.completed)
+	return h.records.completed.Close()
+}
+
+// forwardFirst sends the first request for a key to the backend and stores its response
+func forwardFirst(name string, c *idempotencyConfig, records *idempotencyStore, entry *idempotencyEntry, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	rec := &idempotencyRecorder{
+		ResponseWriter: w,
+		r:              r,
+		replayHeaders:  c.replayHeaders,
+		maxBody:        c.maxBody,
+		budget:         reserveBuffer(),
+	}
+	defer rec.budget.Release()
+
+	// Release waiters even if the handler panics
+	completed := false
+	defer func() {
+		if !completed {
+			records.complete(entry, nil)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+
+	resp := rec.stored()
+	if rec.overflow && !rec.denied {
+		logging.WithContext(r.Context()).Warn().
+			Str("plugin", name).
+			Int64("max_body_bytes", c.maxBody).
+			Msg("idempotency: response too large to store, duplicates will not be deduplicated")
+	}
+	completed = true
+	records.complete(entry, resp)
+}
+
+// Config example :
+// plugins:
+//
+//	enabled: true
+//	chain:
+//	  - name: custom-auth   # optional; list before idempotency to scope keys per API key
+//	    config: {...}
+//	  - name: idempotency
+//	    config:
+//	      header: "Idempotency-Key"   # Request header carrying the key
+//	      methods: ["POST", "PATCH"]  # Methods that are deduplicated
+//	      ttl_seconds: 86400          # How long completed responses are replayed
+//	      wait_timeout_ms: 5000       # How long a duplicate waits for the first request before 409
+//	      max_body_bytes: 1048576     # Larger responses pass through without being stored
+//	      max_memory_bytes: 67108864  # LRU bound for all stored responses
+func init() {
+	// Replays are keyed by the authenticated caller when there is one
+	RegisterOrdering("idempotency", Ordering{MustRunAfter: []string{"custom-auth"}})
+	RegisterBuiltin("idempotency", newIdempotencyMiddleware)
+}
@@ -0,0 +1,345 @@
+package plugins
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/utils"
+)
+
+func newIdempotencyHandler(t *testing.T, cfg map[string]interface{}, next http.Handler) http.Handler {
+	t.Helper()
+	factory := builtins["idempotency"]
+	if factory == nil {
+		t.Fatal("idempotency plugin not registered")
+	}
+	mw, err := factory("idempotency", cfg)
+	if err != nil {
+		t.Fatalf("failed to create idempotency middleware: %v", err)
+	}
+	h := mw(next)
+	if c, ok := h.(io.Closer); ok {
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	return h
+}
+
+// countingBackend answers with a per-call payment id after an optional delay
+func countingBackend(hits *int32, delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Header().Set("Location", "/payments/1")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payment":` + strconv.Itoa(int(n)) + `}`))
+	})
+}
+
+func postWithKey(h http.Handler, key, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"amount":10}`))
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(DefaultIdempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_ConcurrentDuplicatesHitBackendOnce(t *testing.T) {
+	mc := metrics.NewMetricsCollector()
+	SetMetricsCollector(mc)
+	t.Cleanup(func() { SetMetricsCollector(nil) })
+
+	var hits int32
+	h := newIdempotencyHandler(t, nil, countingBackend(&hits, 100*time.Millisecond))
+
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = postWithKey(h, "pay-1", "10.0.0.1:1234")
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected one backend hit, got %d", got)
+	}
+	a, b := results[0], results[1]
+	if a.Code != http.StatusCreated || b.Code != http.StatusCreated {
+		t.Fatalf("expected both 201, got %d and %d", a.Code, b.Code)
+	}
+	if a.Body.String() != b.Body.String() {
+		t.Fatalf("expected identical bodies, got %q and %q", a.Body.String(), b.Body.String())
+	}
+	if a.Header().Get("Location") != b.Header().Get("Location") {
+		t.Error("expected replayed Location header")
+	}
+	if a.Header().Get(idempotencyReplayedHeader) == b.Header().Get(idempotencyReplayedHeader) {
+		t.Error("expected exactly one response to be marked as replayed")
+	}
+
+	// A later duplicate replays without reaching the backend
+	later := postWithKey(h, "pay-1", "10.0.0.1:1234")
+	if later.Header().Get(idempotencyReplayedHeader) != "true" || later.Body.String() != a.Body.String() {
+		t.Fatalf("expected replay, got %d %q", later.Code, later.Body.String())
+	}
+	if later.Header().Get("Set-Cookie") != "" {
+		t.Error("headers outside replay_headers must not be replayed")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected replay without backend hit, got %d hits", got)
+	}
+
+	if got := mc.GetMetrics().PluginMetrics["idempotency"]["replays"]; got != 2 {
+		t.Errorf("expected 2 replays counted, got %d", got)
+	}
+}
+
+func TestIdempotency_KeysAndClientsAreIsolated(t *testing.T) {
+	var hits int32
+	h := newIdempotencyHandler(t, nil, countingBackend(&hits, 0))
+
+	first := postWithKey(h, "k1", "10.0.0.1:1234")
+	other := postWithKey(h, "k2", "10.0.0.1:1234")
+	otherClient := postWithKey(h, "k1", "10.0.0.2:1234")
+	noKey := postWithKey(h, "", "10.0.0.1:1234")
+
+	if got := atomic.LoadInt32(&hits); got != 4 {
+		t.Fatalf("expected every request to reach the backend, got %d hits", got)
+	}
+	for _, rec := range []*httptest.ResponseRecorder{first, other, otherClient, noKey} {
+		if rec.Header().Get(idempotencyReplayedHeader) != "" {
+			t.Fatalf("unexpected replay: %q", rec.Body.String())
+		}
+	}
+	if first.Body.String() == otherClient.Body.String() {
+		t.Error("a different client must not receive another client's response")
+	}
+
+	// An identity from an authentication plugin scopes keys across client IPs
+	authed := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(DefaultIdempotencyHeader, "k3")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, withClientIdentity(req, "api_key:tenant-a"))
+		return rec
+	}
+	authed("10.0.0.1:1234")
+	if rec := authed("10.0.0.9:1234"); rec.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Error("expected the same identity to share keys across client IPs")
+	}
+}
+
+func TestIdempotency_ForgedForwardedForDoesNotReplay(t *testing.T) {
+	var hits int32
+	h := newIdempotencyHandler(t, nil, countingBackend(&hits, 0))
+
+	post := func(remoteAddr, xff string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"amount":10}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(DefaultIdempotencyHeader, "pay-1")
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	victim := post("203.0.113.10:1234", "")
+	attacker := post("198.51.100.7:1234", "203.0.113.10")
+
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("expected both requests to reach the backend, got %d hits", got)
+	}
+	if attacker.Header().Get(idempotencyReplayedHeader) != "" || attacker.Body.String() == victim.Body.String() {
+		t.Fatalf("forged X-Forwarded-For replayed another client's response: %q", attacker.Body.String())
+	}
+
+	// Clients behind the same untrusted proxy keep separate scopes
+	first := post("10.0.0.1:1234", "203.0.113.20")
+	second := post("10.0.0.1:1234", "203.0.113.21")
+	if second.Header().Get(idempotencyReplayedHeader) != "" || second.Body.String() == first.Body.String() {
+		t.Fatalf("clients behind one proxy shared an idempotency scope: %q", second.Body.String())
+	}
+
+	// Behind a trusted proxy the forwarded address is the scope
+	proxies, err := utils.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	SetTrustedProxies(proxies)
+	t.Cleanup(func() { SetTrustedProxies(utils.TrustedProxies{}) })
+	if rec := post("10.0.0.1:1234", "203.0.113.10"); rec.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Error("expected the client behind a trusted proxy to share its own keys")
+	}
+}
+
+func TestIdempotency_InFlightTimeout(t *testing.T) {
+	release := make(chan struct{})
+	h := newIdempotencyHandler(t, map[string]interface{}{"wait_timeout_ms": float64(50)},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postWithKey(h, "slow", "10.0.0.1:1234")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	dup := postWithKey(h, "slow", "10.0.0.1:1234")
+	close(release)
+	<-done
+
+	if dup.Code != http.StatusConflict {
+		t.Fatalf(ExpectedStatusError, http.StatusConflict, dup.Code)
+	}
+	if got := dup.Header().Get("X-Helios-Error"); got != errCodeRequestInFlight {
+		t.Errorf("expected error code %s, got %q", errCodeRequestInFlight, got)
+	}
+}
+
+func TestIdempotency_OversizedAndServerErrorsPassThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		maxBody float64
+	}{
+		{"oversized response", http.StatusOK, strings.Repeat("x", 2048), 1024},
+		{"server error", http.StatusBadGateway, "bad gateway", 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			h := newIdempotencyHandler(t, map[string]interface{}{"max_body_bytes": tt.maxBody},
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&hits, 1)
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(tt.body))
+				}))
+
+			for i := 0; i < 2; i++ {
+				rec := postWithKey(h, "big", "10.0.0.1:1234")
+				if rec.Code != tt.status || rec.Body.String() != tt.body {
+					t.Fatalf("request %d: response altered: %d", i, rec.Code)
+				}
+				if rec.Header().Get(idempotencyReplayedHeader) != "" {
+					t.Fatalf("request %d: unexpected replay", i)
+				}
+			}
+			if got := atomic.LoadInt32(&hits); got != 2 {
+				t.Fatalf("expected unstored responses to be forwarded again, got %d hits", got)
+			}
+		})
+	}
+}
+
+func TestIdempotency_MemoryBoundEvicts(t *testing.T) {
+	mc := metrics.NewMetricsCollector()
+	SetMetricsCollector(mc)
+	t.Cleanup(func() { SetMetricsCollector(nil) })
+
+	var hits int32
+	body := strings.Repeat("y", 600)
+	h := newIdempotencyHandler(t, map[string]interface{}{
+		"max_body_bytes":   float64(1024),
+		"max_memory_bytes": float64(2048),
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	for _, key := range []string{"a", "b", "c", "a"} {
+		postWithKey(h, key, "10.0.0.1:1234")
+	}
+	// Only two ~900 byte entries fit, so "a" was evicted before it was repeated
+	if got := atomic.LoadInt32(&hits); got != 4 {
+		t.Fatalf("expected evicted key to be forwarded again, got %d hits", got)
+	}
+	if got := mc.GetMetrics().PluginMetrics["idempotency"]["evictions"]; got == 0 {
+		t.Error("expected evictions to be counted")
+	}
+}
+
+func TestIdempotency_InvalidConfig(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"header": ""},
+		{"methods": "POST"},
+		{"ttl_seconds": float64(0)},
+		{"ttl_seconds": float64(1.5)},
+		{"ttl_seconds": float64(1 << 40)},
+		{"wait_timeout_ms": "5s"},
+		{"max_body_bytes": float64(4096), "max_memory_bytes": float64(1024)},
+	}
+	for _, cfg := range tests {
+		if _, err := builtins["idempotency"]("idempotency", cfg); err == nil {
+			t.Errorf("expected config %v to be rejected", cfg)
+		}
+	}
+}
+
+func TestIdempotency_StoreOwnedByChain(t *testing.T) {
+	mc := metrics.NewMetricsCollector()
+	SetMetricsCollector(mc)
+	t.Cleanup(func() { SetMetricsCollector(nil) })
+
+	pc := config.PluginsConfig{
+		Enabled: true,
+		Chain:   []config.PluginConfig{{Name: "idempotency", Config: map[string]interface{}{}}},
+	}
+
+	// Validating a config must not create a store
+	if err := Validate(pc); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if _, ok := mc.GetMetrics().Stores["idempotency:idempotency"]; ok {
+		t.Fatal("expected Validate not to create a store")
+	}
+
+	chain, err := BuildChain(pc, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("build chain failed: %v", err)
+	}
+	if _, ok := mc.GetMetrics().Stores["idempotency:idempotency"]; !ok {
+		t.Fatal("expected the built chain to report its store")
+	}
+
+	// A rebuilt chain replaces the store; closing the old one leaves it reported
+	rebuilt, err := BuildChain(pc, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("rebuild chain failed: %v", err)
+	}
+	if err := chain.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, ok := mc.GetMetrics().Stores["idempotency:idempotency"]; !ok {
+		t.Fatal("expected the rebuilt chain's store to stay reported")
+	}
+
+	if err := rebuilt.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, ok := mc.GetMetrics().Stores["idempotency:idempotency"]; ok {
+		t.Fatal("expected Close to release the store")
+	}
+}
@@ -0,0 +1,20 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+)
+
+type clientIdentityKey struct{}
+
+// withClientIdentity returns r annotated with the identity an authentication
+// plugin resolved for the caller
+func withClientIdentity(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, id))
+}
+
+// clientIdentity returns the identity set by an earlier authentication plugin, if any
+func clientIdentity(r *http.Request) string {
+	id, _ := r.Context().Value(clientIdentityKey{}).(string)
+	return id
+}
@@ -1,69 +1,105 @@
 package plugins
 
 import (
-	"bufio"
-	"net"
+	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	logging "github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/proxyinfo"
 )
 
-// statusRecorder records HTTP status and bytes written
-type statusRecorder struct {
-	http.ResponseWriter
-	status      int
-	wroteHeader bool
+// reservedLogFields are written by the logging plugin itself (or by the
+// logger) and can't be overridden by static fields
+var reservedLogFields = map[string]bool{
+	"backend": true, "method": true, "path": true, "status": true, "latency_ms": true,
+	"bytes": true, "retries": true, "upstream_latency_ms": true,
+	"level": true, "time": true, "message": true, "request_id": true, "trace_id": true,
 }
 
-func (sr *statusRecorder) WriteHeader(code int) {
-	sr.status = code
-	sr.wroteHeader = true
-	sr.ResponseWriter.WriteHeader(code)
+// logField is a static key/value added to every request line
+type logField struct {
+	key, value string
 }
 
-func (sr *statusRecorder) Write(b []byte) (int, error) {
-	if !sr.wroteHeader {
-		sr.WriteHeader(http.StatusOK)
+// parseLogFields reads the static fields option, sorted by key so every
+// line lists them in the same order
+func parseLogFields(cfg map[string]interface{}) ([]logField, error) {
+	m, err := toStringMap("fields", cfg["fields"])
+	if err != nil {
+		return nil, err
 	}
-	return sr.ResponseWriter.Write(b)
-}
-
-// Support http.Hijacker if underlying supports it (for websockets)
-func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if h, ok := sr.ResponseWriter.(http.Hijacker); ok {
-		return h.Hijack()
-	}
-	return nil, nil, http.ErrNotSupported
-}
-
-// Support http.Flusher if underlying supports it
-func (sr *statusRecorder) Flush() {
-	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
-		f.Flush()
+	fields := make([]logField, 0, len(m))
+	for k, v := range m {
+		if reservedLogFields[k] {
+			return nil, fmt.Errorf("fields.%s conflicts with a built-in log field", k)
+		}
+		fields = append(fields, logField{key: k, value: v})
 	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields, nil
 }
 
+// init registers the request logging plugin. It logs once the response is
+// complete, including the backend the load balancer picked.
+// Config example:
+// plugins:
+//
+//	enabled: true
+//	chain:
+//	  - name: logging
+//	    config:
+//	      replace_core_log: true
+//	      fields:
+//	        service: storefront
 func init() {
 	RegisterBuiltin("logging", func(name string, cfg map[string]interface{}) (Middleware, error) {
+		if err := rejectUnknownKeys(cfg, "fields", "replace_core_log"); err != nil {
+			return nil, err
+		}
+		fields, err := parseLogFields(cfg)
+		if err != nil {
+			return nil, err
+		}
+		replaceCoreLog, err := parseBool(cfg, "replace_core_log", false)
+		if err != nil {
+			return nil, err
+		}
+		message := "plugin request log"
+		if replaceCoreLog {
+			// Keep the message of the line being replaced for existing queries
+			message = "request completed"
+		}
+
 		return func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				start := time.Now()
-				rec := &statusRecorder{ResponseWriter: w}
-				next.ServeHTTP(rec, r)
-				dur := time.Since(start)
-				// Default to 200 if WriteHeader was never called
-				status := rec.status
-				if !rec.wroteHeader {
-					status = http.StatusOK
+				info := proxyinfo.FromContext(r.Context())
+				if info == nil {
+					info = &proxyinfo.Info{}
+					r = r.WithContext(proxyinfo.NewContext(r.Context(), info))
+				}
+				if replaceCoreLog {
+					info.SuppressCoreLog = true
 				}
-				latencyMs := float64(dur) / float64(time.Millisecond)
-				logging.WithContext(r.Context()).Info().
+				rec := proxyinfo.Wrap(w)
+				next.ServeHTTP(rec, r)
+
+				latencyMs := float64(time.Since(start)) / float64(time.Millisecond)
+				event := logging.WithContext(r.Context()).Info().
+					Str("backend", info.Backend).
 					Str("method", r.Method).
 					Str("path", r.URL.Path).
-					Int("status", status).
+					Int("status", rec.Status()).
 					Float64("latency_ms", latencyMs).
-					Msg("plugin request log")
+					Int64("bytes", rec.BytesWritten()).
+					Int("retries", info.Retries).
+					Float64("upstream_latency_ms", float64(info.UpstreamLatency)/float64(time.Millisecond))
+				for _, f := range fields {
+					event = event.Str(f.key, f.value)
+				}
+				event.Msg(message)
 			})
 		}, nil
 	})
@@ -0,0 +1,195 @@
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/loadbalancer"
+	logging "github.com/0xReLogic/Helios/internal/logging"
+)
+
+// captureLogs sends JSON logs to a temporary file for the test and returns
+// a function reading back the lines written so far
+func captureLogs(t *testing.T) func() []map[string]interface{} {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "helios.log")
+	err := logging.Init(config.LoggingConfig{
+		Level:   "info",
+		Format:  "json",
+		Outputs: []config.LogOutputConfig{{Type: "file", Path: path}},
+	})
+	if err != nil {
+		t.Fatalf("failed to configure logging: %v", err)
+	}
+	t.Cleanup(func() { _ = logging.Init(config.LoggingConfig{Level: "info", Format: "text"}) })
+
+	return func() []map[string]interface{} {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open log file: %v", err)
+		}
+		defer f.Close()
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+}
+
+// newLoggedLB puts the logging plugin in front of a load balancer with one
+// backend named app serving handler, or no backends when handler is nil
+func newLoggedLB(t *testing.T, handler http.HandlerFunc) http.Handler {
+	t.Helper()
+	cfg := &config.Config{LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"}}
+	if handler != nil {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		cfg.Backends = []config.BackendConfig{{Name: "app", Address: server.URL}}
+	}
+	lb, err := loadbalancer.NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create load balancer: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	chain, err := BuildChain(config.PluginsConfig{
+		Enabled: true,
+		Chain: []config.PluginConfig{{
+			Name: "logging",
+			Config: map[string]interface{}{
+				"replace_core_log": true,
+				"fields":           map[string]interface{}{"service": "storefront"},
+			},
+		}},
+	}, lb)
+	if err != nil {
+		t.Fatalf(testFailedBuildPlugin, err)
+	}
+	return chain
+}
+
+func TestLoggingPluginReplacesCoreLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantBackend string
+		wantStatus  int
+		wantBytes   int
+	}{
+		{
+			name: "success",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "hello")
+			},
+			wantBackend: "app",
+			wantStatus:  http.StatusOK,
+			wantBytes:   5,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "boom", http.StatusBadGateway)
+			},
+			wantBackend: "app",
+			wantStatus:  http.StatusBadGateway,
+			wantBytes:   5,
+		},
+		{
+			name:       "no backend",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBytes:  len("No healthy backend servers available\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readLogs := captureLogs(t)
+			chain := newLoggedLB(t, tt.handler)
+
+			rec := httptest.NewRecorder()
+			chain.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			var requestLines []map[string]interface{}
+			for _, line := range readLogs() {
+				if line["message"] == "request completed" || line["message"] == "plugin request log" {
+					requestLines = append(requestLines, line)
+				}
+			}
+			if len(requestLines) != 1 {
+				t.Fatalf("expected exactly one request line, got %d: %v", len(requestLines), requestLines)
+			}
+			line := requestLines[0]
+
+			if line["message"] != "request completed" {
+				t.Errorf("expected the core message to be kept, got %v", line["message"])
+			}
+			if line["backend"] != tt.wantBackend {
+				t.Errorf("expected backend %q, got %v", tt.wantBackend, line["backend"])
+			}
+			if line["status"] != float64(tt.wantStatus) {
+				t.Errorf("expected status %d, got %v", tt.wantStatus, line["status"])
+			}
+			if line["bytes"] != float64(tt.wantBytes) {
+				t.Errorf("expected %d bytes, got %v", tt.wantBytes, line["bytes"])
+			}
+			if line["method"] != http.MethodGet || line["path"] != "/api/users" || line["service"] != "storefront" {
+				t.Errorf("unexpected request fields: %v", line)
+			}
+			for _, key := range []string{"latency_ms", "retries", "upstream_latency_ms"} {
+				if _, ok := line[key]; !ok {
+					t.Errorf("expected field %s in %v", key, line)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggingPluginKeepsCoreLogByDefault(t *testing.T) {
+	readLogs := captureLogs(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	lb, err := loadbalancer.NewLoadBalancer(&config.Config{
+		Backends:     []config.BackendConfig{{Name: "app", Address: server.URL}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create load balancer: %v", err)
+	}
+	defer lb.Stop()
+
+	chain, err := BuildChain(config.PluginsConfig{
+		Enabled: true,
+		Chain:   []config.PluginConfig{{Name: "logging"}},
+	}, lb)
+	if err != nil {
+		t.Fatalf(testFailedBuildPlugin, err)
+	}
+	chain.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	messages := make(map[interface{}]int)
+	for _, line := range readLogs() {
+		if line["backend"] == "app" {
+			messages[line["message"]]++
+		}
+	}
+	if messages["request completed"] != 1 || messages["plugin request log"] != 1 {
+		t.Errorf("expected both the core and plugin lines, got %v", messages)
+	}
+}
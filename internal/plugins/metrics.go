@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"sync"
+
+	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/store"
+)
+
+var (
+	metricsMu        sync.RWMutex
+	metricsCollector *metrics.MetricsCollector
+)
+
+// SetMetricsCollector makes the global metrics collector available to plugins.
+// Plugins report named counters through it; with no collector set they are dropped.
+func SetMetricsCollector(mc *metrics.MetricsCollector) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metricsCollector = mc
+}
+
+// addCounter adds delta to a named counter for the plugin instance
+func addCounter(plugin, counter string, delta uint64) {
+	metricsMu.RLock()
+	mc := metricsCollector
+	metricsMu.RUnlock()
+	if mc != nil {
+		mc.AddPluginCounter(plugin, counter, delta)
+	}
+}
+
+// registerStore reports a plugin's store under stores in the metrics
+func registerStore(name string, s store.Reporter) {
+	metricsMu.RLock()
+	mc := metricsCollector
+	metricsMu.RUnlock()
+	if mc != nil {
+		mc.RegisterStore(name, s)
+	}
+}
+
+// unregisterStore stops reporting s, unless a newer chain has already
+// registered its own store under name
+func unregisterStore(name string, s store.Reporter) {
+	metricsMu.RLock()
+	mc := metricsCollector
+	metricsMu.RUnlock()
+	if mc != nil {
+		mc.UnregisterStore(name, s)
+	}
+}
@@ -0,0 +1,256 @@
+package plugins
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	logging "github.com/0xReLogic/Helios/internal/logging"
+)
+
+// mimeSniffLen is the number of body bytes http.DetectContentType considers
+const mimeSniffLen = 512
+
+// mime_guard actions for responses whose content contradicts their Content-Type
+const (
+	mimeActionAttachment = "attachment" // application/octet-stream, downloaded rather than rendered
+	mimeActionOverride   = "override"   // Content-Type set to the sniffed type, or text/plain for markup
+)
+
+// mimeGuardConfig is the parsed mime_guard plugin configuration
+type mimeGuardConfig struct {
+	enforce bool
+	paths   []string
+	action  string
+}
+
+func parseMimeGuardConfig(cfg map[string]interface{}) (mimeGuardConfig, error) {
+	var mc mimeGuardConfig
+	if err := rejectUnknownKeys(cfg, "enforce", "paths", "action"); err != nil {
+		return mc, err
+	}
+	var err error
+	if mc.enforce, err = parseBool(cfg, "enforce", false); err != nil {
+		return mc, err
+	}
+	if mc.paths, err = parseStringList(cfg, "paths", nil); err != nil {
+		return mc, err
+	}
+	if mc.action, err = parseString(cfg, "action", mimeActionAttachment); err != nil {
+		return mc, err
+	}
+	if mc.action != mimeActionAttachment && mc.action != mimeActionOverride {
+		return mc, fmt.Errorf("action must be %s or %s, got %q", mimeActionAttachment, mimeActionOverride, mc.action)
+	}
+	if mc.enforce && len(mc.paths) == 0 {
+		return mc, fmt.Errorf("paths is required when enforce is true")
+	}
+	for _, p := range mc.paths {
+		if !strings.HasPrefix(p, "/") {
+			return mc, fmt.Errorf("path %q must start with /", p)
+		}
+	}
+	return mc, nil
+}
+
+// inScope reports whether responses to path are sniffed
+func (mc mimeGuardConfig) inScope(path string) bool {
+	if !mc.enforce {
+		return false
+	}
+	for _, p := range mc.paths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// mimeGuardWriter adds X-Content-Type-Options: nosniff to a response and,
+// when sniffing, holds back the first mimeSniffLen body bytes to check them
+// against the declared Content-Type before streaming the rest
+type mimeGuardWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	plugin string
+	sniff  bool
+	action string
+
+	status     int
+	buf        []byte
+	headerSent bool
+}
+
+// WriteHeader records the status code; it is sent with the first body bytes
+// once the Content-Type has been checked
+func (m *mimeGuardWriter) WriteHeader(code int) {
+	if m.headerSent {
+		return
+	}
+	if code < http.StatusOK {
+		// Informational responses go out as is
+		m.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if m.status == 0 {
+		m.status = code
+	}
+	if !m.sniff {
+		m.sendHeader()
+	}
+}
+
+func (m *mimeGuardWriter) Write(b []byte) (int, error) {
+	if m.headerSent {
+		return m.ResponseWriter.Write(b)
+	}
+	if !m.sniff {
+		m.sendHeader()
+		return m.ResponseWriter.Write(b)
+	}
+	n := mimeSniffLen - len(m.buf)
+	if n > len(b) {
+		n = len(b)
+	}
+	m.buf = append(m.buf, b[:n]...)
+	if len(m.buf) < mimeSniffLen {
+		return len(b), nil
+	}
+	if err := m.release(); err != nil {
+		return 0, err
+	}
+	if n == len(b) {
+		return len(b), nil
+	}
+	written, err := m.ResponseWriter.Write(b[n:])
+	return n + written, err
+}
+
+// release checks the held bytes, sends the header and writes them out
+func (m *mimeGuardWriter) release() error {
+	if m.headerSent {
+		return nil
+	}
+	m.guard()
+	m.sendHeader()
+	if len(m.buf) == 0 {
+		return nil
+	}
+	_, err := m.ResponseWriter.Write(m.buf)
+	m.buf = nil
+	return err
+}
+
+// guard neutralizes the response when the held bytes contradict its
+// Content-Type in a way a browser could render as markup
+func (m *mimeGuardWriter) guard() {
+	if len(m.buf) == 0 {
+		return
+	}
+	h := m.Header()
+	if h.Get("Content-Encoding") != "" {
+		// Encoded bytes can't be sniffed
+		return
+	}
+	declared := h.Get("Content-Type")
+	sniffed := http.DetectContentType(m.buf)
+	sniffedType, _, _ := mime.ParseMediaType(sniffed)
+	markup := sniffedType == "text/html" || sniffedType == "text/xml"
+
+	if declared != "" {
+		declaredType, _, err := mime.ParseMediaType(declared)
+		if err == nil && (!markup || declaredType == sniffedType) {
+			return
+		}
+	}
+
+	safe := sniffed
+	if markup {
+		safe = "text/plain; charset=utf-8"
+	}
+	switch m.action {
+	case mimeActionOverride:
+		h.Set("Content-Type", safe)
+	default:
+		h.Set("Content-Type", "application/octet-stream")
+		h.Set("Content-Disposition", "attachment")
+	}
+	addCounter(m.plugin, "neutralized", 1)
+	logging.WithContext(m.r.Context()).Warn().
+		Str("path", m.r.URL.Path).
+		Str("declared_content_type", declared).
+		Str("sniffed_content_type", sniffed).
+		Str("action", m.action).
+		Msg("mime_guard: response content contradicts its Content-Type")
+}
+
+func (m *mimeGuardWriter) sendHeader() {
+	if m.headerSent {
+		return
+	}
+	m.headerSent = true
+	if m.Header().Get("X-Content-Type-Options") == "" {
+		m.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	m.ResponseWriter.WriteHeader(m.status)
+}
+
+// Flush sends what has been held back, checked against fewer bytes
+func (m *mimeGuardWriter) Flush() {
+	_ = m.release()
+	if f, ok := m.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (m *mimeGuardWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := m.ResponseWriter.(http.Hijacker); ok {
+		conn, rw, err := h.Hijack()
+		if err == nil {
+			// The connection belongs to the caller now; nothing left to send
+			m.headerSent = true
+		}
+		return conn, rw, err
+	}
+	return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+}
+
+// Config example:
+// plugins:
+//
+//	enabled: true
+//	chain:
+//	  - name: mime_guard
+//	    config:
+//	      enforce: true  # Sniff responses under paths; nosniff is added everywhere regardless
+//	      paths: ["/uploads/"]
+//	      action: attachment  # or override
+func init() {
+	RegisterBuiltin("mime_guard", func(name string, cfg map[string]interface{}) (Middleware, error) {
+		mc, err := parseMimeGuardConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mw := &mimeGuardWriter{
+					ResponseWriter: w,
+					r:              r,
+					plugin:         name,
+					sniff:          mc.inScope(r.URL.Path),
+					action:         mc.action,
+				}
+				next.ServeHTTP(mw, r)
+				if err := mw.release(); err != nil {
+					logging.WithContext(r.Context()).Error().Err(err).Msg("mime_guard middleware: failed to write response")
+				}
+			})
+		}, nil
+	})
+}
@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const htmlPayload = "<html><body><script>alert(document.cookie)</script></body></html>"
+
+func newMimeGuardMiddleware(t *testing.T, cfg map[string]interface{}) Middleware {
+	t.Helper()
+	mw, err := builtins["mime_guard"]("mime_guard", cfg)
+	if err != nil {
+		t.Fatalf("failed to create mime_guard middleware: %v", err)
+	}
+	return mw
+}
+
+// pngBytes returns a small valid PNG image
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// serveBody serves body with contentType ("" sends none) at path through mw
+func serveBody(mw Middleware, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestMimeGuard_NeutralizesMislabelledHTML(t *testing.T) {
+	tests := []struct {
+		action          string
+		wantType        string
+		wantDisposition string
+	}{
+		{"attachment", "application/octet-stream", "attachment"},
+		{"override", "text/plain; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			mw := newMimeGuardMiddleware(t, map[string]interface{}{
+				"enforce": true,
+				"paths":   []interface{}{"/uploads/"},
+				"action":  tt.action,
+			})
+			for _, declared := range []string{"image/png", ""} {
+				rec := serveBody(mw, "/uploads/avatar.png", declared, []byte(htmlPayload))
+				if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+					t.Errorf("declared %q: expected Content-Type %q, got %q", declared, tt.wantType, got)
+				}
+				if got := rec.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+					t.Errorf("declared %q: expected Content-Disposition %q, got %q", declared, tt.wantDisposition, got)
+				}
+				if rec.Body.String() != htmlPayload {
+					t.Errorf("declared %q: expected the body unchanged, got %q", declared, rec.Body.String())
+				}
+				if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+					t.Errorf("declared %q: expected nosniff, got %q", declared, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMimeGuard_PassesCorrectContent(t *testing.T) {
+	mw := newMimeGuardMiddleware(t, map[string]interface{}{"enforce": true, "paths": []interface{}{"/uploads/"}})
+	img := pngBytes(t)
+
+	rec := serveBody(mw, "/uploads/avatar.png", "image/png", img)
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("expected image/png untouched, got %q", got)
+	}
+	if rec.Header().Get("Content-Disposition") != "" {
+		t.Error("expected no Content-Disposition for a correct image")
+	}
+	if !bytes.Equal(rec.Body.Bytes(), img) {
+		t.Error("expected the image body unchanged")
+	}
+
+	// Honest HTML is left alone
+	rec = serveBody(mw, "/uploads/page.html", "text/html; charset=utf-8", []byte(htmlPayload))
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("expected declared HTML untouched, got %q", got)
+	}
+}
+
+func TestMimeGuard_OutOfScopeUntouched(t *testing.T) {
+	mw := newMimeGuardMiddleware(t, map[string]interface{}{"enforce": true, "paths": []interface{}{"/uploads/"}})
+	rec := serveBody(mw, "/api/avatar", "image/png", []byte(htmlPayload))
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("expected Content-Type untouched outside the paths, got %q", got)
+	}
+	if rec.Header().Get("Content-Disposition") != "" || rec.Body.String() != htmlPayload {
+		t.Error("expected the response untouched outside the paths")
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected nosniff outside the paths, got %q", got)
+	}
+}
+
+func TestMimeGuard_NosniffEverywhere(t *testing.T) {
+	for _, cfg := range []map[string]interface{}{nil, {"enforce": true, "paths": []interface{}{"/"}}} {
+		mw := newMimeGuardMiddleware(t, cfg)
+
+		// Bodiless responses get it too
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/item", nil))
+		if rec.Code != http.StatusNoContent || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("config %v: expected 204 with nosniff, got %d %v", cfg, rec.Code, rec.Header())
+		}
+
+		// An existing value is not duplicated
+		h = mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			_, _ = w.Write([]byte("ok"))
+		}))
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := rec.Header().Values("X-Content-Type-Options"); len(got) != 1 {
+			t.Errorf("config %v: expected a single nosniff header, got %v", cfg, got)
+		}
+	}
+}
+
+func TestMimeGuard_StreamsAfterSniffing(t *testing.T) {
+	mw := newMimeGuardMiddleware(t, map[string]interface{}{"enforce": true, "paths": []interface{}{"/uploads/"}})
+	body := htmlPayload + strings.Repeat("x", 4096)
+
+	rec := httptest.NewRecorder()
+	var afterFirstChunk int
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		// Only the first 512 bytes are held back
+		_, _ = w.Write([]byte(body[:1024]))
+		afterFirstChunk = rec.Body.Len()
+		_, _ = w.Write([]byte(body[1024:]))
+	}))
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/uploads/x", nil))
+	if afterFirstChunk != 1024 {
+		t.Errorf("expected the first chunk passed on once 512 bytes were sniffed, got %d bytes", afterFirstChunk)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment" {
+		t.Errorf("expected the response neutralized, got Content-Disposition %q", got)
+	}
+	if rec.Body.String() != body {
+		t.Errorf("expected the body streamed intact, got %d bytes", rec.Body.Len())
+	}
+}
@@ -0,0 +1,240 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+// Ordering declares where a built-in plugin must sit in the chain relative to
+// other plugins. Chain order is request order: a plugin listed earlier wraps
+// every plugin after it, so it sees the request first and the response last.
+// Constraints only apply when both plugins are in the chain.
+type Ordering struct {
+	MustRunBefore []string
+	MustRunAfter  []string
+}
+
+// orderings holds the declared ordering constraints by plugin name
+var orderings = map[string]Ordering{}
+
+// RegisterOrdering declares the ordering constraints of a built-in plugin
+func RegisterOrdering(name string, o Ordering) {
+	if name == "" {
+		return
+	}
+	orderings[name] = o
+}
+
+// orderEdge requires plugin before to be listed ahead of plugin after
+type orderEdge struct {
+	before, after string
+}
+
+func (e orderEdge) String() string {
+	return e.before + " must run before " + e.after
+}
+
+// orderEdges flattens ordering declarations into edges, sorted so violations
+// are reported in a stable order
+func orderEdges(decls map[string]Ordering) []orderEdge {
+	seen := make(map[orderEdge]bool)
+	var edges []orderEdge
+	add := func(e orderEdge) {
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+	for name, o := range decls {
+		for _, other := range o.MustRunBefore {
+			add(orderEdge{before: name, after: other})
+		}
+		for _, other := range o.MustRunAfter {
+			add(orderEdge{before: other, after: name})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].before != edges[j].before {
+			return edges[i].before < edges[j].before
+		}
+		return edges[i].after < edges[j].after
+	})
+	return edges
+}
+
+// findOrderingCycle returns a cycle in the declared constraints as the list of
+// plugin names along it, first name repeated at the end, or nil if there is none
+func findOrderingCycle(decls map[string]Ordering) []string {
+	next := make(map[string][]string)
+	var names []string
+	for _, e := range orderEdges(decls) {
+		if _, ok := next[e.before]; !ok {
+			names = append(names, e.before)
+		}
+		next[e.before] = append(next[e.before], e.after)
+	}
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, n := range next[name] {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// orderViolations lists the constraints the chain order breaks
+func orderViolations(chain []config.PluginConfig) []string {
+	first := make(map[string]int)
+	last := make(map[string]int)
+	for i, p := range chain {
+		if _, ok := first[p.Name]; !ok {
+			first[p.Name] = i
+		}
+		last[p.Name] = i
+	}
+	var violations []string
+	for _, e := range orderEdges(orderings) {
+		before, okBefore := last[e.before]
+		after, okAfter := first[e.after]
+		if okBefore && okAfter && before > after {
+			violations = append(violations, e.String())
+		}
+	}
+	return violations
+}
+
+// sortChain returns the positions of the chain entries in an order satisfying
+// every constraint. Among entries free to go next, the one listed earliest goes
+// first, so plugins without constraints between them keep their configured
+// relative order.
+func sortChain(chain []config.PluginConfig) ([]int, error) {
+	edges := orderEdges(orderings)
+	blockers := make([]int, len(chain))
+	unblocks := make([][]int, len(chain))
+	for i, a := range chain {
+		for j, b := range chain {
+			for _, e := range edges {
+				if e.before == a.Name && e.after == b.Name && i != j {
+					blockers[j]++
+					unblocks[i] = append(unblocks[i], j)
+				}
+			}
+		}
+	}
+
+	sorted := make([]int, 0, len(chain))
+	placed := make([]bool, len(chain))
+	for len(sorted) < len(chain) {
+		next := -1
+		for i := range chain {
+			if !placed[i] && blockers[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var stuck []string
+			for i, p := range chain {
+				if !placed[i] {
+					stuck = append(stuck, p.Name)
+				}
+			}
+			return nil, fmt.Errorf("plugin ordering constraints form a cycle among %s", strings.Join(stuck, ", "))
+		}
+		placed[next] = true
+		sorted = append(sorted, next)
+		for _, j := range unblocks[next] {
+			blockers[j]--
+		}
+	}
+	return sorted, nil
+}
+
+// ChainOrder is the outcome of checking a chain against the ordering
+// constraints
+type ChainOrder struct {
+	Chain      []config.PluginConfig // The order the chain is built in
+	Violations []string              // Constraints the configured order breaks
+	Suggested  []string              // A valid order when there are violations
+	Reordered  bool                  // Chain differs from the configured order (auto_order)
+
+	positions []int // configured chain position of each Chain entry
+}
+
+// Names returns the plugin names of the effective chain
+func (co ChainOrder) Names() []string {
+	return chainNames(co.Chain)
+}
+
+func chainNames(chain []config.PluginConfig) []string {
+	names := make([]string, 0, len(chain))
+	for _, p := range chain {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// ResolveOrder checks the configured chain against the ordering constraints.
+// With auto_order a violating chain is reordered; with enforce_order it is an
+// error; otherwise it is kept as configured and the violations are reported.
+func ResolveOrder(pc config.PluginsConfig) (ChainOrder, error) {
+	co := ChainOrder{Chain: pc.Chain, Violations: orderViolations(pc.Chain)}
+	co.positions = make([]int, len(pc.Chain))
+	for i := range co.positions {
+		co.positions[i] = i
+	}
+	if len(co.Violations) == 0 {
+		return co, nil
+	}
+	positions, err := sortChain(pc.Chain)
+	if err != nil {
+		return co, err
+	}
+	sorted := make([]config.PluginConfig, 0, len(positions))
+	for _, i := range positions {
+		sorted = append(sorted, pc.Chain[i])
+	}
+	co.Suggested = chainNames(sorted)
+	switch {
+	case pc.AutoOrder:
+		co.Chain = sorted
+		co.positions = positions
+		co.Reordered = true
+	case pc.EnforceOrder:
+		return co, fmt.Errorf("plugins.chain order violates: %s (suggested order: %s)",
+			strings.Join(co.Violations, "; "), strings.Join(co.Suggested, ", "))
+	}
+	return co, nil
+}
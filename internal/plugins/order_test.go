@@ -0,0 +1,171 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func TestOrderingDeclarations(t *testing.T) {
+	if cycle := findOrderingCycle(orderings); cycle != nil {
+		t.Fatalf("built-in ordering constraints form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	for _, e := range orderEdges(orderings) {
+		for _, name := range []string{e.before, e.after} {
+			if _, ok := builtins[name]; !ok {
+				t.Errorf("constraint %q names unknown plugin %s", e, name)
+			}
+		}
+	}
+}
+
+func TestFindOrderingCycle(t *testing.T) {
+	decls := map[string]Ordering{
+		"a": {MustRunBefore: []string{"b"}},
+		"b": {MustRunBefore: []string{"c"}},
+		"d": {MustRunAfter: []string{"c"}, MustRunBefore: []string{"b"}},
+	}
+	cycle := findOrderingCycle(decls)
+	want := []string{"b", "c", "d", "b"}
+	if !reflect.DeepEqual(cycle, want) {
+		t.Fatalf("expected cycle %v, got %v", want, cycle)
+	}
+
+	delete(decls, "d")
+	if cycle := findOrderingCycle(decls); cycle != nil {
+		t.Errorf("expected no cycle, got %v", cycle)
+	}
+}
+
+func orderTestGzipConfig() map[string]interface{} {
+	return map[string]interface{}{"level": 6, "min_size": 0, "content_types": []interface{}{"text/plain"}}
+}
+
+// invertedChain lists size_limit ahead of gzip, so the response limit would
+// count compressed bytes
+func invertedChain() []config.PluginConfig {
+	return []config.PluginConfig{
+		{Name: "request-id"},
+		{Name: "size_limit"},
+		{Name: "logging"},
+		{Name: "gzip", Config: orderTestGzipConfig()},
+		{Name: "headers", Config: map[string]interface{}{"set": map[string]interface{}{"X-Test": "1"}}},
+	}
+}
+
+func TestBuildChain_OrderViolationWarns(t *testing.T) {
+	logs := captureLogs(t)
+	pc := config.PluginsConfig{Enabled: true, Chain: invertedChain()}
+
+	if _, err := BuildChain(pc, http.NotFoundHandler()); err != nil {
+		t.Fatalf("expected the chain to build, got %v", err)
+	}
+	if err := Validate(pc); err != nil {
+		t.Fatalf("expected the chain to validate, got %v", err)
+	}
+	order, err := ResolveOrder(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := order.Names(); !reflect.DeepEqual(got, chainNames(pc.Chain)) {
+		t.Errorf("expected the configured order to be kept, got %v", got)
+	}
+
+	var warned bool
+	for _, line := range logs() {
+		if line["level"] == "warn" && strings.Contains(line["message"].(string), "ordering constraints") {
+			warned = true
+			if got := line["suggested_order"]; got != "request-id, logging, gzip, size_limit, headers" {
+				t.Errorf("unexpected suggested order %v", got)
+			}
+			violations, _ := line["violations"].([]interface{})
+			if len(violations) != 1 || violations[0] != "gzip must run before size_limit" {
+				t.Errorf("unexpected violations %v", line["violations"])
+			}
+		}
+	}
+	if !warned {
+		t.Error("expected an ordering warning")
+	}
+}
+
+func TestBuildChain_EnforceOrder(t *testing.T) {
+	pc := config.PluginsConfig{Enabled: true, Chain: invertedChain(), EnforceOrder: true}
+
+	_, err := BuildChain(pc, http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "gzip must run before size_limit") {
+		t.Fatalf("expected an ordering error, got %v", err)
+	}
+	if err := Validate(pc); err == nil {
+		t.Error("expected Validate to reject the chain")
+	}
+
+	pc.Chain = []config.PluginConfig{{Name: "gzip", Config: orderTestGzipConfig()}, {Name: "size_limit"}}
+	if _, err := BuildChain(pc, http.NotFoundHandler()); err != nil {
+		t.Errorf("expected a valid order to build, got %v", err)
+	}
+}
+
+func TestBuildChain_AutoOrder(t *testing.T) {
+	pc := config.PluginsConfig{Enabled: true, Chain: invertedChain(), AutoOrder: true, EnforceOrder: true}
+
+	order, err := ResolveOrder(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"request-id", "logging", "gzip", "size_limit", "headers"}
+	if got := order.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected order %v, got %v", want, got)
+	}
+	if !order.Reordered {
+		t.Error("expected the chain to be marked reordered")
+	}
+	if violations := orderViolations(order.Chain); len(violations) != 0 {
+		t.Errorf("reordered chain still violates %v", violations)
+	}
+
+	// The reordered chain is what serves requests: size_limit now counts the
+	// uncompressed body, which is over its limit even though the gzipped one
+	// would not be
+	for _, tc := range []struct {
+		autoOrder bool
+		wantErr   bool
+	}{
+		{autoOrder: false, wantErr: false},
+		{autoOrder: true, wantErr: true},
+	} {
+		pc := config.PluginsConfig{Enabled: true, AutoOrder: tc.autoOrder, Chain: []config.PluginConfig{
+			{Name: "size_limit", Config: map[string]interface{}{"max_response_body": 100}},
+			{Name: "gzip", Config: orderTestGzipConfig()},
+		}}
+		var writeErr error
+		handler, err := BuildChain(pc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, writeErr = w.Write([]byte(strings.Repeat("a", 2000)))
+		}))
+		if err != nil {
+			t.Fatalf("auto_order=%v: expected the chain to build, got %v", tc.autoOrder, err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if (writeErr != nil) != tc.wantErr {
+			t.Errorf("auto_order=%v: expected write error %v, got %v", tc.autoOrder, tc.wantErr, writeErr)
+		}
+	}
+}
+
+func TestBuildChain_AutoOrderKeepsConfigErrorPositions(t *testing.T) {
+	pc := config.PluginsConfig{Enabled: true, AutoOrder: true, Chain: []config.PluginConfig{
+		{Name: "size_limit", Config: map[string]interface{}{"bogus": 1}},
+		{Name: "gzip", Config: orderTestGzipConfig()},
+	}}
+	_, err := BuildChain(pc, http.NotFoundHandler())
+	if err == nil || !strings.HasPrefix(err.Error(), "plugins.chain[0] (size_limit)") {
+		t.Fatalf("expected the error to name the configured position, got %v", err)
+	}
+}
@@ -2,10 +2,12 @@ package plugins
 
 import (
 	"errors"
-	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
 )
 
 // Middleware represents an HTTP middleware that wraps a handler
@@ -27,31 +29,72 @@ func RegisterBuiltin(name string, f factory) {
 	builtins[name] = f
 }
 
+// Chain is a built plugin chain. Plugins whose handlers hold resources
+// beyond a request, such as stores swept by the shared janitor, implement
+// io.Closer; Close releases them once the chain stops serving.
+type Chain struct {
+	http.Handler
+	closers []io.Closer
+}
+
+// Close releases the resources held by the chain's plugins. It is safe to
+// call on a nil Chain.
+func (c *Chain) Close() error {
+	if c == nil {
+		return nil
+	}
+	var errs []error
+	for _, cl := range c.closers {
+		if err := cl.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
+
 // BuildChain builds the middleware chain from configuration and applies it to base.
-// Order: plugins are applied in the order listed; the first plugin wraps the entire chain.
-func BuildChain(pc config.PluginsConfig, base http.Handler) (http.Handler, error) {
+// Order: plugins are applied in the order listed, or in the order ResolveOrder
+// settles on; the first plugin wraps the entire chain.
+func BuildChain(pc config.PluginsConfig, base http.Handler) (*Chain, error) {
 	if base == nil {
 		return nil, errors.New("base handler is nil")
 	}
 	if !pc.Enabled || len(pc.Chain) == 0 {
-		return base, nil
+		return &Chain{Handler: base}, nil
+	}
+
+	order, err := ResolveOrder(pc)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case order.Reordered:
+		logging.L().Info().
+			Strs("violations", order.Violations).
+			Strs("order", order.Names()).
+			Msg("plugin chain reordered to satisfy ordering constraints")
+	case len(order.Violations) > 0:
+		logging.L().Warn().
+			Strs("violations", order.Violations).
+			Str("suggested_order", strings.Join(order.Suggested, ", ")).
+			Msg("plugin chain order violates ordering constraints; set plugins.auto_order to fix it or plugins.enforce_order to fail instead")
 	}
 
-	h := base
+	chain := &Chain{Handler: base}
 	// Apply in reverse so the first listed becomes the outermost wrapper
-	for i := len(pc.Chain) - 1; i >= 0; i-- {
-		p := pc.Chain[i]
-		f, ok := builtins[p.Name]
-		if !ok {
-			return nil, fmt.Errorf("unknown plugin: %s", p.Name)
-		}
-		mw, err := f(p.Name, p.Config)
+	for i := len(order.Chain) - 1; i >= 0; i-- {
+		mw, err := newPlugin(order.positions[i], order.Chain[i])
 		if err != nil {
-			return nil, fmt.Errorf("plugin %s init failed: %w", p.Name, err)
+			_ = chain.Close()
+			return nil, err
+		}
+		chain.Handler = mw(chain.Handler)
+		if cl, ok := chain.Handler.(io.Closer); ok {
+			chain.closers = append(chain.closers, cl)
 		}
-		h = mw(h)
 	}
-	return h, nil
+	return chain, nil
 }
 
 // List returns the names of available built-in plugins
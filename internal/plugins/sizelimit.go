@@ -118,16 +118,9 @@ func parseByteLimit(cfg map[string]interface{}, key string, defaultValue int64)
 		return defaultValue, nil
 	}
 
-	var limit int64
-	switch v := val.(type) {
-	case int:
-		limit = int64(v)
-	case int64:
-		limit = v
-	case float64:
-		limit = int64(v)
-	default:
-		return 0, fmt.Errorf("%s must be a number, got %T", key, val)
+	limit, err := parseInt(key, val)
+	if err != nil {
+		return 0, err
 	}
 
 	if limit <= 0 {
@@ -140,6 +133,9 @@ func parseByteLimit(cfg map[string]interface{}, key string, defaultValue int64)
 // newSizeLimitMiddleware creates a new size limit middleware with the given configuration
 func newSizeLimitMiddleware(name string, cfg map[string]interface{}) (Middleware, error) {
 	// Parse and validate configuration
+	if err := rejectUnknownKeys(cfg, "max_request_body", "max_response_body"); err != nil {
+		return nil, err
+	}
 	maxRequestBody, err := parseByteLimit(cfg, "max_request_body", DefaultMaxRequestBody)
 	if err != nil {
 		return nil, err
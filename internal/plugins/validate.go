@@ -0,0 +1,182 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func init() {
+	config.RegisterPluginValidator(Validate)
+}
+
+// Validate builds every plugin of an enabled chain without serving traffic so
+// configuration mistakes fail at load rather than at the first request.
+// Errors name the chain entry: "plugins.chain[2] (gzip): ...".
+func Validate(pc config.PluginsConfig) error {
+	if !pc.Enabled {
+		return nil
+	}
+	for i, p := range pc.Chain {
+		if _, err := newPlugin(i, p); err != nil {
+			return err
+		}
+	}
+	_, err := ResolveOrder(pc)
+	return err
+}
+
+// newPlugin runs the factory of chain entry i, prefixing errors with its YAML path
+func newPlugin(i int, p config.PluginConfig) (Middleware, error) {
+	f, ok := builtins[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("plugins.chain[%d]: unknown plugin: %s", i, p.Name)
+	}
+	mw, err := f(p.Name, p.Config)
+	if err != nil {
+		return nil, fmt.Errorf("plugins.chain[%d] (%s): %w", i, p.Name, err)
+	}
+	return mw, nil
+}
+
+// checkUnknownKeys returns the keys of cfg that are not in allowed, sorted
+func checkUnknownKeys(cfg map[string]interface{}, allowed ...string) []string {
+	var unknown []string
+	for k := range cfg {
+		found := false
+		for _, a := range allowed {
+			if k == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// rejectUnknownKeys fails if cfg holds keys outside allowed, so typos don't
+// silently fall back to defaults
+func rejectUnknownKeys(cfg map[string]interface{}, allowed ...string) error {
+	unknown := checkUnknownKeys(cfg, allowed...)
+	if len(unknown) == 0 {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("unknown config key(s) %s: plugin takes no config", strings.Join(unknown, ", "))
+	}
+	return fmt.Errorf("unknown config key(s) %s (allowed: %s)", strings.Join(unknown, ", "), strings.Join(allowed, ", "))
+}
+
+// typeName describes a decoded YAML value for error messages
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64, float64:
+		return "number"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// parseInt converts a numeric option. YAML decodes whole numbers as int while
+// JSON (and hand-built configs) use float64, so both are accepted.
+func parseInt(key string, v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("%s must be a whole number, got %v", key, n)
+		}
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("%s must be a number, got %s", key, typeName(v))
+}
+
+// parseDuration reads an optional positive whole number of units, such as
+// ttl_seconds with unit time.Second
+func parseDuration(cfg map[string]interface{}, key string, defaultValue int64, unit time.Duration) (time.Duration, error) {
+	raw, ok := cfg[key]
+	if !ok {
+		return time.Duration(defaultValue) * unit, nil
+	}
+	n, err := parseInt(key, raw)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %d", key, n)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("%s is too large, got %d", key, n)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// parseString reads an optional non-empty string option
+func parseString(cfg map[string]interface{}, key, defaultValue string) (string, error) {
+	raw, ok := cfg[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string, got %s", key, typeName(raw))
+	}
+	if s == "" {
+		return "", fmt.Errorf("%s must be non-empty", key)
+	}
+	return s, nil
+}
+
+// parseBool reads an optional boolean option
+func parseBool(cfg map[string]interface{}, key string, defaultValue bool) (bool, error) {
+	raw, ok := cfg[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	b, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be a boolean, got %s", key, typeName(raw))
+	}
+	return b, nil
+}
+
+// parseStringList reads an optional list of strings from cfg
+func parseStringList(cfg map[string]interface{}, key string, defaultValue []string) ([]string, error) {
+	raw, ok := cfg[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of strings, got %s", key, typeName(raw))
+	}
+	out := make([]string, 0, len(items))
+	for i, v := range items {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be a string, got %s", key, i, typeName(v))
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
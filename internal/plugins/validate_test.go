@@ -0,0 +1,158 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xReLogic/Helios/internal/config"
+)
+
+func validGzipConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"level":         5,
+		"min_size":      1024,
+		"content_types": []interface{}{"text/html"},
+	}
+}
+
+func TestValidateNamesChainEntry(t *testing.T) {
+	gzipCfg := validGzipConfig()
+	gzipCfg["min_szie"] = 10
+
+	pc := config.PluginsConfig{
+		Enabled: true,
+		Chain: []config.PluginConfig{
+			{Name: "request-id"},
+			{Name: "logging"},
+			{Name: "gzip", Config: gzipCfg},
+		},
+	}
+
+	for name, err := range map[string]error{
+		"Validate":   Validate(pc),
+		"BuildChain": buildChainErr(pc),
+	} {
+		if err == nil {
+			t.Fatalf("%s: expected error for typo'd key", name)
+		}
+		for _, want := range []string{"plugins.chain[2]", "(gzip)", "min_szie"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected error to contain %q, got: %v", name, want, err)
+			}
+		}
+	}
+}
+
+func buildChainErr(pc config.PluginsConfig) error {
+	_, err := BuildChain(pc, simpleOKHandler())
+	return err
+}
+
+func TestValidateSkipsDisabledChain(t *testing.T) {
+	pc := config.PluginsConfig{Chain: []config.PluginConfig{{Name: "no_such_plugin"}}}
+	if err := Validate(pc); err != nil {
+		t.Fatalf("expected disabled chain to be ignored, got: %v", err)
+	}
+}
+
+func TestBuiltinsRejectBadConfig(t *testing.T) {
+	tests := []struct {
+		plugin string
+		cfg    map[string]interface{}
+		errMsg string
+	}{
+		{"gzip", map[string]interface{}{"min_size": 1, "content_types": []interface{}{}}, "level is required"},
+		{"gzip", map[string]interface{}{"level": "fast", "min_size": 1, "content_types": []interface{}{}}, "level must be a number, got string"},
+		{"gzip", map[string]interface{}{"level": 5, "min_size": 1.5, "content_types": []interface{}{}}, "min_size must be a whole number"},
+		{"gzip", map[string]interface{}{"level": 5, "min_size": 1, "content_types": "text/html"}, "content_types must be a list of strings, got string"},
+		{"etag", map[string]interface{}{"content_types": []interface{}{"text/css", 3}}, "content_types[1] must be a string, got number"},
+		{"etag", map[string]interface{}{"maxsize": 10}, "unknown config key(s) maxsize"},
+		{"custom-auth", map[string]interface{}{}, "apiKey is required"},
+		{"custom-auth", map[string]interface{}{"apiKey": ""}, "apiKey must be non-empty"},
+		{"custom-auth", map[string]interface{}{"apiKey": 1234}, "apiKey must be a string, got number"},
+		{"request-id", map[string]interface{}{"header": "X-Trace"}, "plugin takes no config"},
+		{"headers", map[string]interface{}{"set": []interface{}{"X-App"}}, "set must be a map of header names to strings, got list"},
+		{"headers", map[string]interface{}{"request_set": map[string]interface{}{"X-Num": 1}}, "request_set.X-Num must be a string, got number"},
+		{"headers", map[string]interface{}{"remove": []interface{}{"Server"}}, "unknown config key(s) remove"},
+		{"logging", map[string]interface{}{"level": "debug"}, "unknown config key(s) level"},
+		{"logging", map[string]interface{}{"replace_core_log": "yes"}, "replace_core_log must be a boolean, got string"},
+		{"logging", map[string]interface{}{"fields": map[string]interface{}{"status": "x"}}, "fields.status conflicts with a built-in log field"},
+		{"mime_guard", map[string]interface{}{"enforce": true}, "paths is required when enforce is true"},
+		{"mime_guard", map[string]interface{}{"enforce": true, "paths": []interface{}{"uploads/"}}, `path "uploads/" must start with /`},
+		{"mime_guard", map[string]interface{}{"action": "block"}, `action must be attachment or override, got "block"`},
+		{"size_limit", map[string]interface{}{"max_request_body": "1MB"}, "max_request_body must be a number, got string"},
+		{"size_limit", map[string]interface{}{"max_body": 10}, "unknown config key(s) max_body"},
+		{"idempotency", map[string]interface{}{"header": true}, "header must be a string, got boolean"},
+		{"idempotency", map[string]interface{}{"ttl": 60}, "unknown config key(s) ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.plugin+"/"+tt.errMsg, func(t *testing.T) {
+			err := Validate(config.PluginsConfig{
+				Enabled: true,
+				Chain:   []config.PluginConfig{{Name: tt.plugin, Config: tt.cfg}},
+			})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got: %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestCheckUnknownKeys(t *testing.T) {
+	cfg := map[string]interface{}{"b": 1, "a": 2, "keep": 3}
+	if got := checkUnknownKeys(cfg, "keep"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", got)
+	}
+	if got := checkUnknownKeys(cfg, "a", "b", "keep"); len(got) != 0 {
+		t.Errorf("expected no unknown keys, got %v", got)
+	}
+}
+
+// TestLoadConfigValidatesPlugins checks that a bad chain fails at load and
+// that YAML-decoded numbers (int rather than float64) are accepted
+func TestLoadConfigValidatesPlugins(t *testing.T) {
+	const base = `
+server:
+  port: 8080
+backends:
+  - name: app
+    address: http://localhost:8081
+load_balancer:
+  strategy: round_robin
+plugins:
+  enabled: true
+  chain:
+    - name: request-id
+    - name: gzip
+      config:
+        level: 5
+        min_size: 1024
+        content_types: ["text/html"]
+`
+	write := func(yaml string) string {
+		path := filepath.Join(t.TempDir(), "helios.yaml")
+		if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		return path
+	}
+
+	if _, err := config.LoadConfig(write(base)); err != nil {
+		t.Fatalf("expected valid config to load, got: %v", err)
+	}
+
+	_, err := config.LoadConfig(write(strings.Replace(base, "level: 5", "levle: 5", 1)))
+	if err == nil {
+		t.Fatal("expected config with a typo'd gzip key to fail")
+	}
+	if !strings.Contains(err.Error(), "plugins.chain[1] (gzip)") || !strings.Contains(err.Error(), "levle") {
+		t.Errorf("expected error naming the chain entry and key, got: %v", err)
+	}
+}
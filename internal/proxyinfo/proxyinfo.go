@@ -0,0 +1,133 @@
+// Package proxyinfo carries per-request proxying details between the load
+// balancer and the plugins wrapped around it.
+package proxyinfo
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// SyntheticHeader marks requests generated in-process by synthetic checks
+	SyntheticHeader = "X-Helios-Synthetic"
+
+	// SyntheticRemoteAddr is the client address of synthetic requests. No real
+	// connection has port 0, so a client cannot pass for a synthetic check by
+	// sending the header alone.
+	SyntheticRemoteAddr = "127.0.0.1:0"
+)
+
+// IsSynthetic reports whether r was generated by a synthetic check
+func IsSynthetic(r *http.Request) bool {
+	return r.RemoteAddr == SyntheticRemoteAddr && r.Header.Get(SyntheticHeader) == "true"
+}
+
+// Info describes how a request was proxied. It belongs to a single request
+// and is only touched by the goroutine serving it.
+type Info struct {
+	Backend         string        // Backend that served the request; empty if none was available
+	Retries         int           // Extra backends tried after the first pick
+	UpstreamLatency time.Duration // Time spent waiting on the backend that served the request
+	CostLabel       string        // cost_attribution label the request is billed to, if configured
+
+	// SuppressCoreLog tells the load balancer not to emit its own
+	// "request completed" line because a plugin logs the request instead
+	SuppressCoreLog bool
+}
+
+type infoKey struct{}
+
+// NewContext returns ctx carrying info
+func NewContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// FromContext returns the Info attached to ctx, or nil if there is none
+func FromContext(ctx context.Context) *Info {
+	info, _ := ctx.Value(infoKey{}).(*Info)
+	return info
+}
+
+// SetBackend records the backend chosen for the request
+func (i *Info) SetBackend(name string, retries int) {
+	if i == nil {
+		return
+	}
+	i.Backend = name
+	i.Retries = retries
+}
+
+// SetUpstreamLatency records how long the backend took to respond
+func (i *Info) SetUpstreamLatency(d time.Duration) {
+	if i == nil {
+		return
+	}
+	i.UpstreamLatency = d
+}
+
+// Writer records the status code and body size of a response
+type Writer struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// Wrap returns a Writer recording w's response. If w already is one, it is
+// returned as is so stacked middleware share a single wrapper.
+func Wrap(w http.ResponseWriter) *Writer {
+	if sw, ok := w.(*Writer); ok {
+		return sw
+	}
+	return &Writer{ResponseWriter: w}
+}
+
+// WriteHeader records the status code
+func (sw *Writer) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the bytes written, implying a 200 status if none was set
+func (sw *Writer) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the response status, 200 if none was written
+func (sw *Writer) Status() int {
+	if !sw.wroteHeader {
+		return http.StatusOK
+	}
+	return sw.status
+}
+
+// BytesWritten returns the number of body bytes written
+func (sw *Writer) BytesWritten() int64 {
+	return sw.bytes
+}
+
+// Hijack supports websockets if the underlying writer does
+func (sw *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := sw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Flush forwards to the underlying writer if it supports flushing
+func (sw *Writer) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
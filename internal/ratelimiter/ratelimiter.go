@@ -1,10 +1,12 @@
 package ratelimiter
 
 import (
+	"math"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/0xReLogic/Helios/internal/store"
 	"github.com/0xReLogic/Helios/internal/utils"
 )
 
@@ -13,12 +15,16 @@ type RateLimiter interface {
 	Allow(clientIP string) bool
 }
 
+// bucketIdleTTL is the least time a client's bucket is kept after it was
+// created or last refilled
+const bucketIdleTTL = time.Hour
+
 // TokenBucketRateLimiter implements a token bucket rate limiter with optimized concurrency
 type TokenBucketRateLimiter struct {
-	maxTokens   int           // Maximum number of tokens in the bucket
-	refillRate  time.Duration // Rate at which tokens are refilled
-	buckets     sync.Map      // Use sync.Map for better concurrent access
-	cleanupTick time.Duration
+	maxTokens  int           // Maximum number of tokens in the bucket
+	refillRate time.Duration // Rate at which tokens are refilled
+	buckets    store.Store   // *bucket by client key, expiring once idle
+	bucketTTL  time.Duration // How long an idle bucket is kept; 0 keeps it forever
 }
 
 // bucket represents a token bucket for a specific client with lock-free fast path
@@ -30,17 +36,33 @@ type bucket struct {
 
 // NewTokenBucketRateLimiter creates a new token bucket rate limiter
 func NewTokenBucketRateLimiter(maxTokens int, refillRate time.Duration) *TokenBucketRateLimiter {
-	rl := &TokenBucketRateLimiter{
-		maxTokens:   maxTokens,
-		refillRate:  refillRate,
-		buckets:     sync.Map{},       // sync.Map doesn't need initialization
-		cleanupTick: time.Minute * 10, // Clean up old buckets every 10 minutes
-	}
+	return newTokenBucketRateLimiter(maxTokens, refillRate, store.NewMemory(store.Options{}))
+}
 
-	// Start cleanup routine
-	go rl.cleanupRoutine()
+// newTokenBucketRateLimiter creates a rate limiter keeping its buckets in buckets
+func newTokenBucketRateLimiter(maxTokens int, refillRate time.Duration, buckets store.Store) *TokenBucketRateLimiter {
+	return &TokenBucketRateLimiter{
+		maxTokens:  maxTokens,
+		refillRate: refillRate,
+		buckets:    buckets,
+		bucketTTL:  bucketTTL(maxTokens, refillRate),
+	}
+}
 
-	return rl
+// bucketTTL returns how long an idle bucket must be kept. An expired bucket is
+// recreated full, so it is kept at least until an emptied bucket would have
+// refilled; dropping it sooner would hand a throttled client fresh tokens.
+func bucketTTL(maxTokens int, refillRate time.Duration) time.Duration {
+	if maxTokens <= 0 || refillRate <= 0 {
+		return bucketIdleTTL
+	}
+	if int64(maxTokens) > math.MaxInt64/int64(refillRate) {
+		return 0
+	}
+	if full := time.Duration(maxTokens) * refillRate; full > bucketIdleTTL {
+		return full
+	}
+	return bucketIdleTTL
 }
 
 // Allow checks if a request from the given client IP is allowed with optimized locking
@@ -48,24 +70,26 @@ func (rl *TokenBucketRateLimiter) Allow(clientIP string) bool {
 	b := rl.getOrCreateBucket(clientIP)
 
 	b.mutex.Lock()
-	defer b.mutex.Unlock()
-
-	rl.refillTokens(b)
+	refilled := rl.refillTokens(b)
 
 	// Check if we have tokens available
-	if b.tokens > 0 {
+	allowed := b.tokens > 0
+	if allowed {
 		b.tokens--
-		return true
 	}
+	b.mutex.Unlock()
 
-	return false
+	// A refill marks the bucket as in use; keep it for another idle period
+	if refilled {
+		rl.buckets.Set(clientIP, b, rl.bucketTTL)
+	}
+	return allowed
 }
 
 // getOrCreateBucket retrieves or creates a bucket for the client IP
 func (rl *TokenBucketRateLimiter) getOrCreateBucket(clientIP string) *bucket {
-	// Fast path: try to load existing bucket without locking
-	value, exists := rl.buckets.Load(clientIP)
-	if exists {
+	// Fast path: the bucket already exists
+	if value, exists := rl.buckets.Get(clientIP); exists {
 		return value.(*bucket)
 	}
 
@@ -75,13 +99,14 @@ func (rl *TokenBucketRateLimiter) getOrCreateBucket(clientIP string) *bucket {
 		lastRefill: time.Now(),
 	}
 
-	// LoadOrStore ensures only one goroutine creates the bucket
-	actual, _ := rl.buckets.LoadOrStore(clientIP, newBucket)
+	// GetOrSet ensures only one goroutine creates the bucket
+	actual, _ := rl.buckets.GetOrSet(clientIP, newBucket, rl.bucketTTL)
 	return actual.(*bucket)
 }
 
-// refillTokens refills tokens based on time elapsed (must be called with bucket locked)
-func (rl *TokenBucketRateLimiter) refillTokens(b *bucket) {
+// refillTokens refills tokens based on time elapsed and reports whether any
+// were added (must be called with bucket locked)
+func (rl *TokenBucketRateLimiter) refillTokens(b *bucket) bool {
 	now := time.Now()
 	elapsed := now.Sub(b.lastRefill)
 	tokensToAdd := int(elapsed / rl.refillRate)
@@ -92,38 +117,9 @@ func (rl *TokenBucketRateLimiter) refillTokens(b *bucket) {
 			b.tokens = rl.maxTokens
 		}
 		b.lastRefill = now
+		return true
 	}
-}
-
-// cleanupRoutine removes old buckets that haven't been used recently
-func (rl *TokenBucketRateLimiter) cleanupRoutine() {
-	ticker := time.NewTicker(rl.cleanupTick)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.cleanup()
-	}
-}
-
-// cleanup removes buckets that haven't been used for more than 1 hour
-func (rl *TokenBucketRateLimiter) cleanup() {
-	now := time.Now()
-	cutoff := now.Add(-time.Hour)
-
-	// Use sync.Map's Range method for iteration
-	rl.buckets.Range(func(key, value interface{}) bool {
-		ip := key.(string)
-		b := value.(*bucket)
-
-		b.mutex.Lock()
-		shouldDelete := b.lastRefill.Before(cutoff)
-		b.mutex.Unlock()
-
-		if shouldDelete {
-			rl.buckets.Delete(ip)
-		}
-		return true // continue iteration
-	})
+	return false
 }
 
 // RateLimitMiddleware wraps an http.Handler with rate limiting
@@ -1,6 +1,7 @@
 package ratelimiter
 
 import (
+	"math"
 	"net/http"
 	"testing"
 	"time"
@@ -88,6 +89,27 @@ func TestTokenBucketRefill(t *testing.T) {
 	}
 }
 
+func TestBucketTTLCoversFullRefill(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxTokens  int
+		refillRate time.Duration
+		want       time.Duration
+	}{
+		{"fast refill keeps the idle minimum", 10, time.Second, bucketIdleTTL},
+		{"slow refill keeps the bucket until it is full again", 3, time.Hour, 3 * time.Hour},
+		{"overflowing refill never expires", math.MaxInt32, math.MaxInt64 / 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewTokenBucketRateLimiter(tt.maxTokens, tt.refillRate)
+			if rl.bucketTTL != tt.want {
+				t.Errorf("expected bucket TTL %s, got %s", tt.want, rl.bucketTTL)
+			}
+		})
+	}
+}
+
 // TestGetClientIP tests IP extraction from various HTTP headers
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
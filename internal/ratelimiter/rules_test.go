@@ -0,0 +1,118 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRule(t *testing.T, opts RuleOptions) *Rule {
+	t.Helper()
+	if opts.RefillRate == 0 {
+		opts.RefillRate = time.Hour // no refill during the test
+	}
+	rule, err := NewRule(opts)
+	if err != nil {
+		t.Fatalf("NewRule(%s): %v", opts.Name, err)
+	}
+	return rule
+}
+
+func newRuleRequest(method, path, remoteAddr string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestRuleMatches(t *testing.T) {
+	rule := newTestRule(t, RuleOptions{
+		Name: "expensive", MaxTokens: 1,
+		PathPrefixes: []string{"/expensive", "/reports/"},
+		Methods:      []string{"get", "POST"},
+	})
+
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodGet, "/expensive", true},
+		{http.MethodPost, "/expensive/query", true},
+		{http.MethodGet, "/reports/q3", true},
+		{http.MethodGet, "/cheap", false},
+		{http.MethodDelete, "/expensive", false},
+	}
+	for _, tt := range tests {
+		if got := rule.Matches(newRuleRequest(tt.method, tt.path, testRemoteAddr)); got != tt.want {
+			t.Errorf("Matches(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	all := newTestRule(t, RuleOptions{Name: "all", MaxTokens: 1})
+	if !all.Matches(newRuleRequest(http.MethodPatch, "/anything", testRemoteAddr)) {
+		t.Error("expected a rule without match conditions to match every request")
+	}
+}
+
+func TestRuleKeys(t *testing.T) {
+	rule := newTestRule(t, RuleOptions{Name: "tenant", Key: "ip+header:x-tenant", MaxTokens: 1})
+
+	req := newRuleRequest(http.MethodGet, "/", testRemoteAddr)
+	req.Header.Set("X-Tenant", "a")
+	if !rule.Allow(req) {
+		t.Fatal("first request for tenant a should be allowed")
+	}
+	if rule.Allow(req) {
+		t.Fatal("second request for tenant a should be limited")
+	}
+
+	// Same IP, different tenant: separate bucket
+	req.Header.Set("X-Tenant", "b")
+	if !rule.Allow(req) {
+		t.Fatal("tenant b should have its own bucket")
+	}
+	if got := rule.keyOf(req); got != "10.0.0.1|b" {
+		t.Errorf("expected composite key 10.0.0.1|b, got %q", got)
+	}
+
+	for _, spec := range []string{"cookie", "header:", "ip+", "IP"} {
+		if _, err := NewRule(RuleOptions{Name: "bad", Key: spec, MaxTokens: 1, RefillRate: time.Second}); err == nil {
+			t.Errorf("expected key spec %q to be rejected", spec)
+		}
+	}
+}
+
+func TestRuleSetFirstFailingRule(t *testing.T) {
+	perIP := newTestRule(t, RuleOptions{Name: "per-ip", MaxTokens: 3})
+	expensive := newTestRule(t, RuleOptions{Name: "expensive", MaxTokens: 1, PathPrefixes: []string{"/expensive"}})
+	rs := NewRuleSet(perIP, expensive)
+
+	if rule := rs.Check(newRuleRequest(http.MethodGet, "/expensive", testRemoteAddr)); rule != nil {
+		t.Fatalf("first request should pass, rejected by %s", rule.Name)
+	}
+	if rule := rs.Check(newRuleRequest(http.MethodGet, "/expensive", testRemoteAddr)); rule == nil || rule.Name != "expensive" {
+		t.Fatalf("expected the scoped rule to reject, got %v", rule)
+	}
+
+	// The scoped rule is skipped for other paths; the per-IP rule then runs out
+	if rule := rs.Check(newRuleRequest(http.MethodGet, "/cheap", testRemoteAddr)); rule != nil {
+		t.Fatalf("non-matching request should skip the scoped rule, rejected by %s", rule.Name)
+	}
+	if rule := rs.Check(newRuleRequest(http.MethodGet, "/cheap", testRemoteAddr)); rule == nil || rule.Name != "per-ip" {
+		t.Fatalf("expected the per-IP rule to reject, got %v", rule)
+	}
+}
+
+func TestRuleBucketsReported(t *testing.T) {
+	rule := newTestRule(t, RuleOptions{Name: "per-ip", MaxTokens: 2})
+	defer rule.Close()
+
+	rule.Allow(newRuleRequest(http.MethodGet, "/", testRemoteAddr))
+	rule.Allow(newRuleRequest(http.MethodGet, "/", testRemoteAddr))
+	rule.Allow(newRuleRequest(http.MethodGet, "/", "10.0.0.2:1234"))
+
+	// Each new client misses once, then finds its bucket
+	if st := rule.Buckets().Stats(); st.Size != 2 || st.Misses != 2 || st.Hits != 1 {
+		t.Errorf("expected 2 buckets, 2 misses and 1 hit, got %+v", st)
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultJanitorInterval is how often the shared janitor removes expired
+// entries that nobody accessed
+const defaultJanitorInterval = time.Minute
+
+// sharedJanitor sweeps every Memory store created by NewMemory
+var sharedJanitor = newJanitor(defaultJanitorInterval)
+
+// janitor periodically sweeps a set of stores from one goroutine, which runs
+// only while at least one store is registered
+type janitor struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	stores map[*Memory]struct{}
+	stop   chan struct{} // Closed to stop the goroutine; nil while it isn't running
+}
+
+func newJanitor(interval time.Duration) *janitor {
+	return &janitor{interval: interval, stores: make(map[*Memory]struct{})}
+}
+
+// add registers a store, starting the goroutine for the first one
+func (j *janitor) add(m *Memory) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stores[m] = struct{}{}
+	if j.stop == nil {
+		j.stop = make(chan struct{})
+		go j.run(j.stop)
+	}
+}
+
+// remove forgets a store, stopping the goroutine after the last one
+func (j *janitor) remove(m *Memory) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.stores, m)
+	if len(j.stores) == 0 && j.stop != nil {
+		close(j.stop)
+		j.stop = nil
+	}
+}
+
+func (j *janitor) run(stop chan struct{}) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			j.sweep()
+		}
+	}
+}
+
+// sweep removes expired entries from every registered store. Stores are
+// swept outside the janitor lock so they can be added and closed meanwhile.
+func (j *janitor) sweep() {
+	j.mu.Lock()
+	stores := make([]*Memory, 0, len(j.stores))
+	for m := range j.stores {
+		stores = append(stores, m)
+	}
+	j.mu.Unlock()
+	for _, m := range stores {
+		m.sweep()
+	}
+}
@@ -0,0 +1,336 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultShards is the number of shards used when Options.Shards is unset
+const defaultShards = 16
+
+// Options configures a Memory store
+type Options struct {
+	// Shards splits the store so concurrent users of different keys rarely
+	// contend (0 = 16). Size bounds apply per shard, split evenly, so LRU
+	// order is only exact with one shard.
+	Shards int
+	// MaxEntries bounds the number of entries (0 = unbounded)
+	MaxEntries int
+	// MaxCost bounds the total Cost of the entries (0 = unbounded)
+	MaxCost int64
+	// Cost weighs an entry toward MaxCost, e.g. by its size in bytes.
+	// Required with MaxCost; it must give the same result for a value
+	// every time.
+	Cost func(value interface{}) int64
+	// OnEvict is called for entries that expire or are evicted
+	OnEvict EvictFunc
+}
+
+// Memory is an in-process Store. Each shard is an LRU list guarded by its own
+// mutex. Expired entries are dropped when accessed and by a janitor goroutine
+// shared by all Memory stores.
+type Memory struct {
+	shards     []*shard
+	maxEntries int   // Per shard; 0 = unbounded
+	maxCost    int64 // Per shard; 0 = unbounded
+	cost       func(value interface{}) int64
+	onEvict    EvictFunc
+	now        func() time.Time
+	janitor    *janitor
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+var (
+	_ Store    = (*Memory)(nil)
+	_ Reporter = (*Memory)(nil)
+)
+
+// shard is one LRU partition of a Memory store
+type shard struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List // Most recently used at the front
+	cost  int64
+}
+
+// entry is a stored value
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time // Zero when the entry doesn't expire
+	cost    int64
+}
+
+// evicted is an entry removed under a shard lock, reported after unlocking
+type evicted struct {
+	key    string
+	value  interface{}
+	reason EvictReason
+}
+
+// NewMemory creates an in-memory store swept by the shared janitor. Close
+// it when done so the janitor forgets it.
+func NewMemory(opts Options) *Memory {
+	return newMemory(opts, sharedJanitor)
+}
+
+func newMemory(opts Options, j *janitor) *Memory {
+	shards := opts.Shards
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	m := &Memory{
+		shards:     make([]*shard, shards),
+		maxEntries: perShard(int64(opts.MaxEntries), shards),
+		cost:       opts.Cost,
+		onEvict:    opts.OnEvict,
+		now:        time.Now,
+		janitor:    j,
+	}
+	if opts.Cost != nil {
+		m.maxCost = int64(perShard(opts.MaxCost, shards))
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard{items: make(map[string]*list.Element), lru: list.New()}
+	}
+	j.add(m)
+	return m
+}
+
+// perShard splits a bound across shards, rounding up so the total is never
+// below the configured bound
+func perShard(bound int64, shards int) int {
+	if bound <= 0 {
+		return 0
+	}
+	return int((bound + int64(shards) - 1) / int64(shards))
+}
+
+// shardFor picks a key's shard by its FNV-1a hash
+func (m *Memory) shardFor(key string) *shard {
+	if len(m.shards) == 1 {
+		return m.shards[0]
+	}
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return m.shards[h%uint32(len(m.shards))]
+}
+
+// expiry returns when an entry set now with ttl expires
+func (m *Memory) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
+
+// expired reports whether e has passed its expiry at now
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// Get returns the value of a live entry
+func (m *Memory) Get(key string) (interface{}, bool) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		m.misses.Add(1)
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if e.expired(m.now()) {
+		s.removeLocked(el)
+		s.mu.Unlock()
+		m.misses.Add(1)
+		m.report([]evicted{{e.key, e.value, EvictedExpired}})
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	value := e.value
+	s.mu.Unlock()
+	m.hits.Add(1)
+	return value, true
+}
+
+// Set stores value under key, replacing any existing entry
+func (m *Memory) Set(key string, value interface{}, ttl time.Duration) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	m.putLocked(s, key, value, ttl)
+	out := m.trimLocked(s)
+	s.mu.Unlock()
+	m.report(out)
+}
+
+// GetOrSet returns the live value under key, or stores value if there is
+// none. Finding the key counts as a hit; storing counts as neither, so a Get
+// miss followed by GetOrSet counts one miss.
+func (m *Memory) GetOrSet(key string, value interface{}, ttl time.Duration) (interface{}, bool) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	var out []evicted
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*entry)
+		if !e.expired(m.now()) {
+			s.lru.MoveToFront(el)
+			actual := e.value
+			s.mu.Unlock()
+			m.hits.Add(1)
+			return actual, true
+		}
+		s.removeLocked(el)
+		out = append(out, evicted{e.key, e.value, EvictedExpired})
+	}
+	m.putLocked(s, key, value, ttl)
+	out = append(out, m.trimLocked(s)...)
+	s.mu.Unlock()
+	m.report(out)
+	return value, false
+}
+
+// putLocked inserts or replaces an entry at the front of the shard's LRU.
+// Caller must hold s.mu.
+func (m *Memory) putLocked(s *shard, key string, value interface{}, ttl time.Duration) {
+	var cost int64
+	if m.cost != nil {
+		cost = m.cost(value)
+	}
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*entry)
+		s.cost += cost - e.cost
+		e.value, e.expires, e.cost = value, m.expiry(ttl), cost
+		s.lru.MoveToFront(el)
+		return
+	}
+	e := &entry{key: key, value: value, expires: m.expiry(ttl), cost: cost}
+	s.items[key] = s.lru.PushFront(e)
+	s.cost += cost
+}
+
+// trimLocked evicts least recently used entries until the shard is within
+// its bounds. Caller must hold s.mu.
+func (m *Memory) trimLocked(s *shard) []evicted {
+	var out []evicted
+	for (m.maxEntries > 0 && s.lru.Len() > m.maxEntries) || (m.maxCost > 0 && s.cost > m.maxCost) {
+		back := s.lru.Back()
+		if back == nil {
+			break
+		}
+		e := back.Value.(*entry)
+		s.removeLocked(back)
+		out = append(out, evicted{e.key, e.value, EvictedCapacity})
+	}
+	return out
+}
+
+// removeLocked unlinks an entry. Caller must hold s.mu.
+func (s *shard) removeLocked(el *list.Element) {
+	e := el.Value.(*entry)
+	delete(s.items, e.key)
+	s.lru.Remove(el)
+	s.cost -= e.cost
+}
+
+// report counts removed entries and passes them to OnEvict
+func (m *Memory) report(out []evicted) {
+	for _, ev := range out {
+		if ev.reason == EvictedExpired {
+			m.expirations.Add(1)
+		} else {
+			m.evictions.Add(1)
+		}
+		if m.onEvict != nil {
+			m.onEvict(ev.key, ev.value, ev.reason)
+		}
+	}
+}
+
+// Delete removes key and reports whether it was present
+func (m *Memory) Delete(key string) bool {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if ok {
+		s.removeLocked(el)
+	}
+	return ok
+}
+
+// Len returns the number of entries across all shards
+func (m *Memory) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Range calls fn for each live entry, one shard at a time. fn runs without
+// the shard lock held, so it may use the store.
+func (m *Memory) Range(fn func(key string, value interface{}) bool) {
+	var batch []entry
+	for _, s := range m.shards {
+		now := m.now()
+		batch = batch[:0]
+		s.mu.Lock()
+		for _, el := range s.items {
+			if e := el.Value.(*entry); !e.expired(now) {
+				batch = append(batch, *e)
+			}
+		}
+		s.mu.Unlock()
+		for _, e := range batch {
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// sweep removes the entries that have expired
+func (m *Memory) sweep() {
+	for _, s := range m.shards {
+		now := m.now()
+		var out []evicted
+		s.mu.Lock()
+		for _, el := range s.items {
+			if e := el.Value.(*entry); e.expired(now) {
+				s.removeLocked(el)
+				out = append(out, evicted{e.key, e.value, EvictedExpired})
+			}
+		}
+		s.mu.Unlock()
+		m.report(out)
+	}
+}
+
+// Close stops the janitor from sweeping the store
+func (m *Memory) Close() error {
+	m.janitor.remove(m)
+	return nil
+}
+
+// Stats returns the store's size and counters
+func (m *Memory) Stats() Stats {
+	return Stats{
+		Size:        m.Len(),
+		Hits:        m.hits.Load(),
+		Misses:      m.misses.Load(),
+		Evictions:   m.evictions.Load(),
+		Expirations: m.expirations.Load(),
+	}
+}
@@ -0,0 +1,304 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for TTL tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// newTestMemory creates a store on a fake clock, swept only when the test
+// calls sweep
+func newTestMemory(t *testing.T, opts Options) (*Memory, *fakeClock) {
+	t.Helper()
+	m := newMemory(opts, newJanitor(time.Hour))
+	t.Cleanup(func() { _ = m.Close() })
+	clock := &fakeClock{now: time.Now()}
+	m.now = clock.Now
+	return m, clock
+}
+
+// recordEvictions returns an EvictFunc collecting "key:reason" strings
+func recordEvictions() (EvictFunc, func() []string) {
+	var mu sync.Mutex
+	var got []string
+	return func(key string, _ interface{}, reason EvictReason) {
+			mu.Lock()
+			got = append(got, key+":"+reason.String())
+			mu.Unlock()
+		}, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), got...)
+		}
+}
+
+func TestMemory_TTL(t *testing.T) {
+	onEvict, evictions := recordEvictions()
+	m, clock := newTestMemory(t, Options{OnEvict: onEvict})
+
+	m.Set("session", "a", time.Minute)
+	m.Set("forever", "b", 0)
+	if v, ok := m.Get("session"); !ok || v != "a" {
+		t.Fatalf("expected a live entry, got %v, %v", v, ok)
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := m.Get("session"); ok {
+		t.Error("expected the entry to expire after its TTL")
+	}
+	if _, ok := m.Get("forever"); !ok {
+		t.Error("expected an entry without TTL to stay")
+	}
+	if got := evictions(); len(got) != 1 || got[0] != "session:expired" {
+		t.Errorf("expected one expiry callback, got %v", got)
+	}
+
+	// Set replaces the TTL; an expired key can be taken over by GetOrSet
+	m.Set("session", "c", time.Minute)
+	clock.Advance(30 * time.Second)
+	m.Set("session", "d", time.Minute)
+	clock.Advance(45 * time.Second)
+	if v, ok := m.Get("session"); !ok || v != "d" {
+		t.Fatalf("expected Set to renew the TTL, got %v, %v", v, ok)
+	}
+	clock.Advance(time.Minute)
+	if v, loaded := m.GetOrSet("session", "e", time.Minute); loaded || v != "e" {
+		t.Errorf("expected GetOrSet to replace an expired entry, got %v, %v", v, loaded)
+	}
+
+	st := m.Stats()
+	if st.Size != 2 || st.Hits != 3 || st.Misses != 1 || st.Expirations != 2 || st.Evictions != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestMemory_LRUBound(t *testing.T) {
+	onEvict, evictions := recordEvictions()
+	m, _ := newTestMemory(t, Options{Shards: 1, MaxEntries: 3, OnEvict: onEvict})
+
+	for _, key := range []string{"a", "b", "c"} {
+		m.Set(key, key, 0)
+	}
+	m.Get("a") // b is now the least recently used
+	m.Set("d", "d", 0)
+	m.Set("e", "e", 0)
+
+	if m.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", m.Len())
+	}
+	for _, key := range []string{"a", "d", "e"} {
+		if _, ok := m.Get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+	if got := evictions(); len(got) != 2 || got[0] != "b:capacity" || got[1] != "c:capacity" {
+		t.Errorf("expected b then c to be evicted, got %v", got)
+	}
+	if st := m.Stats(); st.Evictions != 2 {
+		t.Errorf("expected 2 evictions counted, got %+v", st)
+	}
+}
+
+func TestMemory_CostBound(t *testing.T) {
+	m, _ := newTestMemory(t, Options{
+		Shards:  1,
+		MaxCost: 10,
+		Cost:    func(v interface{}) int64 { return int64(len(v.(string))) },
+	})
+
+	m.Set("a", "xxxx", 0)
+	m.Set("b", "xxxx", 0)
+	m.Set("c", "xxxx", 0) // 12 > 10: a goes
+	if _, ok := m.Get("a"); ok {
+		t.Error("expected the oldest entry to be evicted for cost")
+	}
+	// Replacing an entry re-weighs it
+	m.Set("b", "x", 0)
+	m.Set("d", "xxxx", 0)
+	if m.Len() != 3 {
+		t.Errorf("expected b, c and d to fit after b shrank, got %d entries", m.Len())
+	}
+	// An entry over the whole bound doesn't fit at all
+	m.Set("huge", "xxxxxxxxxxxx", 0)
+	if _, ok := m.Get("huge"); ok || m.Len() != 0 {
+		t.Errorf("expected an oversized entry to evict everything including itself, got %d entries", m.Len())
+	}
+}
+
+func TestMemory_BoundSplitAcrossShards(t *testing.T) {
+	m, _ := newTestMemory(t, Options{Shards: 4, MaxEntries: 100})
+	for i := 0; i < 1000; i++ {
+		m.Set(fmt.Sprintf("key-%d", i), i, 0)
+	}
+	if n := m.Len(); n > 100 || n < 50 {
+		t.Errorf("expected about 100 entries across shards, got %d", n)
+	}
+}
+
+func TestMemory_GetOrSetDeleteRange(t *testing.T) {
+	m, clock := newTestMemory(t, Options{})
+
+	if v, loaded := m.GetOrSet("k", 1, 0); loaded || v != 1 {
+		t.Fatalf("expected the value to be stored, got %v, %v", v, loaded)
+	}
+	if v, loaded := m.GetOrSet("k", 2, 0); !loaded || v != 1 {
+		t.Fatalf("expected the existing value, got %v, %v", v, loaded)
+	}
+
+	m.Set("short", 3, time.Second)
+	m.Set("other", 4, 0)
+	clock.Advance(time.Second)
+	seen := make(map[string]interface{})
+	m.Range(func(key string, value interface{}) bool {
+		seen[key] = value
+		return true
+	})
+	if len(seen) != 2 || seen["k"] != 1 || seen["other"] != 4 {
+		t.Errorf("expected Range to visit the live entries, got %v", seen)
+	}
+	visits := 0
+	m.Range(func(string, interface{}) bool {
+		visits++
+		return false
+	})
+	if visits != 1 {
+		t.Errorf("expected Range to stop when fn returns false, got %d visits", visits)
+	}
+
+	if !m.Delete("k") || m.Delete("k") {
+		t.Error("expected Delete to report presence once")
+	}
+	if _, ok := m.Get("k"); ok {
+		t.Error("expected a deleted key to be gone")
+	}
+}
+
+func TestMemory_ConcurrentAccess(t *testing.T) {
+	m, _ := newTestMemory(t, Options{MaxEntries: 64})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := fmt.Sprintf("key-%d", (g*31+i)%200)
+				switch i % 5 {
+				case 0:
+					m.Set(key, i, time.Millisecond)
+				case 1:
+					m.GetOrSet(key, i, 0)
+				case 2:
+					m.Delete(key)
+				case 3:
+					m.Range(func(string, interface{}) bool { return true })
+				default:
+					m.Get(key)
+				}
+			}
+		}(g)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			m.sweep()
+			_ = m.Stats()
+		}
+	}()
+	wg.Wait()
+
+	if n := m.Len(); n > 64+defaultShards {
+		t.Errorf("expected the bound to hold under concurrency, got %d entries", n)
+	}
+}
+
+func TestJanitor_SweepsExpiredEntries(t *testing.T) {
+	j := newJanitor(5 * time.Millisecond)
+	onEvict, evictions := recordEvictions()
+	m := newMemory(Options{OnEvict: onEvict}, j)
+	defer m.Close()
+
+	m.Set("stale", 1, 10*time.Millisecond)
+	m.Set("fresh", 2, time.Hour)
+
+	// Nobody reads the entry again; the janitor removes it
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the janitor to remove the expired entry, %d entries left", m.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := evictions(); len(got) != 1 || got[0] != "stale:expired" {
+		t.Errorf("expected an expiry callback for the swept entry, got %v", got)
+	}
+	if st := m.Stats(); st.Expirations != 1 || st.Misses != 0 {
+		t.Errorf("unexpected stats after sweep: %+v", st)
+	}
+}
+
+func TestJanitor_RunsWhileStoresRegistered(t *testing.T) {
+	j := newJanitor(time.Hour)
+	a := newMemory(Options{}, j)
+	b := newMemory(Options{}, j)
+
+	running := func() bool {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		return j.stop != nil
+	}
+	if !running() {
+		t.Fatal("expected the janitor to run once a store is registered")
+	}
+	_ = a.Close()
+	if !running() {
+		t.Fatal("expected the janitor to keep running for the remaining store")
+	}
+	_ = b.Close()
+	if running() {
+		t.Fatal("expected the janitor to stop after the last store closed")
+	}
+
+	c := newMemory(Options{}, j)
+	defer c.Close()
+	if !running() {
+		t.Fatal("expected the janitor to restart for a new store")
+	}
+}
+
+func TestNull(t *testing.T) {
+	var s Store = Null{}
+	s.Set("k", 1, 0)
+	if _, ok := s.Get("k"); ok {
+		t.Error("expected the null store to keep nothing")
+	}
+	if v, loaded := s.GetOrSet("k", 2, 0); loaded || v != 2 {
+		t.Errorf("expected GetOrSet to hand back the value, got %v, %v", v, loaded)
+	}
+	if s.Len() != 0 || s.Delete("k") {
+		t.Error("expected the null store to be empty")
+	}
+	s.Range(func(string, interface{}) bool {
+		t.Error("expected Range to visit nothing")
+		return true
+	})
+}
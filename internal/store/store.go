@@ -0,0 +1,107 @@
+// Package store holds dynamic state shared across requests, such as rate
+// limit buckets and idempotency records, behind a small key-value interface.
+// Features get TTL expiry, bounded size and metrics from one implementation
+// instead of each keeping its own map and cleanup goroutine.
+package store
+
+import "time"
+
+// EvictReason says why an entry left a store without being deleted
+type EvictReason int
+
+const (
+	// EvictedExpired means the entry's TTL passed
+	EvictedExpired EvictReason = iota + 1
+	// EvictedCapacity means the entry was the least recently used when the
+	// store went over its size bound
+	EvictedCapacity
+)
+
+// String returns the reason as used in logs
+func (r EvictReason) String() string {
+	switch r {
+	case EvictedExpired:
+		return "expired"
+	case EvictedCapacity:
+		return "capacity"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictFunc is called after an entry expires or is evicted for capacity, and
+// never for Delete or an overwriting Set. It runs without the store's locks
+// held, so it may use the store.
+type EvictFunc func(key string, value interface{}, reason EvictReason)
+
+// Store is a key-value store with per-entry expiry. The operations map onto
+// those of a remote store like Redis (GET, SET EX, SET NX, DEL, SCAN), so
+// state can later move out of process. In-process stores keep values as is;
+// a remote store would require values it can encode.
+type Store interface {
+	// Get returns the value of a live entry. A hit makes the entry the most
+	// recently used.
+	Get(key string) (interface{}, bool)
+	// Set stores value under key, replacing any existing entry. A ttl <= 0
+	// means the entry doesn't expire.
+	Set(key string, value interface{}, ttl time.Duration)
+	// GetOrSet returns the live value under key if there is one, otherwise
+	// stores value. loaded reports whether the value was already there.
+	GetOrSet(key string, value interface{}, ttl time.Duration) (actual interface{}, loaded bool)
+	// Delete removes key and reports whether it was present
+	Delete(key string) bool
+	// Len returns the number of entries, including expired entries that
+	// have not been removed yet
+	Len() int
+	// Range calls fn for each live entry until fn returns false. Entries
+	// added or removed during the call may or may not be visited.
+	Range(fn func(key string, value interface{}) bool)
+	// Close releases the store's resources; it must not be used afterwards
+	Close() error
+}
+
+// Stats are a store's counters, reported under stores in the metrics JSON
+type Stats struct {
+	Size        int    `json:"size"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`   // Removed to stay within the size bound
+	Expirations uint64 `json:"expirations"` // Removed after their TTL
+}
+
+// Reporter is a store that reports Stats
+type Reporter interface {
+	Stats() Stats
+}
+
+// Null is a Store that keeps nothing: every Get misses. It stands in for a
+// real store in tests of code that must cope with lost state.
+type Null struct{}
+
+var _ Store = Null{}
+
+// Get always misses
+func (Null) Get(string) (interface{}, bool) { return nil, false }
+
+// Set discards the value
+func (Null) Set(string, interface{}, time.Duration) {}
+
+// GetOrSet returns value without keeping it
+func (Null) GetOrSet(_ string, value interface{}, _ time.Duration) (interface{}, bool) {
+	return value, false
+}
+
+// Delete reports the key absent
+func (Null) Delete(string) bool { return false }
+
+// Len is always 0
+func (Null) Len() int { return 0 }
+
+// Range visits nothing
+func (Null) Range(func(string, interface{}) bool) {}
+
+// Close does nothing
+func (Null) Close() error { return nil }
+
+// Stats are always zero
+func (Null) Stats() Stats { return Stats{} }
@@ -0,0 +1,223 @@
+package synthetics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/logging"
+	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/proxyinfo"
+)
+
+const (
+	// HeaderName marks requests generated by synthetic checks so backends and
+	// metrics consumers can exclude them from billing and reporting
+	HeaderName = proxyinfo.SyntheticHeader
+
+	// DefaultMaxConcurrency bounds how many checks may execute at the same time
+	DefaultMaxConcurrency = 4
+
+	// syntheticRemoteAddr is the client address synthetic requests appear to come from
+	syntheticRemoteAddr = proxyinfo.SyntheticRemoteAddr
+)
+
+// Result is the outcome of a single synthetic check execution
+type Result struct {
+	Name    string
+	Passed  bool
+	Status  int
+	Latency time.Duration
+	Reason  string // Failed assertion, empty when the check passed
+}
+
+// Runner periodically executes synthetic checks against an in-process handler.
+// Requests travel the full proxy path (plugins, rate limiting, load balancing)
+// without going through the network listener.
+type Runner struct {
+	handler http.Handler
+	host    string // Host of checks that do not set a Host header
+	checks  []config.SyntheticCheckConfig
+	mc      *metrics.MetricsCollector
+	sem     chan struct{}
+	gate    func() bool // Scheduled runs are skipped while it returns false; nil runs always
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// DefaultHost returns the Host synthetic checks are addressed to when they
+// do not set one: the first server.allowed_hosts entry, so the request target
+// guard accepts them, or else the server's own listen address
+func DefaultHost(server config.ServerConfig) string {
+	if len(server.AllowedHosts) > 0 {
+		return server.AllowedHosts[0]
+	}
+	return net.JoinHostPort("localhost", strconv.Itoa(server.Port))
+}
+
+// NewRunner creates a synthetic check runner for the given handler. Checks
+// without a Host header are addressed to host.
+func NewRunner(cfg config.SyntheticsConfig, host string, handler http.Handler, mc *metrics.MetricsCollector) *Runner {
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		handler: handler,
+		host:    host,
+		checks:  cfg.Checks,
+		mc:      mc,
+		sem:     make(chan struct{}, maxConcurrency),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetGate makes scheduled runs conditional, e.g. on this instance leading
+// the cluster. It must be called before Start.
+func (r *Runner) SetGate(gate func() bool) {
+	r.gate = gate
+}
+
+// Start launches one scheduler goroutine per configured check
+func (r *Runner) Start() {
+	for _, check := range r.checks {
+		r.wg.Add(1)
+		go r.schedule(check)
+	}
+	logging.L().Info().Int("checks", len(r.checks)).Int("max_concurrency", cap(r.sem)).Msg("synthetic monitoring enabled")
+}
+
+// Stop cancels all scheduled checks and waits for in-flight runs to finish
+func (r *Runner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// schedule runs a check immediately and then on every interval tick
+func (r *Runner) schedule(check config.SyntheticCheckConfig) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(check.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		r.runWithLimit(check)
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runWithLimit executes a check once the global concurrency cap allows it
+func (r *Runner) runWithLimit(check config.SyntheticCheckConfig) {
+	if r.gate != nil && !r.gate() {
+		logging.L().Debug().Str("check", check.Name).Msg("synthetic check skipped; not the cluster leader")
+		return
+	}
+	select {
+	case r.sem <- struct{}{}:
+	case <-r.ctx.Done():
+		return
+	}
+	defer func() { <-r.sem }()
+
+	r.Run(check)
+}
+
+// Run executes a single check, records its result and returns it
+func (r *Runner) Run(check config.SyntheticCheckConfig) Result {
+	result := Execute(r.ctx, r.handler, r.host, check)
+
+	if r.mc != nil {
+		r.mc.RecordSyntheticResult(result.Name, result.Passed, result.Latency, result.Reason)
+	}
+
+	if !result.Passed {
+		logging.L().Warn().
+			Str("check", result.Name).
+			Int("status", result.Status).
+			Dur("latency", result.Latency).
+			Str("assertion", result.Reason).
+			Msg("synthetic check failed")
+	}
+	return result
+}
+
+// Execute sends the check's request through handler and evaluates its
+// assertions. The request is addressed to host unless the check sets a Host header.
+func Execute(ctx context.Context, handler http.Handler, host string, check config.SyntheticCheckConfig) Result {
+	method := check.Request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	body := strings.NewReader(check.Request.Body)
+	req, err := http.NewRequestWithContext(ctx, method, check.Request.Path, body)
+	if err != nil {
+		return Result{Name: check.Name, Reason: fmt.Sprintf("invalid request: %v", err)}
+	}
+	// Fill in what the server would have set for a request read off the wire
+	req.RequestURI = check.Request.Path
+	req.Host = host
+	req.RemoteAddr = syntheticRemoteAddr
+	for k, v := range check.Request.Headers {
+		req.Header.Set(k, v)
+	}
+	// A Host header addresses the request, e.g. to a server.allowed_hosts entry
+	if h := req.Header.Get("Host"); h != "" {
+		req.Host = h
+	}
+	req.Header.Set(HeaderName, "true")
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+	latency := time.Since(start)
+
+	result := Result{
+		Name:    check.Name,
+		Status:  rec.Code,
+		Latency: latency,
+	}
+	result.Reason = evaluate(check.Assertions, rec, latency)
+	result.Passed = result.Reason == ""
+	return result
+}
+
+// evaluate returns a description of the first failed assertion, or "" if all passed
+func evaluate(a config.SyntheticAssertionConfig, rec *httptest.ResponseRecorder, latency time.Duration) string {
+	expectedStatus := a.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if rec.Code != expectedStatus {
+		return fmt.Sprintf("expected status %d, got %d", expectedStatus, rec.Code)
+	}
+
+	if a.MaxLatencyMs > 0 {
+		maxLatency := time.Duration(a.MaxLatencyMs) * time.Millisecond
+		if latency > maxLatency {
+			return fmt.Sprintf("latency %s exceeded max %s", latency, maxLatency)
+		}
+	}
+
+	if a.BodyContains != "" && !strings.Contains(rec.Body.String(), a.BodyContains) {
+		return fmt.Sprintf("body does not contain %q", a.BodyContains)
+	}
+	return ""
+}
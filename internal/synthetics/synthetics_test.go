@@ -0,0 +1,300 @@
+package synthetics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xReLogic/Helios/internal/config"
+	"github.com/0xReLogic/Helios/internal/loadbalancer"
+	"github.com/0xReLogic/Helios/internal/metrics"
+	"github.com/0xReLogic/Helios/internal/plugins"
+)
+
+// testHost is the Host checks without a Host header are addressed to
+const testHost = "localhost:8080"
+
+func init() {
+	// Test-only plugin that replaces the response body
+	plugins.RegisterBuiltin("test-body-rewrite", func(name string, cfg map[string]interface{}) (plugins.Middleware, error) {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rec := httptest.NewRecorder()
+				next.ServeHTTP(rec, r)
+				w.WriteHeader(rec.Code)
+				_, _ = w.Write([]byte("rewritten"))
+			})
+		}, nil
+	})
+}
+
+// newEchoBackend starts a backend that echoes the request path and body
+func newEchoBackend(t *testing.T, seenSynthetic *atomic.Bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if seenSynthetic != nil && r.Header.Get(HeaderName) == "true" {
+			seenSynthetic.Store(true)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var buf bytes.Buffer
+		buf.WriteString("echo " + r.URL.Path + " ")
+		buf.Write(body)
+		_, _ = w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newHandler(t *testing.T, backendURL string, chain []config.PluginConfig) (http.Handler, *metrics.MetricsCollector) {
+	t.Helper()
+	cfg := &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends:     []config.BackendConfig{{Name: "echo", Address: backendURL}},
+	}
+	lb, err := loadbalancer.NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	handler, err := plugins.BuildChain(config.PluginsConfig{Enabled: len(chain) > 0, Chain: chain}, lb)
+	if err != nil {
+		t.Fatalf("failed to build chain: %v", err)
+	}
+	return handler, lb.GetMetricsCollector()
+}
+
+func echoCheck() config.SyntheticCheckConfig {
+	return config.SyntheticCheckConfig{
+		Name:            "echo-body",
+		IntervalSeconds: 1,
+		Request: config.SyntheticRequestConfig{
+			Method: http.MethodPost,
+			Path:   "/synthetic",
+			Body:   "ping",
+		},
+		Assertions: config.SyntheticAssertionConfig{
+			ExpectedStatus: http.StatusOK,
+			MaxLatencyMs:   5000,
+			BodyContains:   "echo /synthetic ping",
+		},
+	}
+}
+
+func TestSyntheticCheckPasses(t *testing.T) {
+	var seenSynthetic atomic.Bool
+	srv := newEchoBackend(t, &seenSynthetic)
+	handler, mc := newHandler(t, srv.URL, nil)
+
+	runner := NewRunner(config.SyntheticsConfig{Checks: []config.SyntheticCheckConfig{echoCheck()}}, testHost, handler, mc)
+	result := runner.Run(echoCheck())
+
+	if !result.Passed {
+		t.Fatalf("expected check to pass, failed with: %s", result.Reason)
+	}
+	if !seenSynthetic.Load() {
+		t.Errorf("expected backend to see %s header", HeaderName)
+	}
+
+	sm := mc.GetMetrics().SyntheticMetrics["echo-body"]
+	if sm == nil || sm.Runs != 1 || sm.Passes != 1 || !sm.LastPassed {
+		t.Fatalf("unexpected synthetic metrics: %+v", sm)
+	}
+}
+
+func TestSyntheticCheckFailsWhenPluginRewritesBody(t *testing.T) {
+	srv := newEchoBackend(t, nil)
+	handler, mc := newHandler(t, srv.URL, []config.PluginConfig{{Name: "test-body-rewrite"}})
+
+	runner := NewRunner(config.SyntheticsConfig{}, testHost, handler, mc)
+	result := runner.Run(echoCheck())
+
+	if result.Passed {
+		t.Fatal("expected check to fail when body is rewritten")
+	}
+	if !strings.Contains(result.Reason, "body does not contain") {
+		t.Errorf("unexpected failure reason: %s", result.Reason)
+	}
+
+	sm := mc.GetMetrics().SyntheticMetrics["echo-body"]
+	if sm == nil || sm.Failures != 1 || sm.LastPassed {
+		t.Fatalf("unexpected synthetic metrics: %+v", sm)
+	}
+	if sm.LastFailureReason != result.Reason {
+		t.Errorf("expected recorded reason %q, got %q", result.Reason, sm.LastFailureReason)
+	}
+}
+
+func TestSyntheticCheckStatusAssertion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	check := echoCheck()
+	result := Execute(context.Background(), handler, testHost, check)
+	if result.Passed || !strings.Contains(result.Reason, "expected status 200, got 503") {
+		t.Fatalf("expected status assertion failure, got %+v", result)
+	}
+}
+
+func TestRunnerSchedulesChecks(t *testing.T) {
+	srv := newEchoBackend(t, nil)
+	handler, mc := newHandler(t, srv.URL, nil)
+
+	runner := NewRunner(config.SyntheticsConfig{MaxConcurrency: 1, Checks: []config.SyntheticCheckConfig{echoCheck()}}, testHost, handler, mc)
+	runner.Start()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if sm := mc.GetMetrics().SyntheticMetrics["echo-body"]; sm != nil && sm.Runs > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	runner.Stop()
+
+	if sm := mc.GetMetrics().SyntheticMetrics["echo-body"]; sm == nil || sm.Runs == 0 {
+		t.Fatal("expected scheduler to run the check at least once")
+	}
+}
+
+func TestRunnerGateSkipsScheduledRuns(t *testing.T) {
+	srv := newEchoBackend(t, nil)
+	handler, mc := newHandler(t, srv.URL, nil)
+
+	check := echoCheck()
+	check.IntervalSeconds = 1
+	var leading atomic.Bool
+	var asked atomic.Int64
+	runner := NewRunner(config.SyntheticsConfig{Checks: []config.SyntheticCheckConfig{check}}, testHost, handler, mc)
+	runner.SetGate(func() bool {
+		asked.Add(1)
+		return leading.Load()
+	})
+	runner.Start()
+
+	// The first run is due immediately; the gate holds it back
+	deadline := time.Now().Add(time.Second)
+	for asked.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sm := mc.GetMetrics().SyntheticMetrics["echo-body"]; sm != nil && sm.Runs > 0 {
+		t.Fatal("expected the gated run to be skipped")
+	}
+
+	leading.Store(true)
+	deadline = time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if sm := mc.GetMetrics().SyntheticMetrics["echo-body"]; sm != nil && sm.Runs > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	runner.Stop()
+
+	if sm := mc.GetMetrics().SyntheticMetrics["echo-body"]; sm == nil || sm.Runs == 0 {
+		t.Fatal("expected the check to run once the gate opened")
+	}
+}
+
+func TestSyntheticCheckHostHeader(t *testing.T) {
+	var host string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		_, _ = io.WriteString(w, "echo /synthetic ping")
+	})
+	check := echoCheck()
+	check.Request.Headers = map[string]string{"Host": "www.example.com"}
+	if result := Execute(context.Background(), handler, testHost, check); !result.Passed {
+		t.Fatalf("expected check to pass, failed with: %s", result.Reason)
+	}
+	if host != "www.example.com" {
+		t.Errorf("expected the request addressed to www.example.com, got %q", host)
+	}
+}
+
+func TestDefaultHost(t *testing.T) {
+	if got := DefaultHost(config.ServerConfig{Port: 8080}); got != "localhost:8080" {
+		t.Errorf("expected the listen address without allowed_hosts, got %q", got)
+	}
+	server := config.ServerConfig{Port: 8080, AllowedHosts: []string{"app.example.com", "www.example.com"}}
+	if got := DefaultHost(server); got != "app.example.com" {
+		t.Errorf("expected the first allowed host, got %q", got)
+	}
+}
+
+func TestSyntheticCheckPassesAllowedHosts(t *testing.T) {
+	srv := newEchoBackend(t, nil)
+	cfg := &config.Config{
+		Server:       config.ServerConfig{Port: 8080, AllowedHosts: []string{"app.example.com"}},
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends:     []config.BackendConfig{{Name: "echo", Address: srv.URL}},
+	}
+	lb, err := loadbalancer.NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+
+	runner := NewRunner(config.SyntheticsConfig{}, DefaultHost(cfg.Server), lb, lb.GetMetricsCollector())
+	if result := runner.Run(echoCheck()); !result.Passed {
+		t.Fatalf("expected check without a Host to pass allowed_hosts, failed with: %s", result.Reason)
+	}
+}
+
+func TestSyntheticCheckInvalidRequestFails(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called for an invalid request")
+	})
+	check := echoCheck()
+	check.Request.Method = "BAD METHOD"
+	result := Execute(context.Background(), handler, testHost, check)
+	if result.Passed || !strings.Contains(result.Reason, "invalid request") {
+		t.Fatalf("expected invalid request failure, got %+v", result)
+	}
+}
+
+func TestSyntheticCheckSkipsTrafficMetricsAndCosts(t *testing.T) {
+	srv := newEchoBackend(t, nil)
+	cfg := &config.Config{
+		LoadBalancer: config.LoadBalancerConfig{Strategy: "round_robin"},
+		Backends:     []config.BackendConfig{{Name: "echo", Address: srv.URL}},
+		CostAttribution: config.CostAttributionConfig{
+			Rules: []config.CostRule{{Label: "api", PathPrefixes: []string{"/"}}},
+		},
+	}
+	lb, err := loadbalancer.NewLoadBalancer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create lb: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	mc := lb.GetMetricsCollector()
+
+	if result := NewRunner(config.SyntheticsConfig{}, testHost, lb, mc).Run(echoCheck()); !result.Passed {
+		t.Fatalf("expected check to pass, failed with: %s", result.Reason)
+	}
+
+	m := mc.GetMetrics()
+	if m.TotalRequests != 0 {
+		t.Errorf("expected synthetic request left out of total_requests, got %d", m.TotalRequests)
+	}
+	if bm := m.BackendMetrics["echo"]; bm != nil && bm.TotalRequests != 0 {
+		t.Errorf("expected synthetic request left out of backend metrics, got %d", bm.TotalRequests)
+	}
+	if cm := m.CostMetrics["api"]; cm != nil && cm.Requests != 0 {
+		t.Errorf("expected synthetic request left out of cost metrics, got %d", cm.Requests)
+	}
+
+	// A client sending the header itself is still counted
+	req := httptest.NewRequest(http.MethodGet, "/synthetic", nil)
+	req.Header.Set(HeaderName, "true")
+	lb.ServeHTTP(httptest.NewRecorder(), req)
+	if m := mc.GetMetrics(); m.TotalRequests != 1 || m.CostMetrics["api"] == nil || m.CostMetrics["api"].Requests != 1 {
+		t.Errorf("expected a client-sent header to be counted, got total %d costs %+v", m.TotalRequests, m.CostMetrics["api"])
+	}
+}
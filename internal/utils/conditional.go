@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONResponse serves read-only JSON snapshots to pollers. The response
+// carries an ETag and a matching If-None-Match is answered with 304 Not
+// Modified and no body. A ?fields= query parameter of comma-separated dotted
+// paths keeps only those fields.
+type JSONResponse struct {
+	Indent string // Indentation of the body; empty writes compact JSON
+}
+
+// Serve writes v, or the requested fields of it, with a strong ETag hashed
+// from the body
+func (jr JSONResponse) Serve(w http.ResponseWriter, r *http.Request, v interface{}) {
+	paths, err := ParseFields(r.URL.Query().Get("fields"), reflect.TypeOf(v))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := encodeFields(v, paths)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	etag := ETag(body)
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	jr.write(w, body)
+}
+
+// ServeVersioned writes the value snapshot returns, or the requested fields
+// of it, with a weak ETag derived from version and the field list. version
+// must change whenever the snapshot does, apart from fields like uptime that
+// change on their own. A request whose If-None-Match matches is answered
+// without calling snapshot, so an unchanged poll costs no encoding. t is the
+// type snapshot returns, used to check the field list.
+func (jr JSONResponse) ServeVersioned(w http.ResponseWriter, r *http.Request, version uint64, t reflect.Type, snapshot func() interface{}) {
+	paths, err := ParseFields(r.URL.Query().Get("fields"), t)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	etag := versionETag(version, paths)
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := encodeFields(snapshot(), paths)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	jr.write(w, body)
+}
+
+// write sends an encoded JSON body
+func (jr JSONResponse) write(w http.ResponseWriter, body []byte) {
+	var out bytes.Buffer
+	if jr.Indent != "" {
+		_ = json.Indent(&out, body, "", jr.Indent)
+	} else {
+		out.Write(body)
+	}
+	out.WriteByte('\n')
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(out.Bytes())
+}
+
+// encodeFields marshals v, reduced to paths when there are any
+func encodeFields(v interface{}, paths []string) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil || len(paths) == 0 {
+		return body, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return json.Marshal(SelectFields(reflect.TypeOf(v), tree, paths))
+}
+
+// ETag returns a strong entity tag for a response body
+func ETag(body []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(body)
+	return fmt.Sprintf(`"%016x"`, h.Sum64())
+}
+
+// versionETag returns a weak entity tag for a snapshot version and the
+// fields selected from it. The fields are sorted since their order doesn't
+// change the selection.
+func versionETag(version uint64, paths []string) string {
+	if len(paths) == 0 {
+		return fmt.Sprintf(`W/"%016x"`, version)
+	}
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join(sorted, ",")))
+	return fmt.Sprintf(`W/"%016x-%016x"`, version, h.Sum64())
+}
+
+// ETagMatches reports whether an If-None-Match header value lists etag,
+// using the weak comparison RFC 9110 prescribes for If-None-Match
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFields splits a comma-separated list of dotted field paths and checks
+// each names a field of the JSON encoding of t. Path segments are json field
+// names; maps and slices are passed through, so "backends.healthy" names the
+// healthy field of every element of backends. Below free-form values
+// (interface{} or map[string]interface{}) segments are object keys.
+func ParseFields(list string, t reflect.Type) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var paths []string
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if err := checkFieldPath(t, strings.Split(path, ".")); err != nil {
+			return nil, fmt.Errorf("unknown field %q: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// checkFieldPath reports whether segs name a field below t
+func checkFieldPath(t reflect.Type, segs []string) error {
+	for len(segs) > 0 {
+		if freeForm(t) {
+			// Anything may be below it
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		case reflect.Struct:
+			field, ok := jsonField(t, segs[0])
+			if !ok {
+				return fmt.Errorf("no field %q", segs[0])
+			}
+			t = field.Type
+			segs = segs[1:]
+		default:
+			return fmt.Errorf("%q has no fields", segs[0])
+		}
+	}
+	return nil
+}
+
+// jsonField finds the struct field encoded under name
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// SelectFields reduces tree, the decoded JSON encoding of a value of type t,
+// to the given paths, which must have been checked with ParseFields
+func SelectFields(t reflect.Type, tree interface{}, paths []string) interface{} {
+	var out interface{}
+	for _, path := range paths {
+		out = mergeTrees(out, selectPath(t, tree, strings.Split(path, ".")))
+	}
+	return out
+}
+
+// selectPath keeps the part of node along segs
+func selectPath(t reflect.Type, node interface{}, segs []string) interface{} {
+	if len(segs) == 0 || node == nil {
+		return node
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if freeForm(t) {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return map[string]interface{}{segs[0]: selectPath(t, obj[segs[0]], segs[1:])}
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		obj, _ := node.(map[string]interface{})
+		out := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			out[k] = selectPath(t.Elem(), v, segs)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		list, _ := node.([]interface{})
+		out := make([]interface{}, len(list))
+		for i, v := range list {
+			out[i] = selectPath(t.Elem(), v, segs)
+		}
+		return out
+	case reflect.Struct:
+		obj, _ := node.(map[string]interface{})
+		out := make(map[string]interface{})
+		field, _ := jsonField(t, segs[0])
+		if v, ok := obj[segs[0]]; ok {
+			out[segs[0]] = selectPath(field.Type, v, segs[1:])
+		}
+		return out
+	}
+	return node
+}
+
+// freeForm reports whether values of t have no fixed shape, so paths below
+// them follow object keys
+func freeForm(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface || (t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Interface)
+}
+
+// mergeTrees combines two selections of the same value
+func mergeTrees(a, b interface{}) interface{} {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return a
+		}
+		for k, v := range bv {
+			av[k] = mergeTrees(av[k], v)
+		}
+		return av
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(bv) != len(av) {
+			return a
+		}
+		for i := range av {
+			av[i] = mergeTrees(av[i], bv[i])
+		}
+		return av
+	case nil:
+		return b
+	}
+	return a
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fieldsTestItem struct {
+	Name    string            `json:"name"`
+	Healthy bool              `json:"healthy"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	hidden  int
+}
+
+type fieldsTestDoc struct {
+	Total  uint64                     `json:"total"`
+	Items  []fieldsTestItem           `json:"items"`
+	ByName map[string]*fieldsTestItem `json:"by_name"`
+	Extra  map[string]interface{}     `json:"extra"`
+	Plain  string
+}
+
+func TestParseFields(t *testing.T) {
+	typ := reflect.TypeOf(fieldsTestDoc{})
+	valid := []string{"total", "items.name", "by_name.healthy", "extra.anything.below", "Plain", "items.labels"}
+	for _, path := range valid {
+		if _, err := ParseFields(path, typ); err != nil {
+			t.Errorf("%s: unexpected error %v", path, err)
+		}
+	}
+	invalid := []string{"totals", "items.hidden", "total.x", "by_name.server1", "items.labels.x.y"}
+	for _, path := range invalid {
+		if _, err := ParseFields(path, typ); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+	if paths, err := ParseFields(" total , ,items.name ", typ); err != nil || !reflect.DeepEqual(paths, []string{"total", "items.name"}) {
+		t.Errorf("unexpected paths %v (%v)", paths, err)
+	}
+}
+
+func TestJSONResponse_Fields(t *testing.T) {
+	doc := fieldsTestDoc{
+		Total:  3,
+		Items:  []fieldsTestItem{{Name: "a", Healthy: true}, {Name: "b"}},
+		ByName: map[string]*fieldsTestItem{"a": {Name: "a", Healthy: true}},
+		Extra:  map[string]interface{}{"nested": map[string]interface{}{"x": 1, "y": 2}},
+	}
+	rec := httptest.NewRecorder()
+	JSONResponse{}.Serve(rec, httptest.NewRequest(http.MethodGet, "/?fields=total,items.name,by_name.healthy,extra.nested.x", nil), doc)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	want := map[string]interface{}{
+		"total":   float64(3),
+		"items":   []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}},
+		"by_name": map[string]interface{}{"a": map[string]interface{}{"healthy": true}},
+		"extra":   map[string]interface{}{"nested": map[string]interface{}{"x": float64(1)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestJSONResponse_ETag(t *testing.T) {
+	jr := JSONResponse{Indent: "  "}
+	serve := func(doc fieldsTestDoc, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		jr.Serve(rec, req, doc)
+		return rec
+	}
+
+	first := serve(fieldsTestDoc{Total: 1, Plain: "t1"}, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("expected 200 with a strong ETag, got %d %q", first.Code, etag)
+	}
+	if rec := serve(fieldsTestDoc{Total: 1, Plain: "t1"}, etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+	if rec := serve(fieldsTestDoc{Total: 1, Plain: "t1"}, `"other", W/`+etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected a weak match in a list to give 304, got %d", rec.Code)
+	}
+	if rec := serve(fieldsTestDoc{Total: 1, Plain: "t2"}, etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("expected 200 with a new ETag, got %d", rec.Code)
+	}
+}
+
+func TestJSONResponse_ServeVersioned(t *testing.T) {
+	jr := JSONResponse{}
+	snapshots := 0
+	serve := func(version uint64, target, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		jr.ServeVersioned(rec, req, version, reflect.TypeOf(fieldsTestDoc{}), func() interface{} {
+			snapshots++
+			return fieldsTestDoc{Total: version}
+		})
+		return rec
+	}
+
+	first := serve(1, "/", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || snapshots != 1 {
+		t.Fatalf("expected 200 with a weak ETag, got %d %q after %d snapshots", first.Code, etag, snapshots)
+	}
+	if rec := serve(1, "/", etag); rec.Code != http.StatusNotModified || snapshots != 1 {
+		t.Errorf("expected 304 without taking a snapshot, got %d after %d snapshots", rec.Code, snapshots)
+	}
+	if rec := serve(2, "/", etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("expected 200 with a new ETag for a new version, got %d", rec.Code)
+	}
+
+	// The field list is part of the tag, in any order
+	fields := serve(1, "/?fields=total,items.name", "").Header().Get("ETag")
+	if fields == etag {
+		t.Error("expected selected fields to get their own ETag")
+	}
+	if rec := serve(1, "/?fields=items.name,total", fields); rec.Code != http.StatusNotModified {
+		t.Errorf("expected reordered fields to match, got %d", rec.Code)
+	}
+	if rec := serve(1, "/?fields=bogus", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown field, got %d", rec.Code)
+	}
+}
@@ -1,11 +1,124 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
 )
 
+// TrustedProxies is the set of proxies whose X-Forwarded-For and X-Real-IP
+// headers are believed. The zero value trusts no one.
+type TrustedProxies struct {
+	nets []*net.IPNet
+}
+
+// ParseTrustedProxies parses server.trusted_proxies, a list of CIDRs or
+// single IPs
+func ParseTrustedProxies(cidrs []string) (TrustedProxies, error) {
+	var tp TrustedProxies
+	for _, cidr := range cidrs {
+		ipNet, err := ParseCIDR(cidr)
+		if err != nil {
+			return TrustedProxies{}, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		tp.nets = append(tp.nets, ipNet)
+	}
+	return tp, nil
+}
+
+// ClientIP resolves the address of the client behind r. The forwarding
+// headers are only honoured when the peer is a trusted proxy, and
+// X-Forwarded-For is read from the right, skipping trusted hops, so clients
+// can't pick their own address. With no trusted proxies it is always the
+// peer address.
+func (tp TrustedProxies) ClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+
+	if !tp.trusts(remote) {
+		return remote
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// Garbage can't be attributed; stop at the last address we trust
+				break
+			}
+			client = hop
+			if !tp.trusts(hop) {
+				break
+			}
+		}
+		return client
+	}
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(xri) != nil {
+		return xri
+	}
+	return remote
+}
+
+// IsEmpty reports whether no proxies are trusted
+func (tp TrustedProxies) IsEmpty() bool {
+	return len(tp.nets) == 0
+}
+
+// TrustsPeer reports whether the peer that sent r is a trusted proxy, whose
+// forwarding headers such as X-Forwarded-Proto may be believed
+func (tp TrustedProxies) TrustsPeer(r *http.Request) bool {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+	return tp.trusts(remote)
+}
+
+// trusts reports whether ip falls within one of the trusted networks
+func (tp TrustedProxies) trusts(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range tp.nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCIDR parses a CIDR notation or single IP address
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	// Check if it's already in CIDR notation
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err == nil {
+		return ipNet, nil
+	}
+
+	// Try parsing as a single IP address
+	ip := net.ParseIP(cidr)
+	if ip == nil {
+		return nil, err // Return original CIDR parse error
+	}
+
+	// Convert single IP to CIDR notation
+	if ip.To4() != nil {
+		// IPv4
+		_, ipNet, _ = net.ParseCIDR(cidr + "/32")
+	} else {
+		// IPv6
+		_, ipNet, _ = net.ParseCIDR(cidr + "/128")
+	}
+
+	return ipNet, nil
+}
+
 // GetClientIP extracts the real client IP address from an HTTP request.
 // It checks headers in order of priority: X-Forwarded-For, X-Real-IP, RemoteAddr.
 // X-Forwarded-For format: "client, proxy1, proxy2, ..." - extracts first IP only
@@ -28,3 +141,32 @@ func GetClientIP(r *http.Request) string {
 	}
 	return r.RemoteAddr
 }
+
+// ErrorResponse is the JSON body written for proxy-generated errors.
+// Code is a stable machine-readable identifier clients can match on.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WriteError writes a JSON error response with the given status and code.
+// The code is also exposed in the X-Helios-Error header so it survives
+// plugins that rewrite or discard the body.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Helios-Error", code)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: message})
+}
+
+// NormalizeHost splits hostport into a lowercased host without IPv6
+// brackets or a trailing dot, and its port, which is empty when absent
+func NormalizeHost(hostport string) (host, port string) {
+	host = hostport
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	return host, port
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -84,3 +86,168 @@ func TestGetClientIP(t *testing.T) {
 		})
 	}
 }
+
+// TestTrustedProxies_ClientIP checks forwarding headers are only honoured
+// from trusted proxies
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		xff        string
+		xri        string
+		remoteAddr string
+		expected   string
+	}{
+		{
+			name:       "forged XFF from untrusted peer",
+			xff:        "1.2.3.4",
+			remoteAddr: "203.0.113.195:1234",
+			expected:   "203.0.113.195",
+		},
+		{
+			name:       "XFF from trusted proxy",
+			xff:        "203.0.113.195",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "203.0.113.195",
+		},
+		{
+			name:       "client-supplied entries left of the real client are ignored",
+			xff:        "1.2.3.4, 203.0.113.195, 10.0.0.2",
+			remoteAddr: "192.168.1.1:1234",
+			expected:   "203.0.113.195",
+		},
+		{
+			name:       "all hops trusted",
+			xff:        "10.0.0.3, 10.0.0.2",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "garbage hop stops the walk",
+			xff:        "203.0.113.195, not-an-ip, 10.0.0.2",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			xri:        "203.0.113.195",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "203.0.113.195",
+		},
+		{
+			name:       "X-Real-IP from untrusted peer",
+			xri:        "1.2.3.4",
+			remoteAddr: "203.0.113.195:1234",
+			expected:   "203.0.113.195",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.xri != "" {
+				req.Header.Set("X-Real-IP", tt.xri)
+			}
+			req.RemoteAddr = tt.remoteAddr
+
+			if got := proxies.ClientIP(req); got != tt.expected {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTrustedProxies_NoneConfigured checks the zero value only believes
+// the peer address, unlike GetClientIP
+func TestTrustedProxies_NoneConfigured(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.RemoteAddr = "203.0.113.195:1234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	req.Header.Set("X-Real-IP", "5.6.7.8")
+
+	if got := (TrustedProxies{}).ClientIP(req); got != "203.0.113.195" {
+		t.Errorf("ClientIP() = %q, want the peer address", got)
+	}
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "proxy.internal"}); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
+
+// TestWriteError verifies the JSON error envelope and code header
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusBadGateway, "backend_header_overflow", "too large")
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Helios-Error"); got != "backend_header_overflow" {
+		t.Errorf("expected X-Helios-Error header, got %q", got)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json body: %v", err)
+	}
+	if body.Code != "backend_header_overflow" || body.Message != "too large" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:    "valid CIDR",
+			input:   "192.168.1.0/24",
+			wantErr: false,
+		},
+		{
+			name:    "valid single IPv4",
+			input:   "192.168.1.1",
+			wantErr: false,
+		},
+		{
+			name:    "valid IPv6 CIDR",
+			input:   "2001:db8::/32",
+			wantErr: false,
+		},
+		{
+			name:    "valid single IPv6",
+			input:   "2001:db8::1",
+			wantErr: false,
+		},
+		{
+			name:    "invalid CIDR",
+			input:   "invalid",
+			wantErr: true,
+		},
+		{
+			name:    "invalid IP",
+			input:   "999.999.999.999",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipNet, err := ParseCIDR(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCIDR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && ipNet == nil {
+				t.Error("ParseCIDR() returned nil without error")
+			}
+		})
+	}
+}